@@ -0,0 +1,91 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"errors"
+	"fmt"
+)
+
+// AlertsConfig sets the limits a calibration must meet before it is accepted, unset limits are not checked
+type AlertsConfig struct {
+	MinSamples         int     `json:"min_samples,omitempty"`
+	MinScanSpan        float64 `json:"min_scan_span,omitempty"` // share of the detected width and height, 0 to 1
+	MaxNormalStdDevDeg float64 `json:"max_normal_std_dev_deg,omitempty"`
+	MaxOffsetStdDevMM  float64 `json:"max_offset_std_dev_mm,omitempty"`
+	MaxResidualRMSMM   float64 `json:"max_residual_rms_mm,omitempty"`
+	MaxFlatnessMM      float64 `json:"max_flatness_mm,omitempty"`
+
+	// Compared with the previous calibration of the same monitor
+	MaxShiftMM float64 `json:"max_shift_mm,omitempty"`
+	MaxTiltDeg float64 `json:"max_tilt_deg,omitempty"`
+}
+
+// Validate checks the alert configuration
+func (cfg *AlertsConfig) Validate(path string) error {
+	if cfg.MinSamples < 0 || cfg.MaxNormalStdDevDeg < 0 || cfg.MaxOffsetStdDevMM < 0 || cfg.MaxResidualRMSMM < 0 ||
+		cfg.MaxFlatnessMM < 0 || cfg.MaxShiftMM < 0 || cfg.MaxTiltDeg < 0 {
+		return fmt.Errorf("alert limits must not be negative in %s", path)
+	}
+	if cfg.MinScanSpan < 0 || cfg.MinScanSpan > 1 {
+		return fmt.Errorf("'min_scan_span' must be between 0 and 1 in %s", path)
+	}
+	return nil
+}
+
+// apply copies the configured limits into the calibration alert config
+func (cfg *AlertsConfig) apply(config *calibrationhelpers.AlertConfig) {
+	config.MinSamples = cfg.MinSamples
+	config.MinScanSpan = cfg.MinScanSpan
+	config.MaxNormalStdDev = cfg.MaxNormalStdDevDeg
+	config.MaxOffsetStdDev = cfg.MaxOffsetStdDevMM
+	config.MaxResidualRMS = cfg.MaxResidualRMSMM
+	config.MaxFlatness = cfg.MaxFlatnessMM
+	config.MaxShift = cfg.MaxShiftMM
+	config.MaxTilt = cfg.MaxTiltDeg
+}
+
+// baselineFromLastResult compares the next calibration against the last one for the movement alerts
+// Pass "accept_move": true to accept a monitor that was moved on purpose
+func (s *monitorCalibration) baselineFromLastResult(cmd map[string]interface{}, config calibrationhelpers.CalibrationConfig) calibrationhelpers.CalibrationConfig {
+	if s.lastResult == nil || s.lastResult.Frame != config.Hardware.ReferenceFrame() {
+		return config
+	}
+	if accept, _ := cmd["accept_move"].(bool); accept {
+		s.logger.Info("Accepting monitor movement since the previous calibration")
+		return config
+	}
+	baseline := *s.lastResult
+	config.Baseline = &baseline
+	return config
+}
+
+// acceptLowSpan skips the scan span check when the command sets "accept_low_span": true
+// It is for experts who know a narrow scan is enough, e.g. on a monitor whose tilt is already known
+func (s *monitorCalibration) acceptLowSpan(cmd map[string]interface{}, config calibrationhelpers.CalibrationConfig) calibrationhelpers.CalibrationConfig {
+	if accept, _ := cmd["accept_low_span"].(bool); accept && config.Alerts.MinScanSpan > 0 {
+		s.logger.Warn("Accepting a scan that may span too little of the monitor, as requested")
+		config.Alerts.MinScanSpan = 0
+	}
+	return config
+}
+
+// validateResult checks the result against the alert limits, logging a remediation hint for every failed check
+func (s *monitorCalibration) validateResult(result types.CalibrationResult, config calibrationhelpers.CalibrationConfig) error {
+	logger := s.runLogger(config)
+	err := calibrationhelpers.ValidateResult(result, config.Baseline, config.Alerts)
+	var validationErr *calibrationhelpers.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	for _, f := range validationErr.Failures {
+		logger.Errorf("✗ Validation failed: %s = %.3g (limit %.3g), hint %s: %s", f.Metric, f.Value, f.Limit, f.Hint, f.Message)
+	}
+	for _, hint := range validationErr.Hints() {
+		if hint == calibrationhelpers.HintMonitorMoved {
+			logger.Error("  If the monitor was moved on purpose, recalibrate with \"accept_move\": true")
+		}
+	}
+	return err
+}
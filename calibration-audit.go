@@ -0,0 +1,156 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.viam.com/rdk/logging"
+)
+
+// defaultAuditLimit is how many entries "get_audit_log" returns unless "limit" is given
+const defaultAuditLimit = 100
+
+// auditedCommands are the DoCommand commands recorded in the audit log: those that calibrate, move the hardware or
+// change the stored calibration. Status queries and exports aren't.
+var auditedCommands = map[string]bool{
+	"":                   true, // calibrate
+	"calibrate":          true,
+	"calibrate_all":      true,
+	"stitch_monitors":    true,
+	"vision_calibrate":   true,
+	"import_calibration": true,
+	"set_pad_thickness":  true,
+	"check_drift":        true,
+	"jog":                true,
+	"move_sensor":        true,
+	"probe_point":        true,
+	"self_test":          true,
+	"estimate_backlash":  true,
+	"resume":             true,
+}
+
+// defaultAuditLogPath places the audit log in the module data directory Viam provides, if any
+func defaultAuditLogPath(resourceName string) string {
+	dataDir := os.Getenv("VIAM_MODULE_DATA")
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, resourceName+"-audit.jsonl")
+}
+
+// newAuditLog opens the configured audit log, nil when there is nowhere to keep it
+func newAuditLog(conf *Config, resourceName string) *calibrationhelpers.AuditLog {
+	path := conf.AuditLogPath
+	if path == "" {
+		path = defaultAuditLogPath(resourceName)
+	}
+	if path == "" {
+		return nil
+	}
+	return calibrationhelpers.NewAuditLog(path)
+}
+
+// newSession starts a calibration session, returning its ID for the run's result, progress, logs and audit entry
+// A run resuming an interrupted one passes its session ID to continue that session.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) newSession(resumed string) string {
+	id := resumed
+	if id == "" {
+		id = uuid.NewString()
+	}
+	s.actionSessions = append(s.actionSessions, id)
+	return id
+}
+
+// runLogger returns the logger of a calibration run, tagged with its session ID
+func (s *monitorCalibration) runLogger(config calibrationhelpers.CalibrationConfig) logging.Logger {
+	if config.SessionID == "" {
+		return s.logger
+	}
+	return s.logger.WithFields("session_id", config.SessionID)
+}
+
+// audited runs an action and appends it to the audit log twice: as started, before it runs, so a crash still
+// leaves a record of who started it, then with the sessions it started and its outcome. Both entries share an ID.
+// The parameters are the command's arguments less "command" and "requested_by", which names who asked for it.
+// A failure to write the log is logged, not returned, so auditing never blocks calibrating.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) audited(action, trigger string, cmd map[string]interface{},
+	run func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if s.audit == nil {
+		return run()
+	}
+	entry := calibrationhelpers.AuditEntry{
+		ID:      uuid.NewString(),
+		Time:    time.Now().UTC(),
+		Action:  action,
+		Trigger: trigger,
+		Outcome: calibrationhelpers.AuditStarted,
+	}
+	if action == "" {
+		entry.Action = "calibrate"
+	}
+	entry.RequestedBy, _ = cmd["requested_by"].(string)
+	for key, value := range cmd {
+		if key == "command" || key == "requested_by" {
+			continue
+		}
+		if entry.Parameters == nil {
+			entry.Parameters = map[string]interface{}{}
+		}
+		entry.Parameters[key] = value
+	}
+	if err := s.audit.Append(entry); err != nil {
+		s.logger.Warnf("Failed to write audit log: %v", err)
+	}
+
+	s.actionSessions = nil
+	response, err := run()
+	entry.SessionIDs, s.actionSessions = s.actionSessions, nil
+	entry.DurationSec = time.Since(entry.Time).Seconds()
+	entry.Outcome = calibrationhelpers.AuditSucceeded
+	if err != nil {
+		entry.Outcome = calibrationhelpers.AuditFailed
+		entry.Error = err.Error()
+		entry.ErrorClass = calibrationhelpers.ErrorClass(err)
+	}
+	if err := s.audit.Append(entry); err != nil {
+		s.logger.Warnf("Failed to write audit log: %v", err)
+	}
+	return response, err
+}
+
+// getAuditLog handles the "get_audit_log" command, returning the newest "limit" entries (default 100), oldest
+// first, optionally only those of a "session_id" or "action", or started at or after "since" (RFC 3339)
+func (s *monitorCalibration) getAuditLog(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.audit == nil {
+		return nil, fmt.Errorf("get_audit_log requires 'audit_log_path' in the service config, or a module data directory")
+	}
+	query := calibrationhelpers.AuditQuery{Limit: defaultAuditLimit}
+	query.SessionID, _ = cmd["session_id"].(string)
+	query.Action, _ = cmd["action"].(string)
+	if raw, ok := cmd["since"]; ok {
+		since, ok := raw.(string)
+		t, err := time.Parse(time.RFC3339, since)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("get_audit_log 'since' must be an RFC 3339 time")
+		}
+		query.Since = t
+	}
+	if raw, ok := cmd["limit"]; ok {
+		v, ok := raw.(float64)
+		if !ok || v < 1 || v != float64(int(v)) {
+			return nil, fmt.Errorf("get_audit_log 'limit' must be a positive integer")
+		}
+		query.Limit = int(v)
+	}
+	page, err := s.audit.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	return page.ToMap()
+}
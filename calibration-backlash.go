@@ -0,0 +1,34 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// estimateBacklash handles the "estimate_backlash" command, measuring the lost motion of the gantry's first axis
+// by sweeping it both ways across the edges of the last calibrated monitor
+// The response's "gantry_backlash_mm" can be copied into the service config to correct for it.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) estimateBacklash(ctx context.Context) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	config := s.calibrationConfig
+	config.Curve = s.lastResult.Cylinder
+
+	// Hold the sensor level with the middle of the monitor, as the edge searches of a calibration do
+	if err := s.arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+		return nil, fmt.Errorf("failed to reset arm: %w", err)
+	}
+	estimate, err := calibrationhelpers.EstimateBacklash(ctx, s.logger, s.fs, s.sensor, s.gantry, s.lastResult.Plane, config)
+	if err != nil {
+		return nil, err
+	}
+	response, err := estimate.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["gantry_backlash_mm"] = []interface{}{estimate.Backlash}
+	return response, nil
+}
@@ -0,0 +1,29 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"time"
+)
+
+// chainFromLastResult seeds the configuration from the last result when chaining is enabled and the result is fresh
+// Pass "chain": false to force a full calibration, e.g. after the monitor has been moved on purpose
+func (s *monitorCalibration) chainFromLastResult(cmd map[string]interface{}, config calibrationhelpers.CalibrationConfig) calibrationhelpers.CalibrationConfig {
+	if s.chainMaxAge == 0 || s.lastResult == nil {
+		return config
+	}
+	if chain, ok := cmd["chain"].(bool); ok && !chain {
+		s.logger.Info("Chaining disabled for this run, performing a full calibration")
+		return config
+	}
+	if err := calibrationhelpers.CheckSeed(*s.lastResult, config.Hardware.ReferenceFrame(), s.chainMaxAge, time.Now()); err != nil {
+		s.logger.Infof("Performing a full calibration: %v", err)
+		return config
+	}
+
+	seed := *s.lastResult
+	config.Seed = &seed
+	config.Scanning = config.Scanning.Chained()
+	s.logger.Infof("Chaining from the calibration of %s: seeding plane and edges, %d x %d scan",
+		seed.Timestamp.Format(time.RFC3339), config.Scanning.XNumSteps, config.Scanning.ZNumSteps)
+	return config
+}
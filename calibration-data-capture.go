@@ -0,0 +1,60 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+
+	"go.viam.com/rdk/data"
+)
+
+// defaultDataCaptureBufferSize is how many scan samples wait for capture unless buffer_size is set
+const defaultDataCaptureBufferSize = 1000
+
+// DataCaptureConfig buffers every scan waypoint for the data management service, which captures them through the
+// "capture_scan_samples" command
+type DataCaptureConfig struct {
+	BufferSize int `json:"buffer_size,omitempty"` // samples kept awaiting capture, oldest dropped first (default 1000)
+}
+
+// Validate checks the data capture configuration
+func (cfg *DataCaptureConfig) Validate(path string) error {
+	if cfg.BufferSize < 0 {
+		return fmt.Errorf("'buffer_size' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// buffer returns an empty buffer of the configured size
+func (cfg *DataCaptureConfig) buffer() *calibrationhelpers.ScanSampleBuffer {
+	size := cfg.BufferSize
+	if size == 0 {
+		size = defaultDataCaptureBufferSize
+	}
+	return calibrationhelpers.NewScanSampleBuffer(size)
+}
+
+// captureScanSamples handles the "capture_scan_samples" command, draining the scan samples buffered since the last
+// capture, at most "max_samples" of them if given
+// With nothing new to report it returns data.ErrNoCaptureToStore, so the data manager stores no empty rows.
+func (s *monitorCalibration) captureScanSamples(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.captureBuffer == nil {
+		return nil, fmt.Errorf("capture_scan_samples requires 'data_capture' in the service config")
+	}
+	limit := 0
+	if raw, ok := cmd["max_samples"]; ok {
+		v, ok := raw.(float64)
+		if !ok || v < 1 || v != float64(int(v)) {
+			return nil, fmt.Errorf("capture_scan_samples 'max_samples' must be a positive integer")
+		}
+		limit = int(v)
+	}
+	batch := s.captureBuffer.Drain(limit)
+	if len(batch.Samples) == 0 && batch.Dropped == 0 {
+		return nil, data.ErrNoCaptureToStore
+	}
+	if batch.Dropped > 0 {
+		s.logger.Warnf("Dropped %d scan samples before they were captured, capture more often or raise 'buffer_size'",
+			batch.Dropped)
+	}
+	return batch.ToMap()
+}
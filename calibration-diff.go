@@ -0,0 +1,43 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+)
+
+// compareCalibration handles the "compare_calibration" command, diffing the last calibration against a saved one
+// The optional tolerances make the diff fail, so acceptance tests after servicing the rig can gate on "pass"
+func (s *monitorCalibration) compareCalibration(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("compare_calibration requires the 'path' of a saved calibration")
+	}
+	var tolerances calibrationhelpers.DiffTolerances
+	tolerances.MaxTranslation, _ = cmd["max_translation_mm"].(float64)
+	tolerances.MaxNormalAngle, _ = cmd["max_normal_angle_deg"].(float64)
+	tolerances.MaxSizeDelta, _ = cmd["max_size_delta_mm"].(float64)
+
+	baseline, err := calibrationhelpers.LoadResult(path)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := calibrationhelpers.Diff(baseline, *s.lastResult, tolerances)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Calibration moved from %s: translation %.2f mm, normal %.3f°, width %+.2f mm, height %+.2f mm",
+		path, diff.TranslationDelta, diff.NormalAngleDelta, diff.WidthDelta, diff.HeightDelta)
+	for _, failure := range diff.Failures {
+		s.logger.Warnf("Calibration comparison failed: %s", failure)
+	}
+	response, err := diff.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["path"] = path
+	return response, nil
+}
@@ -0,0 +1,150 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Drift check defaults
+const (
+	defaultDriftProbes    = 4
+	defaultDriftThreshold = 5.0 // mm
+)
+
+// DriftCheckConfig periodically probes a few points on the calibrated monitor, catching screens that get bumped
+// or re-tilted between calibrations
+type DriftCheckConfig struct {
+	Interval    string  `json:"interval"`               // time between checks, e.g. "1h"
+	Probes      int     `json:"probes,omitempty"`       // points probed per check, default 4
+	ThresholdMM float64 `json:"threshold_mm,omitempty"` // max distance of a probe from the calibrated surface, default 5
+
+	// AutoRecalibrate starts a recalibration job, like "recalibrate_now", when drift is detected
+	AutoRecalibrate bool `json:"auto_recalibrate,omitempty"`
+}
+
+// Validate checks the drift check configuration
+func (cfg *DriftCheckConfig) Validate(path string) error {
+	if cfg.Interval == "" {
+		return fmt.Errorf("missing 'interval' field in %s", path)
+	}
+	interval, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid 'interval' in %s: %w", path, err)
+	}
+	if interval < scheduleCheckInterval {
+		return fmt.Errorf("'interval' must be at least %s in %s", scheduleCheckInterval, path)
+	}
+	if cfg.Probes < 0 || cfg.Probes == 1 {
+		return fmt.Errorf("'probes' must be at least 2 in %s", path)
+	}
+	if cfg.ThresholdMM < 0 {
+		return fmt.Errorf("'threshold_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// probes returns the number of points probed per check
+func (cfg *DriftCheckConfig) probes() int {
+	if cfg.Probes == 0 {
+		return defaultDriftProbes
+	}
+	return cfg.Probes
+}
+
+// threshold returns the max distance of a probe from the calibrated surface in mm
+func (cfg *DriftCheckConfig) threshold() float64 {
+	if cfg.ThresholdMM == 0 {
+		return defaultDriftThreshold
+	}
+	return cfg.ThresholdMM
+}
+
+// checkDrift probes the last calibrated monitor and raises an alert if it has moved
+// The caller must hold doCommandLock
+func (s *monitorCalibration) checkDrift(ctx context.Context) (calibrationhelpers.DriftReport, error) {
+	if s.lastResult == nil {
+		return calibrationhelpers.DriftReport{}, fmt.Errorf("no calibration to check for drift")
+	}
+	if s.lastResult.Frame != s.calibrationConfig.Hardware.ReferenceFrame() {
+		return calibrationhelpers.DriftReport{}, fmt.Errorf("last calibration is in the %s frame, not %s",
+			s.lastResult.Frame, s.calibrationConfig.Hardware.ReferenceFrame())
+	}
+	check := s.cfg.DriftCheck
+	if check == nil {
+		check = &DriftCheckConfig{}
+	}
+
+	config := s.calibrationConfig
+	motionConfig, err := s.motionConfig()
+	if err != nil {
+		return calibrationhelpers.DriftReport{}, err
+	}
+	config.Motion = motionConfig
+	// Probe the region the calibration scanned
+	if config, _, err = s.applyMonitorHint(ctx, config, true); err != nil {
+		return calibrationhelpers.DriftReport{}, err
+	}
+	gantryLengths, err := s.gantry.Lengths(ctx, nil)
+	if err != nil {
+		return calibrationhelpers.DriftReport{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	waypoints, err := calibrationhelpers.PlanDriftProbes(gantryLengths, config.Scanning, check.probes())
+	if err != nil {
+		return calibrationhelpers.DriftReport{}, err
+	}
+
+	s.logger.Infof("Checking calibration drift at %d points...", len(waypoints))
+	report, err := calibrationhelpers.CheckDrift(ctx, s.logger, s.fs, s.sensor, s.arm, s.gantry, *s.lastResult,
+		config, waypoints, check.threshold())
+	if err != nil {
+		return calibrationhelpers.DriftReport{}, err
+	}
+	if !report.Drifted {
+		s.logger.Infof("✓ No drift: %s", report.Message)
+		return report, nil
+	}
+
+	s.logger.Errorf("✗ Calibration drift: max residual %.1f mm, %d probes missed, hint %s: %s",
+		report.MaxResidual, report.Missed, report.Hint, report.Message)
+	s.progress.event("error", "calibration drift: "+report.Message)
+	if check.AutoRecalibrate {
+		job := s.recalibrateNow()
+		s.logger.Infof("Recalibration job %v started for the drift", job["job_id"])
+	}
+	return report, nil
+}
+
+// checkDriftCommand handles the "check_drift" command, probing for drift right away
+func (s *monitorCalibration) checkDriftCommand(ctx context.Context) (map[string]interface{}, error) {
+	report, err := s.checkDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report.ToMap()
+}
+
+// runDriftCheck probes for drift at the configured interval, until the service is closed
+// Checks are skipped until there is a calibration to check
+func (s *monitorCalibration) runDriftCheck(interval time.Duration) {
+	defer s.activeBackgroundWorkers.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.cancelCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.doCommandLock.Lock()
+		if s.lastResult != nil {
+			if _, err := s.checkDrift(s.cancelCtx); err != nil {
+				s.logger.Warnf("Drift check failed: %v", err)
+			}
+		}
+		s.doCommandLock.Unlock()
+	}
+}
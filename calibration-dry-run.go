@@ -0,0 +1,48 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+)
+
+// dryRun handles "calibrate" with "dry_run", returning the plan of the calibration instead of running it
+// Nothing is moved, so an operator can check the waypoints, the workspace and the expected duration first.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) dryRun(ctx context.Context, target string,
+	config calibrationhelpers.CalibrationConfig) (map[string]interface{}, error) {
+	config, hintPlan, err := s.hintWithoutMoving(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := calibrationhelpers.PlanCalibration(ctx, s.logger, s.arm, s.gantry, config)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("Dry run: %d waypoints, about %d scan points, estimated %.0f s",
+		plan.Waypoints, plan.ExpectedSamples, plan.Duration)
+	if err := plan.Workspace.Err(); err != nil {
+		s.logger.Warnf("Dry run: %v", err)
+	}
+	response, err := plan.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["dry_run"] = true
+	switch {
+	case hintPlan != nil:
+		response["monitor_hint"] = map[string]interface{}{
+			"gantry_min_mm":  hintPlan.GantryMin,
+			"gantry_max_mm":  hintPlan.GantryMax,
+			"z_min_mm":       hintPlan.ZMin,
+			"z_step_size_mm": hintPlan.ZStepSize,
+			"standoff_mm":    hintPlan.Standoff,
+			"max_range_mm":   hintPlan.MaxRange,
+		}
+	case config.Hint != nil:
+		response["monitor_hint"] = "not planned, " + errArmNotHome.Error()
+	}
+	if target != "" {
+		response["target"] = target
+	}
+	return response, nil
+}
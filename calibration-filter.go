@@ -0,0 +1,53 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+)
+
+// FilterConfig smooths repeated sensor readings at each scan and edge search pose
+type FilterConfig struct {
+	Mode                string  `json:"mode"` // "none", "ema" or "kalman"
+	Samples             int     `json:"samples,omitempty"`
+	Alpha               float64 `json:"alpha,omitempty"`
+	ProcessNoiseMM2     float64 `json:"process_noise_mm2,omitempty"`
+	MeasurementNoiseMM2 float64 `json:"measurement_noise_mm2,omitempty"`
+}
+
+// Validate checks the filter configuration
+func (cfg *FilterConfig) Validate(path string) error {
+	switch cfg.Mode {
+	case calibrationhelpers.FilterNone, calibrationhelpers.FilterEMA, calibrationhelpers.FilterKalman:
+	case "":
+		return fmt.Errorf("missing 'mode' field in %s", path)
+	default:
+		return fmt.Errorf("unknown filter 'mode' %q in %s", cfg.Mode, path)
+	}
+	if cfg.Samples < 0 {
+		return fmt.Errorf("'samples' must not be negative in %s", path)
+	}
+	if cfg.Alpha < 0 || cfg.Alpha > 1 {
+		return fmt.Errorf("'alpha' must be between 0 and 1 in %s", path)
+	}
+	if cfg.ProcessNoiseMM2 < 0 || cfg.MeasurementNoiseMM2 < 0 {
+		return fmt.Errorf("filter noise must not be negative in %s", path)
+	}
+	return nil
+}
+
+// apply copies the configured filter into the calibration filter config, keeping defaults for unset fields
+func (cfg *FilterConfig) apply(config *calibrationhelpers.FilterConfig) {
+	config.Mode = cfg.Mode
+	if cfg.Samples > 0 {
+		config.Samples = cfg.Samples
+	}
+	if cfg.Alpha > 0 {
+		config.Alpha = cfg.Alpha
+	}
+	if cfg.ProcessNoiseMM2 > 0 {
+		config.ProcessNoise = cfg.ProcessNoiseMM2
+	}
+	if cfg.MeasurementNoiseMM2 > 0 {
+		config.MeasurementNoise = cfg.MeasurementNoiseMM2
+	}
+}
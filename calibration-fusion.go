@@ -0,0 +1,54 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/framesystem"
+)
+
+// defaultFusionOutlierMM is how far a sensor's distance may be from the median before fusion leaves it out
+const defaultFusionOutlierMM = 5.0
+
+// FusionConfig fuses further distance sensors mounted beside 'sensor' into every reading
+type FusionConfig struct {
+	Sensors []string `json:"sensors"`
+	// OutlierMM leaves out distances further than this from the median of all the sensors, default 5
+	OutlierMM float64 `json:"outlier_mm,omitempty"`
+}
+
+// Validate checks the fusion configuration
+func (cfg *FusionConfig) Validate(path string) error {
+	if len(cfg.Sensors) == 0 {
+		return fmt.Errorf("missing 'sensors' field in %s", path)
+	}
+	for i, name := range cfg.Sensors {
+		if name == "" {
+			return fmt.Errorf("'sensors.%d' must not be empty in %s", i, path)
+		}
+	}
+	if cfg.OutlierMM < 0 {
+		return fmt.Errorf("'outlier_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// fusedSensor wraps primary with the configured sensors, which are read together at every pose
+func (cfg *FusionConfig) fusedSensor(deps resource.Dependencies, fs framesystem.RobotFrameSystem, primary sensor.Sensor,
+	config calibrationhelpers.CalibrationConfig) (sensor.Sensor, error) {
+	others := make([]sensor.Sensor, 0, len(cfg.Sensors))
+	for _, name := range cfg.Sensors {
+		s, err := sensor.FromProvider(deps, name)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, s)
+	}
+	outlier := cfg.OutlierMM
+	if outlier == 0 {
+		outlier = defaultFusionOutlierMM
+	}
+	return calibrationhelpers.NewFusedSensor(fs, primary, others, outlier, config), nil
+}
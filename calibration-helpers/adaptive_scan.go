@@ -0,0 +1,230 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"fmt"
+	"sort"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+)
+
+// latticeCell is a rectangle of the full-density grid, given by the indices of its corners
+type latticeCell struct {
+	row0, col0, row1, col1 int
+}
+
+// corners returns the grid cells at the corners of the rectangle
+func (c latticeCell) corners() [4][2]int {
+	return [4][2]int{{c.row0, c.col0}, {c.row0, c.col1}, {c.row1, c.col0}, {c.row1, c.col1}}
+}
+
+// split halves the cell along each axis that is more than one grid step long, nil if it can't be split
+func (c latticeCell) split() []latticeCell {
+	rows := [][2]int{{c.row0, c.row1}}
+	if c.row1-c.row0 > 1 {
+		mid := (c.row0 + c.row1) / 2
+		rows = [][2]int{{c.row0, mid}, {mid, c.row1}}
+	}
+	cols := [][2]int{{c.col0, c.col1}}
+	if c.col1-c.col0 > 1 {
+		mid := (c.col0 + c.col1) / 2
+		cols = [][2]int{{c.col0, mid}, {mid, c.col1}}
+	}
+	if len(rows) == 1 && len(cols) == 1 {
+		return nil
+	}
+	cells := make([]latticeCell, 0, len(rows)*len(cols))
+	for _, r := range rows {
+		for _, col := range cols {
+			cells = append(cells, latticeCell{row0: r[0], col0: col[0], row1: r[1], col1: col[1]})
+		}
+	}
+	return cells
+}
+
+// PerformAdaptiveScan scans a coarse grid, then refines it near the monitor edges and high-residual regions
+// The first pass samples every CoarseFactor-th grid waypoint. Each refinement round splits the cells that have
+// some corners on the monitor and some off it, which brackets an edge, or a corner further than RefineResidual
+// from the plane fit to every point so far. Rounds stop at the grid spacing or when no cell needs refining.
+// Returns the waypoints that produced a point, in the same order as the points, with the full grid's Row and Col.
+// Refinement needs every hit to tell interior cells from edge cells, so the rounds keep all their points and
+// Scanning.MaxPoints only thins the points returned.
+func PerformAdaptiveScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) ([]scanpath.Waypoint, []Point3D, error) {
+
+	region, xSpacing, zSpacing, err := gridRegion(ctx, gantry, config.Scanning)
+	if err != nil {
+		return nil, nil, err
+	}
+	lattice, err := scanpath.NewLattice(region, xSpacing, zSpacing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to plan adaptive scan: %w", err)
+	}
+	rows := scanpath.Every(len(lattice.Zs), config.Scanning.CoarseFactor)
+	cols := scanpath.Every(len(lattice.Xs), config.Scanning.CoarseFactor)
+	waypoints, err := lattice.Generate(rows, cols, scanpath.Pattern(config.Scanning.Pattern))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to plan adaptive scan: %w", err)
+	}
+	var cells []latticeCell
+	for i := 1; i < len(rows); i++ {
+		for j := 1; j < len(cols); j++ {
+			cells = append(cells, latticeCell{row0: rows[i-1], col0: cols[j-1], row1: rows[i], col1: cols[j]})
+		}
+	}
+
+	maxPoints := config.Scanning.MaxPoints
+	config.Scanning.MaxPoints = 0
+	sampled := map[[2]int]bool{}
+	hits := map[[2]int]Point3D{}
+	var points []Point3D
+	var visited []scanpath.Waypoint
+	label := "Adaptive"
+	for round := 1; ; round++ {
+		if len(waypoints) > 0 {
+			roundPoints, roundVisited, err := performWaypointScan(ctx, logger, fs, sensor, arm, gantry, config, label, waypoints, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, wp := range waypoints {
+				sampled[[2]int{wp.Row, wp.Col}] = true
+			}
+			for i, wp := range roundVisited {
+				hits[[2]int{wp.Row, wp.Col}] = roundPoints[i]
+			}
+			points = append(points, roundPoints...)
+			visited = append(visited, roundVisited...)
+		}
+		if len(points) < 3 {
+			break
+		}
+
+		plane, err := NewPlaneFitter(config.Detection).Fit(logger, points)
+		if err != nil {
+			logger.Warnf("Stopping adaptive refinement, plane fit failed: %v", err)
+			break
+		}
+		// Split cells can share corners with cells already sampled, so a round may need no new waypoints
+		cells = refineCells(cells, hits, plane, config.Scanning.RefineResidual)
+		if len(cells) == 0 {
+			break
+		}
+		waypoints = cellWaypoints(lattice, cells, sampled)
+		logger.Infof("Adaptive scan round %d: refining %d cells with %d new waypoints", round, len(cells), len(waypoints))
+		label = fmt.Sprintf("Adaptive refine %d", round)
+	}
+
+	logger.Infof("✓ Adaptive scan sampled %d of %d grid waypoints", len(sampled), len(lattice.Xs)*len(lattice.Zs))
+	if maxPoints > 0 && len(points) > maxPoints {
+		total := len(points)
+		visited, points = thinScan(visited, points, maxPoints)
+		logger.Infof("Keeping %d of %d adaptive scan points for the final fit", len(points), total)
+	}
+	return visited, points, nil
+}
+
+// thinScan keeps maxPoints of a scan's points, spread evenly over it
+// Like the scan collector, the bottom and top grid rows, which the reference lines come from, are always kept.
+func thinScan(visited []scanpath.Waypoint, points []Point3D, maxPoints int) ([]scanpath.Waypoint, []Point3D) {
+	topRow := 0
+	for _, wp := range visited {
+		topRow = max(topRow, wp.Row)
+	}
+	reference := func(wp scanpath.Waypoint) bool { return wp.Row == 0 || wp.Row == topRow }
+	others := 0
+	for _, wp := range visited {
+		if !reference(wp) {
+			others++
+		}
+	}
+	budget := max(0, maxPoints-(len(points)-others))
+
+	var keptVisited []scanpath.Waypoint
+	var kept []Point3D
+	seen := 0
+	for i, wp := range visited {
+		if !reference(wp) {
+			// Keep a point each time the budget's share of the points seen so far steps up
+			seen++
+			if seen*budget/others == (seen-1)*budget/others {
+				continue
+			}
+		}
+		keptVisited = append(keptVisited, wp)
+		kept = append(kept, points[i])
+	}
+	return keptVisited, kept
+}
+
+// refineCells returns the halves of the cells that straddle an edge or hold a high-residual point
+// Cells with no corner on the monitor are dropped, the scan has left the screen there
+func refineCells(cells []latticeCell, hits map[[2]int]Point3D, plane Plane, maxResidual float64) []latticeCell {
+	var refined []latticeCell
+	for _, cell := range cells {
+		onMonitor, rough := 0, false
+		for _, corner := range cell.corners() {
+			point, ok := hits[corner]
+			if !ok {
+				continue
+			}
+			onMonitor++
+			if PointDistanceFromPlane(point, plane) > maxResidual {
+				rough = true
+			}
+		}
+		if onMonitor == 0 || (onMonitor == 4 && !rough) {
+			continue
+		}
+		refined = append(refined, cell.split()...)
+	}
+	return refined
+}
+
+// cellWaypoints returns the corners of the cells that haven't been sampled yet
+// They are visited row by row, alternating direction to avoid long return moves
+func cellWaypoints(lattice scanpath.Lattice, cells []latticeCell, sampled map[[2]int]bool) []scanpath.Waypoint {
+	pending := map[[2]int]bool{}
+	for _, cell := range cells {
+		for _, corner := range cell.corners() {
+			if !sampled[corner] {
+				pending[corner] = true
+			}
+		}
+	}
+	keys := make([][2]int, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	waypoints := make([]scanpath.Waypoint, 0, len(keys))
+	for start, reverse := 0, false; start < len(keys); reverse = !reverse {
+		end := start
+		for end < len(keys) && keys[end][0] == keys[start][0] {
+			end++
+		}
+		row := keys[start:end]
+		if reverse {
+			for i := len(row) - 1; i >= 0; i-- {
+				waypoints = append(waypoints, lattice.At(row[i][0], row[i][1]))
+			}
+		} else {
+			for _, key := range row {
+				waypoints = append(waypoints, lattice.At(key[0], key[1]))
+			}
+		}
+		start = end
+	}
+	return waypoints
+}
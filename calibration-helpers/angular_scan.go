@@ -0,0 +1,152 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// PerformAngularScan holds the end effector at several heights and sweeps one wrist joint at each,
+// casting a fan of rays across the monitor like a scanning lidar
+// Useful when gantry travel is shorter than the monitor, since the rays reach past the gantry limits
+// Returns one fan of surface points per hold position, ordered by sweep angle
+func PerformAngularScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, config CalibrationConfig) ([][]Point3D, error) {
+
+	scan := config.Scanning
+	if scan.AngularHolds < 2 || scan.WristSweepSteps < 2 {
+		return nil, fmt.Errorf("angular scan needs at least 2 holds and 2 sweep steps")
+	}
+	if scan.WristJoint < 0 || scan.WristJoint >= len(config.ArmPositions.Home) {
+		return nil, fmt.Errorf("wrist joint index %d out of range for %d-joint arm", scan.WristJoint, len(config.ArmPositions.Home))
+	}
+
+	// Spread the hold positions over the same vertical span as the linear Z scan
+	zMin, zMax := scan.ZRange()
+	holdSpacing := (zMax - zMin) / float64(scan.AngularHolds-1)
+	sweepStep := 2 * scan.WristSweepAngle / float64(scan.WristSweepSteps-1)
+
+	fans := make([][]Point3D, 0, scan.AngularHolds)
+	for hold := 0; hold < scan.AngularHolds; hold++ {
+		if err := config.Control.Checkpoint(ctx); err != nil {
+			return nil, err
+		}
+		// Reset arm to starting position, then raise it to this hold's height
+		if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+			return nil, fmt.Errorf("failed to reset arm: %w", err)
+		}
+		if z := zMin + float64(hold)*holdSpacing; z != 0 {
+			armPose, err := arm.EndPosition(ctx, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get arm position: %w", err)
+			}
+			holdPose := spatialmath.NewPose(
+				r3.Vector{
+					X: armPose.Point().X,
+					Y: armPose.Point().Y,
+					Z: armPose.Point().Z + z,
+				},
+				armPose.Orientation(),
+			)
+			if err := arm.MoveToPosition(ctx, holdPose, nil); err != nil {
+				return nil, fmt.Errorf("failed to move arm to hold pose %+v: %w", holdPose.Point(), err)
+			}
+		}
+
+		holdJoints, err := arm.JointPositions(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get arm joint positions: %w", err)
+		}
+
+		var fan []Point3D
+		for i := 0; i < scan.WristSweepSteps; i++ {
+			angle := -scan.WristSweepAngle + float64(i)*sweepStep
+			progress := ScanProgress{
+				Label:  "Angular",
+				Index:  hold*scan.WristSweepSteps + i,
+				Total:  scan.AngularHolds * scan.WristSweepSteps,
+				Status: WaypointOK,
+			}
+
+			reading, err := sweepWrist(ctx, logger, fs, sensor, arm, config, holdJoints, angle, &progress)
+			if err != nil {
+				return nil, err
+			}
+			progress.Reading = reading
+			if progress.Status == WaypointOK {
+				progress.Point = reading.SurfacePoint
+				progress.Spread = reading.Spread
+				fan = append(fan, reading.SurfacePoint)
+				logger.Infof("Angular scan hold %d, wrist %+.1f deg: depth=%f, surface=(%f, %f, %f)",
+					hold+1, angle, reading.Depth, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z)
+			} else {
+				logger.Warnf("Angular scan hold %d, wrist %+.1f deg skipped, %s: %s", hold+1, angle, progress.Status, progress.Detail)
+			}
+			if config.Progress != nil {
+				config.Progress(progress)
+			}
+		}
+		if len(fan) < 2 {
+			return nil, fmt.Errorf("angular scan hold %d collected %d points, need at least 2: %w", hold+1, len(fan), ErrInsufficientSamples)
+		}
+		fans = append(fans, fan)
+	}
+
+	return fans, nil
+}
+
+// sweepWrist turns the wrist to angle degrees from the hold pose and reads the surface point there
+// Recoverable failures are recorded in progress.Status, errors are returned only when the scan must stop
+func sweepWrist(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, config CalibrationConfig,
+	holdJoints []float64, angle float64, progress *ScanProgress) (SensorReading, error) {
+	fail := func(status string, err error) (SensorReading, error) {
+		progress.Status, progress.Detail = status, err.Error()
+		return SensorReading{}, nil
+	}
+
+	joints := append([]float64{}, holdJoints...)
+	joints[config.Scanning.WristJoint] += utils.DegToRad(angle)
+	moveCtx, cancel := withOptionalTimeout(ctx, config.Scanning.SettleTimeout)
+	err := arm.MoveToJointPositions(moveCtx, joints, nil)
+	cancel()
+	if timedOut(ctx, err) {
+		return fail(WaypointSettleTimeout, err)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return SensorReading{}, ctx.Err()
+		}
+		return fail(WaypointIKFailure, fmt.Errorf("failed to sweep wrist to %.1f deg: %w", angle, err))
+	}
+	if err := dwell(ctx, config.Scanning); err != nil {
+		return SensorReading{}, err
+	}
+
+	// The frame system gives the tilted sensor pose, so the ray intersection lands on the true surface point
+	readCtx, cancel := withOptionalTimeout(ctx, config.Scanning.ReadTimeout)
+	reading, err := GetFilteredSurfacePoint(readCtx, logger, fs, sensor, config)
+	cancel()
+	if timedOut(ctx, err) {
+		return fail(WaypointReadTimeout, err)
+	}
+	if errors.Is(err, ErrSensorRead) && ctx.Err() == nil {
+		return fail(WaypointReadError, err)
+	}
+	if err != nil {
+		return SensorReading{}, fmt.Errorf("failed to get sensor reading at wrist %.1f deg: %w", angle, err)
+	}
+	if reading.Miss || (config.Scanning.MaxRange > 0 && reading.Depth >= config.Scanning.MaxRange) {
+		progress.Status = WaypointOutOfRange
+		progress.Detail = missDetail(reading, config.Scanning.MaxRange)
+	}
+	return reading, nil
+}
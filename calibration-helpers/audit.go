@@ -0,0 +1,173 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// What started an audited action
+const (
+	AuditTriggerCommand     = "do_command"      // a DoCommand call
+	AuditTriggerRecalibrate = "recalibrate_now" // a one-tap recalibration job
+	AuditTriggerSchedule    = "schedule"        // a target's calibration interval
+)
+
+// Outcomes of an audited action
+const (
+	AuditStarted   = "started" // written before the action runs, followed by its outcome unless the module crashed
+	AuditSucceeded = "succeeded"
+	AuditFailed    = "failed"
+)
+
+// AuditEntry is one calibration action in the audit log: who or what started it, with which parameters, and how it
+// ended
+type AuditEntry struct {
+	ID          string                 `json:"id,omitempty"` // shared by an action's started and outcome entries
+	Time        time.Time              `json:"time"`         // when the action started
+	Action      string                 `json:"action"`
+	Trigger     string                 `json:"trigger"`
+	RequestedBy string                 `json:"requested_by,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	// SessionIDs are the calibration runs the action started, in order
+	SessionIDs  []string `json:"session_ids,omitempty"`
+	Outcome     string   `json:"outcome"`
+	Error       string   `json:"error,omitempty"`
+	ErrorClass  string   `json:"error_class,omitempty"`
+	DurationSec float64  `json:"duration_sec"`
+}
+
+// AuditQuery selects audit entries, zero fields matching everything
+type AuditQuery struct {
+	SessionID string
+	Action    string
+	Since     time.Time // entries started at or after this time
+	Limit     int       // the newest Limit matching entries, zero for all
+}
+
+// matches reports whether the entry is selected by the query
+func (q AuditQuery) matches(e AuditEntry) bool {
+	if q.Action != "" && e.Action != q.Action {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if q.SessionID == "" {
+		return true
+	}
+	for _, id := range e.SessionIDs {
+		if id == q.SessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLog is an append-only JSON Lines file of calibration actions
+// Entries are only ever appended, each synced to disk before Append returns, so the log survives a crash with at
+// most a torn last line, which Query skips and Append starts after. It is safe for concurrent use.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewAuditLog returns the audit log kept at path, which is created on the first append
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Path returns where the log is kept
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Append adds an entry to the end of the log
+func (l *AuditLog) Append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// Start on a new line after a line torn by a crash, so only the torn entry is lost
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// AuditPage is the result of an audit log query
+type AuditPage struct {
+	Path    string       `json:"path"`
+	Entries []AuditEntry `json:"entries"` // oldest first
+	// SkippedLines counts lines that couldn't be read, such as one torn by a crash
+	SkippedLines int `json:"skipped_lines,omitempty"`
+}
+
+// ToMap converts the page to a map for DoCommand responses
+func (p AuditPage) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(p)
+}
+
+// Query returns the entries the query selects
+// A log that doesn't exist yet has no entries.
+func (l *AuditLog) Query(q AuditQuery) (AuditPage, error) {
+	page := AuditPage{Path: l.path, Entries: []AuditEntry{}}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return page, nil
+	}
+	if err != nil {
+		return AuditPage{}, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			page.SkippedLines++
+			continue
+		}
+		if !q.matches(e) {
+			continue
+		}
+		page.Entries = append(page.Entries, e)
+		if q.Limit > 0 && len(page.Entries) > q.Limit {
+			page.Entries = page.Entries[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return AuditPage{}, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return page, nil
+}
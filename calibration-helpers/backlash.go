@@ -0,0 +1,236 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// backlashSweepSpan is how far either side of an edge the bidirectional sweeps of EstimateBacklash start, in
+// multiples of the edge precision
+const backlashSweepSpan = 10
+
+// GantryBacklash models the lost motion of each gantry axis, so sensor poses can be corrected for it
+// After a move, the carriage trails the commanded position by half the axis' backlash against the direction of the
+// move, so readings taken after moving up and down the axis meet in the middle. Axis 0 moves along X of the
+// reference frame, and axes 1 and 2 along Y and Z, matching the scan's use of the gantry. It is safe for
+// concurrent use.
+type GantryBacklash struct {
+	axes []float64 // mm - lost motion per axis
+
+	mu         sync.Mutex
+	commanded  []float64 // last commanded position per axis, NaN before the first move
+	directions []float64 // sign of the last move per axis, zero before the first move
+}
+
+// NewGantryBacklash creates a backlash model with the given lost motion in mm per gantry axis
+func NewGantryBacklash(axes []float64) *GantryBacklash {
+	b := &GantryBacklash{
+		axes:       append([]float64{}, axes...),
+		commanded:  make([]float64, len(axes)),
+		directions: make([]float64, len(axes)),
+	}
+	for i := range b.commanded {
+		b.commanded[i] = math.NaN()
+	}
+	return b
+}
+
+// Wrap returns the gantry with its moves tracked by the model, which needs to see every move to know which way
+// each axis last went
+func (b *GantryBacklash) Wrap(g gantry.Gantry) gantry.Gantry {
+	return &backlashGantry{Gantry: g, backlash: b}
+}
+
+// moved records a commanded move
+// A move to the same position keeps the direction, since the carriage doesn't move and the slack stays taken up.
+func (b *GantryBacklash) moved(positions []float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, pos := range positions {
+		if i >= len(b.axes) {
+			break
+		}
+		if last := b.commanded[i]; !math.IsNaN(last) && pos != last {
+			b.directions[i] = math.Copysign(1, pos-last)
+		}
+		b.commanded[i] = pos
+	}
+}
+
+// Offset returns where the carriage is relative to where it was commanded, in the reference frame
+func (b *GantryBacklash) Offset() r3.Vector {
+	if b == nil {
+		return r3.Vector{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var offset [3]float64
+	for i := 0; i < len(b.axes) && i < 3; i++ {
+		offset[i] = -b.directions[i] * b.axes[i] / 2
+	}
+	return r3.Vector{X: offset[0], Y: offset[1], Z: offset[2]}
+}
+
+// Command returns the position to command the axis to so that its carriage ends up at target
+// Moving up the axis leaves the carriage half the backlash short, so the command overshoots by that much. When
+// that would mean moving back down instead, the command undershoots. Without a model the target is returned as is,
+// and the model only learns which way the axis moved once the command goes through the wrapped gantry.
+func (b *GantryBacklash) Command(axis int, target float64) float64 {
+	if b == nil || axis >= len(b.axes) {
+		return target
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	half := b.axes[axis] / 2
+	last := b.commanded[axis]
+	if math.IsNaN(last) || target+half > last {
+		return target + half
+	}
+	return target - half
+}
+
+// correct moves a sensor pose from the commanded gantry position to where the carriage actually is
+// A moving monitor trails its commanded position instead, which moves the sensor the other way relative to it.
+func (b *GantryBacklash) correct(pose spatialmath.Pose, hardware HardwareConfig) spatialmath.Pose {
+	offset := b.Offset()
+	if offset == (r3.Vector{}) {
+		return pose
+	}
+	return spatialmath.NewPose(pose.Point().Add(offset.Mul(hardware.MotionSign())), pose.Orientation())
+}
+
+// backlashGantry reports the moves commanded through it to a backlash model
+type backlashGantry struct {
+	gantry.Gantry
+	backlash *GantryBacklash
+}
+
+func (g *backlashGantry) MoveToPosition(ctx context.Context, positionsMm, speedsMmPerSec []float64,
+	extra map[string]interface{}) error {
+	g.backlash.moved(positionsMm)
+	return g.Gantry.MoveToPosition(ctx, positionsMm, speedsMmPerSec, extra)
+}
+
+// BacklashEdge is the position of one monitor edge found sweeping the gantry each way
+type BacklashEdge struct {
+	Edge     string  `json:"edge"`
+	Forward  float64 `json:"forward_mm"`  // mm - gantry position where the sweep towards the end of the axis crossed it
+	Reverse  float64 `json:"reverse_mm"`  // mm - gantry position where the sweep back crossed it
+	Backlash float64 `json:"backlash_mm"` // mm - Forward minus Reverse
+}
+
+// BacklashEstimate is the lost motion of gantry axis 0 measured by EstimateBacklash
+type BacklashEstimate struct {
+	Backlash  float64        `json:"backlash_mm"`
+	Precision float64        `json:"precision_mm"` // mm - step of the sweeps, which bounds the estimate's accuracy
+	Edges     []BacklashEdge `json:"edges"`
+}
+
+// ToMap converts the estimate to a map of JSON values, suitable for returning from DoCommand
+func (e BacklashEstimate) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(e)
+}
+
+// EstimateBacklash measures the lost motion of gantry axis 0 from bidirectional sweeps across the monitor's left
+// and right edges
+// Each edge is first found with FindHorizontalEdge. The gantry then sweeps across it in steps of
+// Detection.EdgePrecision, once towards the end of the axis and once back, so every reading near the edge is taken
+// with the slack taken up the same way. A carriage trailing its commanded position sees the edge late in both
+// directions, so the forward crossing lies the backlash beyond the reverse one. The arm must already hold the
+// sensor level with the middle of the monitor, and plane is the calibrated surface readings are compared against.
+func EstimateBacklash(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, gantry gantry.Gantry, plane Plane, config CalibrationConfig) (BacklashEstimate, error) {
+	step := config.Detection.EdgePrecision
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return BacklashEstimate{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	minPos, maxPos, err := config.Scanning.GantryLimits(gantryLengths)
+	if err != nil {
+		return BacklashEstimate{}, err
+	}
+
+	onMonitor := func(pos float64) (bool, error) {
+		if err := gantry.MoveToPosition(ctx, []float64{pos}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return false, fmt.Errorf("failed to move gantry: %w", err)
+		}
+		reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+		if err != nil {
+			return false, fmt.Errorf("failed to get sensor reading: %w", err)
+		}
+		return onSurface(reading, plane, config), nil
+	}
+	// sweep steps from start to end and returns the midpoint of the first step where the reading changes
+	sweep := func(start, end float64) (float64, bool, error) {
+		direction := math.Copysign(1, end-start)
+		// Approach the start the same way as the sweep, so the slack is taken up before the first reading
+		if err := gantry.MoveToPosition(ctx, []float64{math.Max(minPos, math.Min(maxPos, start-direction*step*backlashSweepSpan))},
+			[]float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return 0, false, fmt.Errorf("failed to move gantry: %w", err)
+		}
+		prev, err := onMonitor(start)
+		if err != nil {
+			return 0, false, err
+		}
+		for pos := start + direction*step; direction*(end-pos) >= -1e-9; pos += direction * step {
+			hit, err := onMonitor(pos)
+			if err != nil {
+				return 0, false, err
+			}
+			if hit != prev {
+				return pos - direction*step/2, true, nil
+			}
+		}
+		return 0, false, nil
+	}
+
+	estimate := BacklashEstimate{Precision: step, Edges: []BacklashEdge{}}
+	for _, xDirection := range []int{1, -1} {
+		edge, err := FindHorizontalEdge(ctx, logger, fs, sensor, gantry, plane, gantryLengths, xDirection, config)
+		if err != nil {
+			return BacklashEstimate{}, err
+		}
+		name := "left"
+		if xDirection < 0 {
+			name = "right"
+		}
+		if !edge.Found {
+			logger.Warnf("Skipping the %s edge for the backlash estimate, it is beyond the gantry travel", name)
+			continue
+		}
+
+		span := step * backlashSweepSpan
+		low, high := math.Max(minPos, edge.Position-span), math.Min(maxPos, edge.Position+span)
+		forward, ok, err := sweep(low, high)
+		if err != nil {
+			return BacklashEstimate{}, err
+		}
+		reverse, okReverse, err := sweep(high, low)
+		if err != nil {
+			return BacklashEstimate{}, err
+		}
+		if !ok || !okReverse {
+			logger.Warnf("Skipping the %s edge for the backlash estimate, a sweep didn't cross it", name)
+			continue
+		}
+		result := BacklashEdge{Edge: name, Forward: forward, Reverse: reverse, Backlash: forward - reverse}
+		logger.Infof("%s edge crossed at %.2f mm sweeping forward and %.2f mm sweeping back", name, forward, reverse)
+		estimate.Edges = append(estimate.Edges, result)
+		estimate.Backlash += result.Backlash
+	}
+	if len(estimate.Edges) == 0 {
+		return BacklashEstimate{}, fmt.Errorf("no edge could be swept both ways to estimate backlash: %w", ErrEdgeNotFound)
+	}
+	estimate.Backlash /= float64(len(estimate.Edges))
+	logger.Infof("✓ Estimated gantry backlash of %.2f mm from %d edges", estimate.Backlash, len(estimate.Edges))
+	return estimate, nil
+}
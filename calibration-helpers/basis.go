@@ -0,0 +1,94 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// Corrections CorrectReferencePoints makes to reference points given in the wrong order, each of which would
+// otherwise mirror or upend the monitor frame
+const (
+	// CorrectionSwappedX swaps XPoint1 and XPoint2, which ran towards the right edge instead of the left
+	CorrectionSwappedX = "swapped_x_points"
+	// CorrectionMirroredZ mirrors ZPoint1 through the middle of the X points, as it was below them instead of above
+	CorrectionMirroredZ = "mirrored_z_point"
+)
+
+// CorrectReferencePoints checks the orientation reference points of a result against its corners, or the measured
+// edges of results without corners, and puts them in the order the monitor frame is built from, returning the
+// corrections applied
+// XPoint1 → XPoint2 must run towards the left edge (TopLeft, or LeftX towards +X) and ZPoint1 must lie above the X
+// points (towards TopLeft, or TopZ). Points that can't define a frame, such as a ZPoint1 on the line through the X points, are rejected.
+// The monitor frame applies the same corrections itself, so this is only needed to report and persist them.
+func CorrectReferencePoints(result *CalibrationResult) ([]string, error) {
+	normal, err := NormalFromPlane(result.Plane)
+	if err != nil {
+		return nil, err
+	}
+	_, _, corrections, err := referenceAxes(*result, normal)
+	if err != nil {
+		return nil, err
+	}
+	for _, correction := range corrections {
+		switch correction {
+		case CorrectionSwappedX:
+			result.XPoint1, result.XPoint2 = result.XPoint2, result.XPoint1
+		case CorrectionMirroredZ:
+			result.ZPoint1 = Point3D{
+				X: result.XPoint1.X + result.XPoint2.X - result.ZPoint1.X,
+				Y: result.XPoint1.Y + result.XPoint2.Y - result.ZPoint1.Y,
+				Z: result.XPoint1.Z + result.XPoint2.Z - result.ZPoint1.Z,
+			}
+		}
+	}
+	return corrections, nil
+}
+
+// referenceAxes returns the unit width direction, towards the left edge, and up direction given by the reference
+// points, both in the plane with the given unit normal and perpendicular to each other
+// Also returns the corrections that were needed to make them agree with the corners, which hold for a monitor
+// rolled in its plane. Without corners they are compared with the measured edges, where an edge pair is only
+// compared when it was measured and the direction has a clear component along its world axis.
+func referenceAxes(result CalibrationResult, normal r3.Vector) (across, up r3.Vector, corrections []string, err error) {
+	xPt1 := r3.Vector{X: result.XPoint1.X, Y: result.XPoint1.Y, Z: result.XPoint1.Z}
+	xPt2 := r3.Vector{X: result.XPoint2.X, Y: result.XPoint2.Y, Z: result.XPoint2.Z}
+	zPt := r3.Vector{X: result.ZPoint1.X, Y: result.ZPoint1.Y, Z: result.ZPoint1.Z}
+
+	// Project the measured width direction onto the plane (Gram-Schmidt against the normal)
+	across = xPt2.Sub(xPt1)
+	across = across.Sub(normal.Mul(across.Dot(normal)))
+	if across.Norm() < 1e-9 {
+		return r3.Vector{}, r3.Vector{}, nil, fmt.Errorf("X points do not span a direction on the plane")
+	}
+	across = across.Normalize()
+
+	// Measured "up": from the middle of the X points towards ZPoint1, square to the width
+	up = zPt.Sub(xPt1.Add(xPt2).Mul(0.5))
+	up = up.Sub(normal.Mul(up.Dot(normal)))
+	up = up.Sub(across.Mul(up.Dot(across)))
+	if up.Norm() < 1e-6 {
+		return r3.Vector{}, r3.Vector{}, nil, fmt.Errorf("ZPoint1 lies on the line through the X points, so it doesn't tell which way is up")
+	}
+	up = up.Normalize()
+
+	var swapX, mirrorZ bool
+	if c := result.Corners; c != nil {
+		vector := func(p Point3D) r3.Vector { return r3.Vector{X: p.X, Y: p.Y, Z: p.Z} }
+		swapX = across.Dot(vector(c.TopLeft).Sub(vector(c.TopRight))) < 0
+		mirrorZ = up.Dot(vector(c.TopLeft).Sub(vector(c.BottomLeft))) < 0
+	} else {
+		swapX = result.LeftX != result.RightX && math.Abs(across.X) >= 0.1 && across.X*(result.LeftX-result.RightX) < 0
+		mirrorZ = result.TopZ != result.BottomZ && math.Abs(up.Z) >= 0.1 && up.Z*(result.TopZ-result.BottomZ) < 0
+	}
+	if swapX {
+		across = across.Mul(-1)
+		corrections = append(corrections, CorrectionSwappedX)
+	}
+	if mirrorZ {
+		up = up.Mul(-1)
+		corrections = append(corrections, CorrectionMirroredZ)
+	}
+	return across, up, corrections, nil
+}
@@ -0,0 +1,168 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// seedMarginSteps is how many edge steps short of the previous edge a seeded edge search starts
+const seedMarginSteps = 3
+
+// CheckSeed reports why a previous result can't seed a calibration in the given frame, nil if it can
+func CheckSeed(seed CalibrationResult, frame string, maxAge time.Duration, now time.Time) error {
+	if seed.Timestamp.IsZero() {
+		return fmt.Errorf("previous calibration has no timestamp")
+	}
+	if age := now.Sub(seed.Timestamp); age > maxAge {
+		return fmt.Errorf("previous calibration is %s old, older than %s", age.Round(time.Second), maxAge)
+	}
+	if seed.Frame != "" && seed.Frame != frame {
+		return fmt.Errorf("previous calibration is in frame %q, not %q", seed.Frame, frame)
+	}
+	corners, err := ResultCorners(seed)
+	if err != nil {
+		return fmt.Errorf("previous calibration has no monitor bounds: %w", err)
+	}
+	if corners.TopLeft.X <= corners.TopRight.X || corners.TopLeft.Z <= corners.BottomLeft.Z {
+		return fmt.Errorf("previous calibration has no monitor bounds")
+	}
+	return nil
+}
+
+// seedSides returns the seed's left and right, or top and bottom, sides as pairs of corners
+func seedSides(seed CalibrationResult, vertical bool) ([2][2]Point3D, error) {
+	c, err := ResultCorners(seed)
+	if err != nil {
+		return [2][2]Point3D{}, err
+	}
+	if vertical {
+		return [2][2]Point3D{{c.TopLeft, c.BottomLeft}, {c.TopRight, c.BottomRight}}, nil
+	}
+	return [2][2]Point3D{{c.TopLeft, c.TopRight}, {c.BottomLeft, c.BottomRight}}, nil
+}
+
+// crossing returns the coordinate along one world axis where the side from p to q, in the X-Z plane, reaches
+// value along the other; along and at pick the two coordinates of a point
+func crossing(p, q Point3D, value float64, along, at func(Point3D) float64) float64 {
+	span := at(q) - at(p)
+	if math.Abs(span) < 1e-9 {
+		return (along(p) + along(q)) / 2
+	}
+	return along(p) + (along(q)-along(p))*(value-at(p))/span
+}
+
+func worldX(p Point3D) float64 { return p.X }
+func worldZ(p Point3D) float64 { return p.Z }
+
+// Chained returns scan settings with about half the samples over the same region
+// A seeded calibration only needs to confirm the previous plane, so a sparser scan is enough
+func (c ScanningConfig) Chained() ScanningConfig {
+	chained := c
+
+	// Keep the scan height by widening the steps as the count drops
+	zIntervals := c.ZNumSteps - 1
+	chainedIntervals := max(2, (zIntervals+1)/2)
+	if chainedIntervals < zIntervals {
+		chained.ZNumSteps = chainedIntervals + 1
+		chained.ZStepSize = c.ZStepSize * float64(zIntervals) / float64(chainedIntervals)
+	}
+	chained.XNumSteps = max(3, (c.XNumSteps+1)/2)
+	if c.XSpacing > 0 {
+		chained.XSpacing = 2 * c.XSpacing
+	}
+	if c.ZSpacing > 0 {
+		chained.ZSpacing = 2 * c.ZSpacing
+	}
+	chained.WristSweepSteps = max(3, (c.WristSweepSteps+1)/2)
+	return chained
+}
+
+// seedGantryStart returns the gantry position to start an edge search from, a few steps short of the seed's edge
+// The gantry is moved to centerPos and the reading there must lie on the plane, otherwise the search isn't seeded
+func seedGantryStart(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, gantry gantry.Gantry, plane Plane, xDirection int,
+	centerPos, endPos float64, config CalibrationConfig) (float64, bool, error) {
+	sides, err := seedSides(*config.Seed, true)
+	if err != nil {
+		return 0, false, nil
+	}
+	side := sides[1]
+	if xDirection == 1 {
+		side = sides[0]
+	}
+
+	if err := gantry.MoveToPosition(ctx, []float64{centerPos}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+		return 0, false, fmt.Errorf("failed to move gantry: %w", err)
+	}
+	reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+	if err != nil || !onSurface(reading, plane, config) {
+		return 0, false, nil
+	}
+
+	// A rolled monitor's side is slanted, so aim for where it is at the height of the reading
+	seedX := crossing(side[0], side[1], reading.SurfacePoint.Z, worldX, worldZ)
+	travel := (seedX-reading.SurfacePoint.X)*float64(xDirection) - seedMarginSteps*config.Detection.EdgeStepSize
+	if travel <= config.Detection.EdgeStepSize {
+		return 0, false, nil
+	}
+	moveDirection := float64(xDirection) * config.Hardware.MotionSign()
+	start := centerPos + moveDirection*travel
+	if moveDirection > 0 {
+		start = math.Min(start, endPos)
+	} else {
+		start = math.Max(start, endPos)
+	}
+	return start, true, nil
+}
+
+// seedArmStart moves the arm most of the way from its current pose to the seed's edge
+// Returns the pose the arm started from so the search can fall back to it, and whether the arm moved
+func seedArmStart(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, plane Plane, zDirection int, config CalibrationConfig) (spatialmath.Pose, bool, error) {
+	startPose, err := arm.EndPosition(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get arm position: %w", err)
+	}
+	reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+	if err != nil || !onSurface(reading, plane, config) {
+		return startPose, false, nil
+	}
+
+	sides, err := seedSides(*config.Seed, false)
+	if err != nil {
+		return startPose, false, nil
+	}
+	side := sides[1]
+	if zDirection == 1 {
+		side = sides[0]
+	}
+	seedZ := crossing(side[0], side[1], reading.SurfacePoint.X, worldZ, worldX)
+	travel := (seedZ-reading.SurfacePoint.Z)*float64(zDirection) - seedMarginSteps*config.Detection.EdgeStepSize
+	if travel <= config.Detection.EdgeStepSize {
+		return startPose, false, nil
+	}
+	nextPose := spatialmath.NewPose(
+		r3.Vector{
+			X: startPose.Point().X,
+			Y: startPose.Point().Y,
+			Z: startPose.Point().Z + float64(zDirection)*config.Hardware.MotionSign()*travel,
+		},
+		startPose.Orientation(),
+	)
+	if err := arm.MoveToPosition(ctx, nextPose, nil); err != nil {
+		// Out of reach, search step by step from where the arm is
+		logger.Debugf("could not jump toward previous edge: %v", err)
+		return startPose, false, nil
+	}
+	return startPose, true, nil
+}
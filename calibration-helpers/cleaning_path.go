@@ -0,0 +1,92 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"calibration/types"
+	"fmt"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Surface models used when generating cleaning paths
+//
+// Deprecated: use the types package's Surface* constants.
+const (
+	SurfaceFlat      = types.SurfaceFlat
+	SurfaceCorrected = types.SurfaceCorrected
+)
+
+// CleaningConfig contains parameters for generating cleaning paths over the calibrated monitor
+//
+// Deprecated: use types.CleaningConfig.
+type CleaningConfig = types.CleaningConfig
+
+// CleaningPath is a sequence of tool poses over the monitor surface
+type CleaningPath struct {
+	PadThickness float64            // mm - pad thickness the path was computed for
+	Standoff     float64            // mm - tool flange distance from the glass along the normal
+	Surface      string             // surface model the path follows
+	Poses        []spatialmath.Pose // tool poses in the reference frame, pointing into the glass
+}
+
+// PadStandoff returns how far the tool flange should sit from the glass for the given pad thickness
+// Keeping the pad compression constant as the pad wears keeps the contact pressure constant
+func PadStandoff(padThickness float64, config CleaningConfig) (float64, error) {
+	if padThickness < config.MinPadThickness {
+		return 0, fmt.Errorf("cleaning pad is worn out: %.2f mm < minimum %.2f mm", padThickness, config.MinPadThickness)
+	}
+	standoff := padThickness - config.PadCompression
+	if standoff <= 0 {
+		return 0, fmt.Errorf("pad compression %.2f mm exceeds pad thickness %.2f mm", config.PadCompression, padThickness)
+	}
+	return standoff, nil
+}
+
+// GenerateCleaningPath creates serpentine horizontal strokes covering the calibrated monitor
+// On a flat surface each stroke is a start and end pose and the tool points along the negative plane normal.
+// On a corrected surface strokes are sampled every StrokeSpacing so the poses follow the glass height and normal.
+func GenerateCleaningPath(result CalibrationResult, padThickness float64, config CleaningConfig) (CleaningPath, error) {
+	if config.StrokeSpacing <= 0 {
+		return CleaningPath{}, fmt.Errorf("stroke spacing must be positive")
+	}
+
+	standoff, err := PadStandoff(padThickness, config)
+	if err != nil {
+		return CleaningPath{}, err
+	}
+
+	geometry, err := monitorSurfaceFromResult(result, config.Surface)
+	if err != nil {
+		return CleaningPath{}, fmt.Errorf("failed to build monitor geometry: %w", err)
+	}
+
+	halfWidth := geometry.Width/2 - config.EdgeMargin
+	halfHeight := geometry.Height/2 - config.EdgeMargin
+	if halfWidth <= 0 || halfHeight < 0 {
+		return CleaningPath{}, fmt.Errorf("edge margin %.1f mm leaves no area to clean on a %.1fx%.1f mm monitor",
+			config.EdgeMargin, geometry.Width, geometry.Height)
+	}
+
+	// Strokes in monitor-local coordinates: spacing the width apart leaves just the start and end of each row
+	strokeSampling := 2 * halfWidth
+	if geometry.bent() {
+		strokeSampling = config.StrokeSpacing
+	}
+	region := scanpath.Region{XMin: -halfWidth, XMax: halfWidth, ZMin: -halfHeight, ZMax: halfHeight}
+	strokes, err := scanpath.Generate(region, scanpath.Serpentine, strokeSampling, config.StrokeSpacing)
+	if err != nil {
+		return CleaningPath{}, fmt.Errorf("failed to plan cleaning strokes: %w", err)
+	}
+
+	path := CleaningPath{PadThickness: padThickness, Standoff: standoff, Surface: SurfaceFlat}
+	if geometry.bent() {
+		path.Surface = SurfaceCorrected
+	}
+	for _, wp := range strokes {
+		point, normal := geometry.surfaceFrame(wp.X, wp.Z)
+		toolOrientation := &spatialmath.OrientationVector{OX: -normal.X, OY: -normal.Y, OZ: -normal.Z}
+		toolOrientation.Normalize()
+		path.Poses = append(path.Poses, spatialmath.NewPose(point.Add(normal.Mul(standoff)), toolOrientation))
+	}
+	return path, nil
+}
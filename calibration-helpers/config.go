@@ -1,9 +1,14 @@
 package calibrationhelpers
 
 import (
+	"calibration/scanpath"
 	"errors"
+	"fmt"
 	"math"
 	"os"
+	"time"
+
+	"go.viam.com/rdk/spatialmath"
 )
 
 // CalibrationConfig holds all configuration for the calibration workflow
@@ -13,26 +18,172 @@ type CalibrationConfig struct {
 	Detection    DetectionConfig
 	Robot        RobotConfig
 	ArmPositions ArmPositions
+	Cleaning     CleaningConfig
+	Alerts       AlertConfig
+	Vision       VisionConfig
+	Filter       FilterConfig
+	Retry        RetryConfig
+
+	// SessionID identifies the calibration run in its result, empty for none
+	SessionID string
+
+	// Baseline is the previous result for the same monitor, checked against the movement alerts, nil to skip them
+	Baseline *CalibrationResult
+
+	// Progress is called after every scan point, nil to skip progress reporting
+	Progress func(ScanProgress)
+
+	// Motion plans scan moves with the motion service, nil to move the arm and gantry directly
+	Motion *MotionConfig
+
+	// Control carries scan changes made while the calibration runs, and pauses or aborts it, nil to keep the
+	// scan fixed
+	Control *ScanControl
+
+	// Backlash corrects sensor poses for the lost motion of the gantry, nil to take the gantry position as exact.
+	// It only sees moves made through the gantry it wraps.
+	Backlash *GantryBacklash
+
+	// Homing homes the gantry and checks a reference target before the scan, nil to scan from the gantry's
+	// current zero
+	Homing *HomingConfig
+
+	// OrientationSweep refines the fitted plane normal with wrist sweeps after the plane fit, nil to keep the
+	// normal of the position fit
+	OrientationSweep *OrientationSweepConfig
+
+	// Hint is a rough monitor position the gantry window and Z scan are planned from at the start of a run, nil to
+	// scan the configured region
+	Hint *MonitorHint
+
+	// Resume is the session of an interrupted run, whose visited waypoints are replayed instead of scanned again,
+	// nil to scan every waypoint
+	Resume *ScanSession
+
+	// Curve is the cylinder fit to a curved monitor's scan, which edge searches compare readings against
+	// instead of the plane. Set during calibration, nil for flat monitors.
+	Curve *Cylinder
+
+	// Seed is a previous result used as the initial guess for the plane fit and edge searches, nil for a full search
+	Seed *CalibrationResult
 }
 
+// Calibration topologies describing which side of the measurement moves
+const (
+	// TopologyMovingSensor is the default rig: the sensor rides on the arm/gantry past a fixed monitor
+	TopologyMovingSensor = "moving_sensor"
+	// TopologyMovingMonitor is a rig where the monitor rides on the arm/gantry past a fixed sensor
+	TopologyMovingMonitor = "moving_monitor"
+)
+
 // HardwareConfig contains hardware-specific parameters
 type HardwareConfig struct {
 	GripperWidth float64 // mm - gripper width for collision avoidance
 	WorldFrame   string  // reference frame name for coordinate transforms
+	Topology     string  // TopologyMovingSensor or TopologyMovingMonitor
+	MonitorFrame string  // frame the monitor is mounted to (moving-monitor topology only)
+	SensorMove   string  // SplitGantryFirst, SplitArmFirst or SplitOptimizeReach - how MoveSensorTo splits moves
+	ArmReach     float64 // mm - farthest the end effector gets from the arm base, zero to estimate it from the arm's kinematics
+	// MountOffset is the transducer's pose in the sensor's frame, nil when the sensor frame is the transducer
+	MountOffset spatialmath.Pose
+	// DistanceReading is the shape of the sensors' readings: DistanceUltrasonic (default), DistanceMeters or
+	// DistanceMillimeters, see NewDistanceSource
+	DistanceReading string
 }
 
+// Transducer returns the transducer's pose given the sensor frame's, applying MountOffset
+func (h HardwareConfig) Transducer(sensorPose spatialmath.Pose) spatialmath.Pose {
+	if h.MountOffset == nil {
+		return sensorPose
+	}
+	return spatialmath.Compose(sensorPose, h.MountOffset)
+}
+
+// ReferenceFrame returns the frame that surface points are expressed in
+// For a moving monitor, points are collected in the monitor mount frame so they stay fixed on the screen as it moves
+func (h HardwareConfig) ReferenceFrame() string {
+	if h.Topology == TopologyMovingMonitor {
+		return h.MonitorFrame
+	}
+	return h.WorldFrame
+}
+
+// MotionSign returns the sign that maps a desired direction on the monitor to an actuator move
+// Moving the monitor +Z sweeps the sensor's hit point -Z across the screen, so the search direction inverts
+func (h HardwareConfig) MotionSign() float64 {
+	if h.Topology == TopologyMovingMonitor {
+		return -1
+	}
+	return 1
+}
+
+// Scan modes for collecting surface points
+const (
+	// ScanModeLinear translates the sensor along Z (arm) and X (gantry)
+	ScanModeLinear = "linear"
+	// ScanModeAngular holds the end effector at a few heights and sweeps the wrist to fan rays across the screen
+	ScanModeAngular = "angular"
+	// ScanModeGrid covers the scan region with a scanpath pattern (raster, serpentine, or spiral)
+	ScanModeGrid = "grid"
+	// ScanModeAdaptive scans a coarse grid, then refines it near the edges and where the glass departs from the plane
+	ScanModeAdaptive = "adaptive"
+)
+
 // ScanningConfig contains parameters for the scanning phase
 type ScanningConfig struct {
+	Mode        string  // ScanModeLinear, ScanModeAngular, ScanModeGrid or ScanModeAdaptive
 	ZStepSize   float64 // mm - vertical step size for Z-axis scan
+	ZMin        float64 // mm - arm height offset from the home pose the Z-axis scan starts at
 	ZNumSteps   int     // number of Z-axis scan points
 	XNumSteps   int     // number of X-axis (gantry) scan points
 	GantrySpeed float64 // mm/sec - gantry movement speed
+	GantryMin   float64 // mm - start of the gantry travel used for scanning
+	GantryMax   float64 // mm - end of the gantry travel used for scanning, zero for the full length
+
+	// Waypoint diagnostics: waypoints that time out or miss the monitor are skipped and reported
+	SettleTimeout time.Duration // max time for the arm or gantry to reach a waypoint, zero for no limit
+	ReadTimeout   time.Duration // max time for a sensor reading, zero for no limit
+	MaxRange      float64       // mm - readings at or beyond this distance missed the monitor, zero to keep them
+
+	// Accuracy versus time: holding still longer and reading more often at each waypoint lowers the noise
+	Dwell           time.Duration // time the sensor holds still at a scan waypoint before reading, zero for none
+	SamplesPerPoint int           // readings taken at each pose, zero to follow the filter
+
+	// Scan pipeline: the hardware moves on while captured readings are filtered and reported, moves and reads
+	// themselves staying sequential
+	Workers    int // goroutines filtering captured readings
+	QueueDepth int // captures waiting for a worker before the hardware pauses
+	MaxPoints  int // scan points a pass keeps for the final fit, zero to keep all; the running fit sees every point
+
+	// Grid scan mode parameters
+	Pattern  string  // scanpath pattern name
+	XSpacing float64 // mm - max gantry spacing between samples, zero to derive from XNumSteps
+	ZSpacing float64 // mm - max arm height spacing between samples, zero to use ZStepSize
+
+	// Adaptive scan mode parameters, on top of the grid parameters, which set the finest spacing
+	CoarseFactor   int     // first pass spacing as a multiple of the grid spacing
+	RefineResidual float64 // mm - cells with a sample further than this from the plane are refined
+
+	// Angular scan mode parameters
+	AngularHolds    int     // number of end effector heights to sweep from
+	WristJoint      int     // index of the arm joint swept to fan the rays
+	WristSweepAngle float64 // degrees - half-angle of each wrist sweep
+	WristSweepSteps int     // number of rays cast per sweep
 }
 
 // DetectionConfig contains parameters for edge detection
 type DetectionConfig struct {
-	PlaneThreshold float64 // mm - distance threshold for edge detection
-	EdgeStepSize   float64 // mm - step size when searching for edges
+	PlaneThreshold   float64 // mm - distance threshold for edge detection
+	EdgeStepSize     float64 // mm - step size when searching for edges
+	EdgePrecision    float64 // mm - how closely edge searches bisect the hit/miss transition
+	RansacThreshold  float64 // mm - inlier distance threshold for RANSAC plane fitting
+	RansacIterations int     // number of RANSAC hypotheses to evaluate
+	DeviationOrder   int     // polynomial order of the flatness deviation surface, 0 to only measure the offset
+	CornerSweeps     int     // sweeps per direction for corner detection, 0 to skip it
+	Curved           bool    // fit a cylinder about the vertical axis for curved monitors
+
+	// IncidenceWeighting weighs scan points by the cosine of their beam's incidence angle on the plane
+	IncidenceWeighting bool
 }
 
 // RobotConfig contains robot connection and component information
@@ -45,6 +196,28 @@ type RobotConfig struct {
 	GantryName string // Gantry component name
 }
 
+// GantryLimits returns the gantry travel window to scan within, given the gantry lengths
+// A window that is empty or inverted, once clamped to the gantry travel, is an error.
+func (c ScanningConfig) GantryLimits(gantryLengths []float64) (float64, float64, error) {
+	if len(gantryLengths) == 0 {
+		return 0, 0, errors.New("gantry has no axes")
+	}
+	maxPosition := gantryLengths[0]
+	if c.GantryMax > 0 && c.GantryMax < maxPosition {
+		maxPosition = c.GantryMax
+	}
+	if c.GantryMin < 0 || c.GantryMin >= maxPosition {
+		return 0, 0, fmt.Errorf("gantry window from %.1f to %.1f mm is empty within the %.1f mm gantry travel",
+			c.GantryMin, maxPosition, gantryLengths[0])
+	}
+	return c.GantryMin, maxPosition, nil
+}
+
+// ZRange returns the arm height offsets from the home pose that the Z-axis scan spans
+func (c ScanningConfig) ZRange() (float64, float64) {
+	return c.ZMin, c.ZMin + c.ZStepSize*float64(c.ZNumSteps-1)
+}
+
 // ArmPositions contains named arm joint positions for different phases
 type ArmPositions struct {
 	Home       []float64 // Starting position for calibration
@@ -65,18 +238,62 @@ func NewDefaultConfig() CalibrationConfig {
 		Hardware: HardwareConfig{
 			GripperWidth: 106.4, // mm - default gripper width
 			WorldFrame:   "world",
+			Topology:     TopologyMovingSensor,
+			SensorMove:   SplitGantryFirst,
 		},
 		Scanning: ScanningConfig{
-			ZStepSize:   10.0, // mm
-			ZNumSteps:   10,
-			XNumSteps:   10,
-			GantrySpeed: 50.0, // mm/sec
+			Mode:            ScanModeLinear,
+			ZStepSize:       10.0, // mm
+			ZNumSteps:       10,
+			XNumSteps:       10,
+			GantrySpeed:     50.0, // mm/sec
+			MaxRange:        4000, // mm - ultrasonic sensor max range
+			Workers:         2,
+			QueueDepth:      4,
+			Pattern:         string(scanpath.Serpentine),
+			CoarseFactor:    4,
+			RefineResidual:  3.0, // mm
+			AngularHolds:    3,
+			WristJoint:      3,
+			WristSweepAngle: 20.0, // degrees
+			WristSweepSteps: 9,
 		},
 		Detection: DetectionConfig{
-			PlaneThreshold: 20.0, // mm
-			EdgeStepSize:   10.0, // mm
+			PlaneThreshold:   20.0, // mm
+			EdgeStepSize:     10.0, // mm
+			EdgePrecision:    0.5,  // mm
+			RansacThreshold:  5.0,  // mm
+			RansacIterations: 200,
+			DeviationOrder:   2,
 		},
 		ArmPositions: DefaultArmPositions,
+		Filter: FilterConfig{
+			Mode:             FilterNone,
+			Samples:          5,
+			Alpha:            0.3,
+			ProcessNoise:     0.01, // mm²
+			MeasurementNoise: 4,    // mm² - 2 mm sensor noise
+		},
+		Retry: RetryConfig{
+			MaxAttempts: 3,
+			Backoff:     100 * time.Millisecond,
+			MaxBackoff:  time.Second,
+		},
+		Vision: VisionConfig{
+			MinScore:    0.5,
+			TopLeft:     "0",
+			TopRight:    "1",
+			BottomRight: "2",
+			BottomLeft:  "3",
+		},
+		Cleaning: CleaningConfig{
+			StrokeSpacing:   20.0, // mm
+			EdgeMargin:      10.0, // mm
+			PadThickness:    10.0, // mm
+			PadCompression:  2.0,  // mm
+			MinPadThickness: 4.0,  // mm
+			Surface:         SurfaceFlat,
+		},
 	}
 }
 
@@ -88,18 +305,106 @@ func (c *CalibrationConfig) Validate() error {
 	if c.Scanning.ZNumSteps < 2 || c.Scanning.XNumSteps < 2 {
 		return errors.New("scan steps must be >= 2")
 	}
+	switch c.Scanning.Mode {
+	case ScanModeLinear:
+	case ScanModeGrid, ScanModeAdaptive:
+		if c.Scanning.Mode == ScanModeAdaptive && (c.Scanning.CoarseFactor < 2 || c.Scanning.RefineResidual <= 0) {
+			return errors.New("adaptive coarse factor must be >= 2 and refine residual must be positive")
+		}
+		if c.Scanning.XSpacing < 0 || c.Scanning.ZSpacing < 0 {
+			return errors.New("grid spacing must not be negative")
+		}
+		switch scanpath.Pattern(c.Scanning.Pattern) {
+		case scanpath.Raster, scanpath.Serpentine, scanpath.Spiral:
+		default:
+			return fmt.Errorf("unknown scan pattern %q", c.Scanning.Pattern)
+		}
+	case ScanModeAngular:
+		if c.Scanning.AngularHolds < 2 || c.Scanning.WristSweepSteps < 2 {
+			return errors.New("angular holds and wrist sweep steps must be >= 2")
+		}
+		if c.Scanning.WristSweepAngle <= 0 || c.Scanning.WristSweepAngle >= 90 {
+			return errors.New("wrist sweep angle must be between 0 and 90 degrees")
+		}
+		if c.Scanning.WristJoint < 0 || c.Scanning.WristJoint >= len(c.ArmPositions.Home) {
+			return errors.New("wrist joint index out of range")
+		}
+	default:
+		return fmt.Errorf("unknown scan mode %q", c.Scanning.Mode)
+	}
+	if c.OrientationSweep != nil {
+		if err := c.OrientationSweep.Validate(c.ArmPositions); err != nil {
+			return err
+		}
+	}
+	switch c.Hardware.Topology {
+	case TopologyMovingSensor:
+	case TopologyMovingMonitor:
+		if c.Hardware.MonitorFrame == "" {
+			return errors.New("monitor frame must be set for the moving monitor topology")
+		}
+	default:
+		return fmt.Errorf("unknown topology %q", c.Hardware.Topology)
+	}
+	switch c.Hardware.DistanceReading {
+	case "", DistanceUltrasonic, DistanceMeters, DistanceMillimeters:
+	default:
+		return fmt.Errorf("unknown sensor reading %q", c.Hardware.DistanceReading)
+	}
 	if c.Hardware.GripperWidth <= 0 {
 		return errors.New("gripper width must be positive")
 	}
 	if c.Detection.PlaneThreshold <= 0 {
 		return errors.New("plane threshold must be positive")
 	}
+	if c.Detection.RansacThreshold <= 0 || c.Detection.RansacIterations <= 0 {
+		return errors.New("RANSAC threshold and iterations must be positive")
+	}
+	if c.Detection.DeviationOrder < 0 || c.Detection.DeviationOrder > 4 {
+		return errors.New("deviation order must be between 0 and 4")
+	}
+	if c.Detection.CornerSweeps < 0 || c.Detection.CornerSweeps == 1 {
+		return errors.New("corner sweeps must be 0 (disabled) or at least 2")
+	}
 	if c.Scanning.ZStepSize <= 0 || c.Detection.EdgeStepSize <= 0 {
 		return errors.New("step sizes must be positive")
 	}
+	if c.Detection.EdgePrecision <= 0 {
+		return errors.New("edge precision must be positive")
+	}
 	if c.Scanning.GantrySpeed <= 0 {
 		return errors.New("gantry speed must be positive")
 	}
+	if c.Scanning.SettleTimeout < 0 || c.Scanning.ReadTimeout < 0 || c.Scanning.MaxRange < 0 {
+		return errors.New("settle timeout, read timeout and max range must not be negative")
+	}
+	if c.Scanning.Dwell < 0 || c.Scanning.SamplesPerPoint < 0 {
+		return errors.New("dwell time and samples per point must not be negative")
+	}
+	if c.Scanning.Workers < 1 || c.Scanning.QueueDepth < 1 {
+		return errors.New("scan workers and queue depth must be at least 1")
+	}
+	if c.Scanning.GantryMin < 0 || (c.Scanning.GantryMax > 0 && c.Scanning.GantryMax <= c.Scanning.GantryMin) {
+		return errors.New("gantry scan window must satisfy 0 <= min < max")
+	}
+	if c.Cleaning.StrokeSpacing <= 0 || c.Cleaning.PadThickness <= 0 {
+		return errors.New("cleaning stroke spacing and pad thickness must be positive")
+	}
+	if c.Cleaning.EdgeMargin < 0 || c.Cleaning.PadCompression < 0 || c.Cleaning.MinPadThickness < 0 {
+		return errors.New("cleaning margin, pad compression and minimum pad thickness must not be negative")
+	}
+	if c.Cleaning.PadCompression >= c.Cleaning.PadThickness {
+		return errors.New("pad compression must be less than pad thickness")
+	}
+	if c.Cleaning.Surface != SurfaceFlat && c.Cleaning.Surface != SurfaceCorrected {
+		return fmt.Errorf("unknown cleaning surface %q", c.Cleaning.Surface)
+	}
+	if err := c.Filter.Validate(); err != nil {
+		return err
+	}
+	if err := c.Retry.Validate(); err != nil {
+		return err
+	}
 	if len(c.ArmPositions.Home) == 0 || len(c.ArmPositions.BottomScan) == 0 || len(c.ArmPositions.TopScan) == 0 {
 		return errors.New("arm positions must be defined")
 	}
@@ -0,0 +1,78 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+)
+
+// Coordinate conventions for imported and exported data
+// Everything inside this package is right-handed with Z up, as in Viam, and converted at the boundary
+const (
+	// ConventionViam is right-handed with Z up, used by Viam, ROS and CloudCompare
+	ConventionViam = "right_handed_z_up"
+	// ConventionYUp is right-handed with Y up and Z towards the viewer, used by OpenGL, glTF and three.js
+	ConventionYUp = "right_handed_y_up"
+	// ConventionLeftYUp is left-handed with Y up and Z away from the viewer, used by Unity
+	ConventionLeftYUp = "left_handed_y_up"
+	// ConventionLeftZUp is left-handed with Z up, used by Unreal
+	ConventionLeftZUp = "left_handed_z_up"
+)
+
+// conventionAxes are the rows of the matrix taking Viam coordinates to each convention
+// Every matrix is a signed permutation, so its transpose converts back
+var conventionAxes = map[string][3]r3.Vector{
+	ConventionViam:    {{X: 1}, {Y: 1}, {Z: 1}},
+	ConventionYUp:     {{X: 1}, {Z: 1}, {Y: -1}},
+	ConventionLeftYUp: {{X: 1}, {Z: 1}, {Y: 1}},
+	ConventionLeftZUp: {{X: 1}, {Y: -1}, {Z: 1}},
+}
+
+// ValidateConvention checks that name is a known convention, empty meaning ConventionViam
+func ValidateConvention(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := conventionAxes[name]; !ok {
+		return fmt.Errorf("unknown coordinate convention %q, expected %s, %s, %s or %s",
+			name, ConventionViam, ConventionYUp, ConventionLeftYUp, ConventionLeftZUp)
+	}
+	return nil
+}
+
+// ToConvention converts a point or direction from Viam coordinates to the named convention
+func ToConvention(v r3.Vector, convention string) (r3.Vector, error) {
+	if err := ValidateConvention(convention); err != nil {
+		return r3.Vector{}, err
+	}
+	if convention == "" {
+		return v, nil
+	}
+	axes := conventionAxes[convention]
+	return r3.Vector{X: axes[0].Dot(v), Y: axes[1].Dot(v), Z: axes[2].Dot(v)}, nil
+}
+
+// FromConvention converts a point or direction in the named convention to Viam coordinates
+func FromConvention(v r3.Vector, convention string) (r3.Vector, error) {
+	if err := ValidateConvention(convention); err != nil {
+		return r3.Vector{}, err
+	}
+	if convention == "" {
+		return v, nil
+	}
+	axes := conventionAxes[convention]
+	return axes[0].Mul(v.X).Add(axes[1].Mul(v.Y)).Add(axes[2].Mul(v.Z)), nil
+}
+
+// CheckHandedness checks that x, y and z form a right-handed set of axes
+// Axes that are consistently left-handed usually mean the data came from a left-handed tool, which the error says
+func CheckHandedness(x, y, z r3.Vector) error {
+	switch det := x.Cross(y).Dot(z); {
+	case det > 0.99:
+		return nil
+	case det < -0.99:
+		return fmt.Errorf("axes are left-handed, the data likely uses %s or %s coordinates", ConventionLeftYUp, ConventionLeftZUp)
+	default:
+		return fmt.Errorf("axes are not orthonormal")
+	}
+}
@@ -0,0 +1,407 @@
+package calibrationhelpers
+
+import (
+	"calibration/types"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// MonitorCorners are the four monitor corners on the fitted plane
+//
+// Deprecated: use types.MonitorCorners.
+type MonitorCorners = types.MonitorCorners
+
+// FindMonitorCorners sweeps the sensor across the fitted plane and locates the corners from hit/miss transitions
+// A vertical sweep (arm) through the middle of the gantry window finds how tall the monitor is there. Horizontal
+// sweeps (gantry) at several heights across that span then find the left and right edges, and vertical sweeps at
+// several gantry positions the top and bottom edges. A line is fit to each edge and adjacent edges are intersected,
+// so a monitor that is rolled in its plane is reported correctly.
+func FindMonitorCorners(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry, plane Plane, config CalibrationConfig) (MonitorCorners, error) {
+	sweeps := config.Detection.CornerSweeps
+	if sweeps < 2 {
+		return MonitorCorners{}, fmt.Errorf("corner detection needs at least 2 sweeps per direction, got %d", sweeps)
+	}
+
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return MonitorCorners{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	gantryMin, gantryMax, err := config.Scanning.GantryLimits(gantryLengths)
+	if err != nil {
+		return MonitorCorners{}, err
+	}
+	gantryCenter := (gantryMin + gantryMax) / 2
+	zMin, zMax := config.Scanning.ZRange()
+	scanHeight := zMax - zMin
+
+	if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+		return MonitorCorners{}, fmt.Errorf("failed to reset arm: %w", err)
+	}
+	homePose, err := arm.EndPosition(ctx, nil)
+	if err != nil {
+		return MonitorCorners{}, fmt.Errorf("failed to get arm position: %w", err)
+	}
+	moveArm := func(z float64) error {
+		return arm.MoveToPosition(ctx, spatialmath.NewPose(
+			r3.Vector{X: homePose.Point().X, Y: homePose.Point().Y, Z: homePose.Point().Z + z},
+			homePose.Orientation(),
+		), nil)
+	}
+	read := func() (Point3D, bool, error) {
+		reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+		if err != nil {
+			return Point3D{}, false, fmt.Errorf("failed to get sensor reading: %w", err)
+		}
+		return reading.SurfacePoint, onSurface(reading, plane, config), nil
+	}
+	probeGantry := func(pos float64) (Point3D, bool, bool, error) {
+		if err := gantry.MoveToPosition(ctx, []float64{pos}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return Point3D{}, false, false, fmt.Errorf("failed to move gantry: %w", err)
+		}
+		point, hit, err := read()
+		return point, hit, true, err
+	}
+	probeArm := func(z float64) (Point3D, bool, bool, error) {
+		if err := moveArm(z); err != nil {
+			// Out of reach, treat like the end of the gantry travel
+			logger.Debugf("corner search - arm cannot reach z offset %.1f: %v", z, err)
+			return Point3D{}, false, false, nil
+		}
+		point, hit, err := read()
+		return point, hit, true, err
+	}
+
+	// sweepArm searches up and down from z with the gantry at x, returning the transitions and how far the
+	// monitor reached each way, or the arm's reach
+	var horizontalEdges, verticalEdges []Point3D
+	sweepArm := func(x, z float64) (float64, float64, error) {
+		if err := gantry.MoveToPosition(ctx, []float64{x}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return 0, 0, fmt.Errorf("failed to move gantry: %w", err)
+		}
+		reach := [2]float64{z, z}
+		for i, direction := range []float64{1, -1} {
+			edge, err := FindEdge(probeArm, z, math.Inf(int(direction)), direction*config.Detection.EdgeStepSize,
+				config.Detection.EdgePrecision)
+			if err != nil && !errors.Is(err, ErrEdgeNotFound) {
+				return 0, 0, err
+			}
+			if err != nil {
+				logger.Debugf("corner search - started off the monitor at gantry %.1f, z offset %.1f", x, z)
+				return z, z, nil
+			}
+			reach[i] = edge.Position
+			if !edge.Found {
+				logger.Debugf("corner search - no transition at gantry %.1f in arm direction %+.0f", x, direction)
+				continue
+			}
+			verticalEdges = append(verticalEdges, edge.SurfacePoint)
+		}
+		return reach[1], reach[0], nil
+	}
+
+	// A vertical sweep through the middle of the gantry window bounds the heights of the horizontal sweeps, so on
+	// a rolled monitor they reach its corners rather than only the band the scan covers
+	zBottom, zTop, err := sweepArm(gantryCenter, zMin+scanHeight/2)
+	if err != nil {
+		return MonitorCorners{}, err
+	}
+	if zTop <= zBottom {
+		zBottom, zTop = zMin, zMax
+	}
+
+	// Horizontal sweeps find the left and right edges, and the top and bottom ones where a rolled monitor's edge
+	// crosses their height. Each records the stretch of gantry travel it saw the monitor over.
+	type chord struct{ z, low, high float64 }
+	var chords []chord
+	hitMin, hitMax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < sweeps; i++ {
+		z := zBottom + (zTop-zBottom)*(float64(i)+0.5)/float64(sweeps)
+		if err := moveArm(z); err != nil {
+			return MonitorCorners{}, fmt.Errorf("failed to move arm to z offset %.1f: %w", z, err)
+		}
+		c := chord{z: z, low: gantryMin, high: gantryMax}
+		for _, direction := range []float64{1, -1} {
+			limit := gantryMax
+			if direction < 0 {
+				limit = gantryMin
+			}
+			edge, err := FindEdge(probeGantry, gantryCenter, limit, direction*config.Detection.EdgeStepSize,
+				config.Detection.EdgePrecision)
+			if err != nil && !errors.Is(err, ErrEdgeNotFound) {
+				return MonitorCorners{}, err
+			}
+			if err != nil || !edge.Found {
+				logger.Debugf("corner search - no transition at z offset %.1f towards gantry %.1f", z, limit)
+				continue
+			}
+			horizontalEdges = append(horizontalEdges, edge.SurfacePoint)
+			hitMin, hitMax = math.Min(hitMin, edge.Position), math.Max(hitMax, edge.Position)
+			if direction > 0 {
+				c.high = edge.Position
+			} else {
+				c.low = edge.Position
+			}
+		}
+		chords = append(chords, c)
+	}
+	if len(horizontalEdges) == 0 {
+		return MonitorCorners{}, fmt.Errorf("horizontal sweeps found no monitor edges: %w", ErrEdgeNotFound)
+	}
+
+	// Vertical sweeps between the horizontal transitions find the top and bottom edges. Each starts at the height
+	// of the horizontal sweep that saw the monitor furthest either side of it, which is on the monitor even when
+	// a roll has moved the monitor's middle away from the scan's.
+	for i := 0; i < sweeps; i++ {
+		x := hitMin + (hitMax-hitMin)*(float64(i)+0.5)/float64(sweeps)
+		start, margin := zBottom+(zTop-zBottom)/2, math.Inf(-1)
+		for _, c := range chords {
+			if m := math.Min(x-c.low, c.high-x); m > margin {
+				start, margin = c.z, m
+			}
+		}
+		if _, _, err := sweepArm(x, start); err != nil {
+			return MonitorCorners{}, err
+		}
+	}
+
+	if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+		return MonitorCorners{}, fmt.Errorf("failed to reset arm: %w", err)
+	}
+
+	corners, err := cornersFromEdgePoints(logger, plane, horizontalEdges, verticalEdges)
+	if err != nil {
+		return MonitorCorners{}, err
+	}
+	logger.Infof("✓ Found monitor corners from %d edge points, rotated %.2f° in plane", corners.EdgePoints, corners.Rotation)
+	return corners, nil
+}
+
+// cornersFromEdgePoints fits the monitor rectangle to the hit/miss transitions and intersects its edges
+// The transitions all lie on the outline of the monitor, but a sweep across a rolled monitor can leave it through
+// the top or bottom edge as well as a side, so the sweep that found a point doesn't tell which edge it is on. The
+// points are projected onto the plane and enclosed in their minimum-area rectangle, which for points spread along
+// every edge is the monitor itself. Each point is assigned to the nearest side of that rectangle and a line is fit
+// to each side. The side nearest the plane's horizontal is taken as the top, so rolls up to 45° either way are
+// told apart from a portrait monitor.
+func cornersFromEdgePoints(logger logging.Logger, plane Plane, horizontalEdges, verticalEdges []Point3D) (MonitorCorners, error) {
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}
+	length := normal.Norm()
+	if length < 1e-9 {
+		return MonitorCorners{}, fmt.Errorf("plane normal is zero")
+	}
+	normal = normal.Mul(1 / length)
+	uAxis, vAxis := planeHorizontal(normal)
+
+	project := func(p Point3D) r3.Vector {
+		v := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}
+		return v.Sub(normal.Mul(v.Dot(normal) - plane.D/length))
+	}
+	all := append(append([]Point3D{}, horizontalEdges...), verticalEdges...)
+	projected := make([]r3.Vector, len(all))
+	planar := make([]r2.Point, len(all))
+	for i, p := range all {
+		projected[i] = project(p)
+		planar[i] = r2.Point{X: projected[i].Dot(uAxis), Y: projected[i].Dot(vAxis)}
+	}
+
+	box, err := minAreaRectangle(planar)
+	if err != nil {
+		return MonitorCorners{}, fmt.Errorf("cannot outline the monitor from %d edge points: %w: %w", len(all), err, ErrEdgeNotFound)
+	}
+	var left, right, top, bottom []Point3D
+	for i, p := range planar {
+		u, v := p.Dot(box.across), p.Dot(box.up)
+		q := Point3D{X: projected[i].X, Y: projected[i].Y, Z: projected[i].Z}
+		distances := []float64{box.uMax - u, u - box.uMin, box.vMax - v, v - box.vMin}
+		switch slices.Index(distances, slices.Min(distances)) {
+		case 0:
+			left = append(left, q)
+		case 1:
+			right = append(right, q)
+		case 2:
+			top = append(top, q)
+		default:
+			bottom = append(bottom, q)
+		}
+	}
+
+	type edgeLine struct{ point, direction r3.Vector }
+	lines := map[string]edgeLine{}
+	for _, edge := range []struct {
+		name   string
+		points []Point3D
+	}{{"left", left}, {"right", right}, {"top", top}, {"bottom", bottom}} {
+		if len(edge.points) < 2 {
+			return MonitorCorners{}, fmt.Errorf("need at least 2 points on the %s edge to fit it, found %d: %w", edge.name, len(edge.points), ErrEdgeNotFound)
+		}
+		centroid, direction, err := fitLineToPointsProper(logger, edge.points)
+		if err != nil {
+			return MonitorCorners{}, fmt.Errorf("failed to fit %s edge: %w", edge.name, err)
+		}
+		lines[edge.name] = edgeLine{
+			point:     r3.Vector{X: centroid.X, Y: centroid.Y, Z: centroid.Z},
+			direction: r3.Vector{X: direction.X, Y: direction.Y, Z: direction.Z},
+		}
+	}
+
+	intersect := func(a, b string) (Point3D, error) {
+		l1, l2 := lines[a], lines[b]
+		cross := l1.direction.Cross(l2.direction)
+		denom := cross.Norm2()
+		if denom < 1e-9 {
+			return Point3D{}, fmt.Errorf("%s and %s edges are parallel", a, b)
+		}
+		t := l2.point.Sub(l1.point).Cross(l2.direction).Dot(cross) / denom
+		p := l1.point.Add(l1.direction.Mul(t))
+		return Point3D{X: p.X, Y: p.Y, Z: p.Z}, nil
+	}
+
+	var corners MonitorCorners
+	if corners.TopLeft, err = intersect("left", "top"); err != nil {
+		return MonitorCorners{}, err
+	}
+	if corners.TopRight, err = intersect("right", "top"); err != nil {
+		return MonitorCorners{}, err
+	}
+	if corners.BottomLeft, err = intersect("left", "bottom"); err != nil {
+		return MonitorCorners{}, err
+	}
+	if corners.BottomRight, err = intersect("right", "bottom"); err != nil {
+		return MonitorCorners{}, err
+	}
+
+	topEdge := r3.Vector{X: corners.TopLeft.X - corners.TopRight.X, Y: corners.TopLeft.Y - corners.TopRight.Y, Z: corners.TopLeft.Z - corners.TopRight.Z}
+	corners.Rotation = inPlaneRotation(topEdge, normal)
+	corners.EdgePoints = len(all)
+	return corners, nil
+}
+
+// planeRectangle is a rectangle in the plane's (U, V) coordinates, spanning [uMin, uMax] along across and
+// [vMin, vMax] along up
+type planeRectangle struct {
+	across, up             r2.Point // unit axes, up a quarter turn anticlockwise from across
+	uMin, uMax, vMin, vMax float64
+}
+
+// minAreaRectangle returns the smallest rectangle enclosing the points, with across within 45° of the U axis
+// One side of the smallest rectangle lies along an edge of the points' convex hull, so only those directions
+// are tried.
+func minAreaRectangle(points []r2.Point) (planeRectangle, error) {
+	hull := convexHull(points)
+	if len(hull) < 3 {
+		return planeRectangle{}, fmt.Errorf("the points are collinear")
+	}
+	var best planeRectangle
+	bestArea := math.Inf(1)
+	for i := range hull {
+		edge := hull[(i+1)%len(hull)].Sub(hull[i])
+		// Fold the edge direction into (-45°, 45°], so across stays nearest the U axis
+		angle := math.Atan2(edge.Y, edge.X)
+		angle -= math.Pi / 2 * math.Round(angle/(math.Pi/2))
+		box := planeRectangle{
+			across: r2.Point{X: math.Cos(angle), Y: math.Sin(angle)},
+			up:     r2.Point{X: -math.Sin(angle), Y: math.Cos(angle)},
+			uMin:   math.Inf(1), uMax: math.Inf(-1), vMin: math.Inf(1), vMax: math.Inf(-1),
+		}
+		for _, p := range hull {
+			u, v := p.Dot(box.across), p.Dot(box.up)
+			box.uMin, box.uMax = math.Min(box.uMin, u), math.Max(box.uMax, u)
+			box.vMin, box.vMax = math.Min(box.vMin, v), math.Max(box.vMax, v)
+		}
+		if area := (box.uMax - box.uMin) * (box.vMax - box.vMin); area < bestArea {
+			best, bestArea = box, area
+		}
+	}
+	return best, nil
+}
+
+// convexHull returns the convex hull of the points in anticlockwise order, by Andrew's monotone chain
+func convexHull(points []r2.Point) []r2.Point {
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b r2.Point) int {
+		if a.X != b.X {
+			return cmp.Compare(a.X, b.X)
+		}
+		return cmp.Compare(a.Y, b.Y)
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	var hull []r2.Point
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && hull[len(hull)-1].Sub(hull[len(hull)-2]).Cross(p.Sub(hull[len(hull)-1])) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		// The last point of each chain starts the other
+		hull = hull[:len(hull)-1]
+		slices.Reverse(sorted)
+	}
+	return hull
+}
+
+// planeHorizontal returns the in-plane axes of the plane with the given unit normal: U is world X projected onto
+// the plane (towards the left edge) and V is up
+func planeHorizontal(normal r3.Vector) (r3.Vector, r3.Vector) {
+	uAxis := r3.Vector{X: 1}.Sub(normal.Mul(normal.X))
+	if uAxis.Norm() < 0.1 {
+		uAxis = normal.Ortho()
+	}
+	uAxis = uAxis.Normalize()
+	vAxis := uAxis.Cross(normal)
+	if vAxis.Z < 0 {
+		vAxis = vAxis.Mul(-1)
+	}
+	return uAxis, vAxis
+}
+
+// inPlaneRotation returns the angle in degrees from the plane's horizontal to a direction towards the left edge
+func inPlaneRotation(direction, normal r3.Vector) float64 {
+	uAxis, vAxis := planeHorizontal(normal)
+	return math.Atan2(direction.Dot(vAxis), direction.Dot(uAxis)) * 180 / math.Pi
+}
+
+// ResultCorners returns the monitor corners of a calibration result
+// Calibrations store the detected corners or, without corner detection, those of the rectangle the edge searches
+// found. Results from before the corners were always stored get the latter, derived the same way.
+func ResultCorners(result CalibrationResult) (MonitorCorners, error) {
+	if result.Corners != nil {
+		return *result.Corners, nil
+	}
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return MonitorCorners{}, err
+	}
+	return geometry.monitorCorners(), nil
+}
+
+// monitorCorners returns the corners of the rectangle as MonitorCorners, without edge points as they weren't detected
+func (g monitorGeometry) monitorCorners() MonitorCorners {
+	point := func(v r3.Vector) Point3D { return Point3D{X: v.X, Y: v.Y, Z: v.Z} }
+	// LocalX runs towards the left edge
+	c := g.corners()
+	return MonitorCorners{
+		TopLeft:     point(c[1]),
+		TopRight:    point(c[0]),
+		BottomLeft:  point(c[2]),
+		BottomRight: point(c[3]),
+		Rotation:    inPlaneRotation(g.LocalX, g.LocalY),
+	}
+}
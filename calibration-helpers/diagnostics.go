@@ -0,0 +1,80 @@
+package calibrationhelpers
+
+import (
+	"calibration/types"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Waypoint outcomes reported in ScanProgress.Status
+//
+// Deprecated: use the types package's Waypoint* constants.
+const (
+	WaypointOK            = types.WaypointOK
+	WaypointIKFailure     = types.WaypointIKFailure
+	WaypointSettleTimeout = types.WaypointSettleTimeout
+	WaypointReadTimeout   = types.WaypointReadTimeout
+	WaypointReadError     = types.WaypointReadError
+	WaypointOutOfRange    = types.WaypointOutOfRange
+)
+
+type (
+	// WaypointDiagnostic records a waypoint that did not produce a surface point
+	//
+	// Deprecated: use types.WaypointDiagnostic.
+	WaypointDiagnostic = types.WaypointDiagnostic
+	// ScanDiagnostics aggregates waypoint outcomes over a calibration run, to explain low coverage
+	//
+	// Deprecated: use types.ScanDiagnostics.
+	ScanDiagnostics = types.ScanDiagnostics
+)
+
+// Diagnostic returns the waypoint outcome for ScanDiagnostics.Record
+func (p ScanProgress) Diagnostic() WaypointDiagnostic {
+	return WaypointDiagnostic{
+		Scan:      p.Label,
+		Index:     p.Index,
+		ID:        p.Waypoint.ID,
+		GantryMM:  p.Waypoint.X,
+		ZOffsetMM: p.Waypoint.Z,
+		Status:    p.Status,
+		Detail:    p.Detail,
+		Retries:   p.Retries,
+	}
+}
+
+// withOptionalTimeout bounds ctx by timeout, or only makes it cancelable when timeout is zero
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// dwell holds still for the scan's dwell time so the sensor settles before reading, ending early with ctx
+func dwell(ctx context.Context, config ScanningConfig) error {
+	if config.Dwell <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(config.Dwell):
+		return nil
+	}
+}
+
+// missDetail describes why a waypoint's reading counts as a miss
+func missDetail(reading SensorReading, maxRange float64) string {
+	if maxRange > 0 && reading.Depth >= maxRange {
+		return fmt.Sprintf("depth %.0f mm at or beyond max range %.0f mm", reading.Depth, maxRange)
+	}
+	return "no echo"
+}
+
+// timedOut reports whether err came from a timeout of our own rather than the caller's ctx ending
+func timedOut(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,77 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+
+	"go.viam.com/rdk/utils"
+)
+
+// DiffTolerances are how far two calibrations may disagree before their diff fails, zero disables a check
+type DiffTolerances struct {
+	MaxTranslation float64 `json:"max_translation_mm,omitempty"`
+	MaxNormalAngle float64 `json:"max_normal_angle_deg,omitempty"`
+	MaxSizeDelta   float64 `json:"max_size_delta_mm,omitempty"`
+}
+
+// CalibrationDiff is how far one calibration of a monitor moved from another
+type CalibrationDiff struct {
+	Translation      Point3D `json:"translation_mm"`         // second center minus first center
+	TranslationDelta float64 `json:"translation_delta_mm"`   // distance between the centers
+	NormalAngleDelta float64 `json:"normal_angle_delta_deg"` // angle between the normals
+	WidthDelta       float64 `json:"width_delta_mm"`         // second width minus first width
+	HeightDelta      float64 `json:"height_delta_mm"`        // second height minus first height
+
+	Tolerances DiffTolerances `json:"tolerances"`
+	Pass       bool           `json:"pass"`
+	Failures   []string       `json:"failures,omitempty"`
+}
+
+// ToMap converts the diff to a map of JSON values, suitable for returning from DoCommand
+func (d CalibrationDiff) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(d)
+}
+
+// Diff measures how far calibration b moved from calibration a, and checks the deltas against the tolerances
+// Typically a is the accepted calibration from before the rig was serviced and b a fresh one. Both must be in the
+// same reference frame.
+func Diff(a, b CalibrationResult, tolerances DiffTolerances) (CalibrationDiff, error) {
+	if a.Frame != "" && b.Frame != "" && a.Frame != b.Frame {
+		return CalibrationDiff{}, fmt.Errorf("cannot diff calibrations in the %q and %q frames", a.Frame, b.Frame)
+	}
+	first, err := monitorGeometryFromResult(a)
+	if err != nil {
+		return CalibrationDiff{}, fmt.Errorf("invalid first calibration: %w", err)
+	}
+	second, err := monitorGeometryFromResult(b)
+	if err != nil {
+		return CalibrationDiff{}, fmt.Errorf("invalid second calibration: %w", err)
+	}
+
+	translation := second.Center.Sub(first.Center)
+	diff := CalibrationDiff{
+		Translation:      Point3D{X: translation.X, Y: translation.Y, Z: translation.Z},
+		TranslationDelta: translation.Norm(),
+		WidthDelta:       second.Width - first.Width,
+		HeightDelta:      second.Height - first.Height,
+		Tolerances:       tolerances,
+	}
+	// The fitted normal may face either way, only its axis is compared
+	cos := math.Abs(first.LocalY.Dot(second.LocalY))
+	diff.NormalAngleDelta = utils.RadToDeg(math.Acos(math.Min(1, cos)))
+
+	if tolerances.MaxTranslation > 0 && diff.TranslationDelta > tolerances.MaxTranslation {
+		diff.Failures = append(diff.Failures, fmt.Sprintf("translation %.2f mm exceeds %.2f mm",
+			diff.TranslationDelta, tolerances.MaxTranslation))
+	}
+	if tolerances.MaxNormalAngle > 0 && diff.NormalAngleDelta > tolerances.MaxNormalAngle {
+		diff.Failures = append(diff.Failures, fmt.Sprintf("normal angle %.3f° exceeds %.3f°",
+			diff.NormalAngleDelta, tolerances.MaxNormalAngle))
+	}
+	if tolerances.MaxSizeDelta > 0 && math.Max(math.Abs(diff.WidthDelta), math.Abs(diff.HeightDelta)) > tolerances.MaxSizeDelta {
+		diff.Failures = append(diff.Failures, fmt.Sprintf("size change (width %+.2f mm, height %+.2f mm) exceeds %.2f mm",
+			diff.WidthDelta, diff.HeightDelta, tolerances.MaxSizeDelta))
+	}
+	diff.Pass = len(diff.Failures) == 0
+	return diff, nil
+}
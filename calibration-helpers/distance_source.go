@@ -0,0 +1,106 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"go.viam.com/rdk/components/sensor"
+)
+
+// Reading shapes of the distance sensors NewDistanceSource adapts
+const (
+	// DistanceUltrasonic is the Viam ultrasonic sensor: a float64 "distance" in meters
+	DistanceUltrasonic = "ultrasonic"
+	// DistanceMeters is any sensor returning a numeric "distance" in meters
+	DistanceMeters = "distance"
+	// DistanceMillimeters is any sensor returning a numeric "distance_mm" in millimeters
+	DistanceMillimeters = "distance_mm"
+)
+
+// DistanceSource reads the distance a sensor measures along its beam
+// valid is false for a zero, negative or non-finite distance, which sensors report when they measured nothing and
+// scans count as a miss. extra carries the pose the reading is taken from, which simulated sensors need.
+type DistanceSource interface {
+	Read(ctx context.Context, extra map[string]interface{}) (distance Meters, valid bool, err error)
+}
+
+// NewDistanceSource adapts a sensor whose readings have the given shape, "" for DistanceUltrasonic
+// A sensor that already implements DistanceSource, like FusedSensor, is used as it is.
+func NewDistanceSource(s sensor.Sensor, reading string) (DistanceSource, error) {
+	if source, ok := s.(DistanceSource); ok {
+		return source, nil
+	}
+	switch reading {
+	case "", DistanceUltrasonic:
+		return readingSource{sensor: s, key: "distance", units: UnitsMeters, strict: true}, nil
+	case DistanceMeters:
+		return readingSource{sensor: s, key: "distance", units: UnitsMeters}, nil
+	case DistanceMillimeters:
+		return readingSource{sensor: s, key: "distance_mm", units: UnitsMillimeters}, nil
+	default:
+		return nil, fmt.Errorf("unknown sensor reading %q, expected %s, %s or %s", reading,
+			DistanceUltrasonic, DistanceMeters, DistanceMillimeters)
+	}
+}
+
+// readingSource reads a distance from one key of a sensor's readings
+type readingSource struct {
+	sensor sensor.Sensor
+	key    string
+	units  string // UnitsMeters or UnitsMillimeters
+	strict bool   // the reading must be a float64, as the ultrasonic sensor reports it
+}
+
+// Read implements DistanceSource
+func (r readingSource) Read(ctx context.Context, extra map[string]interface{}) (Meters, bool, error) {
+	readings, err := r.sensor.Readings(ctx, extra)
+	if err != nil && isNoEcho(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	raw, ok := readings[r.key]
+	if !ok {
+		return 0, false, permanentReadError{fmt.Errorf("sensor %s reading has no %q, only %v", r.sensor.Name().Name,
+			r.key, slices.Sorted(maps.Keys(readings)))}
+	}
+	value, ok := raw.(float64)
+	if !ok && !r.strict {
+		value, ok = numberValue(raw)
+	}
+	if !ok {
+		return 0, false, permanentReadError{fmt.Errorf("sensor %s reading %q is a %T, not a number",
+			r.sensor.Name().Name, r.key, raw)}
+	}
+	meters, err := ToMeters(value, r.units)
+	if err != nil {
+		return 0, false, permanentReadError{err}
+	}
+	return meters, meters > 0 && !math.IsInf(float64(meters), 0), nil
+}
+
+// numberValue converts the numeric types sensors may report to float64
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,127 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+)
+
+// DriftProbe is one point measured by a drift check
+type DriftProbe struct {
+	GantryMM  float64 `json:"gantry_mm"`
+	ZOffsetMM float64 `json:"z_offset_mm"`
+	Status    string  `json:"status"`                // one of the Waypoint* outcomes
+	Point     Point3D `json:"point"`                 // surface point, zero unless Status is WaypointOK
+	Residual  float64 `json:"residual_mm,omitempty"` // distance of the point from the calibrated surface
+}
+
+// DriftReport is the outcome of probing a calibrated monitor for movement
+type DriftReport struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	Probes      []DriftProbe `json:"probes"`
+	Missed      int          `json:"missed"` // probes that produced no point
+	MaxResidual float64      `json:"max_residual_mm"`
+	RMSResidual float64      `json:"rms_residual_mm"`
+	Threshold   float64      `json:"threshold_mm"`
+	Drifted     bool         `json:"drifted"`
+	Hint        string       `json:"hint,omitempty"` // HintMonitorMoved when drifted
+	Message     string       `json:"message"`
+}
+
+// PlanDriftProbes spreads count probe waypoints over the scan window, alternating between a quarter and three
+// quarters of the Z scan height so a tilt about either axis moves some of them
+func PlanDriftProbes(gantryLengths []float64, config ScanningConfig, count int) ([]scanpath.Waypoint, error) {
+	minX, maxX, err := config.GantryLimits(gantryLengths)
+	if err != nil {
+		return nil, err
+	}
+	zMin, zMax := config.ZRange()
+	height := zMax - zMin
+	waypoints := make([]scanpath.Waypoint, count)
+	for i := range waypoints {
+		waypoints[i] = scanpath.Waypoint{
+			ID:  fmt.Sprintf("drift-%d", i),
+			X:   minX + (maxX-minX)*float64(i+1)/float64(count+1),
+			Z:   zMin + height*(0.25+0.5*float64(i%2)),
+			Row: i % 2,
+			Col: i,
+		}
+	}
+	return waypoints, nil
+}
+
+// CheckDrift probes the monitor at the given waypoints and compares the points with the calibrated surface
+// The monitor has drifted when any point is farther than threshold from the surface, or when most probes miss
+// the screen entirely
+func CheckDrift(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry, result CalibrationResult, config CalibrationConfig,
+	waypoints []scanpath.Waypoint, threshold float64) (DriftReport, error) {
+	if len(waypoints) == 0 {
+		return DriftReport{}, fmt.Errorf("no drift probes planned")
+	}
+	config.Curve = result.Cylinder
+	config.Control = nil
+	config.Resume = nil
+
+	report := DriftReport{Timestamp: time.Now().UTC(), Threshold: threshold}
+	config.Progress = func(progress ScanProgress) {
+		probe := DriftProbe{GantryMM: progress.Waypoint.X, ZOffsetMM: progress.Waypoint.Z, Status: progress.Status}
+		if progress.Status == WaypointOK {
+			probe.Point = progress.Point
+			probe.Residual = surfaceDistance(progress.Point, result.Plane, config)
+		} else {
+			report.Missed++
+		}
+		report.Probes = append(report.Probes, probe)
+	}
+	if _, err := PerformWaypointScan(ctx, logger, fs, sensor, arm, gantry, config, "Drift", waypoints); err != nil {
+		return DriftReport{}, fmt.Errorf("drift check failed: %w", err)
+	}
+
+	hits, rss := 0, 0.0
+	for _, probe := range report.Probes {
+		if probe.Status != WaypointOK {
+			continue
+		}
+		hits++
+		rss += probe.Residual * probe.Residual
+		report.MaxResidual = math.Max(report.MaxResidual, probe.Residual)
+	}
+	if hits > 0 {
+		report.RMSResidual = math.Sqrt(rss / float64(hits))
+	}
+
+	switch {
+	case 2*report.Missed > len(report.Probes):
+		report.Drifted = true
+		report.Message = fmt.Sprintf("%d of %d probes missed the monitor, it was likely moved or removed",
+			report.Missed, len(report.Probes))
+	case report.MaxResidual > threshold:
+		report.Drifted = true
+		report.Message = fmt.Sprintf("probe %.1f mm from the calibrated surface (limit %.1f mm), the monitor was likely bumped or re-tilted",
+			report.MaxResidual, threshold)
+	default:
+		report.Message = fmt.Sprintf("%d probes within %.1f mm of the calibrated surface", hits, report.MaxResidual)
+	}
+	if report.Drifted {
+		report.Hint = HintMonitorMoved
+	}
+	return report, nil
+}
+
+// ToMap converts the report to a map of JSON values, suitable for returning from DoCommand
+func (r DriftReport) ToMap() (map[string]interface{}, error) {
+	m, err := jsonToMap(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode drift report: %w", err)
+	}
+	return m, nil
+}
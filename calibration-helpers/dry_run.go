@@ -0,0 +1,137 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"fmt"
+	"math"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/logging"
+)
+
+// Rough timings PlanCalibration estimates a run's duration from, on top of the gantry's travel at GantrySpeed
+const (
+	armMoveSeconds = 1.0 // arm move to a waypoint or edge probe, including settling
+	readingSeconds = 0.1 // one sensor reading
+)
+
+// PlannedWaypoint is a scan waypoint a calibration would visit
+type PlannedWaypoint struct {
+	X  float64 `json:"x"`            // mm - gantry position
+	Z  float64 `json:"z"`            // mm - arm height offset from the home pose
+	ID string  `json:"id,omitempty"` // lattice ID of grid waypoints
+}
+
+// PlannedScan is one scan of a calibration plan
+type PlannedScan struct {
+	Scan        string            `json:"scan"`
+	GantryMoves bool              `json:"gantry_moves"` // whether the gantry moves to each waypoint, or stays centered
+	Waypoints   []PlannedWaypoint `json:"waypoints"`
+}
+
+// CalibrationPlan is what a calibration would do, worked out by PlanCalibration without moving anything
+type CalibrationPlan struct {
+	ScanMode        string          `json:"scan_mode"`
+	Scans           []PlannedScan   `json:"scans"`
+	Waypoints       int             `json:"waypoints"`
+	ExpectedSamples int             `json:"expected_samples"` // scan points if every waypoint hits the monitor
+	MaxSamples      int             `json:"max_samples"`      // scan points if an adaptive scan refines everywhere
+	Readings        int             `json:"readings"`         // sensor readings the scan points take
+	EdgeProbes      int             `json:"edge_probes"`      // probes the four edge searches take, roughly
+	Workspace       WorkspaceReport `json:"workspace"`
+	Reachable       bool            `json:"reachable"`
+	Duration        float64         `json:"estimated_duration_s"`
+}
+
+// ToMap converts the plan to a map of JSON values, suitable for returning from DoCommand
+func (p CalibrationPlan) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(p)
+}
+
+// PlanCalibration plans the scan the config describes and checks it against the workspace, without moving anything
+// The duration is a rough estimate: the gantry's travel at GantrySpeed, one arm move per arm waypoint and edge
+// probe, the readings at each and the dwell at each scan waypoint. Edge searches are counted as a bisection of
+// half the gantry window down to EdgePrecision, and corner sweeps aren't counted. Adaptive scans take somewhere
+// between their coarse pass, which is what ExpectedSamples counts, and the full lattice of MaxSamples.
+func PlanCalibration(ctx context.Context, logger logging.Logger, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) (CalibrationPlan, error) {
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return CalibrationPlan{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	if len(gantryLengths) == 0 {
+		return CalibrationPlan{}, fmt.Errorf("gantry has no axes")
+	}
+	scans, err := planScans(ctx, gantry, config.Scanning)
+	if err != nil {
+		return CalibrationPlan{}, err
+	}
+	workspace, err := CheckWorkspace(ctx, logger, arm, gantry, config)
+	if err != nil {
+		return CalibrationPlan{}, err
+	}
+	plan := CalibrationPlan{
+		ScanMode:  config.Scanning.Mode,
+		Scans:     []PlannedScan{},
+		Workspace: workspace,
+		Reachable: workspace.Err() == nil,
+	}
+
+	minPos, maxPos, err := config.Scanning.GantryLimits(gantryLengths)
+	if err != nil {
+		return CalibrationPlan{}, err
+	}
+	gantryPos, armZ := (minPos+maxPos)/2, 0.0
+	var gantryTravel float64
+	armMoves := 0
+	for _, scan := range scans {
+		planned := PlannedScan{Scan: scan.label, GantryMoves: scan.gantryMoves, Waypoints: []PlannedWaypoint{}}
+		for _, wp := range scan.waypoints {
+			planned.Waypoints = append(planned.Waypoints, PlannedWaypoint{X: wp.X, Z: wp.Z, ID: wp.ID})
+			if scan.gantryMoves {
+				gantryTravel += math.Abs(wp.X - gantryPos)
+				gantryPos = wp.X
+			}
+			if wp.Z != armZ || !scan.gantryMoves {
+				armMoves++
+				armZ = wp.Z
+			}
+		}
+		plan.Scans = append(plan.Scans, planned)
+		plan.Waypoints += len(scan.waypoints)
+	}
+
+	plan.ExpectedSamples, plan.MaxSamples = plan.Waypoints, plan.Waypoints
+	switch config.Scanning.Mode {
+	case ScanModeAngular:
+		// Every hold sweeps the wrist through its rays, one arm move each
+		plan.ExpectedSamples = config.Scanning.AngularHolds * config.Scanning.WristSweepSteps
+		plan.MaxSamples = plan.ExpectedSamples
+		armMoves += plan.ExpectedSamples
+	case ScanModeAdaptive:
+		region, xSpacing, zSpacing, err := gridRegion(ctx, gantry, config.Scanning)
+		if err != nil {
+			return CalibrationPlan{}, err
+		}
+		lattice, err := scanpath.NewLattice(region, xSpacing, zSpacing)
+		if err != nil {
+			return CalibrationPlan{}, fmt.Errorf("failed to plan adaptive scan: %w", err)
+		}
+		plan.ExpectedSamples = len(scanpath.Every(len(lattice.Zs), config.Scanning.CoarseFactor)) *
+			len(scanpath.Every(len(lattice.Xs), config.Scanning.CoarseFactor))
+	}
+	plan.Readings = plan.ExpectedSamples * config.ReadingsPerPose()
+
+	if config.Detection.EdgePrecision > 0 {
+		probes := math.Ceil(math.Log2(math.Max(2, (maxPos-minPos)/2/config.Detection.EdgePrecision)))
+		plan.EdgeProbes = 4 * 2 * int(probes)
+	}
+
+	plan.Duration = gantryTravel/config.Scanning.GantrySpeed +
+		float64(armMoves+plan.EdgeProbes)*armMoveSeconds +
+		float64(plan.Readings+plan.EdgeProbes*config.ReadingsPerPose())*readingSeconds +
+		float64(plan.ExpectedSamples)*config.Scanning.Dwell.Seconds()
+	return plan, nil
+}
@@ -0,0 +1,77 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxGallopSteps bounds the doubling steps of an edge search in case the sensor never leaves the monitor
+const maxGallopSteps = 20
+
+// EdgeProbe moves the sensor to a position along an edge search and reports the surface point there
+// hit is whether the point lies on the monitor. reachable is false when the arm or gantry can't get there or the
+// sensor can't be read there, which bounds the search like a miss but isn't an edge.
+type EdgeProbe func(pos float64) (point Point3D, hit, reachable bool, err error)
+
+// FindEdge locates the monitor edge between start, on the monitor, and limit by bisecting the hit/miss transition
+// The search steps from start towards limit, doubling step each time until the sensor misses or limit is reached,
+// then halves the gap between the last hit and the first miss until it is within precision. An edge n steps away
+// takes O(log n) probes instead of the n of a sweep. The result's SurfacePoint and Position are the hit nearest the
+// edge; Found is false when the search reached limit or the end of the actuator's reach without missing.
+// Returns ErrEdgeNotFound when start is off the monitor.
+func FindEdge(probe EdgeProbe, start, limit, step, precision float64) (EdgeSearchResult, error) {
+	if step == 0 || !(precision > 0) {
+		return EdgeSearchResult{}, fmt.Errorf("edge search needs a step and a positive precision")
+	}
+	point, hit, reachable, err := probe(start)
+	result := EdgeSearchResult{Probes: 1}
+	if err != nil {
+		return result, err
+	}
+	if !reachable || !hit {
+		return result, fmt.Errorf("started off the monitor at %.1f: %w", start, ErrEdgeNotFound)
+	}
+	result.SurfacePoint, result.Position = point, start
+
+	beyond := func(pos float64) bool {
+		return (step > 0 && pos > limit) || (step < 0 && pos < limit)
+	}
+	missPos, missed := math.NaN(), false
+	stride := step
+	for i := 0; i < maxGallopSteps && result.Position != limit; i++ {
+		pos := result.Position + stride
+		if beyond(pos) {
+			pos = limit
+		}
+		point, hit, reachable, err := probe(pos)
+		result.Probes++
+		if err != nil {
+			return result, err
+		}
+		if !reachable || !hit {
+			missPos, missed = pos, reachable
+			break
+		}
+		result.SurfacePoint, result.Position = point, pos
+		stride *= 2
+	}
+	if math.IsNaN(missPos) {
+		return result, nil
+	}
+
+	for math.Abs(missPos-result.Position) > precision {
+		pos := (result.Position + missPos) / 2
+		point, hit, reachable, err := probe(pos)
+		result.Probes++
+		if err != nil {
+			return result, err
+		}
+		if reachable && hit {
+			result.SurfacePoint, result.Position = point, pos
+		} else {
+			missPos, missed = pos, reachable
+		}
+	}
+	result.Found = missed
+	return result, nil
+}
@@ -2,6 +2,7 @@ package calibrationhelpers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 
@@ -14,19 +15,18 @@ import (
 	"go.viam.com/rdk/spatialmath"
 )
 
-// Plane represents a plane equation: Ax + By + Cz = D
-type Plane struct {
-	A, B, C, D float64
-}
-
 // EdgeSearchResult contains the result of an edge search
 type EdgeSearchResult struct {
 	SurfacePoint Point3D
 	Found        bool
+	Position     float64 // position along the search the surface point was read at
+	Probes       int     // sensor poses the search visited
 }
 
 // FindVerticalEdge searches for an edge by moving vertically (up or down)
 // zDirection: +1 for up (top edge), -1 for down (bottom edge)
+// The arm moves opposite to zDirection in the moving-monitor topology. The edge is bisected to
+// config.Detection.EdgePrecision, see FindEdge.
 func FindVerticalEdge(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
 	sensor sensor.Sensor, arm arm.Arm, plane Plane, zDirection int, config CalibrationConfig) (EdgeSearchResult, error) {
 	var edgeName string
@@ -35,80 +35,107 @@ func FindVerticalEdge(ctx context.Context, logger logging.Logger, fs framesystem
 	} else {
 		edgeName = "bottom"
 	}
-	var result EdgeSearchResult
-
-	for {
-		armPose, err := arm.EndPosition(ctx, nil)
-		if err != nil {
-			return result, fmt.Errorf("failed to get arm position: %w", err)
-		}
-
-		// Get surface point
-		reading, err := GetSurfacePoint(ctx, logger, fs, sensor, config.Hardware.WorldFrame)
-		if err != nil {
-			return result, fmt.Errorf("failed to get sensor reading: %w", err)
-		}
-
-		// Check if point is on the plane
-		distanceFromPlane := PointDistanceFromPlane(reading.SurfacePoint, plane)
-		logger.Debugf("%s search - Arm Z=%.1f, surface=(%.1f,%.1f,%.1f), dist from plane=%.1f",
-			edgeName, armPose.Point().Z, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z, distanceFromPlane)
 
-		// If we've gone past the edge (point no longer on plane)
-		if distanceFromPlane > config.Detection.PlaneThreshold {
-			result.Found = true
-			logger.Infof("✓ Found %s edge at arm Z=%.1f (point distance from plane: %.1f mm)", edgeName, result.SurfacePoint.Z, distanceFromPlane)
-			break
-		}
-
-		// Update last valid surface point
-		result.SurfacePoint = reading.SurfacePoint
-
-		// Move in Z direction
-		poseX := armPose.Point().X
-		worldArmPose, err := fs.GetPose(ctx, arm.Name().Name, config.Hardware.WorldFrame, nil, nil)
-		if err != nil {
-			return result, fmt.Errorf("failed to get arm world pose: %w", err)
+	// With a previous result, jump most of the way to its edge and search from there
+	var startPose spatialmath.Pose
+	var err error
+	seeded := false
+	if config.Seed != nil {
+		if startPose, seeded, err = seedArmStart(ctx, logger, fs, sensor, arm, plane, zDirection, config); err != nil {
+			return EdgeSearchResult{}, err
 		}
+	} else if startPose, err = arm.EndPosition(ctx, nil); err != nil {
+		return EdgeSearchResult{}, fmt.Errorf("failed to get arm position: %w", err)
+	}
+	worldArmPose, err := fs.GetPose(ctx, arm.Name().Name, config.Hardware.WorldFrame, nil, nil)
+	if err != nil {
+		return EdgeSearchResult{}, fmt.Errorf("failed to get arm world pose: %w", err)
+	}
 
-		nextPose := spatialmath.NewPose(
+	// Positions along the search are offsets from the start pose towards the edge
+	zSign := float64(zDirection) * config.Hardware.MotionSign()
+	moveArm := func(x, offset float64) error {
+		return arm.MoveToPosition(ctx, spatialmath.NewPose(
 			r3.Vector{
-				X: poseX,
-				Y: armPose.Point().Y,
-				Z: armPose.Point().Z + float64(zDirection)*config.Detection.EdgeStepSize,
+				X: x,
+				Y: startPose.Point().Y,
+				Z: startPose.Point().Z + zSign*offset,
 			},
-			armPose.Orientation(),
-		)
-
-		err = arm.MoveToPosition(ctx, nextPose, nil)
+			startPose.Orientation(),
+		), nil)
+	}
+	probe := func(offset float64) (Point3D, bool, bool, error) {
+		poseX := startPose.Point().X
+		err := moveArm(poseX, offset)
 
 		// Handle collision - try moving away from itself in +X direction
 		for err != nil && poseX < -worldArmPose.Pose().Point().Y+config.Hardware.GripperWidth {
 			logger.Debugf("%s search - hit joint limit at X=%.1f, moving in +x dir", edgeName, poseX)
 			poseX += config.Detection.EdgeStepSize
-			nextPose := spatialmath.NewPose(
-				r3.Vector{
-					X: poseX,
-					Y: armPose.Point().Y,
-					Z: armPose.Point().Z + float64(zDirection)*config.Detection.EdgeStepSize,
-				},
-				armPose.Orientation(),
-			)
-			err = arm.MoveToPosition(ctx, nextPose, nil)
+			err = moveArm(poseX, offset)
+		}
+		if err != nil {
+			// Now too close to monitor, the search can't go further
+			logger.Debugf("%s search - arm cannot reach offset %.1f: %v", edgeName, offset, err)
+			return Point3D{}, false, false, nil
+		}
+
+		reading, ok, err := edgeReading(ctx, logger, fs, sensor, config, edgeName, offset)
+		if err != nil || !ok {
+			return Point3D{}, false, false, err
 		}
+		distanceFromSurface := surfaceDistance(reading.SurfacePoint, plane, config)
+		logger.Debugf("%s search - offset=%.1f, surface=(%.1f,%.1f,%.1f), dist from surface=%.1f",
+			edgeName, offset, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z, distanceFromSurface)
+		return reading.SurfacePoint, onSurface(reading, plane, config), true, nil
+	}
 
+	start := 0.0
+	if seeded {
+		seedPose, err := arm.EndPosition(ctx, nil)
 		if err != nil {
-			// Now too close to monitor, give up
-			logger.Infof("✓ Found %s edge at arm Z=%.1f (joint limit)", edgeName, result.SurfacePoint.Z)
-			break
+			return EdgeSearchResult{}, fmt.Errorf("failed to get arm position: %w", err)
 		}
+		start = (seedPose.Point().Z - startPose.Point().Z) * zSign
+	}
+	result, err := FindEdge(probe, start, math.Inf(1), config.Detection.EdgeStepSize, config.Detection.EdgePrecision)
+	if seeded && errors.Is(err, ErrEdgeNotFound) {
+		// The edge moved inward since the previous calibration, search from the start instead
+		logger.Infof("%s edge is short of the previous calibration, searching from the start", edgeName)
+		result, err = FindEdge(probe, 0, math.Inf(1), config.Detection.EdgeStepSize, config.Detection.EdgePrecision)
+	}
+	if err != nil {
+		return result, fmt.Errorf("%s edge search: %w", edgeName, err)
 	}
 
+	if result.Found {
+		logger.Infof("✓ Found %s edge at Z=%.1f after %d probes", edgeName, result.SurfacePoint.Z, result.Probes)
+	} else {
+		logger.Infof("✓ Found %s edge at Z=%.1f (joint limit)", edgeName, result.SurfacePoint.Z)
+	}
 	return result, nil
 }
 
-// FindHorizontalEdge searches for an edge by scanning the gantry
+// edgeReading reads the surface point at pos along an edge search, ok false when the sensor couldn't be read
+// Reads are already retried, so a read that still fails is skipped like a pose out of reach: it bounds the search
+// without counting as the edge. A ctx that ended still ends the search.
+func edgeReading(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem, sensor sensor.Sensor,
+	config CalibrationConfig, edgeName string, pos float64) (SensorReading, bool, error) {
+	reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+	if err == nil {
+		return reading, true, nil
+	}
+	if ctx.Err() != nil {
+		return SensorReading{}, false, fmt.Errorf("failed to get sensor reading: %w", err)
+	}
+	logger.Warnf("%s search - skipping failed reading at %.1f: %v", edgeName, pos, err)
+	return SensorReading{}, false, nil
+}
+
+// FindHorizontalEdge searches for an edge by moving the gantry from its center
 // xDirection: +1 for left edge (scan outward), -1 for right edge (scan inward)
+// The gantry moves opposite to xDirection in the moving-monitor topology. The edge is bisected to
+// config.Detection.EdgePrecision, see FindEdge.
 func FindHorizontalEdge(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
 	sensor sensor.Sensor, gantry gantry.Gantry, plane Plane,
 	gantryLengths []float64, xDirection int, config CalibrationConfig) (EdgeSearchResult, error) {
@@ -118,116 +145,64 @@ func FindHorizontalEdge(ctx context.Context, logger logging.Logger, fs framesyst
 	} else {
 		edgeName = "right"
 	}
-	var result EdgeSearchResult
-	centerPos := gantryLengths[0] / 2
-
-	// Determine start, end, and step based on direction
-	var currentPos float64
-	var endPos float64
-	step := config.Detection.EdgeStepSize * float64(xDirection)
-
-	if xDirection > 0 {
-		// Left edge: scan from center to max
-		currentPos = centerPos
-		endPos = gantryLengths[0]
-	} else {
-		// Right edge: scan from center to 0
-		currentPos = centerPos
-		endPos = 0
+	minPos, maxPos, err := config.Scanning.GantryLimits(gantryLengths)
+	if err != nil {
+		return EdgeSearchResult{}, err
 	}
+	centerPos := (minPos + maxPos) / 2
 
-	for {
-		// Check if we've reached the end
-		if (xDirection > 0 && currentPos > endPos) || (xDirection < 0 && currentPos < endPos) {
-			break
-		}
-
-		err := gantry.MoveToPosition(ctx, []float64{currentPos}, []float64{config.Scanning.GantrySpeed}, nil)
-		if err != nil {
-			return result, fmt.Errorf("failed to move gantry: %w", err)
-		}
+	// Search from the center towards the end of the gantry limits in the move direction
+	moveDirection := float64(xDirection) * config.Hardware.MotionSign()
+	endPos := maxPos
+	if moveDirection < 0 {
+		endPos = minPos
+	}
 
-		// Get surface point
-		reading, err := GetSurfacePoint(ctx, logger, fs, sensor, config.Hardware.WorldFrame)
+	// With a previous result, jump most of the way to its edge and search from there
+	start, seeded := centerPos, false
+	if config.Seed != nil {
+		seedStart, ok, err := seedGantryStart(ctx, logger, fs, sensor, gantry, plane, xDirection, centerPos, endPos, config)
 		if err != nil {
-			currentPos += step
-			continue
+			return EdgeSearchResult{}, err
 		}
-
-		distanceFromPlane := PointDistanceFromPlane(reading.SurfacePoint, plane)
-		logger.Debugf("%s search - Gantry X=%.1f, dist from plane=%.1f", edgeName, currentPos, distanceFromPlane)
-
-		// If we've gone past the edge (point no longer on plane)
-		if distanceFromPlane > config.Detection.PlaneThreshold {
-			result.Found = true
-			logger.Infof("✓ Found %s edge at gantry position X=%.1f (dist from plane=%.1f)", edgeName, result.SurfacePoint.X, distanceFromPlane)
-			break
+		if ok {
+			start, seeded = seedStart, true
 		}
-
-		result.SurfacePoint = reading.SurfacePoint
-		currentPos += step
 	}
 
-	if !result.Found {
-		logger.Infof("Could not find %s edge within gantry range, using point at end position", edgeName)
-		// Get surface point at the final position
-		err := gantry.MoveToPosition(ctx, []float64{endPos}, []float64{config.Scanning.GantrySpeed}, nil)
-		if err != nil {
-			return result, fmt.Errorf("failed to move gantry to end position: %w", err)
+	probe := func(pos float64) (Point3D, bool, bool, error) {
+		// Bisection steps back and forth, so place the carriage rather than the drive, or backlash would shift
+		// the probes by the direction each one came from
+		command := math.Max(minPos, math.Min(maxPos, config.Backlash.Command(0, pos)))
+		if err := gantry.MoveToPosition(ctx, []float64{command}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return Point3D{}, false, false, fmt.Errorf("failed to move gantry: %w", err)
 		}
-		reading, err := GetSurfacePoint(ctx, logger, fs, sensor, config.Hardware.WorldFrame)
-		if err != nil {
-			return result, fmt.Errorf("failed to get final surface point: %w", err)
+		reading, ok, err := edgeReading(ctx, logger, fs, sensor, config, edgeName, pos)
+		if err != nil || !ok {
+			return Point3D{}, false, false, err
 		}
-		logger.Infof("Using reading at gantry X=%.1f for %s edge: %+v", endPos, edgeName, reading.SurfacePoint)
-		result.SurfacePoint = reading.SurfacePoint
+		distanceFromSurface := surfaceDistance(reading.SurfacePoint, plane, config)
+		logger.Debugf("%s search - Gantry X=%.1f, dist from surface=%.1f", edgeName, pos, distanceFromSurface)
+		return reading.SurfacePoint, onSurface(reading, plane, config), true, nil
 	}
 
-	return result, nil
-}
-
-// PointDistanceFromPlane calculates the distance of a point from a plane
-func PointDistanceFromPlane(point Point3D, plane Plane) float64 {
-	// Distance = |Ax + By + Cz - D| / sqrt(A² + B² + C²)
-	numerator := math.Abs(plane.A*point.X + plane.B*point.Y + plane.C*point.Z - plane.D)
-	denominator := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
-	return numerator / denominator
-}
-
-// CalculatePlaneFrom3Points calculates a plane from 3 non-collinear points
-func CalculatePlaneFrom3Points(p1, p2, p3 Point3D) (Plane, error) {
-	// Create two vectors in the plane
-	v1 := Point3D{X: p2.X - p1.X, Y: p2.Y - p1.Y, Z: p2.Z - p1.Z}
-	v2 := Point3D{X: p3.X - p1.X, Y: p3.Y - p1.Y, Z: p3.Z - p1.Z}
-
-	// Normal vector is the cross product
-	normal := Point3D{
-		X: v1.Y*v2.Z - v1.Z*v2.Y,
-		Y: v1.Z*v2.X - v1.X*v2.Z,
-		Z: v1.X*v2.Y - v1.Y*v2.X,
+	step := moveDirection * config.Detection.EdgeStepSize
+	result, err := FindEdge(probe, start, endPos, step, config.Detection.EdgePrecision)
+	if seeded && errors.Is(err, ErrEdgeNotFound) {
+		// The edge moved inward since the previous calibration, search from the center instead
+		logger.Infof("%s edge is short of the previous calibration, searching from the center", edgeName)
+		result, err = FindEdge(probe, centerPos, endPos, step, config.Detection.EdgePrecision)
 	}
-
-	// Check for collinear points
-	length := math.Sqrt(normal.X*normal.X + normal.Y*normal.Y + normal.Z*normal.Z)
-	if length < 0.001 {
-		return Plane{}, fmt.Errorf("points are collinear, cannot define a plane")
-	}
-
-	// Ensure normal points away from monitor (positive Y direction preferred)
-	// This makes the orientation calculation consistent
-	if normal.Y < 0 {
-		normal.X = -normal.X
-		normal.Y = -normal.Y
-		normal.Z = -normal.Z
+	if err != nil {
+		return result, fmt.Errorf("%s edge search: %w", edgeName, err)
 	}
 
-	// Plane equation: A*x + B*y + C*z = D
-	plane := Plane{
-		A: normal.X,
-		B: normal.Y,
-		C: normal.Z,
-		D: normal.X*p1.X + normal.Y*p1.Y + normal.Z*p1.Z,
+	if result.Found {
+		logger.Infof("✓ Found %s edge at gantry position X=%.1f after %d probes (surface X=%.1f)",
+			edgeName, result.Position, result.Probes, result.SurfacePoint.X)
+	} else {
+		logger.Infof("Could not find %s edge within gantry range, using reading at end position X=%.1f: %+v",
+			edgeName, endPos, result.SurfacePoint)
 	}
-
-	return plane, nil
+	return result, nil
 }
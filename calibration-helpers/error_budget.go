@@ -0,0 +1,147 @@
+package calibrationhelpers
+
+import (
+	"calibration/types"
+	"fmt"
+	"math"
+)
+
+// Error budget sources
+//
+// Deprecated: use the types package's ErrorSource* constants.
+const (
+	ErrorSourceSensor      = types.ErrorSourceSensor
+	ErrorSourcePositioning = types.ErrorSourcePositioning
+	ErrorSourceFit         = types.ErrorSourceFit
+)
+
+type (
+	// ErrorSource is one contribution to the uncertainty of the fitted plane
+	//
+	// Deprecated: use types.ErrorSource.
+	ErrorSource = types.ErrorSource
+	// ErrorBudget attributes the uncertainty of the fitted plane to its sources
+	//
+	// Deprecated: use types.ErrorBudget.
+	ErrorBudget = types.ErrorBudget
+)
+
+// ErrorBudgetCollector gathers the error budget inputs from the scan progress
+// It keeps running sums rather than the points, so it doesn't grow with the scan.
+type ErrorBudgetCollector struct {
+	points   int
+	spreads  int // points with a measured reading spread
+	measured int // points with a measured position error
+
+	spreadSquares float64       // sum of the squared reading spreads
+	positionSums  [3][3]float64 // sum of the outer products of the position errors
+}
+
+// Record adds one scan point, other outcomes are ignored
+func (c *ErrorBudgetCollector) Record(progress ScanProgress) {
+	if progress.Status != WaypointOK {
+		return
+	}
+	c.points++
+	if progress.Spread > 0 {
+		c.spreads++
+		c.spreadSquares += progress.Spread * progress.Spread
+	}
+	if e := progress.PositionError; e != (Point3D{}) {
+		c.measured++
+		v := [3]float64{e.X, e.Y, e.Z}
+		for i := range v {
+			for j := range v {
+				c.positionSums[i][j] += v[i] * v[j]
+			}
+		}
+	}
+}
+
+// Budget splits the plane uncertainty between the sources
+// samples is the number of readings filtered into each point, which divides the sensor variance
+func (c *ErrorBudgetCollector) Budget(plane Plane, cov Covariance, samples int) (ErrorBudget, error) {
+	if c.points == 0 {
+		return ErrorBudget{}, fmt.Errorf("no scan points recorded for the error budget")
+	}
+	if samples < 1 {
+		samples = 1
+	}
+	length := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+	if length == 0 {
+		return ErrorBudget{}, fmt.Errorf("plane normal is zero")
+	}
+	normal := [3]float64{plane.A / length, plane.B / length, plane.C / length}
+
+	// The position error along the normal, squared and summed, is the normal through the summed outer products
+	sensorVar, positionVar := c.spreadSquares, 0.0
+	for i := range normal {
+		for j := range normal {
+			positionVar += normal[i] * c.positionSums[i][j] * normal[j]
+		}
+	}
+	if c.spreads > 0 {
+		sensorVar /= float64(c.spreads) * float64(samples)
+	}
+	if c.measured > 0 {
+		positionVar /= float64(c.measured)
+	}
+	// The points are placed from the poses the encoders report, so the commanded-vs-reported position error isn't in
+	// the residual and only the sensor noise is taken out of it. Positioning is shared out on top of the residual.
+	total := cov.ResidualRMS * cov.ResidualRMS
+	fitVar := math.Max(0, total-sensorVar)
+	// Measured noise can exceed the residual when the fit absorbs some of it, so share out the larger of the two
+	total = math.Max(total, sensorVar+fitVar) + positionVar
+
+	budget := ErrorBudget{
+		ResidualRMS:  cov.ResidualRMS,
+		NormalStdDev: cov.NormalStdDev,
+		OffsetStdDev: cov.OffsetStdDev,
+	}
+	add := func(name string, variance float64, measured bool) {
+		share := 0.0
+		if total > 0 {
+			share = variance / total
+		}
+		budget.Sources = append(budget.Sources, ErrorSource{
+			Name:         name,
+			Sigma:        math.Sqrt(variance),
+			Share:        share,
+			NormalStdDev: cov.NormalStdDev * math.Sqrt(share),
+			OffsetStdDev: cov.OffsetStdDev * math.Sqrt(share),
+			Measured:     measured,
+		})
+	}
+	add(ErrorSourceSensor, sensorVar, c.spreads > 0)
+	add(ErrorSourcePositioning, positionVar, c.measured > 0)
+	add(ErrorSourceFit, fitVar, true)
+
+	dominant := budget.Sources[0]
+	for _, source := range budget.Sources[1:] {
+		if source.Share > dominant.Share {
+			dominant = source
+		}
+	}
+	budget.Dominant = dominant.Name
+	budget.Advice = errorBudgetAdvice(budget, dominant)
+	return budget, nil
+}
+
+// errorBudgetAdvice suggests what would most reduce the uncertainty
+func errorBudgetAdvice(budget ErrorBudget, dominant ErrorSource) string {
+	var advice string
+	switch dominant.Name {
+	case ErrorSourceSensor:
+		advice = "Sensor noise dominates: average more readings per pose or use a lower-noise sensor"
+	case ErrorSourcePositioning:
+		advice = "Positioning error dominates: a stiffer or more repeatable gantry and arm would help more than a better sensor"
+	default:
+		advice = "Fit residual dominates: the surface departs from the model, so check for curvature, bezel hits or a loose mount before upgrading hardware"
+	}
+	for _, source := range budget.Sources {
+		if !source.Measured {
+			advice += fmt.Sprintf(". %s was not measured and is counted in fit_residual", source.Name)
+		}
+	}
+	return advice
+}
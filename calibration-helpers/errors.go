@@ -0,0 +1,107 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Failure classes of a calibration, for callers to branch on with errors.Is instead of matching messages
+// Errors wrap them with the context of what failed, and ErrorClass names them for DoCommand responses.
+var (
+	// ErrInsufficientSamples means too few scan points landed on the monitor to fit it
+	ErrInsufficientSamples = errors.New("insufficient samples")
+	// ErrPlaneFitDiverged means the scan points didn't settle on a plane or curve, e.g. because they are degenerate
+	ErrPlaneFitDiverged = errors.New("plane fit diverged")
+	// ErrEdgeNotFound means an edge search found no monitor edge to report
+	ErrEdgeNotFound = errors.New("edge not found")
+	// ErrSensorTimeout means the sensor didn't answer in time
+	ErrSensorTimeout = errors.New("sensor timeout")
+	// ErrSensorRead means the sensor reading failed on every attempt
+	ErrSensorRead = errors.New("sensor read failed")
+	// ErrWorkspaceExceeded means the arm or gantry can't reach a pose
+	ErrWorkspaceExceeded = errors.New("workspace exceeded")
+	// ErrHomingCheckFailed means the reference target didn't read as expected after homing the gantry
+	ErrHomingCheckFailed = errors.New("homing check failed")
+	// ErrCalibrationAborted means an operator aborted the calibration
+	ErrCalibrationAborted = errors.New("calibration aborted")
+)
+
+// ErrNoEcho is the error a sensor may return when it hears no echo, a miss rather than a failed read
+// Distance sources report it as an invalid reading. Errors from a remote sensor only keep their message, so it is
+// matched by message as well.
+var ErrNoEcho = errors.New("no echo within max range")
+
+// isNoEcho reports whether err is a sensor's no-echo miss
+func isNoEcho(err error) bool {
+	return errors.Is(err, ErrNoEcho) || strings.Contains(err.Error(), ErrNoEcho.Error())
+}
+
+// unreachableMessages are fragments of the errors arms, IK solvers and the motion service return for a pose out of
+// reach, lower case. Their error values don't survive a remote component, so they are matched by message.
+var unreachableMessages = []string{
+	"out of reach",
+	"unreachable",
+	"ik solution",
+	"inverse kinematics",
+	"failed constraints",
+	"joint limit",
+	"out of bounds",
+	"failed to find path",
+}
+
+// IsUnreachable reports whether err means the arm or gantry can't reach a pose, rather than the ctx ending or a
+// component failing, so only those are wrapped as ErrWorkspaceExceeded
+func IsUnreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrWorkspaceExceeded) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, fragment := range unreachableMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorClasses names the failure classes, in the order ErrorClass checks them
+var errorClasses = []struct {
+	err   error
+	class string
+}{
+	{ErrInsufficientSamples, "insufficient_samples"},
+	{ErrPlaneFitDiverged, "plane_fit_diverged"},
+	{ErrEdgeNotFound, "edge_not_found"},
+	{ErrSensorTimeout, "sensor_timeout"},
+	{ErrSensorRead, "sensor_read_failed"},
+	{ErrWorkspaceExceeded, "workspace_exceeded"},
+	{ErrHomingCheckFailed, "homing_check_failed"},
+	{ErrCalibrationAborted, "aborted"},
+}
+
+// ErrorClass returns the name of err's failure class, such as "edge_not_found", or "" if it has none
+func ErrorClass(err error) string {
+	for _, c := range errorClasses {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	return ""
+}
+
+// minFitSamples is the fewest points the least-squares plane and cylinder fits accept
+const minFitSamples = 4
+
+// FitError classifies a failed fit of the named surface to n points: ErrInsufficientSamples when there were too
+// few to fit, otherwise ErrPlaneFitDiverged
+func FitError(surface string, n int, err error) error {
+	if n < minFitSamples {
+		return fmt.Errorf("failed to fit %s to %d points: %w: %w", surface, n, ErrInsufficientSamples, err)
+	}
+	return fmt.Errorf("failed to fit %s to %d points: %w: %w", surface, n, ErrPlaneFitDiverged, err)
+}
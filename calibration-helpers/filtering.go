@@ -0,0 +1,240 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Filters for smoothing repeated readings at one pose
+const (
+	// FilterNone takes a single reading per pose
+	FilterNone = "none"
+	// FilterEMA smooths the readings with an exponential moving average
+	FilterEMA = "ema"
+	// FilterKalman smooths the readings with a 1D Kalman filter on the distance
+	FilterKalman = "kalman"
+)
+
+// FilterConfig controls how repeated readings at one pose are combined into one distance
+// Single ultrasonic samples are too noisy to tell a plane point from a bezel point near the edges
+type FilterConfig struct {
+	Mode             string  // FilterNone, FilterEMA or FilterKalman
+	Samples          int     // readings taken at each pose
+	Alpha            float64 // EMA weight of the newest reading, in (0, 1]
+	ProcessNoise     float64 // mm² - Kalman variance added per reading, how much the true distance may drift
+	MeasurementNoise float64 // mm² - Kalman variance of a single reading
+}
+
+// Validate checks the filter configuration
+func (c FilterConfig) Validate() error {
+	switch c.Mode {
+	case FilterNone:
+		return nil
+	case FilterEMA:
+		if c.Alpha <= 0 || c.Alpha > 1 {
+			return fmt.Errorf("EMA alpha must be in (0, 1]")
+		}
+	case FilterKalman:
+		if c.ProcessNoise < 0 || c.MeasurementNoise <= 0 {
+			return fmt.Errorf("process noise must not be negative and measurement noise must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown reading filter %q", c.Mode)
+	}
+	if c.Samples < 1 {
+		return fmt.Errorf("filter samples must be at least 1")
+	}
+	return nil
+}
+
+// ReadingFilter combines a sequence of distance readings into a smoothed estimate
+type ReadingFilter interface {
+	// Update adds a reading in mm and returns the current estimate
+	Update(depth float64) float64
+}
+
+// NewReadingFilter creates a fresh filter for one pose, nil for FilterNone
+func NewReadingFilter(config FilterConfig) ReadingFilter {
+	switch config.Mode {
+	case FilterEMA:
+		return &EMAFilter{Alpha: config.Alpha}
+	case FilterKalman:
+		return &KalmanFilter{ProcessNoise: config.ProcessNoise, MeasurementNoise: config.MeasurementNoise}
+	default:
+		return nil
+	}
+}
+
+// EMAFilter is an exponential moving average, seeded with the first reading
+type EMAFilter struct {
+	Alpha float64
+
+	estimate float64
+	started  bool
+}
+
+// Update adds a reading and returns the average
+func (f *EMAFilter) Update(depth float64) float64 {
+	if !f.started {
+		f.estimate, f.started = depth, true
+		return depth
+	}
+	f.estimate += f.Alpha * (depth - f.estimate)
+	return f.estimate
+}
+
+// KalmanFilter estimates a nearly constant distance from noisy readings
+type KalmanFilter struct {
+	ProcessNoise     float64 // mm²
+	MeasurementNoise float64 // mm²
+
+	estimate float64
+	variance float64 // mm² - uncertainty of the estimate
+	started  bool
+}
+
+// Update adds a reading and returns the filtered distance
+func (f *KalmanFilter) Update(depth float64) float64 {
+	if !f.started {
+		f.estimate, f.variance, f.started = depth, f.MeasurementNoise, true
+		return depth
+	}
+	// Predict: the distance stays put but may have drifted
+	f.variance += f.ProcessNoise
+	// Correct: blend in the reading by the relative confidence
+	gain := f.variance / (f.variance + f.MeasurementNoise)
+	f.estimate += gain * (depth - f.estimate)
+	f.variance *= 1 - gain
+	return f.estimate
+}
+
+// Variance returns the uncertainty of the current estimate in mm²
+func (f *KalmanFilter) Variance() float64 {
+	return f.variance
+}
+
+// GetFilteredSurfacePoint reads the sensor config.ReadingsPerPose() times at the current pose and smooths the distance
+// Readings at or beyond the max range are misses and are left out of the filter, as are the zero and NaN
+// distances some drivers report instead; if most readings miss, the pose is reported as a miss so edge searches
+// still see the transition
+func GetFilteredSurfacePoint(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, config CalibrationConfig) (SensorReading, error) {
+	capture, err := captureReadings(ctx, fs, sensor, config)
+	if err != nil {
+		return SensorReading{}, err
+	}
+	return capture.process(logger, config), nil
+}
+
+// sensorCapture holds the raw readings taken at one pose, before filtering
+// Capturing needs the hardware to hold still, processing doesn't, so a scan can move on while it runs
+type sensorCapture struct {
+	pose    spatialmath.Pose // sensor pose in the reference frame
+	depths  []float64        // mm - raw distances in reading order
+	time    time.Time        // when the first reading was requested
+	retries int              // failed reads retried, also set when capturing failed
+}
+
+// ReadingsPerPose returns how many readings are taken at each pose: Scanning.SamplesPerPoint if set, otherwise
+// Filter.Samples, or one reading without a filter
+func (c CalibrationConfig) ReadingsPerPose() int {
+	if c.Scanning.SamplesPerPoint > 0 {
+		return c.Scanning.SamplesPerPoint
+	}
+	if NewReadingFilter(c.Filter) != nil && c.Filter.Samples > 1 {
+		return c.Filter.Samples
+	}
+	return 1
+}
+
+// captureReadings looks up the sensor pose and reads the sensor config.ReadingsPerPose() times there, retrying
+// failed reads as config.Retry says
+func captureReadings(ctx context.Context, fs framesystem.RobotFrameSystem, sensor sensor.Sensor,
+	config CalibrationConfig) (sensorCapture, error) {
+	poseInFrame, err := fs.GetPose(ctx, sensor.Name().Name, config.Hardware.ReferenceFrame(), nil, nil)
+	if err != nil {
+		return sensorCapture{}, fmt.Errorf("failed to get sensor pose: %w", err)
+	}
+	pose := config.Hardware.Transducer(poseInFrame.Pose())
+	capture := sensorCapture{pose: config.Backlash.correct(pose, config.Hardware), time: time.Now().UTC()}
+
+	source, err := NewDistanceSource(sensor, config.Hardware.DistanceReading)
+	if err != nil {
+		return sensorCapture{}, err
+	}
+	samples := config.ReadingsPerPose()
+	extra := poseExtra(capture.pose)
+	for i := 0; i < samples; i++ {
+		distance, valid, retries, err := ReadWithRetry(ctx, source, extra, config.Retry)
+		capture.retries += retries
+		if errors.Is(err, context.DeadlineExceeded) {
+			return sensorCapture{retries: capture.retries},
+				fmt.Errorf("failed to get sensor reading: %w: %w", ErrSensorTimeout, err)
+		}
+		if err != nil {
+			return sensorCapture{retries: capture.retries},
+				fmt.Errorf("failed to get sensor reading: %w: %w", ErrSensorRead, err)
+		}
+		if !valid {
+			distance = Meters(math.NaN()) // a miss, like the NaN some drivers report
+		}
+		capture.depths = append(capture.depths, float64(distance.Millimeters()))
+	}
+	return capture, nil
+}
+
+// process filters the captured depths and projects the result along the sensor ray
+// Without a filter the hits are averaged. If most readings miss, the last miss is reported so edge searches still
+// see the transition
+func (c sensorCapture) process(logger logging.Logger, config CalibrationConfig) SensorReading {
+	filter := NewReadingFilter(config.Filter)
+	hits, depth, miss := 0, 0.0, config.Scanning.MaxRange
+	mean, m2 := 0.0, 0.0 // running mean and sum of squared deviations of the raw hits
+	for _, d := range c.depths {
+		if !(d > 0) {
+			continue // a zero or NaN miss, reported as the max range
+		}
+		if config.Scanning.MaxRange > 0 && d >= config.Scanning.MaxRange {
+			miss = d
+			continue
+		}
+		hits++
+		depth = d
+		if filter != nil {
+			depth = filter.Update(d)
+		}
+		delta := d - mean
+		mean += delta / float64(hits)
+		m2 += delta * (d - mean)
+	}
+	if filter == nil && hits > 1 {
+		depth = mean
+	}
+	reading := SensorReading{Depth: depth, SensorPose: c.pose, Raw: c.depths, Timestamp: c.time}
+	if 2*hits < len(c.depths) {
+		reading.Depth, reading.Miss = miss, true
+	} else if hits > 1 {
+		reading.Spread = math.Sqrt(m2 / float64(hits-1))
+		logger.Debugf("Filtered %d/%d readings with %s: %.2f mm (last raw %.2f mm)",
+			hits, len(c.depths), config.Filter.Mode, depth, c.depths[len(c.depths)-1])
+	}
+
+	// Surface point = sensor position + depth * sensor direction
+	o := c.pose.Orientation().OrientationVectorRadians()
+	direction := r3.Vector{X: o.OX, Y: o.OY, Z: o.OZ}.Normalize()
+	point := c.pose.Point().Add(direction.Mul(reading.Depth))
+	reading.SurfacePoint = Point3D{X: point.X, Y: point.Y, Z: point.Z}
+	logger.Debugf("Sensor pos=(%f,%f,%f), dir=(%f,%f,%f), depth=%f, surface=(%f,%f,%f)",
+		c.pose.Point().X, c.pose.Point().Y, c.pose.Point().Z, direction.X, direction.Y, direction.Z,
+		reading.Depth, point.X, point.Y, point.Z)
+	return reading
+}
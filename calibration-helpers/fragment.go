@@ -0,0 +1,59 @@
+package calibrationhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+)
+
+// MonitorComponentName is the default name of the component carrying the calibrated monitor frame
+const MonitorComponentName = "calibrated-monitor"
+
+// FragmentConfig controls the monitor frame written to a Viam fragment
+type FragmentConfig struct {
+	ComponentName string  // component carrying the monitor frame, MonitorComponentName if empty
+	Parent        string  // frame the monitor frame is attached to, the result's frame if empty
+	Thickness     float64 // mm - depth of the monitor box geometry
+
+	// AlignAxis turns the monitor frame about its normal so its X axis is this direction projected onto the plane,
+	// zero to keep the measured width direction. Aligning with the gantry axis makes horizontal strokes pure
+	// gantry moves; the box grows to keep covering the screen.
+	AlignAxis r3.Vector
+}
+
+// GantryAxis is the direction the gantry moves in the calibration frame, which the scans assume is X
+var GantryAxis = r3.Vector{X: 1}
+
+// GenerateFragment builds a Viam fragment holding the calibrated monitor as a generic component
+// The component's frame places the monitor relative to the parent frame, with a box geometry for the screen,
+// so other resources can use the monitor frame and the motion planner sees it as an obstacle
+func GenerateFragment(result CalibrationResult, config FragmentConfig) (map[string]interface{}, error) {
+	component, err := MonitorVisualization(result, config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"components": []interface{}{component},
+	}, nil
+}
+
+// AlignmentYaw returns how far in degrees the monitor frame turns about its normal to align with axis
+// Positive turns run from the measured width direction towards up
+func AlignmentYaw(result CalibrationResult, axis r3.Vector) (float64, error) {
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return 0, err
+	}
+	_, yaw, err := geometry.alignedTo(axis)
+	return yaw, err
+}
+
+// WriteFragment writes the fragment to path as indented JSON, ready to paste into the Viam app
+func WriteFragment(path string, fragment map[string]interface{}) error {
+	data, err := json.MarshalIndent(fragment, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fragment: %w", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
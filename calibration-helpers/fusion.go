@@ -0,0 +1,187 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// FusedSensor reads several distance sensors mounted side by side at known offsets as one sensor
+// It has the primary sensor's name, so fused readings are taken from the primary's pose like a single sensor's.
+// Each sensor's surface point is measured along the primary's beam and the distances are averaged, leaving out
+// those too far from their median: a sensor looking past the monitor edge or at a bezel disagrees
+// with the others. The waypoint is a miss unless most of the sensors hit, so edge searches find the edge where
+// the sensors on one side of the primary run off the monitor.
+type FusedSensor struct {
+	sensor.Sensor // primary
+
+	fs             framesystem.RobotFrameSystem
+	others         []sensor.Sensor
+	referenceFrame string
+	hardware       HardwareConfig // places the primary's transducer with its MountOffset
+	maxRange       float64        // mm
+	outlier        float64        // mm
+}
+
+// NewFusedSensor fuses the primary sensor with others, all posed in the reference frame of config
+// outlierMM is how far a distance may be from the median of all the sensors' distances, 0 to keep every hit
+func NewFusedSensor(fs framesystem.RobotFrameSystem, primary sensor.Sensor, others []sensor.Sensor, outlierMM float64,
+	config CalibrationConfig) *FusedSensor {
+	return &FusedSensor{
+		Sensor:         primary,
+		fs:             fs,
+		others:         others,
+		referenceFrame: config.Hardware.ReferenceFrame(),
+		hardware:       config.Hardware,
+		maxRange:       config.Scanning.MaxRange,
+		outlier:        outlierMM,
+	}
+}
+
+// fusedReading is one sensor's reading, as a distance along the primary's beam
+type fusedReading struct {
+	distance float64 // mm
+	hit      bool
+	err      error
+}
+
+// Readings reads every sensor at once and returns the fused "distance" in meters
+// Also returns how many sensors were "fused" and "rejected" as outliers. The extra pose is ignored, since each
+// sensor is read with its own pose from the frame system.
+func (f *FusedSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	primary, err := f.pose(ctx, f.Sensor)
+	if err != nil {
+		return nil, err
+	}
+	origin, beam := primary.Point(), beamDirection(primary)
+
+	sensors := append([]sensor.Sensor{f.Sensor}, f.others...)
+	readings := make([]fusedReading, len(sensors))
+	var wg sync.WaitGroup
+	for i, s := range sensors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readings[i] = f.read(ctx, s, origin, beam)
+		}()
+	}
+	wg.Wait()
+
+	var hits []float64
+	miss := math.NaN()
+	for i, r := range readings {
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", sensors[i].Name().Name, r.err)
+		}
+		if r.hit {
+			hits = append(hits, r.distance)
+		} else {
+			miss = r.distance
+		}
+	}
+	if 2*len(hits) <= len(sensors) {
+		return map[string]interface{}{"distance": float64(Millimeters(miss).Meters()), "fused": 0, "rejected": 0}, nil
+	}
+
+	median := medianOf(hits)
+	sum, fused := 0.0, 0
+	for _, d := range hits {
+		if f.outlier > 0 && math.Abs(d-median) > f.outlier {
+			continue
+		}
+		sum += d
+		fused++
+	}
+	if fused == 0 {
+		// An even number of hits can all be further than outlier from the median between them, so take the median
+		return map[string]interface{}{
+			"distance": float64(Millimeters(median).Meters()),
+			"fused":    len(hits),
+			"rejected": 0,
+		}, nil
+	}
+	return map[string]interface{}{
+		"distance": float64(Millimeters(sum / float64(fused)).Meters()),
+		"fused":    fused,
+		"rejected": len(hits) - fused,
+	}, nil
+}
+
+// Read implements DistanceSource with the fused distance, so scans read it whatever the fused sensors' reading shape
+func (f *FusedSensor) Read(ctx context.Context, extra map[string]interface{}) (Meters, bool, error) {
+	readings, err := f.Readings(ctx, extra)
+	if err != nil {
+		return 0, false, err
+	}
+	meters := Meters(readings["distance"].(float64))
+	return meters, meters > 0 && !math.IsInf(float64(meters), 0), nil
+}
+
+// read takes one sensor's reading at its own pose and measures its surface point along the primary's beam
+// A miss keeps the raw distance, so the fused miss reads like the sensor's own.
+func (f *FusedSensor) read(ctx context.Context, s sensor.Sensor, origin, beam r3.Vector) fusedReading {
+	pose, err := f.pose(ctx, s)
+	if err != nil {
+		return fusedReading{err: err}
+	}
+	source, err := NewDistanceSource(s, f.hardware.DistanceReading)
+	if err != nil {
+		return fusedReading{err: err}
+	}
+	distance, valid, err := source.Read(ctx, poseExtra(pose))
+	if err != nil {
+		return fusedReading{err: err}
+	}
+	depth := float64(distance.Millimeters())
+	if !valid || (f.maxRange > 0 && depth >= f.maxRange) {
+		return fusedReading{distance: depth}
+	}
+	point := pose.Point().Add(beamDirection(pose).Mul(depth))
+	return fusedReading{distance: point.Sub(origin).Dot(beam), hit: true}
+}
+
+// pose returns a sensor's pose in the reference frame, with the mount offset applied to the primary's
+// The other sensors' frames are taken to be their transducers.
+func (f *FusedSensor) pose(ctx context.Context, s sensor.Sensor) (spatialmath.Pose, error) {
+	pose, err := f.fs.GetPose(ctx, s.Name().Name, f.referenceFrame, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sensor pose: %w", err)
+	}
+	if s.Name() == f.Sensor.Name() {
+		return f.hardware.Transducer(pose.Pose()), nil
+	}
+	return pose.Pose(), nil
+}
+
+// beamDirection returns the unit direction a sensor at pose points in
+func beamDirection(pose spatialmath.Pose) r3.Vector {
+	o := pose.Orientation().OrientationVectorRadians()
+	return r3.Vector{X: o.OX, Y: o.OY, Z: o.OZ}.Normalize()
+}
+
+// poseExtra returns the sensor pose as the extra parameters of a reading, which simulated sensors ray cast from
+func poseExtra(pose spatialmath.Pose) map[string]any {
+	o := pose.Orientation().OrientationVectorRadians()
+	return map[string]any{
+		"x": pose.Point().X, "y": pose.Point().Y, "z": pose.Point().Z,
+		"ox": o.OX, "oy": o.OY, "oz": o.OZ, "th": o.Theta,
+	}
+}
+
+// medianOf returns the median of values, which must not be empty
+func medianOf(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,141 @@
+package calibrationhelpers
+
+import (
+	"calibration/geometry"
+	"math"
+
+	"go.viam.com/rdk/logging"
+)
+
+// The plane and fitting math lives in the geometry package, which has no rdk dependencies
+// These aliases keep it available under this package's names
+type (
+	// Point3D represents a 3D point in world space
+	//
+	// Deprecated: use types.Point3D.
+	Point3D = geometry.Point3D
+	// Plane represents a plane equation: Ax + By + Cz = D
+	//
+	// Deprecated: use types.Plane.
+	Plane = geometry.Plane
+	// PlaneFitter fits a plane to noisy surface samples using RANSAC
+	PlaneFitter = geometry.PlaneFitter
+	// StreamingPlaneFit fits a least-squares plane to points as they arrive, without storing them
+	StreamingPlaneFit = geometry.StreamingPlaneFit
+	// Covariance describes the parameter uncertainty of a least-squares plane fit
+	//
+	// Deprecated: use types.Covariance.
+	Covariance = geometry.Covariance
+	// DeviationSurface models how the glass departs from the fitted plane with a low-order polynomial
+	//
+	// Deprecated: use types.DeviationSurface.
+	DeviationSurface = geometry.DeviationSurface
+	// Cylinder models a curved monitor as a patch of a circular cylinder
+	//
+	// Deprecated: use types.Cylinder.
+	Cylinder = geometry.Cylinder
+)
+
+// NewPlaneFitter creates a RANSAC plane fitter from the detection config
+func NewPlaneFitter(config DetectionConfig) *PlaneFitter {
+	return &PlaneFitter{
+		InlierThreshold: config.RansacThreshold,
+		Iterations:      config.RansacIterations,
+	}
+}
+
+// PointDistanceFromPlane calculates the distance of a point from a plane
+func PointDistanceFromPlane(point Point3D, plane Plane) float64 {
+	return geometry.PointDistanceFromPlane(point, plane)
+}
+
+// PointToPlaneDistance returns the signed distance of a point from a plane, positive on the side the normal points to
+func PointToPlaneDistance(point Point3D, plane Plane) float64 {
+	return geometry.PointToPlaneDistance(point, plane)
+}
+
+// ProjectPointOntoPlane returns the point on a plane nearest to the given point
+func ProjectPointOntoPlane(point Point3D, plane Plane) Point3D {
+	return geometry.ProjectPointOntoPlane(point, plane)
+}
+
+// RayPlaneIntersection intersects the ray from origin along direction with a plane
+// Returns the hit and the distance along the ray in units of direction's length, ok is false when the ray runs
+// parallel to the plane or the plane is behind the origin.
+func RayPlaneIntersection(origin, direction Point3D, plane Plane) (Point3D, float64, bool) {
+	return geometry.RayPlaneIntersection(origin, direction, plane)
+}
+
+// PlaneFromThreePoints calculates a plane from 3 non-collinear points, with the normal towards +Y
+func PlaneFromThreePoints(p1, p2, p3 Point3D) (Plane, error) {
+	return geometry.PlaneFromThreePoints(p1, p2, p3)
+}
+
+// CalculatePlaneFrom3Points calculates a plane from 3 non-collinear points
+//
+// Deprecated: use PlaneFromThreePoints.
+func CalculatePlaneFrom3Points(p1, p2, p3 Point3D) (Plane, error) {
+	return geometry.PlaneFromThreePoints(p1, p2, p3)
+}
+
+// FitPlaneLeastSquares fits a plane to the points and estimates the uncertainty of its parameters
+func FitPlaneLeastSquares(points []Point3D) (Plane, Covariance, error) {
+	return geometry.FitPlaneLeastSquares(points)
+}
+
+// FitPlaneWeightedLeastSquares is like FitPlaneLeastSquares with a relative weight per point, nil for equal weights
+func FitPlaneWeightedLeastSquares(points []Point3D, weights []float64) (Plane, Covariance, error) {
+	return geometry.FitPlaneWeightedLeastSquares(points, weights)
+}
+
+// IncidenceWeights returns the cosine of each beam's incidence angle on the plane, with a small floor
+func IncidenceWeights(beams []Point3D, plane Plane) []float64 {
+	return geometry.IncidenceWeights(beams, plane)
+}
+
+// FitDeviationSurface fits a polynomial deviation surface to the residuals of the points from the plane
+func FitDeviationSurface(points []Point3D, plane Plane, order int, maxResidual float64) (DeviationSurface, error) {
+	return geometry.FitDeviationSurface(points, plane, order, maxResidual)
+}
+
+// FitCylinderDeviationSurface fits a deviation surface on the plane to the residuals of the points from the cylinder
+func FitCylinderDeviationSurface(points []Point3D, cylinder Cylinder, plane Plane, order int,
+	maxResidual float64) (DeviationSurface, error) {
+	return geometry.FitCylinderDeviationSurface(points, cylinder, plane, order, maxResidual)
+}
+
+// FitCylinder fits a cylinder with the given axis direction to the points, for curved monitors
+func FitCylinder(points []Point3D, axis Point3D) (Cylinder, error) {
+	return geometry.FitCylinder(points, axis)
+}
+
+// FitCylinderRANSAC fits a cylinder to the largest set of points within threshold of it, and returns those inliers
+func FitCylinderRANSAC(logger logging.Logger, points []Point3D, axis Point3D, threshold float64,
+	iterations int) (Cylinder, []Point3D, error) {
+	return geometry.FitCylinderRANSAC(logger, points, axis, threshold, iterations)
+}
+
+// TangentPlane returns the plane touching the cylinder in the middle of its extent, with the normal towards +Y
+// like PlaneFromThreePoints, so a curved monitor gets the same frame as a flat one
+func TangentPlane(cylinder Cylinder) Plane {
+	apex, normal := cylinder.Apex()
+	if normal.Y < 0 {
+		normal = Point3D{X: -normal.X, Y: -normal.Y, Z: -normal.Z}
+	}
+	return Plane{A: normal.X, B: normal.Y, C: normal.Z, D: normal.X*apex.X + normal.Y*apex.Y + normal.Z*apex.Z}
+}
+
+// surfaceDistance returns how far a point is from the monitor surface: the fitted cylinder for a curved monitor,
+// otherwise the plane
+func surfaceDistance(point Point3D, plane Plane, config CalibrationConfig) float64 {
+	if config.Curve != nil {
+		return math.Abs(config.Curve.Distance(point))
+	}
+	return PointDistanceFromPlane(point, plane)
+}
+
+// onSurface reports whether a reading hit the monitor: it isn't a miss and its point is within the plane threshold
+// of the surface
+func onSurface(reading SensorReading, plane Plane, config CalibrationConfig) bool {
+	return !reading.Miss && surfaceDistance(reading.SurfacePoint, plane, config) <= config.Detection.PlaneThreshold
+}
@@ -0,0 +1,141 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+// GroundTruth is the true pose and size of a simulated monitor, in the coordinates of the sensor's world frame
+type GroundTruth struct {
+	Frame  string  `json:"frame,omitempty"` // the sensor's world frame
+	Center Point3D `json:"center"`
+	Normal Point3D `json:"normal"`
+	Up     Point3D `json:"up"`
+	Width  float64 `json:"width"`  // mm
+	Height float64 `json:"height"` // mm
+}
+
+// GroundTruthLimits are the errors a calibration may have before the report fails, zero disables a check
+type GroundTruthLimits struct {
+	MaxTranslation float64 `json:"max_translation_mm,omitempty"`
+	MaxNormalAngle float64 `json:"max_normal_angle_deg,omitempty"`
+	MaxSizeError   float64 `json:"max_size_error_mm,omitempty"`
+}
+
+// GroundTruthReport compares a calibration result with the simulated monitor it measured
+type GroundTruthReport struct {
+	MonitorIndex     int     `json:"monitor_index"`          // which simulated monitor the result was matched to
+	Translation      Point3D `json:"translation_mm"`         // measured center minus true center
+	TranslationError float64 `json:"translation_error_mm"`   // distance between the centers
+	NormalAngleError float64 `json:"normal_angle_error_deg"` // angle between the measured and true normals
+	RollError        float64 `json:"roll_error_deg"`         // turn about the normal from the true up to the measured up
+	WidthError       float64 `json:"width_error_mm"`         // measured minus true width
+	HeightError      float64 `json:"height_error_mm"`        // measured minus true height
+
+	Limits   GroundTruthLimits `json:"limits"`
+	Pass     bool              `json:"pass"`
+	Failures []string          `json:"failures,omitempty"`
+}
+
+// FetchGroundTruth asks a simulated sensor for the true monitor poses with the "get_ground_truth" command
+func FetchGroundTruth(ctx context.Context, sensor resource.Resource) ([]GroundTruth, error) {
+	resp, err := sensor.DoCommand(ctx, map[string]interface{}{"command": "get_ground_truth"})
+	if err != nil {
+		return nil, fmt.Errorf("sensor %s does not report ground truth: %w", sensor.Name().Name, err)
+	}
+
+	// Round-trip through JSON since DoCommand responses may arrive as generic maps over the wire
+	data, err := json.Marshal(resp["monitors"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ground truth: %w", err)
+	}
+	var truths []GroundTruth
+	if err := json.Unmarshal(data, &truths); err != nil {
+		return nil, fmt.Errorf("failed to decode ground truth: %w", err)
+	}
+	if len(truths) == 0 {
+		return nil, fmt.Errorf("sensor %s reported no monitors", sensor.Name().Name)
+	}
+	// Sensors that predate configurable world frames don't report one
+	frame, _ := resp["frame"].(string)
+	if frame == "" {
+		frame = referenceframe.World
+	}
+	for i := range truths {
+		truths[i].Frame = frame
+	}
+	return truths, nil
+}
+
+// CompareToGroundTruth measures the errors of a calibration result against the nearest simulated monitor
+func CompareToGroundTruth(result CalibrationResult, truths []GroundTruth, limits GroundTruthLimits) (GroundTruthReport, error) {
+	if len(truths) == 0 {
+		return GroundTruthReport{}, fmt.Errorf("no ground truth monitors to compare with")
+	}
+	measured, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return GroundTruthReport{}, err
+	}
+
+	// Several simulated monitors: the calibration measured whichever is nearest
+	report := GroundTruthReport{MonitorIndex: -1, Limits: limits}
+	var truth GroundTruth
+	for i, t := range truths {
+		center := r3.Vector{X: t.Center.X, Y: t.Center.Y, Z: t.Center.Z}
+		if d := measured.Center.Distance(center); report.MonitorIndex < 0 || d < report.TranslationError {
+			report.MonitorIndex, report.TranslationError, truth = i, d, t
+		}
+	}
+
+	report.Translation = Point3D{
+		X: measured.Center.X - truth.Center.X,
+		Y: measured.Center.Y - truth.Center.Y,
+		Z: measured.Center.Z - truth.Center.Z,
+	}
+	trueNormal := r3.Vector{X: truth.Normal.X, Y: truth.Normal.Y, Z: truth.Normal.Z}
+	if trueNormal.Norm() < 1e-9 {
+		return GroundTruthReport{}, fmt.Errorf("ground truth monitor %d has a zero normal", report.MonitorIndex)
+	}
+	// The fitted normal may face either way, only its axis is measured
+	cos := math.Abs(measured.LocalY.Dot(trueNormal.Normalize()))
+	report.NormalAngleError = utils.RadToDeg(math.Acos(math.Min(1, cos)))
+	// Roll is the turn about the measured normal from the true up, projected onto the calibrated plane, to the measured up
+	trueUp := r3.Vector{X: truth.Up.X, Y: truth.Up.Y, Z: truth.Up.Z}
+	trueUp = trueUp.Sub(measured.LocalY.Mul(trueUp.Dot(measured.LocalY)))
+	if trueUp.Norm() > 1e-9 {
+		report.RollError = utils.RadToDeg(math.Atan2(trueUp.Cross(measured.LocalZ).Dot(measured.LocalY), trueUp.Dot(measured.LocalZ)))
+	}
+	report.WidthError = measured.Width - truth.Width
+	report.HeightError = measured.Height - truth.Height
+
+	if limits.MaxTranslation > 0 && report.TranslationError > limits.MaxTranslation {
+		report.Failures = append(report.Failures, fmt.Sprintf("translation error %.2f mm exceeds %.2f mm",
+			report.TranslationError, limits.MaxTranslation))
+	}
+	if limits.MaxNormalAngle > 0 && report.NormalAngleError > limits.MaxNormalAngle {
+		report.Failures = append(report.Failures, fmt.Sprintf("normal angle error %.3f° exceeds %.3f°",
+			report.NormalAngleError, limits.MaxNormalAngle))
+	}
+	if limits.MaxSizeError > 0 && math.Max(math.Abs(report.WidthError), math.Abs(report.HeightError)) > limits.MaxSizeError {
+		report.Failures = append(report.Failures, fmt.Sprintf("size error (width %+.2f mm, height %+.2f mm) exceeds %.2f mm",
+			report.WidthError, report.HeightError, limits.MaxSizeError))
+	}
+	report.Pass = len(report.Failures) == 0
+	return report, nil
+}
+
+// ToMap converts the report to a map of JSON values, suitable for returning from DoCommand
+func (r GroundTruthReport) ToMap() (map[string]interface{}, error) {
+	m, err := jsonToMap(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ground truth report: %w", err)
+	}
+	return m, nil
+}
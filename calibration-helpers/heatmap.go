@@ -0,0 +1,217 @@
+package calibrationhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/geo/r3"
+)
+
+// Residual heatmap file formats supported by WriteHeatmap
+const (
+	HeatmapPNG  = "png"
+	HeatmapJSON = "json"
+)
+
+// HeatmapCell is one cell of a residual heatmap
+type HeatmapCell struct {
+	Row      int     `json:"row"` // 0 at the top of the monitor
+	Col      int     `json:"col"` // 0 at the left of the monitor, as seen from the sensor
+	U        float64 `json:"u"`   // mm - cell center right of the monitor center, as seen from the sensor
+	V        float64 `json:"v"`   // mm - cell center above the monitor center
+	Samples  int     `json:"samples"`
+	Rejected int     `json:"rejected"`         // samples the plane fit rejected as outliers
+	Mean     float64 `json:"mean_residual_mm"` // mean signed residual, positive towards the sensor
+	Peak     float64 `json:"peak_residual_mm"` // signed residual of the largest magnitude
+}
+
+// ResidualHeatmap bins scan residuals over the calibrated monitor in a grid of cells
+// Residuals are distances from the fitted surface, the plane or a curved monitor's cylinder, positive towards the
+// sensor. A warped corner shows as a patch of cells bending the same way, and a contaminated scan region as cells
+// with large peaks or rejected samples.
+type ResidualHeatmap struct {
+	Surface string        `json:"surface"`   // "plane" or "cylinder"
+	Width   float64       `json:"width_mm"`  // along the glass for a curved monitor
+	Height  float64       `json:"height_mm"` // along the glass for a curved monitor
+	Columns int           `json:"columns"`
+	Rows    int           `json:"rows"`
+	Samples int           `json:"samples"` // samples binned into cells
+	Outside int           `json:"outside"` // samples beyond the calibrated extent, left out
+	Worst   *HeatmapCell  `json:"worst,omitempty"`
+	Cells   []HeatmapCell `json:"cells"` // row by row from the top
+}
+
+// ToMap converts the heatmap to a map of JSON values, suitable for returning from DoCommand
+func (h ResidualHeatmap) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(h)
+}
+
+// BuildResidualHeatmap bins the scan points of a calibration into columns by rows cells over the monitor
+// Flat monitors are binned over the calibrated rectangle and curved ones over their cylinder's arc and axial extent.
+// Zero rows picks as many as make the cells about square. Worst is the cell whose mean residual is largest in
+// magnitude.
+func BuildResidualHeatmap(result CalibrationResult, points []CloudPoint, columns, rows int) (ResidualHeatmap, error) {
+	if columns < 1 || rows < 0 {
+		return ResidualHeatmap{}, fmt.Errorf("heatmap needs at least 1 column and no negative rows, got %dx%d", columns, rows)
+	}
+	// locate returns a point's position right of and above the monitor center and its residual
+	var locate func(p Point3D) (u, v, residual float64)
+	heatmap := ResidualHeatmap{Columns: columns, Rows: rows, Cells: []HeatmapCell{}}
+	if c := result.Cylinder; c != nil {
+		heatmap.Surface = "cylinder"
+		heatmap.Width, heatmap.Height = c.ArcMax-c.ArcMin, c.AxialMax-c.AxialMin
+		midArc, midAxial := (c.ArcMin+c.ArcMax)/2, (c.AxialMin+c.AxialMax)/2
+		// Arc length grows to the viewer's left when the axis points up; flip both when it points down
+		up := 1.0
+		if c.Axis.Z < 0 {
+			up = -1
+		}
+		locate = func(p Point3D) (float64, float64, float64) {
+			arc, axial := c.Locate(p)
+			return -up * (arc - midArc), up * (axial - midAxial), -c.Distance(p)
+		}
+	} else {
+		g, err := monitorGeometryFromResult(result)
+		if err != nil {
+			return ResidualHeatmap{}, err
+		}
+		heatmap.Surface = "plane"
+		heatmap.Width, heatmap.Height = g.Width, g.Height
+		// LocalX points to the viewer's left and LocalY out of the glass
+		locate = func(p Point3D) (float64, float64, float64) {
+			d := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}.Sub(g.Center)
+			return -d.Dot(g.LocalX), d.Dot(g.LocalZ), d.Dot(g.LocalY)
+		}
+	}
+	if !(heatmap.Width > 0 && heatmap.Height > 0) {
+		return ResidualHeatmap{}, fmt.Errorf("calibrated monitor has no extent to map residuals over")
+	}
+	if rows == 0 {
+		rows = max(1, int(math.Round(float64(columns)*heatmap.Height/heatmap.Width)))
+		heatmap.Rows = rows
+	}
+
+	cellWidth, cellHeight := heatmap.Width/float64(columns), heatmap.Height/float64(rows)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			heatmap.Cells = append(heatmap.Cells, HeatmapCell{
+				Row: row,
+				Col: col,
+				U:   -heatmap.Width/2 + (float64(col)+0.5)*cellWidth,
+				V:   heatmap.Height/2 - (float64(row)+0.5)*cellHeight,
+			})
+		}
+	}
+	// cellIndex returns the cell a coordinate falls in, with the far edge belonging to the last cell
+	cellIndex := func(offset, size float64, n int) (int, bool) {
+		i := int(math.Floor(offset / size))
+		if i == n && offset <= size*float64(n)+1e-9 {
+			i = n - 1
+		}
+		return i, offset >= 0 && i < n
+	}
+	for _, p := range points {
+		u, v, residual := locate(p.Point3D)
+		col, okCol := cellIndex(u+heatmap.Width/2, cellWidth, columns)
+		row, okRow := cellIndex(heatmap.Height/2-v, cellHeight, rows)
+		if !okCol || !okRow {
+			heatmap.Outside++
+			continue
+		}
+		cell := &heatmap.Cells[row*columns+col]
+		cell.Samples++
+		cell.Mean += (residual - cell.Mean) / float64(cell.Samples)
+		if math.Abs(residual) > math.Abs(cell.Peak) {
+			cell.Peak = residual
+		}
+		if !p.Valid {
+			cell.Rejected++
+		}
+		heatmap.Samples++
+	}
+	for i := range heatmap.Cells {
+		cell := heatmap.Cells[i]
+		if cell.Samples > 0 && (heatmap.Worst == nil || math.Abs(cell.Mean) > math.Abs(heatmap.Worst.Mean)) {
+			heatmap.Worst = &cell
+		}
+	}
+	return heatmap, nil
+}
+
+// HeatmapFormatFromPath infers the heatmap format from a file extension
+func HeatmapFormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case HeatmapPNG, HeatmapJSON:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("cannot infer heatmap format from %q, expected a .png or .json file", path)
+	}
+}
+
+// WriteHeatmap writes the heatmap as a PNG image or as JSON
+// The image draws each cell as a cellPixels square, as seen from the sensor, colored from blue (scale mm into the
+// glass) through white to red (scale mm towards the sensor) by its mean residual. Empty cells are grey, and cells
+// with rejected samples get a black outline. A scale of zero uses the largest mean residual.
+func WriteHeatmap(w io.Writer, format string, heatmap ResidualHeatmap, cellPixels int, scale float64) error {
+	switch format {
+	case HeatmapJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(heatmap); err != nil {
+			return fmt.Errorf("failed to write heatmap: %w", err)
+		}
+		return nil
+	case HeatmapPNG:
+	default:
+		return fmt.Errorf("unknown heatmap format %q", format)
+	}
+
+	if cellPixels < 1 {
+		return fmt.Errorf("heatmap cells must be at least 1 pixel, got %d", cellPixels)
+	}
+	if scale <= 0 && heatmap.Worst != nil {
+		scale = math.Abs(heatmap.Worst.Mean)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, heatmap.Columns*cellPixels, heatmap.Rows*cellPixels))
+	for _, cell := range heatmap.Cells {
+		fill := color.RGBA{R: 160, G: 160, B: 160, A: 255}
+		if cell.Samples > 0 {
+			fill = residualColor(cell.Mean, scale)
+		}
+		x0, y0 := cell.Col*cellPixels, cell.Row*cellPixels
+		for y := y0; y < y0+cellPixels; y++ {
+			for x := x0; x < x0+cellPixels; x++ {
+				outline := x == x0 || y == y0 || x == x0+cellPixels-1 || y == y0+cellPixels-1
+				if cell.Rejected > 0 && outline && cellPixels > 2 {
+					img.SetRGBA(x, y, color.RGBA{A: 255})
+				} else {
+					img.SetRGBA(x, y, fill)
+				}
+			}
+		}
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to write heatmap: %w", err)
+	}
+	return nil
+}
+
+// residualColor maps a residual to a diverging blue-white-red color, saturating at scale
+func residualColor(residual, scale float64) color.RGBA {
+	t := 0.0
+	if scale > 0 {
+		t = math.Max(-1, math.Min(1, residual/scale))
+	}
+	fade := uint8(math.Round(255 * (1 - math.Abs(t))))
+	if t >= 0 {
+		return color.RGBA{R: 255, G: fade, B: fade, A: 255}
+	}
+	return color.RGBA{R: fade, G: fade, B: 255, A: 255}
+}
@@ -0,0 +1,123 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+)
+
+// Named arm rest positions a homing step can move the arm to, see ArmPositions.Named
+const (
+	RestPositionHome       = "home"
+	RestPositionBottomScan = "bottom_scan"
+	RestPositionTopScan    = "top_scan"
+)
+
+// Named returns the joint positions of the named rest position
+func (p ArmPositions) Named(name string) ([]float64, error) {
+	switch name {
+	case RestPositionHome:
+		return p.Home, nil
+	case RestPositionBottomScan:
+		return p.BottomScan, nil
+	case RestPositionTopScan:
+		return p.TopScan, nil
+	}
+	return nil, fmt.Errorf("unknown arm position %q, expected %s, %s or %s",
+		name, RestPositionHome, RestPositionBottomScan, RestPositionTopScan)
+}
+
+// HomingConfig homes the gantry before a scan, so gantry positions are measured from a fresh zero, and
+// optionally checks that zero against a fixed reference target
+type HomingConfig struct {
+	RestPosition string // named arm position to move to after homing, "" to leave the arm where it is
+	// Reference is a target at a known distance from the sensor, read after homing, nil to skip the check
+	Reference *ReferenceTarget
+}
+
+// ReferenceTarget is a fixed target the sensor reads at a known distance when the arm is at its rest position and
+// the gantry at Gantry
+type ReferenceTarget struct {
+	Gantry    float64 // mm - gantry position to read the target from
+	Distance  float64 // mm - expected reading
+	Tolerance float64 // mm - largest accepted difference from Distance
+}
+
+// HomingReport is the outcome of a homing step
+type HomingReport struct {
+	RestPosition string          `json:"rest_position,omitempty"`
+	Reference    *ReferenceCheck `json:"reference,omitempty"`
+}
+
+// ReferenceCheck compares the reference target reading after homing with the expected distance
+type ReferenceCheck struct {
+	GantryMM   float64 `json:"gantry_mm"`
+	DistanceMM float64 `json:"distance_mm"`
+	ExpectedMM float64 `json:"expected_mm"`
+	ErrorMM    float64 `json:"error_mm"` // reading minus expected
+	Tolerance  float64 `json:"tolerance_mm"`
+	Pass       bool    `json:"pass"`
+}
+
+// ToMap converts the report for DoCommand responses
+func (r HomingReport) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// HomeGantry runs the homing step of config.Homing: homes the gantry, moves the arm to the rest position and reads
+// the reference target. A reading off by more than the tolerance fails with ErrHomingCheckFailed, since every
+// gantry position of the scan would be off with it.
+func HomeGantry(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem, sensor sensor.Sensor,
+	arm arm.Arm, gantry gantry.Gantry, config CalibrationConfig) (HomingReport, error) {
+	homing := config.Homing
+	report := HomingReport{RestPosition: homing.RestPosition}
+	homed, err := gantry.Home(ctx, nil)
+	if err != nil {
+		return report, fmt.Errorf("failed to home gantry: %w", err)
+	}
+	if !homed {
+		return report, fmt.Errorf("gantry did not home")
+	}
+
+	if homing.RestPosition != "" {
+		joints, err := config.ArmPositions.Named(homing.RestPosition)
+		if err != nil {
+			return report, err
+		}
+		if err := arm.MoveToJointPositions(ctx, joints, nil); err != nil {
+			return report, fmt.Errorf("failed to move arm to %s position: %w", homing.RestPosition, err)
+		}
+	}
+
+	ref := homing.Reference
+	if ref == nil {
+		return report, nil
+	}
+	if err := gantry.MoveToPosition(ctx, []float64{ref.Gantry}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+		return report, fmt.Errorf("failed to move gantry to reference position: %w", err)
+	}
+	reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+	if err != nil {
+		return report, fmt.Errorf("failed to read reference target: %w", err)
+	}
+	check := &ReferenceCheck{
+		GantryMM:   ref.Gantry,
+		DistanceMM: reading.Depth,
+		ExpectedMM: ref.Distance,
+		ErrorMM:    reading.Depth - ref.Distance,
+		Tolerance:  ref.Tolerance,
+	}
+	check.Pass = math.Abs(check.ErrorMM) <= ref.Tolerance
+	report.Reference = check
+	if !check.Pass {
+		return report, fmt.Errorf("reference target read %.1f mm after homing, expected %.1f ± %.1f mm: %w",
+			check.DistanceMM, check.ExpectedMM, check.Tolerance, ErrHomingCheckFailed)
+	}
+	return report, nil
+}
@@ -0,0 +1,50 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PostProcessor adjusts or vetoes a calibration result after fitting and before it is persisted or returned
+// Returning an error rejects the result and fails the calibration run
+type PostProcessor func(*CalibrationResult) error
+
+type namedPostProcessor struct {
+	name string
+	fn   PostProcessor
+}
+
+var (
+	postProcessorsMu sync.RWMutex
+	postProcessors   []namedPostProcessor
+)
+
+// RegisterPostProcessor adds a hook that runs on every calibration result, in registration order
+// Embedders typically call this from an init function, the same way resources are registered
+func RegisterPostProcessor(name string, fn PostProcessor) {
+	if fn == nil {
+		panic(fmt.Sprintf("post-processor %q is nil", name))
+	}
+
+	postProcessorsMu.Lock()
+	defer postProcessorsMu.Unlock()
+	for _, p := range postProcessors {
+		if p.name == name {
+			panic(fmt.Sprintf("post-processor %q already registered", name))
+		}
+	}
+	postProcessors = append(postProcessors, namedPostProcessor{name: name, fn: fn})
+}
+
+// RunPostProcessors applies all registered hooks to the result, stopping at the first error
+func RunPostProcessors(result *CalibrationResult) error {
+	postProcessorsMu.RLock()
+	defer postProcessorsMu.RUnlock()
+
+	for _, p := range postProcessors {
+		if err := p.fn(result); err != nil {
+			return fmt.Errorf("post-processor %q rejected calibration result: %w", p.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Formats accepted by ImportCalibration
+const (
+	// ImportOpenCV is an OpenCV FileStorage YAML with the calibration board extrinsics (rvec or R, and tvec or T)
+	ImportOpenCV = "opencv"
+	// ImportPLY is a PLY point cloud of the segmented monitor plane, e.g. exported from MeshLab or CloudCompare
+	ImportPLY = "ply"
+	// ImportMatrix is a 4x4 row-major homogeneous transform from the monitor frame to the source frame
+	ImportMatrix = "matrix"
+)
+
+// ImportOptions describes how externally produced calibration data maps onto this package's conventions
+// The monitor frame has X along the width, Y along the outward normal and Z up, with its origin at the center
+type ImportOptions struct {
+	Width, Height float64 // mm - monitor size, required for formats that only carry a pose
+
+	// SourcePose is the pose of the frame the data is expressed in (e.g. the camera) in the reference frame
+	// nil when the data is already in the reference frame
+	SourcePose spatialmath.Pose
+	// Frame is the reference frame recorded in the result
+	Frame string
+	// OriginAtCorner marks an OpenCV board origin at the monitor's top-left corner instead of its center
+	OriginAtCorner bool
+	// Convention is the coordinate convention of PLY and matrix data, empty for ConventionViam or, for a PLY
+	// file, the convention its header is tagged with. OpenCV extrinsics always use OpenCV camera coordinates.
+	Convention string
+}
+
+// ImportCalibration converts calibration data produced by external tools into a CalibrationResult
+// Distances are in mm. The result can be used with every export and path API in this package.
+func ImportCalibration(r io.Reader, format string, opts ImportOptions) (CalibrationResult, error) {
+	if err := ValidateConvention(opts.Convention); err != nil {
+		return CalibrationResult{}, err
+	}
+	var geometry monitorGeometry
+	var err error
+	switch format {
+	case ImportOpenCV:
+		if opts.Convention != "" && opts.Convention != ConventionViam {
+			return CalibrationResult{}, fmt.Errorf("OpenCV extrinsics are in OpenCV camera coordinates, a convention does not apply")
+		}
+		geometry, err = importOpenCVExtrinsics(r, opts)
+	case ImportPLY:
+		geometry, err = importPLYPlane(r, opts.Convention)
+	case ImportMatrix:
+		geometry, err = importMatrix(r, opts)
+	default:
+		return CalibrationResult{}, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to import %s calibration: %w", format, err)
+	}
+
+	if opts.SourcePose != nil {
+		geometry = geometry.transform(opts.SourcePose)
+	}
+	result := resultFromGeometry(geometry)
+	result.Timestamp = time.Now().UTC()
+	result.Frame = opts.Frame
+	return result, nil
+}
+
+// ResultFromMonitorPose builds a calibration result from the monitor pose in the reference frame
+// The pose's local X is the width direction, local Y the outward normal and local Z up
+func ResultFromMonitorPose(pose spatialmath.Pose, width, height float64) (CalibrationResult, error) {
+	if width <= 0 || height <= 0 {
+		return CalibrationResult{}, fmt.Errorf("monitor width and height must be positive")
+	}
+	rm := pose.Orientation().RotationMatrix()
+	return resultFromGeometry(monitorGeometry{
+		Center: pose.Point(),
+		LocalX: rm.Row(0),
+		LocalY: rm.Row(1),
+		LocalZ: rm.Row(2),
+		Width:  width,
+		Height: height,
+	}), nil
+}
+
+// resultFromGeometry is the inverse of monitorGeometryFromResult
+// The result stores the corners, which hold the geometry even for a monitor rolled in its plane. The edges and
+// reference points straddle the center so that readers of results without corners recover it as well.
+func resultFromGeometry(g monitorGeometry) CalibrationResult {
+	xSpan := g.Width
+	if math.Abs(g.LocalX.X) >= 0.1 {
+		xSpan = g.Width * math.Abs(g.LocalX.X)
+	}
+	zSpan := g.Height
+	if math.Abs(g.LocalZ.Z) >= 0.1 {
+		zSpan = g.Height * math.Abs(g.LocalZ.Z)
+	}
+	toPoint := func(v r3.Vector) Point3D { return Point3D{X: v.X, Y: v.Y, Z: v.Z} }
+	corners := g.monitorCorners()
+
+	return CalibrationResult{
+		Plane:         Plane{A: g.LocalY.X, B: g.LocalY.Y, C: g.LocalY.Z, D: g.LocalY.Dot(g.Center)},
+		LeftX:         g.Center.X + xSpan/2,
+		RightX:        g.Center.X - xSpan/2,
+		BottomZ:       g.Center.Z - zSpan/2,
+		TopZ:          g.Center.Z + zSpan/2,
+		MonitorWidth:  g.Width,
+		MonitorHeight: g.Height,
+		XPoint1:       toPoint(g.Center.Sub(g.LocalX.Mul(g.Width / 4))),
+		XPoint2:       toPoint(g.Center.Add(g.LocalX.Mul(g.Width / 4))),
+		ZPoint1:       toPoint(g.Center.Add(g.LocalZ.Mul(g.Height / 4))),
+		Corners:       &corners,
+	}
+}
+
+// transform re-expresses the geometry, given in a source frame, in the frame that pose is expressed in
+func (g monitorGeometry) transform(pose spatialmath.Pose) monitorGeometry {
+	rotate := func(v r3.Vector) r3.Vector {
+		return spatialmath.Compose(spatialmath.NewPoseFromOrientation(pose.Orientation()), spatialmath.NewPoseFromPoint(v)).Point()
+	}
+	g.Center = spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(g.Center)).Point()
+	g.LocalX, g.LocalY, g.LocalZ = rotate(g.LocalX), rotate(g.LocalY), rotate(g.LocalZ)
+	return g
+}
+
+// importOpenCVExtrinsics reads board extrinsics, which map board coordinates into the camera frame
+// The board frame has X right, Y down and Z into the board, so the monitor frame is (-X, -Z, -Y) of the board
+func importOpenCVExtrinsics(r io.Reader, opts ImportOptions) (monitorGeometry, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return monitorGeometry{}, fmt.Errorf("monitor width and height are required")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+	text := string(data)
+
+	var boardX, boardY, boardZ r3.Vector
+	if rvec, err := openCVMatrix(text, 3, "rvec", "rvecs", "rotation_vector"); err == nil {
+		rm := spatialmath.R3ToR4(r3.Vector{X: rvec[0], Y: rvec[1], Z: rvec[2]}).RotationMatrix()
+		// Row(i) of a RotationMatrix is the rotated i-th axis
+		boardX, boardY, boardZ = rm.Row(0), rm.Row(1), rm.Row(2)
+	} else if rmat, err := openCVMatrix(text, 9, "R", "rmat", "rotation_matrix"); err == nil {
+		// OpenCV stores R row-major; its columns are the board axes in the camera frame
+		boardX = r3.Vector{X: rmat[0], Y: rmat[3], Z: rmat[6]}
+		boardY = r3.Vector{X: rmat[1], Y: rmat[4], Z: rmat[7]}
+		boardZ = r3.Vector{X: rmat[2], Y: rmat[5], Z: rmat[8]}
+	} else {
+		return monitorGeometry{}, fmt.Errorf("no rotation found, expected rvec or R")
+	}
+	tvec, err := openCVMatrix(text, 3, "tvec", "tvecs", "T", "translation_vector")
+	if err != nil {
+		return monitorGeometry{}, fmt.Errorf("no translation found, expected tvec or T")
+	}
+
+	center := r3.Vector{X: tvec[0], Y: tvec[1], Z: tvec[2]}
+	if opts.OriginAtCorner {
+		center = center.Add(boardX.Mul(opts.Width / 2)).Add(boardY.Mul(opts.Height / 2))
+	}
+	return monitorGeometry{
+		Center: center,
+		LocalX: boardX.Mul(-1).Normalize(),
+		LocalY: boardZ.Mul(-1).Normalize(),
+		LocalZ: boardY.Mul(-1).Normalize(),
+		Width:  opts.Width,
+		Height: opts.Height,
+	}, nil
+}
+
+// openCVMatrix extracts the data of the first named !!opencv-matrix with the expected number of elements
+func openCVMatrix(text string, size int, names ...string) ([]float64, error) {
+	for _, name := range names {
+		re := regexp.MustCompile(`(?ms)^\s*` + regexp.QuoteMeta(name) + `\s*:\s*!!opencv-matrix.*?data\s*:\s*\[([^\]]*)\]`)
+		match := re.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		values, err := parseNumbers(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(values) != size {
+			return nil, fmt.Errorf("%s has %d elements, expected %d", name, len(values), size)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("none of %v found", names)
+}
+
+// importMatrix reads a 4x4 homogeneous transform whose rotation columns are the monitor X, Y and Z axes
+func importMatrix(r io.Reader, opts ImportOptions) (monitorGeometry, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return monitorGeometry{}, fmt.Errorf("monitor width and height are required")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+	m, err := parseNumbers(strings.NewReplacer("[", " ", "]", " ", ";", " ").Replace(string(data)))
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+	if len(m) != 16 {
+		return monitorGeometry{}, fmt.Errorf("expected 16 values for a 4x4 matrix, got %d", len(m))
+	}
+	if math.Abs(m[12]) > 1e-6 || math.Abs(m[13]) > 1e-6 || math.Abs(m[14]) > 1e-6 || math.Abs(m[15]-1) > 1e-6 {
+		return monitorGeometry{}, fmt.Errorf("last row must be 0 0 0 1")
+	}
+
+	// Convert the axes and translation to Viam coordinates before checking the rotation, since a left-handed
+	// convention flips its handedness
+	columns := [4]r3.Vector{}
+	for i := range columns {
+		columns[i], err = FromConvention(r3.Vector{X: m[i], Y: m[4+i], Z: m[8+i]}, opts.Convention)
+		if err != nil {
+			return monitorGeometry{}, err
+		}
+	}
+	localX, localY, localZ := columns[0], columns[1], columns[2]
+	if math.Abs(localX.Norm()-1) > 1e-3 || math.Abs(localY.Norm()-1) > 1e-3 || math.Abs(localZ.Norm()-1) > 1e-3 ||
+		math.Abs(localX.Dot(localY)) > 1e-3 {
+		return monitorGeometry{}, fmt.Errorf("rotation part is not a proper rotation matrix")
+	}
+	if err := CheckHandedness(localX.Normalize(), localY.Normalize(), localZ.Normalize()); err != nil {
+		return monitorGeometry{}, fmt.Errorf("rotation part is not a proper rotation matrix: %w", err)
+	}
+	return monitorGeometry{
+		Center: columns[3],
+		LocalX: localX.Normalize(),
+		LocalY: localY.Normalize(),
+		LocalZ: localZ.Normalize(),
+		Width:  opts.Width,
+		Height: opts.Height,
+	}, nil
+}
+
+// importPLYPlane fits a plane to a segmented monitor point cloud and measures its in-plane extent
+// The points are converted from convention, or from the convention the file is tagged with
+func importPLYPlane(r io.Reader, convention string) (monitorGeometry, error) {
+	points, tagged, err := readPLYVertices(r)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+	switch {
+	case convention == "":
+		convention = tagged
+	case tagged != "" && tagged != convention:
+		return monitorGeometry{}, fmt.Errorf("PLY file is tagged %s, not %s", tagged, convention)
+	}
+	if err := ValidateConvention(convention); err != nil {
+		return monitorGeometry{}, err
+	}
+	for i, p := range points {
+		v, _ := FromConvention(r3.Vector{X: p.X, Y: p.Y, Z: p.Z}, convention)
+		points[i] = Point3D{X: v.X, Y: v.Y, Z: v.Z}
+	}
+	plane, _, err := FitPlaneLeastSquares(points)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}.Normalize()
+	offset := plane.D / r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}.Norm()
+	if normal.Y < 0 {
+		normal, offset = normal.Mul(-1), -offset
+	}
+	localX := r3.Vector{X: 1}.Sub(normal.Mul(normal.X))
+	if localX.Norm() < 0.1 {
+		localX = normal.Ortho()
+	}
+	localX = localX.Normalize()
+	localZ := localX.Cross(normal)
+	if localZ.Z < 0 {
+		localX, localZ = localX.Mul(-1), localZ.Mul(-1)
+	}
+
+	// Bounding rectangle of the projected points along the in-plane axes
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minZ, maxZ := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		v := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}
+		minX, maxX = math.Min(minX, v.Dot(localX)), math.Max(maxX, v.Dot(localX))
+		minZ, maxZ = math.Min(minZ, v.Dot(localZ)), math.Max(maxZ, v.Dot(localZ))
+	}
+	center := localX.Mul((minX + maxX) / 2).Add(localZ.Mul((minZ + maxZ) / 2)).Add(normal.Mul(offset))
+
+	return monitorGeometry{
+		Center: center,
+		LocalX: localX,
+		LocalY: normal,
+		LocalZ: localZ,
+		Width:  maxX - minX,
+		Height: maxZ - minZ,
+	}, nil
+}
+
+// plyProperty is a scalar vertex property in a PLY header
+type plyProperty struct {
+	name, kind string
+}
+
+// readPLYVertices reads the x, y, z vertex coordinates of an ASCII or binary little-endian PLY file, and the
+// coordinate convention from a "comment convention" header line if there is one
+// The vertex element must come first; later elements such as faces are ignored
+func readPLYVertices(r io.Reader) ([]Point3D, string, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return nil, "", fmt.Errorf("not a PLY file")
+	}
+
+	var format, convention string
+	var vertexCount int
+	var properties []plyProperty
+	element := ""
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, "", fmt.Errorf("unterminated PLY header: %w", err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "comment":
+			if len(fields) == 3 && fields[1] == "convention" {
+				convention = fields[2]
+			}
+		case "format":
+			if len(fields) > 1 {
+				format = fields[1]
+			}
+		case "element":
+			if len(fields) < 3 {
+				return nil, "", fmt.Errorf("malformed PLY element line %q", strings.TrimSpace(line))
+			}
+			if element == "" && fields[1] != "vertex" {
+				return nil, "", fmt.Errorf("PLY vertex element must come first")
+			}
+			element = fields[1]
+			if element == "vertex" {
+				if vertexCount, err = strconv.Atoi(fields[2]); err != nil {
+					return nil, "", fmt.Errorf("bad PLY vertex count: %w", err)
+				}
+			}
+		case "property":
+			if element != "vertex" {
+				continue
+			}
+			if len(fields) != 3 {
+				return nil, "", fmt.Errorf("unsupported PLY vertex property %q", strings.TrimSpace(line))
+			}
+			properties = append(properties, plyProperty{kind: fields[1], name: fields[2]})
+		}
+		if fields[0] == "end_header" {
+			break
+		}
+	}
+
+	index := map[string]int{}
+	for i, p := range properties {
+		index[p.name] = i
+	}
+	for _, name := range []string{"x", "y", "z"} {
+		if _, ok := index[name]; !ok {
+			return nil, "", fmt.Errorf("PLY vertices have no %q property", name)
+		}
+	}
+
+	points := make([]Point3D, 0, vertexCount)
+	values := make([]float64, len(properties))
+	for i := 0; i < vertexCount; i++ {
+		switch format {
+		case "ascii":
+			line, err := br.ReadString('\n')
+			if err != nil && line == "" {
+				return nil, "", fmt.Errorf("PLY ended after %d of %d vertices", i, vertexCount)
+			}
+			fields := strings.Fields(line)
+			if len(fields) < len(properties) {
+				return nil, "", fmt.Errorf("PLY vertex %d has %d values, expected %d", i, len(fields), len(properties))
+			}
+			for j := range properties {
+				if values[j], err = strconv.ParseFloat(fields[j], 64); err != nil {
+					return nil, "", fmt.Errorf("PLY vertex %d: %w", i, err)
+				}
+			}
+		case "binary_little_endian":
+			for j, p := range properties {
+				if values[j], err = readPLYBinary(br, p.kind); err != nil {
+					return nil, "", fmt.Errorf("PLY vertex %d: %w", i, err)
+				}
+			}
+		default:
+			return nil, "", fmt.Errorf("unsupported PLY format %q", format)
+		}
+		points = append(points, Point3D{X: values[index["x"]], Y: values[index["y"]], Z: values[index["z"]]})
+	}
+	return points, convention, nil
+}
+
+// readPLYBinary reads one little-endian scalar of the given PLY type
+func readPLYBinary(r io.Reader, kind string) (float64, error) {
+	var err error
+	switch kind {
+	case "char", "int8":
+		var v int8
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "uchar", "uint8":
+		var v uint8
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "short", "int16":
+		var v int16
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "ushort", "uint16":
+		var v uint16
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "int", "int32":
+		var v int32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "uint", "uint32":
+		var v uint32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "float", "float32":
+		var v float32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return float64(v), err
+	case "double", "float64":
+		var v float64
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return v, err
+	default:
+		return 0, fmt.Errorf("unsupported PLY property type %q", kind)
+	}
+}
+
+// parseNumbers parses comma or whitespace separated numbers
+func parseNumbers(s string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r' }) {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
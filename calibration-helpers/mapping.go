@@ -0,0 +1,134 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Pixel is a position on the screen in pixels
+// (0, 0) is the top-left corner of the screen as seen by a viewer facing it, X grows to the right and Y downwards
+type Pixel struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Mapping converts between points on the calibrated monitor and screen pixels
+// It is the homography taking the monitor corners to the screen corners, which absorbs in-plane rotation. Curved
+// monitors are mapped through the tangent plane, like their cleaning paths.
+type Mapping struct {
+	ResolutionX, ResolutionY int
+
+	geometry monitorGeometry
+	toPixel  mat.Dense // homography from monitor-local (u, v) in mm to pixels
+	toLocal  mat.Dense // its inverse
+}
+
+// NewMapping builds the mapping for a monitor calibrated as result, with the given screen resolution
+func NewMapping(result CalibrationResult, resolutionX, resolutionY int) (*Mapping, error) {
+	if resolutionX <= 0 || resolutionY <= 0 {
+		return nil, fmt.Errorf("screen resolution must be positive, got %dx%d", resolutionX, resolutionY)
+	}
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if geometry.Width <= 0 || geometry.Height <= 0 {
+		return nil, fmt.Errorf("calibrated monitor has no area: %.1f x %.1f mm", geometry.Width, geometry.Height)
+	}
+
+	m := &Mapping{ResolutionX: resolutionX, ResolutionY: resolutionY, geometry: geometry}
+	w, h := float64(resolutionX), float64(resolutionY)
+	c, err := ResultCorners(result)
+	if err != nil {
+		return nil, err
+	}
+	corners := [4]Point3D{c.TopLeft, c.TopRight, c.BottomRight, c.BottomLeft}
+	pixels := [4]Pixel{{0, 0}, {w, 0}, {w, h}, {0, h}}
+	var local [4]Pixel
+	for i, corner := range corners {
+		local[i].X, local[i].Y = m.local(corner)
+	}
+	homography, err := solveHomography(local, pixels)
+	if err != nil {
+		return nil, fmt.Errorf("monitor corners do not span the screen: %w", err)
+	}
+	m.toPixel.CloneFrom(homography)
+	if err := m.toLocal.Inverse(&m.toPixel); err != nil {
+		return nil, fmt.Errorf("pixel mapping is not invertible: %w", err)
+	}
+	return m, nil
+}
+
+// Homography returns the matrix taking monitor-local (u, v, 1), as used by MonitorLocalToWorld, to homogeneous pixels
+func (m *Mapping) Homography() [3][3]float64 {
+	var h [3][3]float64
+	for i := range h {
+		for j := range h[i] {
+			h[i][j] = m.toPixel.At(i, j)
+		}
+	}
+	return h
+}
+
+// ToPixel returns the pixel under a point, projected onto the calibrated plane along its normal
+func (m *Mapping) ToPixel(p Point3D) Pixel {
+	u, v := m.local(p)
+	x, y := applyHomography(&m.toPixel, u, v)
+	return Pixel{X: x, Y: y}
+}
+
+// ToLocal returns the monitor-local (u, v) in mm of a pixel, for MonitorLocalToWorld
+func (m *Mapping) ToLocal(px Pixel) (float64, float64) {
+	return applyHomography(&m.toLocal, px.X, px.Y)
+}
+
+// ToWorld returns the point on the calibrated plane under a pixel, in the calibration's reference frame
+func (m *Mapping) ToWorld(px Pixel) Point3D {
+	u, v := m.ToLocal(px)
+	p := m.geometry.toWorld(u, v, 0)
+	return Point3D{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+// OnScreen reports whether a pixel lies within the screen
+func (m *Mapping) OnScreen(px Pixel) bool {
+	return px.X >= 0 && px.Y >= 0 && px.X <= float64(m.ResolutionX) && px.Y <= float64(m.ResolutionY)
+}
+
+// local returns the monitor-local (u, v) in mm of a point
+func (m *Mapping) local(p Point3D) (float64, float64) {
+	d := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}.Sub(m.geometry.Center)
+	return d.Dot(m.geometry.LocalX), d.Dot(m.geometry.LocalZ)
+}
+
+// applyHomography maps (x, y) through the 3x3 homography h
+func applyHomography(h *mat.Dense, x, y float64) (float64, float64) {
+	w := h.At(2, 0)*x + h.At(2, 1)*y + h.At(2, 2)
+	return (h.At(0, 0)*x + h.At(0, 1)*y + h.At(0, 2)) / w,
+		(h.At(1, 0)*x + h.At(1, 1)*y + h.At(1, 2)) / w
+}
+
+// solveHomography finds the homography taking each from point to the matching to point
+// The bottom-right entry is fixed at 1, leaving eight unknowns for the four correspondences
+func solveHomography(from, to [4]Pixel) (*mat.Dense, error) {
+	a := mat.NewDense(8, 8, nil)
+	b := mat.NewVecDense(8, nil)
+	for i := range from {
+		x, y, X, Y := from[i].X, from[i].Y, to[i].X, to[i].Y
+		a.SetRow(2*i, []float64{x, y, 1, 0, 0, 0, -X * x, -X * y})
+		a.SetRow(2*i+1, []float64{0, 0, 0, x, y, 1, -Y * x, -Y * y})
+		b.SetVec(2*i, X)
+		b.SetVec(2*i+1, Y)
+	}
+	var params mat.VecDense
+	if err := params.SolveVec(a, b); err != nil {
+		return nil, err
+	}
+	h := make([]float64, 9)
+	for i := 0; i < 8; i++ {
+		h[i] = params.AtVec(i)
+	}
+	h[8] = 1
+	return mat.NewDense(3, 3, h), nil
+}
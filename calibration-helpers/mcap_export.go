@@ -0,0 +1,397 @@
+package calibrationhelpers
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Topics of the MCAP files written by WriteSessionMCAP
+const (
+	MCAPTopicSensorPose = "/calibration/sensor_pose" // foxglove.PoseInFrame at every waypoint with a sensor pose
+	MCAPTopicReadings   = "/calibration/readings"    // calibration.ScanReading for every waypoint
+	MCAPTopicScanPoints = "/calibration/scan_points" // foxglove.PointCloud of each surface point, then of them all
+	MCAPTopicScene      = "/calibration/scene"       // foxglove.SceneUpdate with the trajectory, plane and monitor
+	MCAPTopicResult     = "/calibration/result"      // calibration.CalibrationResult
+)
+
+// MCAPSession is a calibration session to write as MCAP
+type MCAPSession struct {
+	Entries []ScanLogEntry     // scan waypoints in the order they were visited
+	Result  *CalibrationResult // nil for a scan without a result, such as one read from a scan log
+	Frame   string             // frame of the poses and points, defaults to the result's frame or "world"
+}
+
+// MCAPExport summarizes a written MCAP file
+type MCAPExport struct {
+	Waypoints int      `json:"waypoints"`
+	Messages  int      `json:"messages"`
+	Topics    []string `json:"topics"`
+}
+
+// ToMap converts the summary to a map for DoCommand responses
+func (e MCAPExport) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(e)
+}
+
+// WriteSessionMCAP writes a calibration session as an MCAP file for replay in Foxglove
+// Messages are JSON with Foxglove's standard schemas where one fits, so the 3D panel draws the sensor poses, the
+// surface points and a scene with the scan trajectory, the fitted plane and the monitor, while the raw readings
+// and the result can be plotted or inspected in the raw message panel. Each waypoint's messages are logged at the
+// time it was read; the final point cloud, scene and result follow the last waypoint. Like Foxglove, the file uses
+// meters, converted from the calibration's mm.
+func WriteSessionMCAP(w io.Writer, session MCAPSession) (MCAPExport, error) {
+	frame := session.Frame
+	if frame == "" && session.Result != nil {
+		frame = session.Result.Frame
+	}
+	if frame == "" {
+		frame = "world"
+	}
+
+	m := newMCAPWriter(w)
+	channels := []struct {
+		topic, schema, jsonSchema string
+	}{
+		{MCAPTopicSensorPose, "foxglove.PoseInFrame", foxglovePoseInFrameSchema},
+		{MCAPTopicReadings, "calibration.ScanReading", scanReadingSchema},
+		{MCAPTopicScanPoints, "foxglove.PointCloud", foxglovePointCloudSchema},
+		{MCAPTopicScene, "foxglove.SceneUpdate", foxgloveSceneUpdateSchema},
+		{MCAPTopicResult, "calibration.CalibrationResult", `{"type":"object"}`},
+	}
+	export := MCAPExport{Waypoints: len(session.Entries)}
+	for i, c := range channels {
+		m.schema(uint16(i+1), c.schema, c.jsonSchema)
+		m.channel(uint16(i), uint16(i+1), c.topic)
+		export.Topics = append(export.Topics, c.topic)
+	}
+	const (
+		poseChannel = iota
+		readingChannel
+		pointsChannel
+		sceneChannel
+		resultChannel
+	)
+	publish := func(channel uint16, t time.Time, message interface{}) error {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s message: %w", channels[channel].topic, err)
+		}
+		m.message(channel, uint64(max(0, t.UnixNano())), data)
+		export.Messages++
+		return nil
+	}
+
+	var end time.Time
+	var trajectory []foxgloveVector3
+	var hits []Point3D
+	for _, entry := range session.Entries {
+		t := entry.Timestamp
+		end = maxTime(end, t)
+		if entry.SensorPose != nil {
+			pose, err := PoseFromMap(entry.SensorPose)
+			if err != nil {
+				return export, fmt.Errorf("scan %s waypoint %d: %w", entry.Scan, entry.Index, err)
+			}
+			stamped := foxglovePoseInFrame{Timestamp: foxgloveStamp(t), FrameID: frame, Pose: foxglovePoseOf(pose)}
+			if err := publish(poseChannel, t, stamped); err != nil {
+				return export, err
+			}
+			trajectory = append(trajectory, stamped.Pose.Position)
+		}
+		if err := publish(readingChannel, t, entry); err != nil {
+			return export, err
+		}
+		if entry.Status == WaypointOK {
+			hits = append(hits, entry.Point)
+			if err := publish(pointsChannel, t, foxglovePointCloudOf(t, frame, []Point3D{entry.Point})); err != nil {
+				return export, err
+			}
+		}
+	}
+	if session.Result != nil {
+		end = maxTime(end, session.Result.Timestamp)
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	if err := publish(pointsChannel, end, foxglovePointCloudOf(end, frame, hits)); err != nil {
+		return export, err
+	}
+	scene, err := sessionScene(end, frame, trajectory, hits, session.Result)
+	if err != nil {
+		return export, err
+	}
+	if err := publish(sceneChannel, end, scene); err != nil {
+		return export, err
+	}
+	if session.Result != nil {
+		if err := publish(resultChannel, end, session.Result); err != nil {
+			return export, err
+		}
+	}
+	return export, m.close()
+}
+
+// sessionScene draws the scan trajectory and, with a result, the fitted plane across the scan points and the
+// monitor
+func sessionScene(t time.Time, frame string, trajectory []foxgloveVector3, hits []Point3D,
+	result *CalibrationResult) (foxgloveSceneUpdate, error) {
+	scene := foxgloveSceneUpdate{Deletions: []interface{}{}, Entities: []foxgloveSceneEntity{}}
+	entity := func(id string) foxgloveSceneEntity {
+		return foxgloveSceneEntity{
+			Timestamp: foxgloveStamp(t),
+			FrameID:   frame,
+			ID:        id,
+			Metadata:  []interface{}{},
+			Arrows:    []interface{}{}, Cubes: []foxgloveCube{}, Spheres: []interface{}{}, Cylinders: []interface{}{},
+			Lines: []foxgloveLine{}, Triangles: []interface{}{}, Texts: []interface{}{}, Models: []interface{}{},
+		}
+	}
+	if len(trajectory) >= 2 {
+		path := entity("trajectory")
+		path.Lines = append(path.Lines, foxgloveLine{
+			Type:           foxgloveLineStrip,
+			Pose:           foxgloveIdentityPose(),
+			Thickness:      2,
+			ScaleInvariant: true,
+			Points:         trajectory,
+			Color:          foxgloveColor{R: 0.2, G: 0.6, B: 1, A: 1},
+			Colors:         []interface{}{},
+			Indices:        []interface{}{},
+		})
+		scene.Entities = append(scene.Entities, path)
+	}
+	if result == nil {
+		return scene, nil
+	}
+
+	geometry, err := monitorGeometryFromResult(*result)
+	if err != nil {
+		return scene, fmt.Errorf("failed to build monitor geometry: %w", err)
+	}
+	orientation, err := geometry.orientation()
+	if err != nil {
+		return scene, fmt.Errorf("failed to build monitor geometry: %w", err)
+	}
+	pose := foxglovePoseOf(spatialmath.NewPose(geometry.Center, orientation))
+
+	// The plane reaches 50 mm past the monitor and every scan point, so off-screen hits show against it
+	halfWidth, halfHeight := geometry.Width/2, geometry.Height/2
+	for _, p := range hits {
+		offset := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}.Sub(geometry.Center)
+		halfWidth = math.Max(halfWidth, math.Abs(offset.Dot(geometry.LocalX)))
+		halfHeight = math.Max(halfHeight, math.Abs(offset.Dot(geometry.LocalZ)))
+	}
+	plane := entity("fitted_plane")
+	plane.Cubes = append(plane.Cubes, foxgloveCube{
+		Pose:  pose,
+		Size:  foxgloveVector3{X: mmToM(2*halfWidth + 100), Y: mmToM(0.1), Z: mmToM(2*halfHeight + 100)},
+		Color: foxgloveColor{R: 1, G: 0.8, B: 0.2, A: 0.25},
+	})
+	monitor := entity("monitor")
+	monitor.Cubes = append(monitor.Cubes, foxgloveCube{
+		Pose:  pose,
+		Size:  foxgloveVector3{X: mmToM(geometry.Width), Y: mmToM(1), Z: mmToM(geometry.Height)},
+		Color: foxgloveColor{R: 0.1, G: 0.8, B: 0.3, A: 0.6},
+	})
+	scene.Entities = append(scene.Entities, plane, monitor)
+	return scene, nil
+}
+
+// mmToM converts mm to the meters Foxglove uses
+func mmToM(mm float64) float64 {
+	return mm / 1000
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Foxglove message types, in the JSON form of https://docs.foxglove.dev/docs/visualization/message-schemas
+
+type foxgloveTime struct {
+	Sec  int64 `json:"sec"`
+	Nsec int64 `json:"nsec"`
+}
+
+func foxgloveStamp(t time.Time) foxgloveTime {
+	return foxgloveTime{Sec: t.Unix(), Nsec: int64(t.Nanosecond())}
+}
+
+type foxgloveVector3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+type foxgloveQuaternion struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+	W float64 `json:"w"`
+}
+
+type foxglovePose struct {
+	Position    foxgloveVector3    `json:"position"`
+	Orientation foxgloveQuaternion `json:"orientation"`
+}
+
+// foxglovePoseOf converts a pose in mm to a Foxglove pose in meters
+func foxglovePoseOf(pose spatialmath.Pose) foxglovePose {
+	p, q := pose.Point(), pose.Orientation().Quaternion()
+	return foxglovePose{
+		Position:    foxgloveVector3{X: mmToM(p.X), Y: mmToM(p.Y), Z: mmToM(p.Z)},
+		Orientation: foxgloveQuaternion{X: q.Imag, Y: q.Jmag, Z: q.Kmag, W: q.Real},
+	}
+}
+
+func foxgloveIdentityPose() foxglovePose {
+	return foxglovePose{Orientation: foxgloveQuaternion{W: 1}}
+}
+
+type foxglovePoseInFrame struct {
+	Timestamp foxgloveTime `json:"timestamp"`
+	FrameID   string       `json:"frame_id"`
+	Pose      foxglovePose `json:"pose"`
+}
+
+// foxgloveFloat64 is the FLOAT64 type of a foxglove.PackedElementField
+const foxgloveFloat64 = 8
+
+type foxglovePackedElementField struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Type   int    `json:"type"`
+}
+
+type foxglovePointCloud struct {
+	Timestamp   foxgloveTime                 `json:"timestamp"`
+	FrameID     string                       `json:"frame_id"`
+	Pose        foxglovePose                 `json:"pose"`
+	PointStride int                          `json:"point_stride"`
+	Fields      []foxglovePackedElementField `json:"fields"`
+	Data        string                       `json:"data"` // base64 of the packed points
+}
+
+// foxglovePointCloudOf packs points in mm as a cloud of float64 x, y, z in meters
+func foxglovePointCloudOf(t time.Time, frame string, points []Point3D) foxglovePointCloud {
+	data := make([]byte, 0, 24*len(points))
+	for _, p := range points {
+		for _, v := range []float64{p.X, p.Y, p.Z} {
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(mmToM(v)))
+		}
+	}
+	return foxglovePointCloud{
+		Timestamp:   foxgloveStamp(t),
+		FrameID:     frame,
+		Pose:        foxgloveIdentityPose(),
+		PointStride: 24,
+		Fields: []foxglovePackedElementField{
+			{Name: "x", Offset: 0, Type: foxgloveFloat64},
+			{Name: "y", Offset: 8, Type: foxgloveFloat64},
+			{Name: "z", Offset: 16, Type: foxgloveFloat64},
+		},
+		Data: base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+type foxgloveColor struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+	A float64 `json:"a"`
+}
+
+type foxgloveCube struct {
+	Pose  foxglovePose    `json:"pose"`
+	Size  foxgloveVector3 `json:"size"`
+	Color foxgloveColor   `json:"color"`
+}
+
+// foxgloveLineStrip is the LINE_STRIP type of a foxglove.LinePrimitive
+const foxgloveLineStrip = 0
+
+type foxgloveLine struct {
+	Type           int               `json:"type"`
+	Pose           foxglovePose      `json:"pose"`
+	Thickness      float64           `json:"thickness"`
+	ScaleInvariant bool              `json:"scale_invariant"`
+	Points         []foxgloveVector3 `json:"points"`
+	Color          foxgloveColor     `json:"color"`
+	Colors         []interface{}     `json:"colors"`
+	Indices        []interface{}     `json:"indices"`
+}
+
+type foxgloveSceneEntity struct {
+	Timestamp   foxgloveTime   `json:"timestamp"`
+	FrameID     string         `json:"frame_id"`
+	ID          string         `json:"id"`
+	Lifetime    foxgloveTime   `json:"lifetime"` // zero keeps the entity until it is replaced
+	FrameLocked bool           `json:"frame_locked"`
+	Metadata    []interface{}  `json:"metadata"`
+	Arrows      []interface{}  `json:"arrows"`
+	Cubes       []foxgloveCube `json:"cubes"`
+	Spheres     []interface{}  `json:"spheres"`
+	Cylinders   []interface{}  `json:"cylinders"`
+	Lines       []foxgloveLine `json:"lines"`
+	Triangles   []interface{}  `json:"triangles"`
+	Texts       []interface{}  `json:"texts"`
+	Models      []interface{}  `json:"models"`
+}
+
+type foxgloveSceneUpdate struct {
+	Deletions []interface{}         `json:"deletions"`
+	Entities  []foxgloveSceneEntity `json:"entities"`
+}
+
+// JSON schemas of the channels, trimmed to the fields written
+const (
+	foxgloveTimeSchema       = `{"type":"object","properties":{"sec":{"type":"integer"},"nsec":{"type":"integer"}}}`
+	foxgloveVector3Schema    = `{"type":"object","properties":{"x":{"type":"number"},"y":{"type":"number"},"z":{"type":"number"}}}`
+	foxgloveQuaternionSchema = `{"type":"object","properties":{"x":{"type":"number"},"y":{"type":"number"},"z":{"type":"number"},"w":{"type":"number"}}}`
+	foxglovePoseSchema       = `{"type":"object","properties":{"position":` + foxgloveVector3Schema + `,"orientation":` + foxgloveQuaternionSchema + `}}`
+	foxgloveColorSchema      = `{"type":"object","properties":{"r":{"type":"number"},"g":{"type":"number"},"b":{"type":"number"},"a":{"type":"number"}}}`
+
+	foxglovePoseInFrameSchema = `{"title":"foxglove.PoseInFrame","type":"object","properties":{` +
+		`"timestamp":` + foxgloveTimeSchema + `,"frame_id":{"type":"string"},"pose":` + foxglovePoseSchema + `}}`
+
+	foxglovePointCloudSchema = `{"title":"foxglove.PointCloud","type":"object","properties":{` +
+		`"timestamp":` + foxgloveTimeSchema + `,"frame_id":{"type":"string"},"pose":` + foxglovePoseSchema + `,` +
+		`"point_stride":{"type":"integer","minimum":0},` +
+		`"fields":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"},` +
+		`"offset":{"type":"integer","minimum":0},"type":{"type":"integer"}}}},` +
+		`"data":{"type":"string","contentEncoding":"base64"}}}`
+
+	foxgloveSceneUpdateSchema = `{"title":"foxglove.SceneUpdate","type":"object","properties":{` +
+		`"deletions":{"type":"array"},` +
+		`"entities":{"type":"array","items":{"type":"object","properties":{` +
+		`"timestamp":` + foxgloveTimeSchema + `,"frame_id":{"type":"string"},"id":{"type":"string"},` +
+		`"lifetime":` + foxgloveTimeSchema + `,"frame_locked":{"type":"boolean"},"metadata":{"type":"array"},` +
+		`"arrows":{"type":"array"},"spheres":{"type":"array"},"cylinders":{"type":"array"},` +
+		`"triangles":{"type":"array"},"texts":{"type":"array"},"models":{"type":"array"},` +
+		`"cubes":{"type":"array","items":{"type":"object","properties":{` +
+		`"pose":` + foxglovePoseSchema + `,"size":` + foxgloveVector3Schema + `,"color":` + foxgloveColorSchema + `}}},` +
+		`"lines":{"type":"array","items":{"type":"object","properties":{"type":{"type":"integer"},` +
+		`"pose":` + foxglovePoseSchema + `,"thickness":{"type":"number"},"scale_invariant":{"type":"boolean"},` +
+		`"points":{"type":"array","items":` + foxgloveVector3Schema + `},"color":` + foxgloveColorSchema + `,` +
+		`"colors":{"type":"array"},"indices":{"type":"array"}}}}}}}}}`
+
+	scanReadingSchema = `{"title":"calibration.ScanReading","type":"object","properties":{` +
+		`"timestamp":{"type":"string","format":"date-time"},"scan":{"type":"string"},"index":{"type":"integer"},` +
+		`"gantry_mm":{"type":"number"},"z_offset_mm":{"type":"number"},"status":{"type":"string"},` +
+		`"detail":{"type":"string"},"sensor_pose":{"type":"object"},` +
+		`"raw_mm":{"type":"array","items":{"type":["number","null"]}},"depth_mm":{"type":"number"},` +
+		`"point":` + foxgloveVector3Schema + `}}`
+)
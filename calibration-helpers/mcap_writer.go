@@ -0,0 +1,171 @@
+package calibrationhelpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// mcapMagic starts and ends every MCAP file
+var mcapMagic = []byte{0x89, 'M', 'C', 'A', 'P', '0', '\r', '\n'}
+
+// MCAP record opcodes, see https://mcap.dev/spec
+const (
+	mcapOpHeader     = 0x01
+	mcapOpFooter     = 0x02
+	mcapOpSchema     = 0x03
+	mcapOpChannel    = 0x04
+	mcapOpMessage    = 0x05
+	mcapOpStatistics = 0x0B
+	mcapOpDataEnd    = 0x0F
+)
+
+// mcapLibrary names the writer in the file header
+// The profile is left empty, since the channels carry JSON with JSON schemas rather than ROS messages.
+const mcapLibrary = "viam-monitor-calibration"
+
+// mcapWriter writes an unchunked MCAP file: the data section holds the records in the order they are added, and
+// the summary section repeats the schemas and channels with message statistics so readers can list the topics
+// without scanning the file
+// Records aren't indexed, which readers handle by reading the file through; calibration sessions are small.
+type mcapWriter struct {
+	w        io.Writer
+	offset   uint64
+	err      error
+	schemas  [][]byte // schema records, for the summary
+	channels [][]byte // channel records, for the summary
+	counts   map[uint16]uint64
+	messages uint64
+	start    uint64
+	end      uint64
+}
+
+// newMCAPWriter starts an MCAP file on w
+func newMCAPWriter(w io.Writer) *mcapWriter {
+	m := &mcapWriter{w: w, counts: map[uint16]uint64{}}
+	m.write(mcapMagic)
+	var header mcapRecord
+	header.string("")
+	header.string(mcapLibrary)
+	m.record(mcapOpHeader, header.Bytes())
+	return m
+}
+
+// mcapRecord builds the content of a record with MCAP's little-endian primitives
+type mcapRecord struct {
+	bytes.Buffer
+}
+
+func (r *mcapRecord) uint16(v uint16) { _ = binary.Write(r, binary.LittleEndian, v) }
+func (r *mcapRecord) uint32(v uint32) { _ = binary.Write(r, binary.LittleEndian, v) }
+func (r *mcapRecord) uint64(v uint64) { _ = binary.Write(r, binary.LittleEndian, v) }
+
+// string writes a string prefixed with its uint32 length, as is a byte array
+func (r *mcapRecord) string(s string) {
+	r.uint32(uint32(len(s)))
+	r.WriteString(s)
+}
+
+func (m *mcapWriter) write(b []byte) {
+	if m.err != nil {
+		return
+	}
+	n, err := m.w.Write(b)
+	m.offset += uint64(n)
+	m.err = err
+}
+
+// record frames content as a record with the opcode and returns the framed bytes
+func (m *mcapWriter) record(op byte, content []byte) []byte {
+	framed := make([]byte, 0, 9+len(content))
+	framed = append(framed, op)
+	framed = binary.LittleEndian.AppendUint64(framed, uint64(len(content)))
+	framed = append(framed, content...)
+	m.write(framed)
+	return framed
+}
+
+// schema adds a JSON schema with the given id and name
+func (m *mcapWriter) schema(id uint16, name, jsonSchema string) {
+	var r mcapRecord
+	r.uint16(id)
+	r.string(name)
+	r.string("jsonschema")
+	r.string(jsonSchema)
+	m.schemas = append(m.schemas, m.record(mcapOpSchema, r.Bytes()))
+}
+
+// channel adds a topic of JSON messages with the given schema
+func (m *mcapWriter) channel(id, schemaID uint16, topic string) {
+	var r mcapRecord
+	r.uint16(id)
+	r.uint16(schemaID)
+	r.string(topic)
+	r.string("json")
+	r.uint32(0) // no metadata
+	m.channels = append(m.channels, m.record(mcapOpChannel, r.Bytes()))
+	m.counts[id] = 0
+}
+
+// message adds a message on a channel, logged and published at ns nanoseconds since the Unix epoch
+func (m *mcapWriter) message(channelID uint16, ns uint64, data []byte) {
+	var r mcapRecord
+	r.uint16(channelID)
+	r.uint32(uint32(m.counts[channelID]))
+	r.uint64(ns)
+	r.uint64(ns)
+	r.Write(data)
+	m.record(mcapOpMessage, r.Bytes())
+	if m.messages == 0 || ns < m.start {
+		m.start = ns
+	}
+	m.end = max(m.end, ns)
+	m.counts[channelID]++
+	m.messages++
+}
+
+// close ends the data section and writes the summary and footer
+// CRCs are written as zero, which tells readers not to validate them.
+func (m *mcapWriter) close() error {
+	var dataEnd mcapRecord
+	dataEnd.uint32(0)
+	m.record(mcapOpDataEnd, dataEnd.Bytes())
+
+	summaryStart := m.offset
+	for _, record := range append(m.schemas, m.channels...) {
+		m.write(record)
+	}
+	ids := make([]int, 0, len(m.counts))
+	for id := range m.counts {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	var stats mcapRecord
+	stats.uint64(m.messages)
+	stats.uint16(uint16(len(m.schemas)))
+	stats.uint32(uint32(len(m.channels)))
+	stats.uint32(0) // attachments
+	stats.uint32(0) // metadata
+	stats.uint32(0) // chunks
+	stats.uint64(m.start)
+	stats.uint64(m.end)
+	stats.uint32(uint32(len(ids) * 10))
+	for _, id := range ids {
+		stats.uint16(uint16(id))
+		stats.uint64(m.counts[uint16(id)])
+	}
+	m.record(mcapOpStatistics, stats.Bytes())
+
+	var footer mcapRecord
+	footer.uint64(summaryStart)
+	footer.uint64(0) // no summary offsets
+	footer.uint32(0)
+	m.record(mcapOpFooter, footer.Bytes())
+	m.write(mcapMagic)
+	if m.err != nil {
+		return fmt.Errorf("failed to write MCAP file: %w", m.err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/geo/r3"
+)
+
+// Mesh file formats supported by ExportMesh
+const (
+	MeshOBJ = "obj"
+	MeshSTL = "stl"
+)
+
+// Mesh is a closed triangle mesh in the calibration's reference frame, in mm
+// Triangles index into Vertices counterclockwise seen from outside, so their normals point out of the mesh.
+type Mesh struct {
+	Vertices  []Point3D
+	Triangles [][3]int
+}
+
+// MeshFormatFromPath infers the mesh format from a file extension
+func MeshFormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case MeshOBJ, MeshSTL:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("cannot infer mesh format from %q, expected an .obj or .stl file", path)
+	}
+}
+
+// MonitorMesh builds the calibrated monitor as a slab thickness mm deep behind the glass
+// Flat monitors are a box over the calibrated rectangle. Curved monitors follow their cylinder over its arc and
+// axial extent, with the arc split into segments strips.
+func MonitorMesh(result CalibrationResult, thickness float64, segments int) (Mesh, error) {
+	if !(thickness > 0) {
+		return Mesh{}, fmt.Errorf("mesh thickness must be positive, got %.2f", thickness)
+	}
+	if result.Cylinder != nil {
+		if segments < 1 {
+			return Mesh{}, fmt.Errorf("curved monitor mesh needs at least 1 segment, got %d", segments)
+		}
+		return curvedMonitorMesh(*result.Cylinder, thickness, segments), nil
+	}
+
+	g, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return Mesh{}, err
+	}
+	var m meshBuilder
+	// Corner indices: bit 0 picks the u side, bit 1 the v side, bit 2 the back of the slab
+	for i := 0; i < 8; i++ {
+		u, v, w := g.Width/2, g.Height/2, 0.0
+		if i&1 == 0 {
+			u = -u
+		}
+		if i&2 == 0 {
+			v = -v
+		}
+		if i&4 != 0 {
+			w = -thickness
+		}
+		m.vertex(g.toWorld(u, v, w))
+	}
+	m.quad(0, 1, 3, 2, g.LocalY)         // glass
+	m.quad(4, 5, 7, 6, g.LocalY.Mul(-1)) // back
+	m.quad(0, 1, 5, 4, g.LocalZ.Mul(-1)) // bottom
+	m.quad(2, 3, 7, 6, g.LocalZ)         // top
+	m.quad(0, 2, 6, 4, g.LocalX.Mul(-1))
+	m.quad(1, 3, 7, 5, g.LocalX)
+	return m.mesh, nil
+}
+
+// curvedMonitorMesh builds a curved monitor as a slab following the cylinder, thickness mm outside the radius
+func curvedMonitorMesh(c Cylinder, thickness float64, segments int) Mesh {
+	origin := r3.Vector{X: c.Origin.X, Y: c.Origin.Y, Z: c.Origin.Z}
+	axis := r3.Vector{X: c.Axis.X, Y: c.Axis.Y, Z: c.Axis.Z}
+	reference := r3.Vector{X: c.Reference.X, Y: c.Reference.Y, Z: c.Reference.Z}
+	radial := func(arc float64) r3.Vector {
+		angle := arc / c.Radius
+		return reference.Mul(math.Cos(angle)).Add(axis.Cross(reference).Mul(math.Sin(angle)))
+	}
+
+	// Each arc step has four vertices: glass bottom, glass top, back bottom, back top
+	var m meshBuilder
+	step := (c.ArcMax - c.ArcMin) / float64(segments)
+	for i := 0; i <= segments; i++ {
+		r := radial(c.ArcMin + float64(i)*step)
+		for _, radius := range []float64{c.Radius, c.Radius + thickness} {
+			for _, axial := range []float64{c.AxialMin, c.AxialMax} {
+				m.vertex(origin.Add(axis.Mul(axial)).Add(r.Mul(radius)))
+			}
+		}
+	}
+	for i := 0; i < segments; i++ {
+		a, b := 4*i, 4*(i+1)
+		out := radial(c.ArcMin + (float64(i)+0.5)*step)
+		m.quad(a, a+1, b+1, b, out.Mul(-1)) // glass faces the axis, like a viewer of a concave screen
+		m.quad(a+2, a+3, b+3, b+2, out)
+		m.quad(a, b, b+2, a+2, axis.Mul(-1))
+		m.quad(a+1, b+1, b+3, a+3, axis)
+	}
+	last := 4 * segments
+	m.quad(0, 1, 3, 2, axis.Cross(radial(c.ArcMin)).Mul(-1))
+	m.quad(last, last+1, last+3, last+2, axis.Cross(radial(c.ArcMax)))
+	return m.mesh
+}
+
+// meshBuilder collects vertices and outward-facing triangles
+type meshBuilder struct {
+	mesh     Mesh
+	vertices []r3.Vector
+}
+
+func (m *meshBuilder) vertex(v r3.Vector) {
+	m.vertices = append(m.vertices, v)
+	m.mesh.Vertices = append(m.mesh.Vertices, Point3D{X: v.X, Y: v.Y, Z: v.Z})
+}
+
+// quad adds the quadrilateral a-b-c-d (in order around its edge) as two triangles facing outward
+func (m *meshBuilder) quad(a, b, c, d int, outward r3.Vector) {
+	normal := m.vertices[b].Sub(m.vertices[a]).Cross(m.vertices[c].Sub(m.vertices[a]))
+	if normal.Dot(outward) < 0 {
+		b, d = d, b
+	}
+	m.mesh.Triangles = append(m.mesh.Triangles, [3]int{a, b, c}, [3]int{a, c, d})
+}
+
+// ExportMesh writes the mesh as an ASCII OBJ or STL file, with vertices in mm
+// Both open in Blender, MeshLab and most CAD tools; STL has no units, so import it in millimeters.
+// The coordinates are converted to convention, empty for ConventionViam, which is noted in a comment, and the
+// triangles are rewound for left-handed conventions so they still face outward.
+func ExportMesh(w io.Writer, format string, mesh Mesh, name, convention string) error {
+	if err := ValidateConvention(convention); err != nil {
+		return err
+	}
+	if convention == "" {
+		convention = ConventionViam
+	}
+	axes := conventionAxes[convention]
+	leftHanded := axes[0].Cross(axes[1]).Dot(axes[2]) < 0
+
+	vertices := make([]r3.Vector, len(mesh.Vertices))
+	for i, p := range mesh.Vertices {
+		vertices[i], _ = ToConvention(r3.Vector{X: p.X, Y: p.Y, Z: p.Z}, convention)
+	}
+	triangles := make([][3]int, len(mesh.Triangles))
+	for i, t := range mesh.Triangles {
+		if leftHanded {
+			t[1], t[2] = t[2], t[1]
+		}
+		triangles[i] = t
+	}
+
+	bw := bufio.NewWriter(w)
+	switch format {
+	case MeshOBJ:
+		fmt.Fprintf(bw, "# calibrated monitor, units mm\n")
+		fmt.Fprintf(bw, "# convention %s\n", convention)
+		fmt.Fprintf(bw, "o %s\n", name)
+		for _, v := range vertices {
+			fmt.Fprintf(bw, "v %.4f %.4f %.4f\n", v.X, v.Y, v.Z)
+		}
+		for _, t := range triangles {
+			// OBJ indices start at 1
+			fmt.Fprintf(bw, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1)
+		}
+	case MeshSTL:
+		fmt.Fprintf(bw, "solid %s\n", name)
+		for _, t := range triangles {
+			a, b, c := vertices[t[0]], vertices[t[1]], vertices[t[2]]
+			n := b.Sub(a).Cross(c.Sub(a)).Normalize()
+			fmt.Fprintf(bw, "  facet normal %.6f %.6f %.6f\n    outer loop\n", n.X, n.Y, n.Z)
+			for _, v := range []r3.Vector{a, b, c} {
+				fmt.Fprintf(bw, "      vertex %.4f %.4f %.4f\n", v.X, v.Y, v.Z)
+			}
+			fmt.Fprintf(bw, "    endloop\n  endfacet\n")
+		}
+		fmt.Fprintf(bw, "endsolid %s\n", name)
+	default:
+		return fmt.Errorf("unknown mesh format %q", format)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write mesh: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package calibrationhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// resultMigrations upgrade a saved result from the schema version they are keyed by to the next one
+// Each takes and returns the whole file as JSON values, so a migration only needs to know its own two formats.
+var resultMigrations = map[int]func(map[string]interface{}) (map[string]interface{}, error){
+	0: migrateUnversionedResult,
+}
+
+// MigrateResult upgrades a saved calibration result to the current schema version
+// Returns the result encoded as EncodeResult would and the schema version it was saved with. Files from before
+// schema_version was added are version 0 (see migrateUnversionedResult); files from a newer module are rejected.
+func MigrateResult(data []byte) ([]byte, int, error) {
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode calibration result: %w", err)
+	}
+	version := 0
+	if v, ok := saved["schema_version"].(float64); ok {
+		version = int(v)
+	}
+	if version > ResultSchemaVersion {
+		return nil, version, fmt.Errorf("calibration result has schema version %d, newer than the supported %d",
+			version, ResultSchemaVersion)
+	}
+	if version == ResultSchemaVersion {
+		return data, version, nil
+	}
+
+	migrated := saved
+	for v := version; v < ResultSchemaVersion; v++ {
+		migrate, ok := resultMigrations[v]
+		if !ok {
+			return nil, version, fmt.Errorf("no migration for calibration result schema version %d", v)
+		}
+		var err error
+		if migrated, err = migrate(migrated); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate calibration result from schema version %d: %w", v, err)
+		}
+	}
+	migrated["schema_version"] = ResultSchemaVersion
+	data, err := json.MarshalIndent(migrated, "", "  ")
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to encode migrated calibration result: %w", err)
+	}
+	return data, version, nil
+}
+
+// legacyResultFields are the JSON tags of the CalibrationResult fields the first releases dumped under their Go
+// names. The nested plane and points decode case-insensitively, so only the top level needs renaming.
+var legacyResultFields = map[string]string{
+	"Plane":         "plane",
+	"BottomZ":       "bottom_z",
+	"TopZ":          "top_z",
+	"LeftX":         "left_x",
+	"RightX":        "right_x",
+	"MonitorWidth":  "monitor_width",
+	"MonitorHeight": "monitor_height",
+	"XPoint1":       "x_point_1",
+	"XPoint2":       "x_point_2",
+	"ZPoint1":       "z_point_1",
+}
+
+// migrateUnversionedResult wraps the result dumps written before the versioned envelope in one
+// Three shapes were saved in the field:
+//   - the untagged CalibrationResult of the first releases, with Go field names such as "BottomZ"
+//   - a bare result with today's field names, e.g. the "result" of get_last_calibration
+//   - the monitor component config the first releases' "calibrate" command returned, with the monitor pose as
+//     its frame and the monitor size as its box geometry
+func migrateUnversionedResult(saved map[string]interface{}) (map[string]interface{}, error) {
+	if frame, ok := saved["frame"].(map[string]interface{}); ok {
+		result, err := resultFromFrameConfig(frame)
+		if err != nil {
+			return nil, err
+		}
+		m, err := ResultToMap(result)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"result": m}, nil
+	}
+	if _, ok := saved["Plane"]; ok {
+		result := map[string]interface{}{}
+		for key, value := range saved {
+			if field, ok := legacyResultFields[key]; ok {
+				key = field
+			}
+			result[key] = value
+		}
+		return map[string]interface{}{"result": result}, nil
+	}
+	if _, ok := saved["plane"]; ok {
+		return map[string]interface{}{"result": saved}, nil
+	}
+	return nil, fmt.Errorf("unrecognized calibration result format")
+}
+
+// resultFromFrameConfig rebuilds a calibration result from a monitor component's frame config
+func resultFromFrameConfig(frame map[string]interface{}) (CalibrationResult, error) {
+	orientationConfig, ok := frame["orientation"].(map[string]interface{})
+	if !ok {
+		return CalibrationResult{}, fmt.Errorf("not a legacy frame config: monitor frame has no orientation object")
+	}
+	if kind, _ := orientationConfig["type"].(string); kind != "quaternion" {
+		return CalibrationResult{}, fmt.Errorf("monitor frame orientation is %q, expected a quaternion", kind)
+	}
+	var missing []string
+	number := func(path ...string) float64 {
+		m := frame
+		for _, key := range path[:len(path)-1] {
+			m, _ = m[key].(map[string]interface{})
+		}
+		v, ok := m[path[len(path)-1]].(float64)
+		if !ok {
+			missing = append(missing, strings.Join(path, "."))
+		}
+		return v
+	}
+	center := r3.Vector{X: number("translation", "x"), Y: number("translation", "y"), Z: number("translation", "z")}
+	orientation := &spatialmath.Quaternion{
+		Real: number("orientation", "value", "w"),
+		Imag: number("orientation", "value", "x"),
+		Jmag: number("orientation", "value", "y"),
+		Kmag: number("orientation", "value", "z"),
+	}
+	width, height := number("geometry", "x"), number("geometry", "z")
+	if len(missing) > 0 {
+		return CalibrationResult{}, fmt.Errorf("monitor frame is missing %s", strings.Join(missing, ", "))
+	}
+
+	result, err := ResultFromMonitorPose(spatialmath.NewPose(center, orientation), width, height)
+	if err != nil {
+		return CalibrationResult{}, err
+	}
+	result.Frame, _ = frame["parent"].(string)
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// MonitorHint is a rough position and size of the monitor in the world frame, to plan the scan region from
+type MonitorHint struct {
+	Center  Point3D
+	Width   float64 // mm
+	Height  float64 // mm
+	Padding float64 // mm - how far the hint may be off
+}
+
+// HintPlan is the scan region PlanFromHint worked out
+type HintPlan struct {
+	GantryMin float64 `json:"gantry_min_mm"`
+	GantryMax float64 `json:"gantry_max_mm"`
+	ZMin      float64 `json:"z_min_mm"`       // arm height offset from the home pose the Z scan starts at
+	ZStepSize float64 `json:"z_step_size_mm"` // spreads the configured Z steps over the hinted glass
+	Standoff  float64 `json:"standoff_mm"`    // distance along the beam from the sensor at home to the hinted glass
+	MaxRange  float64 `json:"max_range_mm"`   // readings beyond this can't be the hinted glass
+}
+
+// Apply returns the scan settings with the planned gantry window, Z scan and range
+func (p HintPlan) Apply(config ScanningConfig) ScanningConfig {
+	config.GantryMin, config.GantryMax = p.GantryMin, p.GantryMax
+	config.ZMin, config.ZStepSize = p.ZMin, p.ZStepSize
+	config.MaxRange = p.MaxRange
+	return config
+}
+
+// PlanFromHint plans the scan region around a monitor hint
+// sensorPose is the sensor's pose in the world frame with the arm at its home pose and the gantry at
+// gantryPosition. Like the scans, the gantry is taken to carry the sensor along world X and the arm to raise it
+// along world Z, both scaled by motionSign (HardwareConfig.MotionSign). The gantry window covers the hinted width
+// with Padding either side, clamped to the gantry travel, so the edges lie inside it and the edge searches, which run
+// outward from its center, can step past them. The Z scan covers the hinted height less Padding at the top and
+// bottom, so every Z waypoint lands on the glass even if the hint is off. The standoff is measured along the sensor's
+// beam to the hinted center, and must be within the scan's MaxRange. The planned range then ends Padding plus half
+// the hinted diagonal beyond the standoff, as far as any part of the glass can be however it is tilted, so readings
+// past the monitor count as misses.
+func PlanFromHint(hint MonitorHint, sensorPose spatialmath.Pose, gantryPosition float64, gantryLengths []float64,
+	motionSign float64, config ScanningConfig) (HintPlan, error) {
+	if len(gantryLengths) == 0 {
+		return HintPlan{}, fmt.Errorf("gantry has no axes")
+	}
+	if config.ZNumSteps < 2 {
+		return HintPlan{}, fmt.Errorf("Z scan needs at least 2 steps to cover the monitor hint")
+	}
+	if motionSign == 0 {
+		motionSign = 1
+	}
+	sensor := sensorPose.Point()
+	center := r3.Vector{X: hint.Center.X, Y: hint.Center.Y, Z: hint.Center.Z}
+
+	// Gantry position that puts the sensor at world X
+	gantryAt := func(x float64) float64 {
+		return gantryPosition + motionSign*(x-sensor.X)
+	}
+	left, right := gantryAt(center.X-hint.Width/2-hint.Padding), gantryAt(center.X+hint.Width/2+hint.Padding)
+	plan := HintPlan{
+		GantryMin: math.Max(0, math.Min(left, right)),
+		GantryMax: math.Min(gantryLengths[0], math.Max(left, right)),
+	}
+	if plan.GantryMax <= plan.GantryMin {
+		return HintPlan{}, fmt.Errorf("monitor hint at x=%.1f is outside the gantry travel of %.1f mm",
+			center.X, gantryLengths[0])
+	}
+
+	bottom := center.Z - hint.Height/2 + hint.Padding - sensor.Z
+	top := center.Z + hint.Height/2 - hint.Padding - sensor.Z
+	if top <= bottom {
+		return HintPlan{}, fmt.Errorf("monitor hint is %.1f mm tall, leaving nothing to scan inside %.1f mm of padding",
+			hint.Height, hint.Padding)
+	}
+	// Arm offsets from the home pose, which run the other way when the arm carries the monitor
+	bottom, top = motionSign*bottom, motionSign*top
+	plan.ZMin = math.Min(bottom, top)
+	plan.ZStepSize = math.Abs(top-bottom) / float64(config.ZNumSteps-1)
+
+	ov := sensorPose.Orientation().OrientationVectorRadians()
+	beam := r3.Vector{X: ov.OX, Y: ov.OY, Z: ov.OZ}.Normalize()
+	plan.Standoff = center.Sub(sensor).Dot(beam)
+	if plan.Standoff <= 0 {
+		return HintPlan{}, fmt.Errorf("monitor hint is behind the sensor at its home pose")
+	}
+	if config.MaxRange > 0 && plan.Standoff >= config.MaxRange {
+		return HintPlan{}, fmt.Errorf("monitor hint is %.1f mm from the sensor at its home pose, beyond its %.1f mm range",
+			plan.Standoff, config.MaxRange)
+	}
+	plan.MaxRange = plan.Standoff + hint.Padding + math.Hypot(hint.Width, hint.Height)/2
+	if config.MaxRange > 0 {
+		plan.MaxRange = math.Min(plan.MaxRange, config.MaxRange)
+	}
+	return plan, nil
+}
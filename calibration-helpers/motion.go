@@ -0,0 +1,108 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/services/motion"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// MonitorObstacleLabel labels the calibrated monitor in the world state passed to the motion service
+const MonitorObstacleLabel = "calibrated-monitor"
+
+// MotionConfig plans scan moves with the motion service instead of moving the arm and gantry directly
+// Each waypoint becomes one planned move of the arm's end effector, so the planner can use the gantry and arm
+// together while respecting joint limits and avoiding the obstacles in WorldState
+type MotionConfig struct {
+	Service    motion.Service
+	WorldState *referenceframe.WorldState // obstacles to avoid, nil for none
+}
+
+// BuildWorldState builds the obstacles for motion planning: the previously calibrated monitor, if any, plus extra
+// The monitor is a box of the given thickness behind the glass, so planned moves never cross the screen
+func BuildWorldState(previous *CalibrationResult, thickness float64, extra []*referenceframe.GeometriesInFrame) (*referenceframe.WorldState, error) {
+	obstacles := append([]*referenceframe.GeometriesInFrame{}, extra...)
+	if previous != nil {
+		if thickness <= 0 {
+			return nil, fmt.Errorf("monitor thickness must be positive")
+		}
+		g, err := monitorGeometryFromResult(*previous)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build monitor obstacle: %w", err)
+		}
+		orientation, err := g.orientation()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build monitor obstacle: %w", err)
+		}
+		box, err := spatialmath.NewBox(
+			spatialmath.NewPose(g.toWorld(0, 0, -thickness/2), orientation),
+			r3.Vector{X: g.Width, Y: thickness, Z: g.Height},
+			MonitorObstacleLabel,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build monitor obstacle: %w", err)
+		}
+		obstacles = append(obstacles, referenceframe.NewGeometriesInFrame(previous.Frame, []spatialmath.Geometry{box}))
+	}
+	return referenceframe.NewWorldState(obstacles, nil)
+}
+
+// motionScan turns scan waypoints into end effector poses in the world frame for the motion service
+// Waypoints are offsets from where the scan started: X along the gantry travel (world X) and Z up from the arm home pose
+type motionScan struct {
+	config    *MotionConfig
+	armName   string
+	frame     string
+	home      spatialmath.Pose // end effector pose in frame at the home pose
+	gantryPos float64          // gantry position home was measured at
+}
+
+// newMotionScan records the end effector pose at the arm home pose, with the gantry wherever it is
+func newMotionScan(ctx context.Context, fs framesystem.RobotFrameSystem, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) (*motionScan, error) {
+	scan := &motionScan{config: config.Motion, armName: arm.Name().ShortName(), frame: config.Hardware.WorldFrame}
+	home, err := fs.GetPose(ctx, scan.armName, scan.frame, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get arm pose in %s frame: %w", scan.frame, err)
+	}
+	scan.home = home.Pose()
+	if gantry != nil {
+		positions, err := gantry.Position(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gantry position: %w", err)
+		}
+		if len(positions) > 0 {
+			scan.gantryPos = positions[0]
+		}
+	}
+	return scan, nil
+}
+
+// moveTo plans and executes a move of the end effector to the waypoint
+// gantryMoves is false for scans that keep the gantry where it is
+func (m *motionScan) moveTo(ctx context.Context, wp scanpath.Waypoint, gantryMoves bool) error {
+	offset := r3.Vector{Z: wp.Z}
+	if gantryMoves {
+		offset.X = wp.X - m.gantryPos
+	}
+	destination := spatialmath.NewPose(m.home.Point().Add(offset), m.home.Orientation())
+	_, err := m.config.Service.Move(ctx, motion.MoveReq{
+		ComponentName: m.armName,
+		Destination:   referenceframe.NewPoseInFrame(m.frame, destination),
+		WorldState:    m.config.WorldState,
+	})
+	if err != nil {
+		if IsUnreachable(err) {
+			return fmt.Errorf("motion service failed to reach gantry=%.1f, z offset=%.1f: %w: %w", wp.X, wp.Z, ErrWorkspaceExceeded, err)
+		}
+		return fmt.Errorf("motion service failed to move to gantry=%.1f, z offset=%.1f: %w", wp.X, wp.Z, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// NormalFromPlane returns the unit normal of plane
+// A fitted plane's normal may point into or out of the glass, so callers that care must turn it themselves.
+func NormalFromPlane(plane Plane) (r3.Vector, error) {
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}
+	if normal.Norm() < 1e-9 {
+		return r3.Vector{}, fmt.Errorf("plane normal is zero")
+	}
+	return normal.Normalize(), nil
+}
+
+// PlaneAxes returns the right-handed unit axes of a frame on plane: x is xDir projected onto the plane, y is the
+// plane normal and z is x × y
+// This is the monitor frame's convention, so with xDir towards the left edge and a normal out of the glass, z is up.
+func PlaneAxes(plane Plane, xDir r3.Vector) (x, y, z r3.Vector, err error) {
+	normal, err := NormalFromPlane(plane)
+	if err != nil {
+		return r3.Vector{}, r3.Vector{}, r3.Vector{}, err
+	}
+	x = xDir.Sub(normal.Mul(xDir.Dot(normal)))
+	if x.Norm() < 1e-9 {
+		return r3.Vector{}, r3.Vector{}, r3.Vector{}, fmt.Errorf("x direction %v is perpendicular to the plane", xDir)
+	}
+	x = x.Normalize()
+	return x, normal, x.Cross(normal).Normalize(), nil
+}
+
+// PlaneOrientation returns the orientation of the PlaneAxes frame on plane
+func PlaneOrientation(plane Plane, xDir r3.Vector) (spatialmath.Orientation, error) {
+	x, y, z, err := PlaneAxes(plane, xDir)
+	if err != nil {
+		return nil, err
+	}
+	return AxesOrientation(x, y, z)
+}
+
+// AxesOrientation returns the rotation from the reference frame to the frame with the given right-handed unit axes
+func AxesOrientation(x, y, z r3.Vector) (*spatialmath.RotationMatrix, error) {
+	return spatialmath.NewRotationMatrix([]float64{
+		x.X, x.Y, x.Z,
+		y.X, y.Y, y.Z,
+		z.X, z.Y, z.Z,
+	})
+}
+
+// QuaternionConfig returns an orientation in the quaternion form of a frame config
+func QuaternionConfig(orientation spatialmath.Orientation) map[string]any {
+	quaternion := orientation.Quaternion()
+	return map[string]any{
+		"type": "quaternion",
+		"value": map[string]any{
+			"x": quaternion.Imag,
+			"y": quaternion.Jmag,
+			"z": quaternion.Kmag,
+			"w": quaternion.Real,
+		},
+	}
+}
@@ -0,0 +1,307 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/utils"
+	"gonum.org/v1/gonum/mat"
+)
+
+// OrientationSweepConfig refines the fitted plane normal by sweeping wrist joints at a few gantry positions and
+// finding the angle where the sensor reads the shortest distance, where its beam is perpendicular to the glass
+// A sweep pins the normal only within the plane its beam turns in, so one joint refines the normal about one axis
+// and the position fit keeps the rest. Two joints turning about different axes pin the normal completely.
+type OrientationSweepConfig struct {
+	Waypoints     int     // gantry positions the sweeps are made at, spread evenly over the scan travel
+	Joints        []int   // indices of the arm joints swept at each position
+	SweepAngle    float64 // degrees - half-angle of each sweep about the home joint positions
+	Steps         int     // readings per sweep
+	MaxCorrection float64 // degrees - largest change to the fitted normal accepted, larger ones are discarded
+}
+
+// Validate checks the sweep against the arm positions it starts from
+func (c OrientationSweepConfig) Validate(arm ArmPositions) error {
+	if c.Waypoints < 1 {
+		return errors.New("orientation sweep needs at least 1 waypoint")
+	}
+	if c.Steps < 3 {
+		return errors.New("orientation sweep needs at least 3 steps to find a minimum")
+	}
+	if c.SweepAngle <= 0 || c.SweepAngle >= 45 {
+		return errors.New("orientation sweep angle must be between 0 and 45 degrees")
+	}
+	if len(c.Joints) == 0 {
+		return errors.New("orientation sweep needs at least 1 joint")
+	}
+	for _, joint := range c.Joints {
+		if joint < 0 || joint >= len(arm.Home) {
+			return fmt.Errorf("orientation sweep joint index %d out of range for %d-joint arm", joint, len(arm.Home))
+		}
+	}
+	if c.MaxCorrection <= 0 {
+		return errors.New("orientation sweep max correction must be positive")
+	}
+	return nil
+}
+
+// OrientationSweep is one wrist sweep of an orientation refinement
+type OrientationSweep struct {
+	GantryMM float64 `json:"gantry_mm"`
+	Joint    int     `json:"joint"`
+	Readings int     `json:"readings"` // readings that struck the glass
+	// PerpendicularDeg is the joint offset from home where the depth is shortest, from a parabola fit to the
+	// readings
+	PerpendicularDeg float64 `json:"perpendicular_deg"`
+	DepthMM          float64 `json:"depth_mm"`        // shortest depth of the parabola
+	Error            string  `json:"error,omitempty"` // why the sweep couldn't be used
+}
+
+// OrientationRefinement is the outcome of the orientation sweeps
+type OrientationRefinement struct {
+	Sweeps []OrientationSweep `json:"sweeps"`
+	// Constraints is how many independent directions the usable sweeps pinned the normal in: 1 for sweeps of one
+	// joint, 2 when the normal is fully determined
+	Constraints   int     `json:"constraints"`
+	CorrectionDeg float64 `json:"correction_deg"` // angle between the fitted and refined normals
+	Applied       bool    `json:"applied"`
+	Reason        string  `json:"reason,omitempty"` // why the refinement wasn't applied
+}
+
+// ToMap converts the refinement to a map for DoCommand responses
+func (r OrientationRefinement) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// sweepSample is a reading at one joint offset of a sweep
+type sweepSample struct {
+	angle float64   // degrees from the hold position
+	depth float64   // mm
+	beam  r3.Vector // unit direction from the sensor to the surface point
+}
+
+// RefineOrientation sweeps the configured joints at each waypoint and corrects plane's normal to agree with the
+// perpendicular beams found, returning the refined plane through the points on the original plane
+// The minimum depth marks the perpendicular beam when the swept joint turns the sensor about a point on its beam
+// line, as a wrist-mounted sensor looking along the tool axis does. Unusable sweeps, such as ones whose minimum
+// lies outside the sweep, are reported and skipped. If no sweep is usable, or the correction exceeds
+// MaxCorrection, the plane is returned unchanged with the reason in the report. Errors are returned only when the
+// hardware fails.
+func RefineOrientation(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry, config CalibrationConfig,
+	plane Plane, points []Point3D) (Plane, OrientationRefinement, error) {
+	sweep := config.OrientationSweep
+	report := OrientationRefinement{Sweeps: []OrientationSweep{}}
+	normal, err := NormalFromPlane(plane)
+	if err != nil {
+		return plane, report, err
+	}
+
+	lengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return plane, report, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	minPosition, maxPosition, err := config.Scanning.GantryLimits(lengths)
+	if err != nil {
+		return plane, report, err
+	}
+
+	var constraints []r3.Vector
+	for i := 0; i < sweep.Waypoints; i++ {
+		position := minPosition + (maxPosition-minPosition)*float64(i+1)/float64(sweep.Waypoints+1)
+		if err := gantry.MoveToPosition(ctx, []float64{position}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
+			return plane, report, fmt.Errorf("failed to move gantry to %.1f mm: %w", position, err)
+		}
+		for _, joint := range sweep.Joints {
+			if err := config.Control.Checkpoint(ctx); err != nil {
+				return plane, report, err
+			}
+			samples, err := sweepJoint(ctx, logger, fs, sensor, arm, config, joint)
+			if err != nil {
+				return plane, report, err
+			}
+			result := OrientationSweep{GantryMM: position, Joint: joint, Readings: len(samples)}
+			constraint, err := perpendicularConstraint(samples, &result)
+			if err != nil {
+				result.Error = err.Error()
+				logger.Warnf("Orientation sweep of joint %d at gantry %.1f mm skipped: %v", joint, position, err)
+			} else {
+				constraints = append(constraints, constraint)
+				logger.Infof("Orientation sweep of joint %d at gantry %.1f mm: perpendicular at %+.2f deg, depth %.1f mm",
+					joint, position, result.PerpendicularDeg, result.DepthMM)
+			}
+			report.Sweeps = append(report.Sweeps, result)
+		}
+	}
+	if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+		return plane, report, fmt.Errorf("failed to reset arm: %w", err)
+	}
+	if len(constraints) == 0 {
+		report.Reason = "no sweep found a perpendicular beam"
+		return plane, report, nil
+	}
+
+	refined, rank, err := constrainNormal(normal, constraints)
+	if err != nil {
+		report.Reason = err.Error()
+		return plane, report, nil
+	}
+	report.Constraints = rank
+	report.CorrectionDeg = utils.RadToDeg(math.Acos(math.Max(-1, math.Min(1, refined.Dot(normal)))))
+	if report.CorrectionDeg > sweep.MaxCorrection {
+		report.Reason = fmt.Sprintf("correction %.2f deg exceeds the %.2f deg limit", report.CorrectionDeg, sweep.MaxCorrection)
+		return plane, report, nil
+	}
+
+	// Keep the plane through the points the position fit accepted
+	sum, inliers := 0.0, 0
+	for _, p := range points {
+		if PointDistanceFromPlane(p, plane) <= config.Detection.RansacThreshold {
+			sum += refined.Dot(r3.Vector{X: p.X, Y: p.Y, Z: p.Z})
+			inliers++
+		}
+	}
+	if inliers == 0 {
+		report.Reason = "no scan points lie on the fitted plane"
+		return plane, report, nil
+	}
+	report.Applied = true
+	return Plane{A: refined.X, B: refined.Y, C: refined.Z, D: sum / float64(inliers)}, report, nil
+}
+
+// sweepJoint turns one joint across the sweep from the home joint positions and returns the readings that struck
+// the glass, in sweep order
+func sweepJoint(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, config CalibrationConfig, joint int) ([]sweepSample, error) {
+	sweep := config.OrientationSweep
+	step := 2 * sweep.SweepAngle / float64(sweep.Steps-1)
+	var samples []sweepSample
+	for i := 0; i < sweep.Steps; i++ {
+		angle := -sweep.SweepAngle + float64(i)*step
+		joints := append([]float64{}, config.ArmPositions.Home...)
+		joints[joint] += utils.DegToRad(angle)
+		if err := arm.MoveToJointPositions(ctx, joints, nil); err != nil {
+			return nil, fmt.Errorf("failed to sweep joint %d to %+.1f deg: %w", joint, angle, err)
+		}
+		reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+		if errors.Is(err, ErrSensorRead) && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sensor reading at joint %d %+.1f deg: %w", joint, angle, err)
+		}
+		if reading.Miss || (config.Scanning.MaxRange > 0 && reading.Depth >= config.Scanning.MaxRange) {
+			continue
+		}
+		origin := reading.SensorPose.Point()
+		beam := r3.Vector{X: reading.SurfacePoint.X, Y: reading.SurfacePoint.Y, Z: reading.SurfacePoint.Z}.Sub(origin)
+		if beam.Norm() < 1e-9 {
+			continue
+		}
+		samples = append(samples, sweepSample{angle: angle, depth: reading.Depth, beam: beam.Normalize()})
+	}
+	return samples, nil
+}
+
+// perpendicularConstraint finds where a sweep's beam was perpendicular to the glass and returns the direction
+// across the joint axis and that beam, which the normal must be perpendicular to
+// It fits a parabola to depth against angle, whose vertex must lie within the sweep, and interpolates the beam at
+// the vertex between the neighboring readings.
+func perpendicularConstraint(samples []sweepSample, result *OrientationSweep) (r3.Vector, error) {
+	if len(samples) < 3 {
+		return r3.Vector{}, fmt.Errorf("%d readings struck the glass, need at least 3", len(samples))
+	}
+	a := mat.NewDense(len(samples), 3, nil)
+	b := mat.NewVecDense(len(samples), nil)
+	for i, s := range samples {
+		a.SetRow(i, []float64{s.angle * s.angle, s.angle, 1})
+		b.SetVec(i, s.depth)
+	}
+	var coefficients mat.VecDense
+	if err := coefficients.SolveVec(a, b); err != nil {
+		return r3.Vector{}, fmt.Errorf("failed to fit depth against angle: %w", err)
+	}
+	curvature, slope, offset := coefficients.AtVec(0), coefficients.AtVec(1), coefficients.AtVec(2)
+	if curvature <= 0 {
+		return r3.Vector{}, errors.New("depth has no minimum across the sweep")
+	}
+	vertex := -slope / (2 * curvature)
+	first, last := samples[0], samples[len(samples)-1]
+	if vertex < first.angle || vertex > last.angle {
+		return r3.Vector{}, fmt.Errorf("shortest depth at %+.2f deg lies outside the readings from %+.1f to %+.1f deg",
+			vertex, first.angle, last.angle)
+	}
+	result.PerpendicularDeg = vertex
+	result.DepthMM = offset - slope*slope/(4*curvature)
+
+	// The beam turns rigidly with the joint, so interpolating its angle between neighbors is exact
+	beam := first.beam
+	for i := 1; i < len(samples); i++ {
+		if vertex <= samples[i].angle {
+			lo, hi := samples[i-1], samples[i]
+			beam = slerp(lo.beam, hi.beam, (vertex-lo.angle)/(hi.angle-lo.angle))
+			break
+		}
+	}
+	// The beams trace a cone, or a plane, about the joint axis, and the normal lies in the plane through the axis
+	// and the perpendicular beam
+	middle := samples[len(samples)/2].beam
+	axis := middle.Sub(first.beam).Cross(last.beam.Sub(middle))
+	if axis.Norm() < 1e-9 {
+		return r3.Vector{}, errors.New("the beam didn't turn during the sweep")
+	}
+	return axis.Normalize().Cross(beam).Normalize(), nil
+}
+
+// constrainNormal removes from normal its components along the independent directions of the constraints, so it
+// is perpendicular to them, returning the unit result and how many directions were removed
+func constrainNormal(normal r3.Vector, constraints []r3.Vector) (r3.Vector, int, error) {
+	m := mat.NewSymDense(3, nil)
+	for _, c := range constraints {
+		v := [3]float64{c.X, c.Y, c.Z}
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				m.SetSym(i, j, m.At(i, j)+v[i]*v[j])
+			}
+		}
+	}
+	var eigen mat.EigenSym
+	if ok := eigen.Factorize(m, true); !ok {
+		return r3.Vector{}, 0, errors.New("eigendecomposition of the sweep constraints failed")
+	}
+	var vectors mat.Dense
+	eigen.VectorsTo(&vectors)
+	values := eigen.Values(nil)
+
+	// Sweeps of the same joint agree up to noise, so only directions carrying a good share of them count
+	refined, rank := normal, 0
+	for i, value := range values {
+		if value < 0.2*values[len(values)-1] {
+			continue
+		}
+		e := r3.Vector{X: vectors.At(0, i), Y: vectors.At(1, i), Z: vectors.At(2, i)}
+		refined = refined.Sub(e.Mul(refined.Dot(e)))
+		rank++
+	}
+	if rank > 2 || refined.Norm() < 1e-6 {
+		return r3.Vector{}, 0, errors.New("the sweeps disagree, no normal is perpendicular to every beam")
+	}
+	return refined.Normalize(), rank, nil
+}
+
+// slerp interpolates between unit vectors a and b by fraction t of the angle between them
+func slerp(a, b r3.Vector, t float64) r3.Vector {
+	angle := math.Acos(math.Max(-1, math.Min(1, a.Dot(b))))
+	if angle < 1e-9 {
+		return a
+	}
+	return a.Mul(math.Sin((1-t)*angle) / math.Sin(angle)).Add(b.Mul(math.Sin(t*angle) / math.Sin(angle))).Normalize()
+}
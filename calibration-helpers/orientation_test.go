@@ -0,0 +1,128 @@
+package calibrationhelpers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+)
+
+const orientationTolerance = 1e-9
+
+func vectorsClose(a, b r3.Vector) bool {
+	return a.Sub(b).Norm() < orientationTolerance
+}
+
+func TestPlaneAxes(t *testing.T) {
+	tilt := 10 * math.Pi / 180
+	roll := 20 * math.Pi / 180
+	tests := []struct {
+		name  string
+		plane Plane
+		xDir  r3.Vector
+		x     r3.Vector // expected axes
+		y     r3.Vector
+		z     r3.Vector
+	}{
+		{
+			name:  "upright",
+			plane: Plane{B: -1, D: 400},
+			xDir:  r3.Vector{X: 1},
+			x:     r3.Vector{X: 1},
+			y:     r3.Vector{Y: -1},
+			z:     r3.Vector{Z: -1},
+		},
+		{
+			name:  "unnormalized normal",
+			plane: Plane{B: -250, D: 100000},
+			xDir:  r3.Vector{X: 3},
+			x:     r3.Vector{X: 1},
+			y:     r3.Vector{Y: -1},
+			z:     r3.Vector{Z: -1},
+		},
+		{
+			name:  "tilted back",
+			plane: Plane{B: math.Cos(tilt), C: math.Sin(tilt), D: 400},
+			xDir:  r3.Vector{X: -1},
+			x:     r3.Vector{X: -1},
+			y:     r3.Vector{Y: math.Cos(tilt), Z: math.Sin(tilt)},
+			z:     r3.Vector{Y: math.Sin(tilt), Z: -math.Cos(tilt)},
+		},
+		{
+			name:  "rolled in its plane",
+			plane: Plane{B: 1, D: 400},
+			xDir:  r3.Vector{X: -math.Cos(roll), Z: math.Sin(roll)},
+			x:     r3.Vector{X: -math.Cos(roll), Z: math.Sin(roll)},
+			y:     r3.Vector{Y: 1},
+			z:     r3.Vector{X: -math.Sin(roll), Z: -math.Cos(roll)},
+		},
+		{
+			name:  "x direction off the plane is projected",
+			plane: Plane{B: 1, D: 400},
+			xDir:  r3.Vector{X: -1, Y: 5},
+			x:     r3.Vector{X: -1},
+			y:     r3.Vector{Y: 1},
+			z:     r3.Vector{Z: -1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			x, y, z, err := PlaneAxes(tc.plane, tc.xDir)
+			if err != nil {
+				t.Fatalf("PlaneAxes: %v", err)
+			}
+			if !vectorsClose(x, tc.x) || !vectorsClose(y, tc.y) || !vectorsClose(z, tc.z) {
+				t.Fatalf("axes = %v, %v, %v, want %v, %v, %v", x, y, z, tc.x, tc.y, tc.z)
+			}
+			if !vectorsClose(x.Cross(y), z) {
+				t.Errorf("axes aren't right-handed: x × y = %v, z = %v", x.Cross(y), z)
+			}
+
+			orientation, err := PlaneOrientation(tc.plane, tc.xDir)
+			if err != nil {
+				t.Fatalf("PlaneOrientation: %v", err)
+			}
+			rotation := orientation.RotationMatrix()
+			for i, axis := range []r3.Vector{x, y, z} {
+				if row := rotation.Row(i); !vectorsClose(row, axis) {
+					t.Errorf("rotation row %d = %v, want %v", i, row, axis)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaneAxesDegenerate(t *testing.T) {
+	tests := []struct {
+		name  string
+		plane Plane
+		xDir  r3.Vector
+	}{
+		{"zero normal", Plane{D: 400}, r3.Vector{X: 1}},
+		{"x direction along the normal", Plane{B: 1, D: 400}, r3.Vector{Y: -2}},
+		{"zero x direction", Plane{B: 1, D: 400}, r3.Vector{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, _, err := PlaneAxes(tc.plane, tc.xDir); err == nil {
+				t.Error("PlaneAxes succeeded, want an error")
+			}
+			if _, err := PlaneOrientation(tc.plane, tc.xDir); err == nil {
+				t.Error("PlaneOrientation succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestNormalFromPlane(t *testing.T) {
+	normal, err := NormalFromPlane(Plane{A: 3, C: 4, D: 10})
+	if err != nil {
+		t.Fatalf("NormalFromPlane: %v", err)
+	}
+	if want := (r3.Vector{X: 0.6, Z: 0.8}); !vectorsClose(normal, want) {
+		t.Errorf("normal = %v, want %v", normal, want)
+	}
+	if _, err := NormalFromPlane(Plane{D: 10}); err == nil {
+		t.Error("NormalFromPlane of a zero normal succeeded, want an error")
+	}
+}
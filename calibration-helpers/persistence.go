@@ -0,0 +1,120 @@
+package calibrationhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ResultSchemaVersion is the version of the saved calibration result format
+// Bump it whenever a change to CalibrationResult would make older files load incorrectly
+const ResultSchemaVersion = 1
+
+// savedResult is the on-disk envelope for a calibration result
+// Results are always saved in ConventionViam; files saved before the tag was added have none
+type savedResult struct {
+	SchemaVersion int               `json:"schema_version"`
+	Convention    string            `json:"convention"`
+	Result        CalibrationResult `json:"result"`
+}
+
+// EncodeResult serializes the calibration result as versioned JSON
+func EncodeResult(result CalibrationResult) ([]byte, error) {
+	data, err := json.MarshalIndent(savedResult{SchemaVersion: ResultSchemaVersion, Convention: ConventionViam, Result: result}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode calibration result: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeResult parses a calibration result encoded by EncodeResult, upgrading older formats with MigrateResult
+func DecodeResult(data []byte) (CalibrationResult, error) {
+	data, _, err := MigrateResult(data)
+	if err != nil {
+		return CalibrationResult{}, err
+	}
+	var saved savedResult
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to decode calibration result: %w", err)
+	}
+	if saved.Convention != "" && saved.Convention != ConventionViam {
+		return CalibrationResult{}, fmt.Errorf("calibration result is in %s coordinates, expected %s",
+			saved.Convention, ConventionViam)
+	}
+	return saved.Result, nil
+}
+
+// SaveResult writes the calibration result to path as versioned JSON, with text and Markdown summaries beside it
+// The file is written next to its destination and renamed into place so a crash never leaves a partial result.
+// The summaries are written first, so an error means the result wasn't saved.
+func SaveResult(path string, result CalibrationResult) error {
+	data, err := EncodeResult(result)
+	if err != nil {
+		return err
+	}
+	if err := writeSummaries(path, result); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadResult reads a calibration result saved by SaveResult
+// A missing file returns an error wrapping os.ErrNotExist
+func LoadResult(path string) (CalibrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to read calibration result: %w", err)
+	}
+	result, err := DecodeResult(data)
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the destination directory and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
+
+// ResultToMap converts a calibration result to a map of JSON values, suitable for returning from DoCommand
+func ResultToMap(result CalibrationResult) (map[string]interface{}, error) {
+	m, err := jsonToMap(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode calibration result: %w", err)
+	}
+	return m, nil
+}
+
+// jsonToMap converts a JSON-tagged struct to a map of JSON values
+func jsonToMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
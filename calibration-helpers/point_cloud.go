@@ -0,0 +1,109 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/geo/r3"
+)
+
+// Point cloud file formats supported by ExportPointCloud
+const (
+	PointCloudPLY = "ply"
+	PointCloudPCD = "pcd"
+)
+
+// CloudPoint is a scan sample annotated for inspection in point cloud viewers
+type CloudPoint struct {
+	Point3D
+	Intensity float64 // scalar field, signed distance from the fitted plane in mm for scan points
+	Valid     bool    // false for samples rejected by the plane fit
+}
+
+// CloudPointsFromScan annotates scan points with their signed distance from the plane as the intensity,
+// marking points within inlierThreshold mm of the plane as valid
+func CloudPointsFromScan(points []Point3D, plane Plane, inlierThreshold float64) []CloudPoint {
+	length := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+	cloud := make([]CloudPoint, 0, len(points))
+	for _, p := range points {
+		residual := 0.0
+		if length > 0 {
+			residual = (plane.A*p.X + plane.B*p.Y + plane.C*p.Z - plane.D) / length
+		}
+		cloud = append(cloud, CloudPoint{
+			Point3D:   p,
+			Intensity: residual,
+			Valid:     math.Abs(residual) <= inlierThreshold,
+		})
+	}
+	return cloud
+}
+
+// PointCloudFormatFromPath infers the point cloud format from a file extension
+func PointCloudFormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case PointCloudPLY, PointCloudPCD:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("cannot infer point cloud format from %q, expected a .ply or .pcd file", path)
+	}
+}
+
+// ExportPointCloud writes the points as an ASCII PLY or PCD file with x, y, z (mm), intensity and valid (0/1) fields
+// Both formats open in CloudCompare and Open3D; color by intensity to see the plane residuals
+// The coordinates are converted to convention, empty for ConventionViam, which is tagged in the header
+func ExportPointCloud(w io.Writer, format string, points []CloudPoint, convention string) error {
+	if err := ValidateConvention(convention); err != nil {
+		return err
+	}
+	if convention == "" {
+		convention = ConventionViam
+	}
+	bw := bufio.NewWriter(w)
+
+	switch format {
+	case PointCloudPLY:
+		fmt.Fprintf(bw, "ply\n")
+		fmt.Fprintf(bw, "format ascii 1.0\n")
+		fmt.Fprintf(bw, "comment monitor calibration scan, units mm, intensity is the signed distance from the fitted plane\n")
+		fmt.Fprintf(bw, "comment convention %s\n", convention)
+		fmt.Fprintf(bw, "element vertex %d\n", len(points))
+		fmt.Fprintf(bw, "property float x\nproperty float y\nproperty float z\n")
+		fmt.Fprintf(bw, "property float intensity\nproperty uchar valid\n")
+		fmt.Fprintf(bw, "end_header\n")
+	case PointCloudPCD:
+		fmt.Fprintf(bw, "# .PCD v0.7 - Point Cloud Data file format\n")
+		fmt.Fprintf(bw, "# convention %s\n", convention)
+		fmt.Fprintf(bw, "VERSION 0.7\n")
+		fmt.Fprintf(bw, "FIELDS x y z intensity valid\n")
+		fmt.Fprintf(bw, "SIZE 4 4 4 4 1\n")
+		fmt.Fprintf(bw, "TYPE F F F F U\n")
+		fmt.Fprintf(bw, "COUNT 1 1 1 1 1\n")
+		fmt.Fprintf(bw, "WIDTH %d\n", len(points))
+		fmt.Fprintf(bw, "HEIGHT 1\n")
+		fmt.Fprintf(bw, "VIEWPOINT 0 0 0 1 0 0 0\n")
+		fmt.Fprintf(bw, "POINTS %d\n", len(points))
+		fmt.Fprintf(bw, "DATA ascii\n")
+	default:
+		return fmt.Errorf("unknown point cloud format %q", format)
+	}
+
+	// Both formats share the same ASCII row layout
+	for _, p := range points {
+		valid := 0
+		if p.Valid {
+			valid = 1
+		}
+		v, _ := ToConvention(r3.Vector{X: p.X, Y: p.Y, Z: p.Z}, convention)
+		fmt.Fprintf(bw, "%.4f %.4f %.4f %.4f %d\n", v.X, v.Y, v.Z, p.Intensity, valid)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write point cloud: %w", err)
+	}
+	return nil
+}
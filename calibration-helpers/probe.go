@@ -0,0 +1,115 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// ProbeResult is a single targeted measurement of a calibrated monitor
+type ProbeResult struct {
+	Target     Point3D                `json:"target"`      // requested point, in the world frame
+	SensorPose map[string]interface{} `json:"sensor_pose"` // where the readings were taken from
+	Standoff   float64                `json:"standoff_mm"` // distance of the sensor from the target along the normal
+	Raw        RawDistances           `json:"raw_mm"`      // raw distances in reading order
+	Depth      float64                `json:"depth_mm"`    // filtered distance
+	Spread     float64                `json:"spread_mm"`   // std dev of the raw hits
+	Hit        bool                   `json:"hit"`
+
+	// The rest is only set for a hit
+	Point        Point3D `json:"point"`            // measured surface point
+	Residual     float64 `json:"residual_mm"`      // signed distance of the point from the calibrated surface, positive towards the sensor
+	TargetOffset float64 `json:"target_offset_mm"` // distance of the point from the target
+}
+
+// ToMap converts the probe result to a map of JSON values, suitable for returning from DoCommand
+func (r ProbeResult) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// ProbePoint moves the sensor to face target on the calibrated monitor and measures it there
+// The sensor is placed standoff mm out along the calibrated surface normal at the target, beaming back along it,
+// so the reading lands on the target if the calibration is right. A zero standoff keeps the sensor's current
+// distance from the surface. config.Filter sets how many readings are taken and how they are filtered.
+func ProbePoint(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem, sensor sensor.Sensor,
+	arm arm.Arm, gantry gantry.Gantry, result CalibrationResult, config CalibrationConfig, target Point3D,
+	standoff float64) (ProbeResult, error) {
+	if standoff < 0 {
+		return ProbeResult{}, fmt.Errorf("probe standoff must not be negative, got %.1f mm", standoff)
+	}
+	g, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	targetVector := r3.Vector{X: target.X, Y: target.Y, Z: target.Z}
+	// The surface normal towards the sensor: the plane's, or towards the axis of a concave curved monitor
+	normal := g.LocalY
+	if c := result.Cylinder; c != nil {
+		axis := r3.Vector{X: c.Axis.X, Y: c.Axis.Y, Z: c.Axis.Z}.Normalize()
+		d := targetVector.Sub(r3.Vector{X: c.Origin.X, Y: c.Origin.Y, Z: c.Origin.Z})
+		radial := d.Sub(axis.Mul(d.Dot(axis)))
+		if radial.Norm() == 0 {
+			return ProbeResult{}, fmt.Errorf("probe target is on the curved monitor's axis")
+		}
+		normal = radial.Normalize().Mul(-1)
+	}
+
+	frame := config.Hardware.ReferenceFrame()
+	current, err := fs.GetPose(ctx, sensor.Name().Name, frame, nil, nil)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("failed to get sensor pose in %s frame: %w", frame, err)
+	}
+	transducer := config.Hardware.Transducer(current.Pose())
+	if standoff == 0 {
+		standoff = transducer.Point().Sub(targetVector).Dot(normal)
+		if standoff <= 0 {
+			return ProbeResult{}, fmt.Errorf("sensor is behind the calibrated monitor, give the probe a standoff")
+		}
+	}
+	if config.Scanning.MaxRange > 0 && standoff >= config.Scanning.MaxRange {
+		return ProbeResult{}, fmt.Errorf("probe standoff %.1f mm is beyond the sensor's %.1f mm range",
+			standoff, config.Scanning.MaxRange)
+	}
+
+	// Keep the sensor's roll about its beam, which an ultrasonic reading doesn't depend on
+	theta := transducer.Orientation().OrientationVectorRadians().Theta
+	pose := spatialmath.NewPose(targetVector.Add(normal.Mul(standoff)),
+		&spatialmath.OrientationVector{OX: -normal.X, OY: -normal.Y, OZ: -normal.Z, Theta: theta})
+	if _, err := NewSensorMover(fs, arm, gantry, sensor.Name().Name, config).MoveSensorTo(ctx, pose); err != nil {
+		return ProbeResult{}, fmt.Errorf("failed to move the sensor to face the probe target: %w", err)
+	}
+
+	reading, err := GetFilteredSurfacePoint(ctx, logger, fs, sensor, config)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	probe := ProbeResult{
+		Target:     target,
+		SensorPose: PoseToMap(reading.SensorPose),
+		Standoff:   standoff,
+		Raw:        reading.Raw,
+		Depth:      reading.Depth,
+		Spread:     reading.Spread,
+		Hit: !reading.Miss && reading.Depth > 0 &&
+			(config.Scanning.MaxRange <= 0 || reading.Depth < config.Scanning.MaxRange),
+	}
+	if !probe.Hit {
+		return probe, nil
+	}
+	probe.Point = reading.SurfacePoint
+	point := r3.Vector{X: reading.SurfacePoint.X, Y: reading.SurfacePoint.Y, Z: reading.SurfacePoint.Z}
+	probe.TargetOffset = point.Sub(targetVector).Norm()
+	if c := result.Cylinder; c != nil {
+		probe.Residual = -c.Distance(reading.SurfacePoint)
+	} else {
+		probe.Residual = point.Sub(g.Center).Dot(g.LocalY)
+	}
+	return probe, nil
+}
@@ -0,0 +1,282 @@
+package calibrationhelpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"slices"
+	"strings"
+
+	"go.viam.com/rdk/referenceframe"
+)
+
+// Layout of the residual heatmap and edge plot embedded in reports
+const (
+	reportHeatmapColumns    = 16
+	reportHeatmapCellPixels = 24
+	reportPlotWidth         = 560
+	reportPlotHeight        = 400
+	reportPlotMargin        = 48
+)
+
+// reportPage is the data the report template renders
+type reportPage struct {
+	Title     string
+	Name      string
+	Grade     string
+	Summary   [][2]string
+	Scan      *ScanDiagnostics
+	Heatmap   template.URL // PNG data URL, empty without scan points
+	Worst     *HeatmapCell
+	EdgePlot  template.HTML // inline SVG
+	Detected  bool          // the plotted edges join detected corners
+	Fitted    [][2]string
+	Budget    *ErrorBudget
+	Fragment  string
+	Generated string
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(share float64) float64 { return 100 * share },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 900px; color: #222; }
+h1 { margin-bottom: 0.2em; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.grade { display: inline-block; padding: 2px 10px; border-radius: 4px; background: #333; color: #fff; font-weight: bold; }
+.caption { color: #666; font-size: 0.9em; }
+pre { background: #f8f8f8; border: 1px solid #ddd; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{if .Name}}{{.Name}} &middot; {{end}}grade <span class="grade">{{.Grade}}</span></p>
+
+<h2>Summary</h2>
+<table>
+{{range .Summary}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{with .Scan}}
+<h2>Scan</h2>
+<table>
+<tr><th>Waypoints</th><td>{{.Waypoints}}</td></tr>
+{{range $status, $count := .Counts}}<tr><th>{{$status}}</th><td>{{$count}}</td></tr>
+{{end}}</table>
+{{if .Failures}}<table>
+<tr><th>Scan</th><th>Waypoint</th><th>Gantry (mm)</th><th>Height (mm)</th><th>Status</th><th>Detail</th></tr>
+{{range .Failures}}<tr><td>{{.Scan}}</td><td>{{.Index}}</td><td>{{printf "%.1f" .GantryMM}}</td><td>{{printf "%.1f" .ZOffsetMM}}</td><td>{{.Status}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}
+{{if .Heatmap}}
+<h2>Residual heatmap</h2>
+<img src="{{.Heatmap}}" alt="residual heatmap">
+<p class="caption">Mean residual of the scan points in each cell as seen from the sensor, red towards the sensor and blue into
+the glass. Grey cells have no points, outlined cells have points the fit rejected.{{with .Worst}} The worst cell, row {{.Row}}
+column {{.Col}}, is {{printf "%+.2f" .Mean}} mm from the fitted surface.{{end}}</p>
+{{end}}
+{{if .EdgePlot}}
+<h2>Edges</h2>
+{{.EdgePlot}}
+<p class="caption">Scan points and monitor edges in the calibration frame's X-Z plane as seen from the sensor. Grey points
+were rejected by the fit. The edges join the {{if .Detected}}detected corners{{else}}corners of the rectangle the edge
+searches found{{end}}.</p>
+{{end}}
+<h2>Fitted parameters</h2>
+<table>
+{{range .Fitted}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{with .Budget}}
+<h2>Error budget</h2>
+<table>
+<tr><th>Source</th><th>Per point (mm)</th><th>Share</th><th>Normal (°)</th><th>Offset (mm)</th></tr>
+{{range .Sources}}<tr><td>{{.Name}}{{if not .Measured}} (not measured){{end}}</td><td>{{printf "%.2f" .Sigma}}</td><td>{{printf "%.0f%%" (percent .Share)}}</td><td>±{{printf "%.3f" .NormalStdDev}}</td><td>±{{printf "%.2f" .OffsetStdDev}}</td></tr>
+{{end}}</table>
+{{if .Advice}}<p>{{.Advice}}</p>{{end}}
+{{end}}
+<h2>Fragment</h2>
+<pre>{{.Fragment}}</pre>
+<p class="caption">Generated {{.Generated}}</p>
+</body>
+</html>
+`))
+
+// RenderReport renders a calibration as a self-contained HTML page for operators to review
+// The page has the summary, the scan's waypoint outcomes, the residual heatmap and a plot of the scan points and
+// detected edges, the fitted parameters, the error budget and the monitor fragment. points are the scan's, with
+// their residuals; without them the heatmap and plot are left out. name identifies the calibrated target, if any.
+func RenderReport(name string, result CalibrationResult, points []CloudPoint) ([]byte, error) {
+	summary, err := Summarize(result)
+	if err != nil {
+		return nil, err
+	}
+	page := reportPage{
+		Title:     summary.title(),
+		Name:      name,
+		Grade:     summary.Grade,
+		Summary:   summary.lines(),
+		Scan:      result.Scan,
+		Fitted:    fittedParameters(result),
+		Budget:    result.ErrorBudget,
+		Generated: result.Timestamp.UTC().Format("2006-01-02 15:04:05 MST"),
+	}
+
+	if len(points) > 0 {
+		heatmap, err := BuildResidualHeatmap(result, points, reportHeatmapColumns, 0)
+		if err != nil {
+			return nil, err
+		}
+		var image bytes.Buffer
+		if err := WriteHeatmap(&image, HeatmapPNG, heatmap, reportHeatmapCellPixels, 0); err != nil {
+			return nil, err
+		}
+		page.Heatmap = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image.Bytes()))
+		page.Worst = heatmap.Worst
+		corners, err := ResultCorners(result)
+		if err != nil {
+			return nil, err
+		}
+		page.EdgePlot = template.HTML(edgePlotSVG(corners, points))
+		page.Detected = corners.EdgePoints > 0
+	}
+
+	frame := result.Frame
+	if frame == "" {
+		frame = referenceframe.World
+	}
+	fragment, err := GenerateFragment(result, FragmentConfig{Parent: frame, Thickness: 1})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(fragment, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fragment: %w", err)
+	}
+	page.Fragment = string(data)
+
+	var b bytes.Buffer
+	if err := reportTemplate.Execute(&b, page); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// WriteReport renders the report of a calibration and writes it to path
+func WriteReport(path, name string, result CalibrationResult, points []CloudPoint) error {
+	page, err := RenderReport(name, result, points)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, page)
+}
+
+// fittedParameters lists the raw fit of a calibration, for the report
+func fittedParameters(result CalibrationResult) [][2]string {
+	point := func(p Point3D) string { return fmt.Sprintf("(%.2f, %.2f, %.2f)", p.X, p.Y, p.Z) }
+	p := result.Plane
+	cov := result.PlaneUncertainty
+	fitted := [][2]string{}
+	if result.SessionID != "" {
+		fitted = append(fitted, [2]string{"Session", result.SessionID})
+	}
+	fitted = append(fitted, [][2]string{
+		{"Frame", result.Frame},
+		{"Plane", fmt.Sprintf("%.6fx %+.6fy %+.6fz = %.3f", p.A, p.B, p.C, p.D)},
+		{"Reference points", fmt.Sprintf("X %s to %s, Z %s", point(result.XPoint1), point(result.XPoint2), point(result.ZPoint1))},
+		{"Uncertainty", fmt.Sprintf("normal ±%.3f°, offset ±%.2f mm, residual RMS %.2f mm from %d samples",
+			cov.NormalStdDev, cov.OffsetStdDev, cov.ResidualRMS, cov.Samples)},
+	}...)
+	if c, err := ResultCorners(result); err == nil {
+		source := "from the edge searches"
+		if c.EdgePoints > 0 {
+			source = fmt.Sprintf("detected from %d edge points", c.EdgePoints)
+		}
+		fitted = append(fitted, [2]string{"Corners", fmt.Sprintf(
+			"top left %s, top right %s, bottom right %s, bottom left %s, rotated %.2f°, %s",
+			point(c.TopLeft), point(c.TopRight), point(c.BottomRight), point(c.BottomLeft), c.Rotation, source)})
+	}
+	if c := result.Cylinder; c != nil {
+		fitted = append(fitted, [2]string{"Cylinder", fmt.Sprintf("radius %.1f mm about %s through %s, arc %.1f to %.1f mm",
+			c.Radius, point(c.Axis), point(c.Origin), c.ArcMin, c.ArcMax)})
+	}
+	if d := result.Deviation; len(d.Coefficients) > 0 {
+		fitted = append(fitted, [2]string{"Flatness", fmt.Sprintf("%.2f mm peak-to-valley from %d polynomial terms",
+			d.PeakToValley, len(d.Coefficients))})
+	}
+	var flags []string
+	if result.Chained {
+		flags = append(flags, "chained from the previous calibration")
+	}
+	if result.Coarse {
+		flags = append(flags, "coarse vision estimate")
+	}
+	if len(flags) > 0 {
+		fitted = append(fitted, [2]string{"Notes", strings.Join(flags, ", ")})
+	}
+	return fitted
+}
+
+// edgePlotSVG plots the scan points and the monitor edges joining the corners in the X-Z plane as seen from the
+// sensor, so +X is to the left like the left edge
+func edgePlotSVG(corners MonitorCorners, points []CloudPoint) string {
+	outline := []Point3D{corners.TopLeft, corners.TopRight, corners.BottomRight, corners.BottomLeft}
+	var xs, zs []float64
+	for _, p := range outline {
+		xs, zs = append(xs, p.X), append(zs, p.Z)
+	}
+	for _, p := range points {
+		xs, zs = append(xs, p.X), append(zs, p.Z)
+	}
+	minX, maxX := slices.Min(xs), slices.Max(xs)
+	minZ, maxZ := slices.Min(zs), slices.Max(zs)
+	// Pad the extent and keep millimeters square
+	span := math.Max(math.Max(maxX-minX, maxZ-minZ), 1) * 1.1
+	plotW, plotH := float64(reportPlotWidth-2*reportPlotMargin), float64(reportPlotHeight-2*reportPlotMargin)
+	scale := math.Min(plotW, plotH) / span
+	midX, midZ := (minX+maxX)/2, (minZ+maxZ)/2
+	sx := func(x float64) float64 { return reportPlotMargin + plotW/2 - (x-midX)*scale }
+	sz := func(z float64) float64 { return reportPlotMargin + plotH/2 - (z-midZ)*scale }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="11">`,
+		reportPlotWidth, reportPlotHeight)
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.0f" height="%.0f" fill="none" stroke="#ccc"/>`,
+		reportPlotMargin, reportPlotMargin, plotW, plotH)
+	for _, p := range points {
+		fill := "#1f77b4"
+		if !p.Valid {
+			fill = "#bbb"
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="%s"/>`, sx(p.X), sz(p.Z), fill)
+	}
+	// The edges follow the corners, so a rolled monitor's edges are drawn slanted
+	for _, edge := range []struct {
+		label string
+		from  Point3D
+		to    Point3D
+	}{
+		{"top", corners.TopLeft, corners.TopRight},
+		{"right", corners.TopRight, corners.BottomRight},
+		{"bottom", corners.BottomRight, corners.BottomLeft},
+		{"left", corners.BottomLeft, corners.TopLeft},
+	} {
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#d62728" stroke-dasharray="6 4"/>`,
+			sx(edge.from.X), sz(edge.from.Z), sx(edge.to.X), sz(edge.to.Z))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#d62728">%s</text>`,
+			sx((edge.from.X+edge.to.X)/2), sz((edge.from.Z+edge.to.Z)/2)-4, edge.label)
+	}
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#666">X (mm), +X to the left</text>`,
+		float64(reportPlotMargin)+plotW/2, reportPlotHeight-12)
+	fmt.Fprintf(&b, `<text x="14" y="%.1f" text-anchor="middle" fill="#666" transform="rotate(-90 14 %.1f)">Z (mm)</text>`,
+		float64(reportPlotMargin)+plotH/2, float64(reportPlotMargin)+plotH/2)
+	b.WriteString(`</svg>`)
+	return b.String()
+}
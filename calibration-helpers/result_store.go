@@ -0,0 +1,154 @@
+package calibrationhelpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrNoSavedResult is returned by ResultStore.Load when no calibration has been saved yet
+var ErrNoSavedResult = errors.New("no saved calibration result")
+
+// ResultStore persists the most recent calibration result
+type ResultStore interface {
+	// Save stores the result, replacing the previously saved one
+	Save(ctx context.Context, result CalibrationResult) error
+	// Load returns the most recently saved result, or an error wrapping ErrNoSavedResult
+	Load(ctx context.Context) (CalibrationResult, error)
+	// String describes where results are stored, for logging
+	String() string
+}
+
+// FileStore keeps the result in a local JSON file
+type FileStore struct {
+	Path string
+}
+
+// Save writes the result to the file atomically
+func (f *FileStore) Save(_ context.Context, result CalibrationResult) error {
+	return SaveResult(f.Path, result)
+}
+
+// Load reads the result from the file
+func (f *FileStore) Load(_ context.Context) (CalibrationResult, error) {
+	result, err := LoadResult(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return CalibrationResult{}, fmt.Errorf("%w at %s", ErrNoSavedResult, f.Path)
+	}
+	return result, err
+}
+
+func (f *FileStore) String() string {
+	return "file " + f.Path
+}
+
+// DataSyncStore drops a timestamped copy of every result into a directory synced by the Viam data manager
+// The data manager deletes files once they are uploaded, so the latest result is also kept in Local for Load
+type DataSyncStore struct {
+	Local   *FileStore
+	SyncDir string // must be one of the data manager's additional sync paths
+	Name    string // file name prefix, usually the resource name
+}
+
+// Save queues a timestamped copy for upload, then writes the local copy
+// The local copy is what Load returns, so it is written last and an error leaves the previous result in place.
+func (d *DataSyncStore) Save(ctx context.Context, result CalibrationResult) error {
+	timestamp := result.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	name := fmt.Sprintf("%s-calibration-%s.json", d.Name, timestamp.Format("20060102T150405Z"))
+	if err := SaveResult(filepath.Join(d.SyncDir, name), result); err != nil {
+		return fmt.Errorf("failed to queue calibration result for data sync: %w", err)
+	}
+	if err := d.Local.Save(ctx, result); err != nil {
+		return fmt.Errorf("queued calibration result for data sync, but failed to save the local copy: %w", err)
+	}
+	return nil
+}
+
+// Load reads the local copy of the latest result
+func (d *DataSyncStore) Load(ctx context.Context) (CalibrationResult, error) {
+	return d.Local.Load(ctx)
+}
+
+func (d *DataSyncStore) String() string {
+	return fmt.Sprintf("data sync directory %s (local copy %s)", d.SyncDir, d.Local.Path)
+}
+
+// S3Store keeps the result as an object in an S3-compatible bucket
+type S3Store struct {
+	Client *s3.Client
+	Bucket string
+	Key    string
+}
+
+// Save uploads the result, replacing the object, and its text and Markdown summaries next to it
+// Like SaveResult, the summaries go first, so an error means the stored result wasn't replaced.
+func (b *S3Store) Save(ctx context.Context, result CalibrationResult) error {
+	data, err := EncodeResult(result)
+	if err != nil {
+		return err
+	}
+	summary, err := Summarize(result)
+	if err != nil {
+		return err
+	}
+	textKey, markdownKey := SummaryPaths(b.Key)
+	objects := []struct {
+		key, contentType string
+		data             []byte
+	}{
+		{textKey, "text/plain; charset=utf-8", []byte(summary.Text())},
+		{markdownKey, "text/markdown; charset=utf-8", []byte(summary.Markdown())},
+		{b.Key, "application/json", data},
+	}
+	for _, object := range objects {
+		_, err = b.Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(b.Bucket),
+			Key:         aws.String(object.key),
+			Body:        bytes.NewReader(object.data),
+			ContentType: aws.String(object.contentType),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload calibration result to s3://%s/%s: %w", b.Bucket, object.key, err)
+		}
+	}
+	return nil
+}
+
+// Load downloads the result object
+func (b *S3Store) Load(ctx context.Context) (CalibrationResult, error) {
+	out, err := b.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.Key),
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return CalibrationResult{}, fmt.Errorf("%w at s3://%s/%s", ErrNoSavedResult, b.Bucket, b.Key)
+		}
+		return CalibrationResult{}, fmt.Errorf("failed to download calibration result from s3://%s/%s: %w", b.Bucket, b.Key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to read calibration result from s3://%s/%s: %w", b.Bucket, b.Key, err)
+	}
+	return DecodeResult(data)
+}
+
+func (b *S3Store) String() string {
+	return fmt.Sprintf("s3://%s/%s", b.Bucket, strings.TrimPrefix(b.Key, "/"))
+}
@@ -0,0 +1,78 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryConfig retries failed sensor reads with exponential backoff, so one flaky reading doesn't fail a waypoint
+type RetryConfig struct {
+	MaxAttempts    int           // reads tried for each sample before giving up, 1 to never retry
+	Backoff        time.Duration // wait before the first retry, doubled before each following one
+	MaxBackoff     time.Duration // longest wait between retries, zero for no limit
+	AttemptTimeout time.Duration // max time for a single read attempt, zero for no limit
+}
+
+// Validate checks the retry configuration
+func (c RetryConfig) Validate() error {
+	if c.MaxAttempts < 1 {
+		return errors.New("read attempts must be at least 1")
+	}
+	if c.Backoff < 0 || c.MaxBackoff < 0 || c.AttemptTimeout < 0 {
+		return errors.New("read backoff and attempt timeout must not be negative")
+	}
+	return nil
+}
+
+// delay returns the wait before the given retry, counted from 1
+func (c RetryConfig) delay(retry int) time.Duration {
+	d := c.Backoff
+	for i := 1; i < retry && (c.MaxBackoff == 0 || d < c.MaxBackoff); i++ {
+		d *= 2
+	}
+	if c.MaxBackoff > 0 && d > c.MaxBackoff {
+		return c.MaxBackoff
+	}
+	return d
+}
+
+// permanentReadError marks a failed read that reading again won't fix, such as a reading without the distance
+type permanentReadError struct {
+	error
+}
+
+func (e permanentReadError) Unwrap() error {
+	return e.error
+}
+
+// transient reports whether a failed read may succeed if retried: not a malformed reading or a cancellation
+func transient(err error) bool {
+	var permanent permanentReadError
+	return !errors.As(err, &permanent) && !errors.Is(err, context.Canceled)
+}
+
+// ReadWithRetry reads the source, retrying transient read failures as config says
+// Each attempt is bounded by config.AttemptTimeout. Returns the reading of the first attempt that succeeds, or the
+// last attempt's error, with the number of retries made. A no-echo miss is returned at once as an invalid reading,
+// and a malformed reading fails at once. The caller's ctx ending stops the retries at once.
+func ReadWithRetry(ctx context.Context, source DistanceSource, extra map[string]interface{},
+	config RetryConfig) (distance Meters, valid bool, retries int, err error) {
+	for attempt := 1; ; attempt++ {
+		readCtx, cancel := withOptionalTimeout(ctx, config.AttemptTimeout)
+		distance, valid, err = source.Read(readCtx, extra)
+		cancel()
+		if err != nil && isNoEcho(err) {
+			return 0, false, attempt - 1, nil
+		}
+		if err == nil || ctx.Err() != nil || attempt >= config.MaxAttempts || !transient(err) {
+			return distance, valid, attempt - 1, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, false, attempt - 1, fmt.Errorf("%w while retrying: %w", ctx.Err(), err)
+		case <-time.After(config.delay(attempt)):
+		}
+	}
+}
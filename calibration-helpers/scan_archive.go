@@ -0,0 +1,225 @@
+package calibrationhelpers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Scan log compression, both lossless
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionExtensions are appended to the names of compressed scan log files
+var compressionExtensions = map[string]string{"": "", CompressionGzip: ".gz", CompressionZstd: ".zst"}
+
+// ScanLogOptions controls how CreateScanRecorder stores a scan log
+// Continuous-motion scans produce hundreds of thousands of samples, so long logs can be compressed and split into
+// chunks of ChunkEntries waypoints. Compressed output is only flushed when its file closes, so a crash loses the
+// open chunk; keep chunks small when that matters.
+type ScanLogOptions struct {
+	Format       string // ScanLogCSV or ScanLogJSONL
+	Compression  string // CompressionGzip or CompressionZstd, empty to write plain text
+	ChunkEntries int    // waypoints per chunk file, zero for a single file
+}
+
+// Validate checks the scan log options
+func (o ScanLogOptions) Validate() error {
+	if err := validateScanLogFormat(o.Format); err != nil {
+		return err
+	}
+	if _, ok := compressionExtensions[o.Compression]; !ok {
+		return fmt.Errorf("unknown scan log compression %q, expected %s or %s", o.Compression, CompressionGzip, CompressionZstd)
+	}
+	if o.ChunkEntries < 0 {
+		return fmt.Errorf("scan log chunk size must not be negative, got %d", o.ChunkEntries)
+	}
+	return nil
+}
+
+// ScanLogIndex lists the chunk files of a chunked scan log, in order
+type ScanLogIndex struct {
+	Format       string         `json:"format"`
+	Compression  string         `json:"compression,omitempty"`
+	ChunkEntries int            `json:"chunk_entries"`
+	Entries      int            `json:"entries"`
+	Chunks       []ScanLogChunk `json:"chunks"`
+}
+
+// ScanLogChunk is one file of a chunked scan log
+type ScanLogChunk struct {
+	File    string    `json:"file"` // name relative to the index
+	Entries int       `json:"entries"`
+	First   time.Time `json:"first"` // timestamps of the first and last entries
+	Last    time.Time `json:"last"`
+	Bytes   int64     `json:"bytes"` // size on disk, after compression
+}
+
+// LoadScanLogIndex reads the index of a chunked scan log
+func LoadScanLogIndex(path string) (ScanLogIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ScanLogIndex{}, fmt.Errorf("failed to read scan log index: %w", err)
+	}
+	var index ScanLogIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return ScanLogIndex{}, fmt.Errorf("failed to parse scan log index %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// OpenScanLogChunk opens a scan log file, decompressing it according to its extension
+// The caller closes the returned reader
+func OpenScanLogChunk(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scan log: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case compressionExtensions[CompressionGzip]:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to decompress scan log %s: %w", path, err)
+		}
+		return readCloser{Reader: gz, close: func() error { gz.Close(); return f.Close() }}, nil
+	case compressionExtensions[CompressionZstd]:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to decompress scan log %s: %w", path, err)
+		}
+		return readCloser{Reader: zr, close: func() error { zr.Close(); return f.Close() }}, nil
+	default:
+		return f, nil
+	}
+}
+
+// readCloser closes a decompressor together with its file
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+// scanLogFiles writes a scan log to disk, compressing it and rotating chunk files as configured
+type scanLogFiles struct {
+	opts  ScanLogOptions
+	base  string // path without the format extension
+	index ScanLogIndex
+
+	file       *os.File
+	compressor io.WriteCloser // nil without compression
+	chunk      ScanLogChunk   // the open chunk
+}
+
+func newScanLogFiles(path string, opts ScanLogOptions) *scanLogFiles {
+	base := path
+	if strings.EqualFold(filepath.Ext(path), "."+opts.Format) {
+		base = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	return &scanLogFiles{
+		opts:  opts,
+		base:  base,
+		index: ScanLogIndex{Format: opts.Format, Compression: opts.Compression, ChunkEntries: opts.ChunkEntries},
+	}
+}
+
+// path returns the index of a chunked log, otherwise the log file
+func (f *scanLogFiles) path() string {
+	if f.opts.ChunkEntries > 0 {
+		return f.base + ".index.json"
+	}
+	return f.fileName(0)
+}
+
+// fileName returns the path of the numbered chunk, or of the only file without chunking
+func (f *scanLogFiles) fileName(chunk int) string {
+	ext := "." + f.opts.Format + compressionExtensions[f.opts.Compression]
+	if f.opts.ChunkEntries > 0 {
+		return fmt.Sprintf("%s.%04d%s", f.base, chunk, ext)
+	}
+	return f.base + ext
+}
+
+// full reports whether the open chunk holds ChunkEntries waypoints
+func (f *scanLogFiles) full() bool {
+	return f.opts.ChunkEntries > 0 && f.chunk.Entries >= f.opts.ChunkEntries
+}
+
+// recorded notes a waypoint written to the open chunk
+func (f *scanLogFiles) recorded(timestamp time.Time) {
+	if f.chunk.Entries == 0 {
+		f.chunk.First = timestamp
+	}
+	f.chunk.Last = timestamp
+	f.chunk.Entries++
+}
+
+// open starts the next chunk, returning the writer for its entries
+func (f *scanLogFiles) open() (io.Writer, error) {
+	name := f.fileName(len(f.index.Chunks))
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scan log: %w", err)
+	}
+	f.file, f.chunk = file, ScanLogChunk{File: filepath.Base(name)}
+	switch f.opts.Compression {
+	case CompressionGzip:
+		f.compressor = gzip.NewWriter(file)
+	case CompressionZstd:
+		if f.compressor, err = zstd.NewWriter(file, zstd.WithEncoderConcurrency(1)); err != nil {
+			file.Close()
+			f.file = nil
+			return nil, fmt.Errorf("failed to start scan log compression: %w", err)
+		}
+	default:
+		f.compressor = nil
+		return file, nil
+	}
+	return f.compressor, nil
+}
+
+// close finishes the open chunk and, for a chunked log, rewrites the index to include it
+func (f *scanLogFiles) close() error {
+	if f.file == nil {
+		return nil
+	}
+	var err error
+	if f.compressor != nil {
+		err = f.compressor.Close()
+	}
+	if info, statErr := f.file.Stat(); statErr == nil {
+		f.chunk.Bytes = info.Size()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file, f.compressor = nil, nil
+	if err != nil {
+		return err
+	}
+	if f.opts.ChunkEntries == 0 {
+		return nil
+	}
+
+	f.index.Chunks = append(f.index.Chunks, f.chunk)
+	f.index.Entries += f.chunk.Entries
+	data, err := json.MarshalIndent(f.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path(), data, 0o644)
+}
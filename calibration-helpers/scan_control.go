@@ -0,0 +1,170 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"math"
+	"sync"
+
+	"go.viam.com/rdk/logging"
+)
+
+// ScanChange modifies the scan parameters of a running calibration
+type ScanChange func(*ScanningConfig)
+
+// ScanControl lets an operator change the density, speed and region of a running scan, and pause or abort it
+// Changes are kept for the whole run, so a scan that starts after a change also picks it up
+type ScanControl struct {
+	mu      sync.Mutex
+	changes []ScanChange
+
+	paused  bool
+	aborted bool
+	wake    chan struct{} // closed when a pause ends
+}
+
+// NewScanControl creates a control with no changes
+func NewScanControl() *ScanControl {
+	return &ScanControl{}
+}
+
+// Update queues a change, applied before the next waypoint
+func (c *ScanControl) Update(change ScanChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = append(c.changes, change)
+}
+
+// Pause holds the calibration at its next checkpoint, once the move in progress has finished
+// Returns false if it was already paused or aborted.
+func (c *ScanControl) Pause() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused || c.aborted {
+		return false
+	}
+	c.paused = true
+	c.wake = make(chan struct{})
+	return true
+}
+
+// Resume lets a paused calibration continue, false if it wasn't paused
+func (c *ScanControl) Resume() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return false
+	}
+	c.paused = false
+	close(c.wake)
+	return true
+}
+
+// Abort stops the calibration at its next checkpoint, or straight away if it is paused
+func (c *ScanControl) Abort() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aborted = true
+	if c.paused {
+		c.paused = false
+		close(c.wake)
+	}
+}
+
+// Paused reports whether the calibration is paused
+func (c *ScanControl) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Aborted reports whether the calibration was aborted
+func (c *ScanControl) Aborted() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aborted
+}
+
+// Checkpoint blocks while the calibration is paused, and returns ErrCalibrationAborted once it is aborted
+// Calibrations call it between scan waypoints and phases, where the arm and gantry are at rest.
+func (c *ScanControl) Checkpoint(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	aborted, paused, wake := c.aborted, c.paused, c.wake
+	c.mu.Unlock()
+	if paused {
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		c.mu.Lock()
+		aborted = c.aborted
+		c.mu.Unlock()
+	}
+	if aborted {
+		return ErrCalibrationAborted
+	}
+	return nil
+}
+
+// changesSince returns the changes after the first applied ones, and the new count of applied changes
+func (c *ScanControl) changesSince(applied int) ([]ScanChange, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changes[applied:], len(c.changes)
+}
+
+// scanReplanner regenerates the waypoints of a scan from updated scan parameters
+type scanReplanner func(ScanningConfig) ([]scanpath.Waypoint, error)
+
+// liveScan applies operator changes to a scan in progress
+type liveScan struct {
+	control *ScanControl
+	replan  scanReplanner // nil when the waypoints are fixed, so only speed changes apply
+	applied int
+}
+
+// update applies new changes to config and, if the plan depends on them, replans the rest of the scan
+// next is the index of the waypoint about to be visited. The new plan continues from the same share of the
+// pattern, so rows already scanned aren't repeated. Invalid changes are logged and discarded.
+func (l *liveScan) update(logger logging.Logger, config *CalibrationConfig, label string,
+	waypoints []scanpath.Waypoint, next int) ([]scanpath.Waypoint, int) {
+	if l.control == nil {
+		return waypoints, next
+	}
+	changes, applied := l.control.changesSince(l.applied)
+	l.applied = applied
+	if len(changes) == 0 {
+		return waypoints, next
+	}
+
+	updated := *config
+	for _, change := range changes {
+		change(&updated.Scanning)
+	}
+	if err := updated.Validate(); err != nil {
+		logger.Warnf("Ignoring scan change for %s scan: %v", label, err)
+		return waypoints, next
+	}
+	*config = updated
+	logger.Infof("Applied scan change to %s scan at waypoint %d", label, next+1)
+	if l.replan == nil {
+		return waypoints, next
+	}
+
+	plan, err := l.replan(config.Scanning)
+	if err != nil {
+		logger.Warnf("Keeping the %s scan plan, replanning failed: %v", label, err)
+		return waypoints, next
+	}
+	done := float64(next) / float64(len(waypoints))
+	resume := int(math.Round(done * float64(len(plan))))
+	logger.Infof("Replanned %s scan: %d waypoints, resuming at %d", label, len(plan), resume+1)
+	return plan, resume
+}
@@ -0,0 +1,162 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnmarshalJSON implements json.Unmarshaler, reading null as NaN
+func (d *RawDistances) UnmarshalJSON(data []byte) error {
+	var values []*float64
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*d = make(RawDistances, len(values))
+	for i, v := range values {
+		(*d)[i] = math.NaN()
+		if v != nil {
+			(*d)[i] = *v
+		}
+	}
+	return nil
+}
+
+// ReadScanLog reads every entry of a scan log written by ScanRecorder
+// path is either a log file, compressed or not, or the index of a chunked log, whose chunks are read in order.
+func ReadScanLog(path string) ([]ScanLogEntry, error) {
+	if strings.HasSuffix(path, ".index.json") {
+		index, err := LoadScanLogIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		var entries []ScanLogEntry
+		for _, chunk := range index.Chunks {
+			chunkEntries, err := readScanLogFile(filepath.Join(filepath.Dir(path), chunk.File), index.Format)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, chunkEntries...)
+		}
+		return entries, nil
+	}
+
+	// The format is the extension under any compression extension
+	name := path
+	for _, ext := range compressionExtensions {
+		if ext != "" && strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+	format, err := ScanLogFormatFromPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return readScanLogFile(path, format)
+}
+
+// readScanLogFile reads the entries of one scan log file in format
+func readScanLogFile(path, format string) ([]ScanLogEntry, error) {
+	if err := validateScanLogFormat(format); err != nil {
+		return nil, err
+	}
+	r, err := OpenScanLogChunk(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []ScanLogEntry
+	switch format {
+	case ScanLogCSV:
+		entries, err = readScanLogCSV(r)
+	case ScanLogJSONL:
+		entries, err = readScanLogJSONL(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan log %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// readScanLogJSONL reads one entry per line, skipping blank lines
+func readScanLogJSONL(r io.Reader) ([]ScanLogEntry, error) {
+	var entries []ScanLogEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry ScanLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// readScanLogCSV reads the rows under the scanLogColumns header
+func readScanLogCSV(r io.Reader) ([]ScanLogEntry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || !reflect.DeepEqual(rows[0], scanLogColumns) {
+		return nil, fmt.Errorf("missing scan log header")
+	}
+	entries := make([]ScanLogEntry, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		entry, err := scanLogEntryFromCSV(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// scanLogEntryFromCSV parses a row written by csvRow
+func scanLogEntryFromCSV(row []string) (ScanLogEntry, error) {
+	var err error
+	f := func(s string) float64 {
+		v, parseErr := strconv.ParseFloat(s, 64)
+		if parseErr != nil && err == nil {
+			err = parseErr
+		}
+		return v
+	}
+
+	var entry ScanLogEntry
+	if entry.Timestamp, err = time.Parse(time.RFC3339Nano, row[0]); err != nil {
+		return ScanLogEntry{}, err
+	}
+	if entry.Index, err = strconv.Atoi(row[2]); err != nil {
+		return ScanLogEntry{}, err
+	}
+	entry.Scan, entry.Status, entry.Detail = row[1], row[5], row[18]
+	entry.GantryMM, entry.ZOffsetMM = f(row[3]), f(row[4])
+	if row[6] != "" {
+		entry.SensorPose = map[string]interface{}{}
+		for i, key := range scanLogPoseKeys {
+			entry.SensorPose[key] = f(row[6+i])
+		}
+	}
+	if row[13] != "" {
+		for _, d := range strings.Split(row[13], ";") {
+			entry.Raw = append(entry.Raw, f(d))
+		}
+	}
+	entry.Depth = f(row[14])
+	entry.Point = Point3D{X: f(row[15]), Y: f(row[16]), Z: f(row[17])}
+	return entry, err
+}
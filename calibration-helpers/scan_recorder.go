@@ -0,0 +1,274 @@
+package calibrationhelpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scan log formats written by ScanRecorder
+const (
+	ScanLogCSV   = "csv"
+	ScanLogJSONL = "jsonl"
+)
+
+// scanLogColumns is the CSV header, one column per ScanLogEntry field with the pose and point flattened
+var scanLogColumns = []string{
+	"timestamp", "scan", "index", "gantry_mm", "z_offset_mm", "status",
+	"sensor_x", "sensor_y", "sensor_z", "sensor_ox", "sensor_oy", "sensor_oz", "sensor_theta",
+	"raw_mm", "depth_mm", "point_x", "point_y", "point_z", "detail",
+}
+
+// scanLogPoseKeys are the PoseToMap keys of the sensor pose columns, in order
+var scanLogPoseKeys = []string{"x", "y", "z", "o_x", "o_y", "o_z", "theta"}
+
+// ScanLogEntry is one scan waypoint in a scan log
+type ScanLogEntry struct {
+	Timestamp time.Time `json:"timestamp"` // when the readings were taken, when the waypoint was reported if none were
+	Scan      string    `json:"scan"`
+	Index     int       `json:"index"`
+	GantryMM  float64   `json:"gantry_mm"`
+	ZOffsetMM float64   `json:"z_offset_mm"`
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+
+	// Sensor pose in the reference frame (mm, orientation vector with theta in degrees), nil without a reading
+	SensorPose map[string]interface{} `json:"sensor_pose,omitempty"`
+	Raw        RawDistances           `json:"raw_mm,omitempty"` // raw distances in reading order
+	Depth      float64                `json:"depth_mm"`         // filtered distance
+	Point      Point3D                `json:"point"`            // surface point, zero unless Status is WaypointOK
+}
+
+// RawDistances are raw sensor distances in mm, encoded in JSON with null for the NaN a sensor may report as a miss
+type RawDistances []float64
+
+// MarshalJSON implements json.Marshaler
+func (d RawDistances) MarshalJSON() ([]byte, error) {
+	values := make([]*float64, len(d))
+	for i := range d {
+		if !math.IsNaN(d[i]) && !math.IsInf(d[i], 0) {
+			values[i] = &d[i]
+		}
+	}
+	return json.Marshal(values)
+}
+
+// ScanLogEntryFromProgress converts a scan progress report to a scan log entry
+func ScanLogEntryFromProgress(progress ScanProgress) ScanLogEntry {
+	reading := progress.Reading
+	entry := ScanLogEntry{
+		Timestamp: reading.Timestamp,
+		Scan:      progress.Label,
+		Index:     progress.Index,
+		GantryMM:  progress.Waypoint.X,
+		ZOffsetMM: progress.Waypoint.Z,
+		Status:    progress.Status,
+		Detail:    progress.Detail,
+		Raw:       reading.Raw,
+		Depth:     reading.Depth,
+		Point:     progress.Point,
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	if reading.SensorPose != nil {
+		entry.SensorPose = PoseToMap(reading.SensorPose)
+	}
+	return entry
+}
+
+// validateScanLogFormat checks that format is a known scan log format
+func validateScanLogFormat(format string) error {
+	switch format {
+	case ScanLogCSV, ScanLogJSONL:
+		return nil
+	default:
+		return fmt.Errorf("unknown scan log format %q, expected %s or %s", format, ScanLogCSV, ScanLogJSONL)
+	}
+}
+
+// ScanLogFormatFromPath infers the scan log format from a file extension
+func ScanLogFormatFromPath(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case ScanLogCSV, ScanLogJSONL:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("cannot infer scan log format from %q, expected a .csv or .jsonl file", path)
+	}
+}
+
+// ScanRecorder streams every scan waypoint, with its sensor pose and raw and filtered readings, to a CSV or JSONL log
+// Record has the signature of CalibrationConfig.Progress, so it chains after the other progress consumers.
+// Uncompressed entries are written through as they arrive, so the log survives a calibration that crashes part way.
+type ScanRecorder struct {
+	mu      sync.Mutex
+	format  string
+	w       io.Writer
+	csv     *csv.Writer
+	files   *scanLogFiles // the files opened by CreateScanRecorder, nil for NewScanRecorder
+	path    string
+	entries int
+	err     error // first write error, reported by Close
+}
+
+// NewScanRecorder writes an uncompressed scan log in format to w, which the caller closes after Close
+func NewScanRecorder(w io.Writer, format string) (*ScanRecorder, error) {
+	if err := validateScanLogFormat(format); err != nil {
+		return nil, err
+	}
+	r := &ScanRecorder{format: format}
+	r.start(w)
+	return r, nil
+}
+
+// CreateScanRecorder creates a scan log at path, and any missing parent directories
+// An empty opts.Format is inferred from the file extension. Compression appends .gz or .zst to path, and chunking
+// writes numbered chunk files next to path plus a <path>.index.json index, see ScanLogOptions.
+func CreateScanRecorder(path string, opts ScanLogOptions) (*ScanRecorder, error) {
+	if opts.Format == "" {
+		var err error
+		if opts.Format, err = ScanLogFormatFromPath(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scan log directory: %w", err)
+	}
+	files := newScanLogFiles(path, opts)
+	w, err := files.open()
+	if err != nil {
+		return nil, err
+	}
+	r := &ScanRecorder{format: opts.Format, files: files, path: files.path()}
+	r.start(w)
+	if r.err != nil {
+		files.close()
+		return nil, fmt.Errorf("failed to write scan log: %w", r.err)
+	}
+	return r, nil
+}
+
+// start points the recorder at the next output, writing the CSV header so every chunk reads on its own
+func (r *ScanRecorder) start(w io.Writer) {
+	r.w = w
+	if r.format == ScanLogCSV {
+		r.csv = csv.NewWriter(w)
+		r.err = r.csv.Write(scanLogColumns)
+		r.flush()
+	}
+}
+
+// flush hands buffered CSV rows to the output
+func (r *ScanRecorder) flush() {
+	if r.csv == nil {
+		return
+	}
+	r.csv.Flush()
+	if r.err == nil {
+		r.err = r.csv.Error()
+	}
+}
+
+// Record appends one waypoint to the log
+// Write errors stop the recording and are returned by Close, so a full disk never fails the calibration itself
+func (r *ScanRecorder) Record(progress ScanProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.files != nil && r.files.full() {
+		r.flush()
+		var w io.Writer
+		if r.err = r.files.close(); r.err == nil {
+			w, r.err = r.files.open()
+		}
+		if r.err != nil {
+			return
+		}
+		r.start(w)
+	}
+
+	entry := ScanLogEntryFromProgress(progress)
+	switch r.format {
+	case ScanLogCSV:
+		r.err = r.csv.Write(entry.csvRow())
+		r.flush()
+	case ScanLogJSONL:
+		var line []byte
+		if line, r.err = json.Marshal(entry); r.err == nil {
+			_, r.err = r.w.Write(append(line, '\n'))
+		}
+	}
+	if r.err == nil {
+		r.entries++
+		if r.files != nil {
+			r.files.recorded(entry.Timestamp)
+		}
+	}
+}
+
+// Entries returns how many waypoints have been written
+func (r *ScanRecorder) Entries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entries
+}
+
+// Path returns the file to open the log from: the index for a chunked log, otherwise the log itself
+// Empty for a recorder made by NewScanRecorder
+func (r *ScanRecorder) Path() string {
+	return r.path
+}
+
+// Close finishes the log, returning the first error from writing it
+func (r *ScanRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.flush()
+	}
+	err := r.err
+	if r.files != nil {
+		if closeErr := r.files.close(); err == nil {
+			err = closeErr
+		}
+		r.files = nil
+	}
+	if r.err == nil {
+		r.err = fmt.Errorf("scan log is closed")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write scan log: %w", err)
+	}
+	return nil
+}
+
+// csvRow flattens the entry into the scanLogColumns order
+func (e ScanLogEntry) csvRow() []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	pose := make([]string, 7)
+	if e.SensorPose != nil {
+		for i, key := range scanLogPoseKeys {
+			pose[i] = f(e.SensorPose[key].(float64))
+		}
+	}
+	raw := make([]string, len(e.Raw))
+	for i, d := range e.Raw {
+		raw[i] = f(d)
+	}
+	row := []string{e.Timestamp.Format(time.RFC3339Nano), e.Scan, strconv.Itoa(e.Index), f(e.GantryMM), f(e.ZOffsetMM), e.Status}
+	row = append(row, pose...)
+	return append(row, strings.Join(raw, ";"), f(e.Depth), f(e.Point.X), f(e.Point.Y), f(e.Point.Z), e.Detail)
+}
@@ -0,0 +1,93 @@
+package calibrationhelpers
+
+import "sync"
+
+// ScanSample is a scan log entry tagged with the calibration session it was scanned in
+type ScanSample struct {
+	SessionID string `json:"session_id"`
+	Target    string `json:"target,omitempty"`
+	Seq       int    `json:"seq"` // position of the waypoint in its session, from 0
+	ScanLogEntry
+}
+
+// ScanSampleBatch is what one Drain takes out of a ScanSampleBuffer
+type ScanSampleBatch struct {
+	SessionID string       `json:"session_id"` // session of the samples recorded last, "" before the first session
+	Samples   []ScanSample `json:"samples"`
+	Pending   int          `json:"pending"` // samples left in the buffer for the next drain
+	Dropped   int          `json:"dropped"` // samples discarded since the last drain because the buffer was full
+}
+
+// ToMap converts the batch to a map of JSON values, suitable for returning from DoCommand
+func (b ScanSampleBatch) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(b)
+}
+
+// ScanSampleBuffer holds scan samples until a poller, such as the data manager capturing DoCommand, drains them
+// Record has the signature of CalibrationConfig.Progress, so it chains after the other progress consumers. When the
+// buffer is full the oldest samples are dropped, so an unpolled buffer can't grow without bound. It is safe for
+// concurrent use.
+type ScanSampleBuffer struct {
+	size int
+
+	mu      sync.Mutex
+	session string
+	target  string
+	seq     int
+	samples []ScanSample
+	dropped int
+}
+
+// NewScanSampleBuffer returns a buffer holding up to size samples
+func NewScanSampleBuffer(size int) *ScanSampleBuffer {
+	return &ScanSampleBuffer{size: max(1, size)}
+}
+
+// Begin tags the samples recorded from now on with a session, starting their sequence numbers at 0
+// Samples of earlier sessions stay in the buffer until drained.
+func (b *ScanSampleBuffer) Begin(sessionID, target string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.session, b.target, b.seq = sessionID, target, 0
+}
+
+// Record adds a scan waypoint to the buffer
+// Waypoints replayed from an interrupted run were recorded by that run, so they only advance the sequence number.
+func (b *ScanSampleBuffer) Record(progress ScanProgress) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	seq := b.seq
+	b.seq++
+	if progress.Replayed {
+		return
+	}
+	if len(b.samples) == b.size {
+		b.samples = b.samples[1:]
+		b.dropped++
+	}
+	b.samples = append(b.samples, ScanSample{
+		SessionID:    b.session,
+		Target:       b.target,
+		Seq:          seq,
+		ScanLogEntry: ScanLogEntryFromProgress(progress),
+	})
+}
+
+// Drain takes up to limit of the oldest samples out of the buffer, all of them when limit is zero
+func (b *ScanSampleBuffer) Drain(limit int) ScanSampleBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(b.samples)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	batch := ScanSampleBatch{
+		SessionID: b.session,
+		Samples:   append([]ScanSample{}, b.samples[:n]...),
+		Pending:   len(b.samples) - n,
+		Dropped:   b.dropped,
+	}
+	b.samples = append(b.samples[:0:0], b.samples[n:]...)
+	b.dropped = 0
+	return batch
+}
@@ -0,0 +1,98 @@
+package calibrationhelpers
+
+import (
+	"sync"
+	"time"
+)
+
+// ScanTracePoint is one scan waypoint in a ScanTrace: where the sensor was and what it read
+type ScanTracePoint struct {
+	Seq        int                    `json:"seq"` // from 1, increasing over the trace's lifetime
+	Time       time.Time              `json:"time"`
+	Target     string                 `json:"target,omitempty"`
+	Scan       string                 `json:"scan"`
+	Index      int                    `json:"index"`
+	Status     string                 `json:"status"`
+	SensorPose map[string]interface{} `json:"sensor_pose,omitempty"` // unset when the waypoint failed before reading
+	Depth      float64                `json:"depth_mm,omitempty"`
+	Point      *Point3D               `json:"point,omitempty"` // surface point, only for waypoints that hit
+}
+
+// ScanTraceWindow is the part of a ScanTrace after a sequence number
+type ScanTraceWindow struct {
+	Points  []ScanTracePoint `json:"points"`
+	LastSeq int              `json:"last_seq"` // pass back as since to get only newer points
+	// Truncated is set when points after since were overwritten before they were read
+	Truncated bool `json:"truncated"`
+}
+
+// ToMap converts the window to a map of JSON values, suitable for returning from DoCommand
+func (w ScanTraceWindow) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(w)
+}
+
+// ScanTrace is a ring buffer of the latest scan waypoints with their sensor poses and readings, for a UI to draw
+// the scan trajectory while it runs
+// Record has the signature of CalibrationConfig.Progress. It is safe for concurrent use.
+type ScanTrace struct {
+	mu     sync.Mutex
+	target string
+	points []ScanTracePoint // ring, the point with sequence number n is at (n-1) % len
+	last   int              // sequence number of the latest point
+}
+
+// NewScanTrace returns a trace keeping the latest size waypoints
+func NewScanTrace(size int) *ScanTrace {
+	return &ScanTrace{points: make([]ScanTracePoint, max(1, size))}
+}
+
+// Begin tags the waypoints recorded from now on with the target being calibrated, empty for an untargeted run
+func (t *ScanTrace) Begin(target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.target = target
+}
+
+// Record adds a scan waypoint to the trace, overwriting the oldest when it is full
+// Waypoints replayed from an interrupted run didn't move the sensor, so they are left out.
+func (t *ScanTrace) Record(progress ScanProgress) {
+	if progress.Replayed {
+		return
+	}
+	point := ScanTracePoint{
+		Time:   time.Now(),
+		Scan:   progress.Label,
+		Index:  progress.Index,
+		Status: progress.Status,
+		Depth:  progress.Reading.Depth,
+	}
+	if progress.Reading.SensorPose != nil {
+		point.SensorPose = PoseToMap(progress.Reading.SensorPose)
+	}
+	if progress.Status == WaypointOK {
+		surface := progress.Point
+		point.Point = &surface
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last++
+	point.Seq, point.Target = t.last, t.target
+	t.points[(t.last-1)%len(t.points)] = point
+}
+
+// Since returns the points after sequence number since, oldest first, all the trace holds for zero
+func (t *ScanTrace) Since(since int) ScanTraceWindow {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	oldest := max(1, t.last-len(t.points)+1)
+	window := ScanTraceWindow{
+		Points:    []ScanTracePoint{},
+		LastSeq:   t.last,
+		Truncated: since > 0 && since+1 < oldest,
+	}
+	for seq := max(since+1, oldest); seq <= t.last; seq++ {
+		window.Points = append(window.Points, t.points[(seq-1)%len(t.points)])
+	}
+	return window
+}
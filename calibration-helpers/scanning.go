@@ -1,8 +1,11 @@
 package calibrationhelpers
 
 import (
+	"calibration/scanpath"
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/golang/geo/r3"
 	"go.viam.com/rdk/components/arm"
@@ -13,14 +16,18 @@ import (
 	"go.viam.com/rdk/spatialmath"
 )
 
-// CenterGantry centers the gantry at the midpoint of its range
+// CenterGantry centers the gantry at the midpoint of its scan range
 func CenterGantry(ctx context.Context, gantry gantry.Gantry, config ScanningConfig) (float64, error) {
 	gantryLengths, err := gantry.Lengths(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get gantry lengths: %w", err)
 	}
 
-	centerPosition := gantryLengths[0] / 2
+	minPosition, maxPosition, err := config.GantryLimits(gantryLengths)
+	if err != nil {
+		return 0, err
+	}
+	centerPosition := (minPosition + maxPosition) / 2
 
 	if err := gantry.MoveToPosition(ctx, []float64{centerPosition}, []float64{config.GantrySpeed}, nil); err != nil {
 		return 0, fmt.Errorf("failed to center gantry: %w", err)
@@ -33,88 +40,558 @@ func CenterGantry(ctx context.Context, gantry gantry.Gantry, config ScanningConf
 func PerformZScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
 	sensor sensor.Sensor, arm arm.Arm, config CalibrationConfig) ([]Point3D, error) {
 
-	var points []Point3D
+	waypoints, err := planZScan(config.Scanning)
+	if err != nil {
+		return nil, err
+	}
+
+	// Z scan keeps the gantry where it is
+	points, _, err := performWaypointScan(ctx, logger, fs, sensor, arm, nil, config, "Z", waypoints, planZScan)
+	return points, err
+}
+
+// planZScan generates the Z scan waypoints, a single column up from the arm home pose
+func planZScan(config ScanningConfig) ([]scanpath.Waypoint, error) {
+	var region scanpath.Region
+	region.ZMin, region.ZMax = config.ZRange()
+	waypoints, err := scanpath.Generate(region, scanpath.Raster, 1, config.ZStepSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan Z scan: %w", err)
+	}
+	return waypoints, nil
+}
+
+// PerformXScan scans horizontally along the X-axis (gantry), collecting surface points
+func PerformXScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) ([]Point3D, error) {
+
+	// Get gantry range
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+
+	plan := func(scanning ScanningConfig) ([]scanpath.Waypoint, error) {
+		return planXScan(gantryLengths, scanning)
+	}
+	waypoints, err := plan(config.Scanning)
+	if err != nil {
+		return nil, err
+	}
+
+	points, _, err := performWaypointScan(ctx, logger, fs, sensor, arm, gantry, config, "X", waypoints, plan)
+	return points, err
+}
+
+// planXScan generates the X scan waypoints, a single row across the configured gantry range
+func planXScan(gantryLengths []float64, config ScanningConfig) ([]scanpath.Waypoint, error) {
+	startXPosition, endXPosition, err := config.GantryLimits(gantryLengths)
+	if err != nil {
+		return nil, err
+	}
+	xStepSize := (endXPosition - startXPosition) / float64(config.XNumSteps-1)
+
+	region := scanpath.Region{XMin: startXPosition, XMax: endXPosition}
+	waypoints, err := scanpath.Generate(region, scanpath.Raster, xStepSize, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan X scan: %w", err)
+	}
+	return waypoints, nil
+}
+
+// PerformGridScan scans the configured region with the configured pattern
+// The region defaults to the gantry scan window by the Z scan height
+// Returns the waypoints that produced a point, in the same order as the points
+func PerformGridScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) ([]scanpath.Waypoint, []Point3D, error) {
+
+	waypoints, err := PlanGridScan(ctx, gantry, config.Scanning)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := func(scanning ScanningConfig) ([]scanpath.Waypoint, error) {
+		return PlanGridScan(ctx, gantry, scanning)
+	}
+	points, visited, err := performWaypointScan(ctx, logger, fs, sensor, arm, gantry, config, "Grid", waypoints, plan)
+	if err != nil {
+		return nil, nil, err
+	}
+	return visited, points, nil
+}
+
+// PlanGridScan generates the grid scan waypoints for the configured pattern and spacing
+func PlanGridScan(ctx context.Context, gantry gantry.Gantry, config ScanningConfig) ([]scanpath.Waypoint, error) {
+	region, xSpacing, zSpacing, err := gridRegion(ctx, gantry, config)
+	if err != nil {
+		return nil, err
+	}
+	waypoints, err := scanpath.Generate(region, scanpath.Pattern(config.Pattern), xSpacing, zSpacing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan grid scan: %w", err)
+	}
+	return waypoints, nil
+}
+
+// gridRegion returns the grid scan region and the max sample spacing along X and Z
+func gridRegion(ctx context.Context, gantry gantry.Gantry, config ScanningConfig) (scanpath.Region, float64, float64, error) {
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return scanpath.Region{}, 0, 0, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	xMin, xMax, err := config.GantryLimits(gantryLengths)
+	if err != nil {
+		return scanpath.Region{}, 0, 0, err
+	}
+
+	xSpacing := config.XSpacing
+	if xSpacing <= 0 {
+		xSpacing = (xMax - xMin) / float64(config.XNumSteps-1)
+	}
+	zSpacing := config.ZSpacing
+	if zSpacing <= 0 {
+		zSpacing = config.ZStepSize
+	}
+
+	zMin, zMax := config.ZRange()
+	region := scanpath.Region{
+		XMin: xMin,
+		XMax: xMax,
+		ZMin: zMin,
+		ZMax: zMax,
+	}
+	return region, xSpacing, zSpacing, nil
+}
+
+// ScanProgress describes the outcome of one scan waypoint, reported through CalibrationConfig.Progress
+type ScanProgress struct {
+	Label    string            // scan name, e.g. "Z", "X", "Grid" or "Angular"
+	Index    int               // zero-based index of the waypoint within this scan
+	Total    int               // number of waypoints in this scan
+	Waypoint scanpath.Waypoint // gantry position and arm height offset, zero for angular scans
+	Status   string            // one of the Waypoint* outcomes
+	Detail   string            // error message for failed waypoints
+	Retries  int               // failed sensor reads retried at this waypoint
+	Point    Point3D           // surface point, only set when Status is WaypointOK
+
+	// Error budget inputs, zero when not measured
+	Spread        float64 // mm - std dev of the repeated readings at this waypoint
+	PositionError Point3D // mm - where the arm and gantry reported they ended up, minus where they were sent
+
+	// Fit is the least-squares plane through this scan's points so far, nil until they span a plane
+	Fit *Covariance
+
+	// Reading is the filtered reading with its sensor pose and raw distances, zero when the waypoint failed
+	// before reading
+	Reading SensorReading
+
+	// Replayed is true when the waypoint was visited by the interrupted run being resumed, not scanned again
+	Replayed bool
+
+	// Dropped lists earlier points the scan stopped keeping to stay within ScanningConfig.MaxPoints, so callers
+	// holding data per point can let it go too
+	Dropped []Point3D
+}
+
+// PerformWaypointScan visits each waypoint and collects one surface point per waypoint, in order
+// Waypoint Z is an offset from the arm's home pose; gantry may be nil to keep the current gantry position
+// Waypoints that can't be reached or read are skipped and reported through config.Progress
+func PerformWaypointScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry, config CalibrationConfig,
+	label string, waypoints []scanpath.Waypoint) ([]Point3D, error) {
+	points, _, err := performWaypointScan(ctx, logger, fs, sensor, arm, gantry, config, label, waypoints, nil)
+	return points, err
+}
+
+// performWaypointScan is PerformWaypointScan, also returning the waypoints that produced each point
+// Changes from config.Control are applied before each waypoint; replan regenerates the rest of the scan
+// when they change its density or region, nil keeps the given waypoints
+// A waypoint whose ID already produced a point is not visited again, so a replan that lands on the same
+// grid never scans a location twice
+// The scan is a pipeline: this goroutine moves and captures the raw readings, config.Scanning.Workers goroutines
+// filter them into surface points, and a collector reports them in waypoint order and refits the running plane.
+// Up to config.Scanning.QueueDepth captures wait for a worker before the hardware pauses. Moves and reads stay
+// sequential, so only the filtering overlaps the hardware.
+func performWaypointScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
+	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry, config CalibrationConfig,
+	label string, waypoints []scanpath.Waypoint, replan scanReplanner) ([]Point3D, []scanpath.Waypoint, error) {
 
 	// Reset arm to starting position
 	if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
-		return nil, fmt.Errorf("failed to reset arm: %w", err)
+		return nil, nil, fmt.Errorf("failed to reset arm: %w", err)
+	}
+	homePose, err := arm.EndPosition(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get arm position: %w", err)
+	}
+
+	var planned *motionScan
+	if config.Motion != nil {
+		if planned, err = newMotionScan(ctx, fs, arm, gantry, config); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	queue := make(chan *scanCapture, config.Scanning.QueueDepth)
+	processed := make(chan *scanCapture, config.Scanning.QueueDepth)
+	var workers sync.WaitGroup
+	for w := 0; w < max(1, config.Scanning.Workers); w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for capture := range queue {
+				capture.process(logger)
+				processed <- capture
+			}
+		}()
 	}
+	go func() {
+		workers.Wait()
+		close(processed)
+	}()
 
-	for i := 0; i < config.Scanning.ZNumSteps; i++ {
-		// Get surface point
-		reading, err := GetSurfacePoint(ctx, logger, fs, sensor, config.Hardware.WorldFrame)
+	claims := &scanClaims{ids: map[string]bool{}}
+	collector := &scanCollector{logger: logger, label: label, claims: claims, progress: config.Progress,
+		maxPoints: config.Scanning.MaxPoints}
+	resumed := replayResumed(collector, config.Resume, label, waypoints)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		collector.run(processed)
+	}()
+
+	currentZ := 0.0
+	live := liveScan{control: config.Control, replan: replan}
+	for i, seq := 0, 0; i < len(waypoints); i++ {
+		waypoints, i = live.update(logger, &config, label, waypoints, i)
+		if i >= len(waypoints) {
+			break
+		}
+		wp := waypoints[i]
+		if resumedAt(resumed, i, wp) {
+			continue
+		}
+		if err = config.Control.Checkpoint(ctx); err != nil {
+			break
+		}
+		if wp.ID != "" && !claims.claim(wp.ID) {
+			logger.Debugf("%s scan point %d already scanned as %s", label, i+1, wp.ID)
+			continue
+		}
+		capture := &scanCapture{
+			seq:      seq,
+			config:   config,
+			progress: ScanProgress{Label: label, Index: i, Total: len(waypoints), Waypoint: wp, Status: WaypointOK},
+		}
+		seq++
+		if err = capture.acquire(ctx, fs, sensor, arm, gantry, homePose, planned, &currentZ); err != nil {
+			break
+		}
+		select {
+		case queue <- capture:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to get sensor reading at step %d: %w", i, err)
+			break
 		}
+	}
 
-		points = append(points, reading.SurfacePoint)
-		logger.Infof("Z scan point %d: depth=%f, surface=(%f, %f, %f)",
-			i+1, reading.Depth, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z)
+	// Let the waypoints already captured finish, so their progress is reported even if the scan stopped
+	close(queue)
+	<-collected
+	if err != nil {
+		return nil, nil, err
+	}
+	return collector.points, collector.visited, nil
+}
 
-		// Move up for next reading (except after last point)
-		if i < config.Scanning.ZNumSteps-1 {
-			armPose, err := arm.EndPosition(ctx, nil)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get arm position: %w", err)
+// replayResumed reports the waypoints of the scan an interrupted session already visited, as if just scanned
+// Returns the session waypoints replayed, which the scan skips.
+func replayResumed(collector *scanCollector, session *ScanSession, label string,
+	waypoints []scanpath.Waypoint) []SessionWaypoint {
+	var replayed []SessionWaypoint
+	for _, done := range session.visited(label) {
+		for i, wp := range waypoints {
+			if !done.matches(i, wp) {
+				continue
 			}
-
-			nextPose := spatialmath.NewPose(
-				r3.Vector{
-					X: armPose.Point().X,
-					Y: armPose.Point().Y,
-					Z: armPose.Point().Z + config.Scanning.ZStepSize,
-				},
-				armPose.Orientation(),
-			)
-
-			if err := arm.MoveToPosition(ctx, nextPose, nil); err != nil {
-				return nil, fmt.Errorf("failed to move arm up to pose %+v: %w", nextPose.Point(), err)
+			if wp.ID != "" {
+				collector.claims.claim(wp.ID)
 			}
+			progress := done.progress(i, wp, len(waypoints))
+			collector.report(&scanCapture{progress: progress, reading: progress.Reading})
+			replayed = append(replayed, done)
+			break
 		}
 	}
+	if len(replayed) > 0 {
+		collector.logger.Infof("Resuming %s scan after %d of %d waypoints", label, len(replayed), len(waypoints))
+	}
+	return replayed
+}
 
-	return points, nil
+// resumedAt reports whether a replayed session waypoint matches the index'th waypoint of the scan
+func resumedAt(replayed []SessionWaypoint, index int, wp scanpath.Waypoint) bool {
+	for _, done := range replayed {
+		if done.matches(index, wp) {
+			return true
+		}
+	}
+	return false
 }
 
-// PerformXScan scans horizontally along the X-axis (gantry), collecting surface points
-func PerformXScan(ctx context.Context, logger logging.Logger, fs framesystem.RobotFrameSystem,
-	sensor sensor.Sensor, arm arm.Arm, gantry gantry.Gantry,
-	config CalibrationConfig) ([]Point3D, error) {
+// scanCapture is one visited waypoint on its way through the scan pipeline
+type scanCapture struct {
+	seq      int               // order the waypoint was visited in
+	config   CalibrationConfig // snapshot, live scan changes may update the scan's own copy
+	progress ScanProgress      // outcome, failures before the reading are recorded by acquire
+	capture  sensorCapture     // raw readings, empty when the waypoint failed before reading
+	reading  SensorReading     // filtered by process
+}
 
-	var points []Point3D
+// acquire moves to the waypoint and captures the raw readings there
+// With planned set the move goes through the motion service, otherwise the gantry and arm move directly
+// Recoverable failures are recorded in progress.Status, errors are returned only when the scan must stop
+func (c *scanCapture) acquire(ctx context.Context, fs framesystem.RobotFrameSystem, sensor sensor.Sensor,
+	arm arm.Arm, gantry gantry.Gantry, homePose spatialmath.Pose, planned *motionScan, currentZ *float64) error {
+	config, wp, progress := c.config, c.progress.Waypoint, &c.progress
+	fail := func(status string, err error) error {
+		progress.Status, progress.Detail = status, err.Error()
+		return nil
+	}
 
-	// Reset arm to starting position
-	if err := arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
-		return nil, fmt.Errorf("failed to reset arm: %w", err)
+	if planned != nil {
+		moveCtx, cancel := withOptionalTimeout(ctx, config.Scanning.SettleTimeout)
+		err := planned.moveTo(moveCtx, wp, gantry != nil)
+		cancel()
+		if timedOut(ctx, err) {
+			return fail(WaypointSettleTimeout, err)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// No collision-free plan reaches the waypoint
+			return fail(WaypointIKFailure, err)
+		}
+	} else if gantry != nil {
+		moveCtx, cancel := withOptionalTimeout(ctx, config.Scanning.SettleTimeout)
+		err := gantry.MoveToPosition(moveCtx, []float64{wp.X}, []float64{config.Scanning.GantrySpeed}, nil)
+		cancel()
+		if timedOut(ctx, err) {
+			return fail(WaypointSettleTimeout, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move gantry: %w", err)
+		}
 	}
 
-	// Get gantry range
-	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if planned == nil && wp.Z != *currentZ {
+		nextPose := spatialmath.NewPose(
+			r3.Vector{
+				X: homePose.Point().X,
+				Y: homePose.Point().Y,
+				Z: homePose.Point().Z + wp.Z,
+			},
+			homePose.Orientation(),
+		)
+		moveCtx, cancel := withOptionalTimeout(ctx, config.Scanning.SettleTimeout)
+		err := arm.MoveToPosition(moveCtx, nextPose, nil)
+		cancel()
+		if timedOut(ctx, err) {
+			return fail(WaypointSettleTimeout, err)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fail(WaypointIKFailure, fmt.Errorf("failed to move arm to pose %+v: %w", nextPose.Point(), err))
+		}
+		*currentZ = wp.Z
+	}
+	if planned == nil {
+		progress.PositionError = positionError(ctx, arm, gantry, homePose, wp)
+	}
+	if err := dwell(ctx, config.Scanning); err != nil {
+		return err
+	}
+
+	// Capture the raw readings, the only part that needs the hardware to hold still
+	readCtx, cancel := withOptionalTimeout(ctx, config.Scanning.ReadTimeout)
+	capture, err := captureReadings(readCtx, fs, sensor, config)
+	cancel()
+	progress.Retries = capture.retries
+	if timedOut(ctx, err) {
+		return fail(WaypointReadTimeout, err)
+	}
+	if errors.Is(err, ErrSensorRead) && ctx.Err() == nil {
+		return fail(WaypointReadError, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gantry lengths: %w", err)
+		return fmt.Errorf("failed to get sensor reading at step %d: %w", progress.Index, err)
+	}
+	c.capture = capture
+	return nil
+}
+
+// process filters the captured readings into a surface point, failing readings beyond the max range
+func (c *scanCapture) process(logger logging.Logger) {
+	if c.progress.Status != WaypointOK {
+		return
+	}
+	c.reading = c.capture.process(logger, c.config)
+	c.progress.Reading = c.reading
+	maxRange := c.config.Scanning.MaxRange
+	if c.reading.Miss || (maxRange > 0 && c.reading.Depth >= maxRange) {
+		c.progress.Status = WaypointOutOfRange
+		c.progress.Detail = missDetail(c.reading, maxRange)
+		return
+	}
+	c.progress.Point = c.reading.SurfacePoint
+	c.progress.Spread = c.reading.Spread
+}
+
+// scanClaims tracks the waypoint IDs that produced a point or are still in the pipeline
+type scanClaims struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// claim reserves an ID for a visit, false if it is already taken
+func (c *scanClaims) claim(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids[id] {
+		return false
 	}
+	c.ids[id] = true
+	return true
+}
+
+// release frees the ID of a visit that produced no point, so a replan may try it again
+func (c *scanClaims) release(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ids, id)
+}
+
+// scanCollector reports processed waypoints in the order they were visited
+type scanCollector struct {
+	logger   logging.Logger
+	label    string
+	claims   *scanClaims
+	progress func(ScanProgress)
+
+	points  []Point3D
+	visited []scanpath.Waypoint
+	fit     StreamingPlaneFit // running plane fit, updated per point instead of refit from all of them
+
+	// With maxPoints set, only every stride-th point outside the reference rows is kept
+	maxPoints int
+	stride    int
+	seen      int // points outside the reference rows reported so far
+	topRow    int
+}
 
-	// Scan across full gantry range
-	xStepSize := gantryLengths[0] / float64(config.Scanning.XNumSteps-1)
-	startXPosition := 0.0
+// run reports captures as they arrive, holding back any that overtook an earlier waypoint
+func (c *scanCollector) run(processed <-chan *scanCapture) {
+	pending := map[int]*scanCapture{}
+	next := 0
+	for capture := range processed {
+		pending[capture.seq] = capture
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			c.report(ready)
+		}
+	}
+}
 
-	for i := 0; i < config.Scanning.XNumSteps; i++ {
-		// Move gantry to position
-		xPosition := startXPosition + float64(i)*xStepSize
-		if err := gantry.MoveToPosition(ctx, []float64{xPosition}, []float64{config.Scanning.GantrySpeed}, nil); err != nil {
-			return nil, fmt.Errorf("failed to move gantry: %w", err)
+// report logs one waypoint, keeps its point and updates the running plane fit
+func (c *scanCollector) report(capture *scanCapture) {
+	progress, wp, reading := capture.progress, capture.progress.Waypoint, capture.reading
+	if progress.Status == WaypointOK {
+		progress.Dropped = c.keep(reading.SurfacePoint, wp)
+		c.logger.Infof("%s scan point %d: gantry=%f, z offset=%f, depth=%f, surface=(%f, %f, %f)",
+			c.label, progress.Index+1, wp.X, wp.Z, reading.Depth, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z)
+		c.fit.Add(reading.SurfacePoint)
+		if _, fit, err := c.fit.Fit(); err == nil {
+			progress.Fit = &fit
 		}
+	} else {
+		if wp.ID != "" {
+			c.claims.release(wp.ID)
+		}
+		c.logger.Warnf("%s scan point %d skipped: gantry=%f, z offset=%f, %s: %s",
+			c.label, progress.Index+1, wp.X, wp.Z, progress.Status, progress.Detail)
+	}
+	if c.progress != nil {
+		c.progress(progress)
+	}
+}
 
-		// Get surface point
-		reading, err := GetSurfacePoint(ctx, logger, fs, sensor, config.Hardware.WorldFrame)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get sensor reading at step %d: %w", i, err)
+// keep stores a scan point for the final fit and returns the stored points it displaced
+// Once more than maxPoints are stored, every other one is dropped and only every stride-th later point is kept, so
+// the points stay spread over the whole scan. The bottom and top grid rows, which the scan's reference lines come
+// from, are always kept.
+func (c *scanCollector) keep(point Point3D, wp scanpath.Waypoint) []Point3D {
+	c.topRow = max(c.topRow, wp.Row)
+	if c.maxPoints > 0 && !c.reference(wp) {
+		c.seen++
+		if c.stride > 1 && (c.seen-1)%c.stride != 0 {
+			return nil
 		}
+	}
+	c.points = append(c.points, point)
+	c.visited = append(c.visited, wp)
+	if c.maxPoints <= 0 || len(c.points) <= c.maxPoints {
+		return nil
+	}
 
-		points = append(points, reading.SurfacePoint)
-		logger.Infof("X scan point %d: gantry=%f, depth=%f, surface=(%f, %f, %f)",
-			i+1, xPosition, reading.Depth, reading.SurfacePoint.X, reading.SurfacePoint.Y, reading.SurfacePoint.Z)
+	// A row that has stopped being the top row since its points were stored is thinned like the rest
+	var dropped []Point3D
+	points, visited := c.points[:0], c.visited[:0]
+	thinnable := 0
+	for i, p := range c.points {
+		if !c.reference(c.visited[i]) {
+			if thinnable++; thinnable%2 == 0 {
+				dropped = append(dropped, p)
+				continue
+			}
+		}
+		points = append(points, p)
+		visited = append(visited, c.visited[i])
 	}
+	c.points, c.visited = points, visited
+	c.stride = max(2, 2*c.stride)
+	return dropped
+}
 
-	return points, nil
+// reference reports whether a waypoint is on the bottom or top grid row seen so far
+func (c *scanCollector) reference(wp scanpath.Waypoint) bool {
+	return wp.ID != "" && (wp.Row == 0 || wp.Row == c.topRow)
+}
+
+// positionError compares where the arm and gantry report they are with where the waypoint sent them
+// It is best effort: an axis whose position can't be read counts as exact
+func positionError(ctx context.Context, arm arm.Arm, gantry gantry.Gantry, homePose spatialmath.Pose,
+	wp scanpath.Waypoint) Point3D {
+	var offset r3.Vector
+	if gantry != nil {
+		if positions, err := gantry.Position(ctx, nil); err == nil && len(positions) > 0 {
+			offset.X = positions[0] - wp.X
+		}
+	}
+	if pose, err := arm.EndPosition(ctx, nil); err == nil {
+		target := homePose.Point().Add(r3.Vector{Z: wp.Z})
+		offset = offset.Add(pose.Point().Sub(target))
+	}
+	return Point3D{X: offset.X, Y: offset.Y, Z: offset.Z}
 }
@@ -0,0 +1,233 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Self-test check outcomes
+const (
+	SelfTestPass = "pass"
+	SelfTestFail = "fail"
+	SelfTestSkip = "skip" // not checked, because a check it depends on failed
+)
+
+const (
+	// selfTestReadTimeout bounds the sensor check's reading when no read timeout is configured
+	selfTestReadTimeout = 5 * time.Second
+	// maxSensorMountMM is the farthest the sensor may be from the arm's end effector before its frame is taken to be
+	// attached to the wrong parent
+	maxSensorMountMM = 500.0
+)
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name    string                 `json:"name"`
+	Status  string                 `json:"status"` // one of the SelfTest* outcomes
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// SelfTestReport is what SelfTest found, one check per dependency
+type SelfTestReport struct {
+	Pass   bool            `json:"pass"`
+	Checks []SelfTestCheck `json:"checks"`
+}
+
+// ToMap converts the report to a map of JSON values, suitable for returning from DoCommand
+func (r SelfTestReport) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// SelfTest checks that the arm, gantry, sensor and frame system are wired up well enough to calibrate, without
+// moving anything
+// The checks are, in order:
+//   - "arm": the arm answers, and its home pose is within its joint limits and reach, when it has a kinematic model
+//   - "gantry": the gantry answers, isn't moving, and reports a position within its travel; a gantry that has lost its
+//     home usually doesn't. It isn't homed here, since that moves it.
+//   - "sensor": the sensor gives a reading within the read timeout
+//   - "frame_system": the sensor, arm and gantry frames resolve in the reference frame, and the sensor is within
+//     500 mm of the arm's end effector, as a sensor mounted on it is, unless the arm moves the monitor
+//
+// The sensor is read from its pose in the frame system, so it is skipped when the frame system check fails.
+func SelfTest(ctx context.Context, fs framesystem.RobotFrameSystem, sensor sensor.Sensor, arm arm.Arm,
+	gantry gantry.Gantry, config CalibrationConfig) SelfTestReport {
+	frames, sensorPose := selfTestFrameSystem(ctx, fs, sensor, arm, gantry, config)
+	checks := []SelfTestCheck{
+		selfTestArm(ctx, arm, config),
+		selfTestGantry(ctx, gantry),
+		selfTestSensor(ctx, sensor, sensorPose, config),
+		frames,
+	}
+	report := SelfTestReport{Pass: true, Checks: checks}
+	for _, check := range checks {
+		if check.Status != SelfTestPass {
+			report.Pass = false
+		}
+	}
+	return report
+}
+
+// selfTestArm checks that the arm answers and can reach its home pose
+func selfTestArm(ctx context.Context, arm arm.Arm, config CalibrationConfig) SelfTestCheck {
+	check := SelfTestCheck{Name: "arm", Status: SelfTestFail}
+	joints, err := arm.JointPositions(ctx, nil)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get arm joint positions: %v", err)
+		return check
+	}
+	if len(joints) != len(config.ArmPositions.Home) {
+		check.Message = fmt.Sprintf("arm has %d joints but the home position has %d", len(joints), len(config.ArmPositions.Home))
+		return check
+	}
+	model, err := arm.Kinematics(ctx)
+	if err != nil || model == nil || len(model.DoF()) == 0 {
+		check.Status = SelfTestPass
+		check.Message = "arm answers, its reach isn't checked without a kinematic model"
+		return check
+	}
+	home, err := model.Transform(config.ArmPositions.Home)
+	if err != nil {
+		check.Message = fmt.Sprintf("home joint positions are outside the arm's limits: %v", err)
+		return check
+	}
+	reach := config.Hardware.ArmReach
+	if reach <= 0 {
+		reach = estimateReach(model)
+	}
+	distance := home.Point().Norm()
+	check.Details = map[string]interface{}{"home_pose": PoseToMap(home), "home_distance_mm": distance, "reach_mm": reach}
+	if distance > reach {
+		check.Message = fmt.Sprintf("home pose is %.1f mm from the arm base, beyond its %.1f mm reach", distance, reach)
+		return check
+	}
+	check.Status = SelfTestPass
+	check.Message = fmt.Sprintf("home pose is %.1f mm from the arm base, within its %.1f mm reach", distance, reach)
+	return check
+}
+
+// selfTestGantry checks that the gantry answers, is still and reports a position within its travel
+func selfTestGantry(ctx context.Context, gantry gantry.Gantry) SelfTestCheck {
+	check := SelfTestCheck{Name: "gantry", Status: SelfTestFail}
+	lengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get gantry lengths: %v", err)
+		return check
+	}
+	positions, err := gantry.Position(ctx, nil)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get gantry position: %v", err)
+		return check
+	}
+	if len(lengths) == 0 || len(positions) == 0 {
+		check.Message = "gantry has no axes"
+		return check
+	}
+	check.Details = map[string]interface{}{"position_mm": positions[0], "travel_mm": lengths[0]}
+	moving, err := gantry.IsMoving(ctx)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to check whether the gantry is moving: %v", err)
+		return check
+	}
+	if moving {
+		check.Message = "gantry is moving"
+		return check
+	}
+	// Allow a millimeter of encoder slop at either end of the travel
+	if positions[0] < -1 || positions[0] > lengths[0]+1 {
+		check.Message = fmt.Sprintf("gantry reports %.1f mm, outside its 0 to %.1f mm travel: home it", positions[0], lengths[0])
+		return check
+	}
+	check.Status = SelfTestPass
+	check.Message = fmt.Sprintf("gantry is at %.1f mm of its %.1f mm travel", positions[0], lengths[0])
+	return check
+}
+
+// selfTestSensor takes one reading from the sensor's pose, nil when the frame system couldn't place it
+func selfTestSensor(ctx context.Context, sensor sensor.Sensor, pose spatialmath.Pose, config CalibrationConfig) SelfTestCheck {
+	check := SelfTestCheck{Name: "sensor", Status: SelfTestFail}
+	if pose == nil {
+		check.Status = SelfTestSkip
+		check.Message = "not read, the frame system can't place the sensor"
+		return check
+	}
+	source, err := NewDistanceSource(sensor, config.Hardware.DistanceReading)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	timeout := config.Scanning.ReadTimeout
+	if timeout <= 0 {
+		timeout = selfTestReadTimeout
+	}
+	readCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	distance, valid, err := source.Read(readCtx, poseExtra(pose))
+	latency := time.Since(start)
+	if err != nil {
+		if timedOut(ctx, err) {
+			check.Message = fmt.Sprintf("sensor didn't answer within %s", timeout)
+		} else {
+			check.Message = fmt.Sprintf("failed to get sensor reading: %v", err)
+		}
+		return check
+	}
+	depth := float64(distance.Millimeters())
+	check.Details = map[string]interface{}{"latency_ms": float64(latency) / float64(time.Millisecond)}
+	check.Status = SelfTestPass
+	if !valid || (config.Scanning.MaxRange > 0 && depth >= config.Scanning.MaxRange) {
+		check.Message = fmt.Sprintf("sensor answers in %s but sees nothing within range", latency.Round(time.Millisecond))
+		return check
+	}
+	check.Details["distance_mm"] = depth
+	check.Message = fmt.Sprintf("sensor reads %.1f mm in %s", depth, latency.Round(time.Millisecond))
+	return check
+}
+
+// selfTestFrameSystem checks that the frame system places the sensor, arm and gantry, and that the sensor rides on
+// the arm. Returns the sensor's transducer pose, nil if it couldn't be looked up.
+func selfTestFrameSystem(ctx context.Context, fs framesystem.RobotFrameSystem, sensor sensor.Sensor, arm arm.Arm,
+	gantry gantry.Gantry, config CalibrationConfig) (SelfTestCheck, spatialmath.Pose) {
+	check := SelfTestCheck{Name: "frame_system", Status: SelfTestFail}
+	frame := config.Hardware.ReferenceFrame()
+	inFrame, err := fs.GetPose(ctx, sensor.Name().Name, frame, nil, nil)
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get sensor pose in %s frame: %v", frame, err)
+		return check, nil
+	}
+	sensorPose := config.Hardware.Transducer(inFrame.Pose())
+	check.Details = map[string]interface{}{"frame": frame, "sensor_pose": PoseToMap(sensorPose)}
+
+	var end spatialmath.Pose
+	for _, name := range []string{arm.Name().ShortName(), gantry.Name().ShortName()} {
+		pose, err := fs.GetPose(ctx, name, frame, nil, nil)
+		if err != nil {
+			check.Message = fmt.Sprintf("failed to get %s pose in %s frame: %v", name, frame, err)
+			return check, sensorPose
+		}
+		if end == nil {
+			end = pose.Pose()
+		}
+	}
+	// With a moving monitor the arm carries the monitor, not the sensor
+	if config.Hardware.Topology != TopologyMovingMonitor {
+		mount := sensorPose.Point().Sub(end.Point()).Norm()
+		check.Details["sensor_mount_mm"] = mount
+		if mount > maxSensorMountMM {
+			check.Message = fmt.Sprintf("sensor is %.1f mm from the arm's end effector, check that its frame's parent is the arm",
+				mount)
+			return check, sensorPose
+		}
+	}
+	check.Status = SelfTestPass
+	check.Message = fmt.Sprintf("sensor, arm and gantry frames resolve in the %s frame", frame)
+	return check, sensorPose
+}
@@ -0,0 +1,134 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Ways MoveSensorTo splits a sensor move between the gantry and the arm
+const (
+	// SplitGantryFirst moves the gantry by the whole move along its travel, then the arm for the rest
+	SplitGantryFirst = "gantry_first"
+	// SplitArmFirst tries the move with the arm alone and only moves the gantry when the arm can't reach
+	SplitArmFirst = "arm_first"
+	// SplitOptimizeReach moves the gantry to put the arm's base level with the target, where the arm reaches
+	// furthest either side, then the arm for the rest
+	SplitOptimizeReach = "optimize_reach"
+)
+
+// SensorMove is how MoveSensorTo split a move
+type SensorMove struct {
+	GantryPosition float64          // mm - gantry position after the move
+	ArmPose        spatialmath.Pose // end effector pose in the arm's frame after the move
+}
+
+// SensorMover moves the sensor to poses in the world frame with the gantry and arm together
+// Like the scans, the gantry travels along world X within the scan's gantry limits and the arm's frame is taken to
+// be aligned with the world frame. The sensor is rigidly mounted to the arm, so its mount is read from the frame
+// system before every move, with the transducer placed by Hardware.MountOffset.
+type SensorMover struct {
+	fs         framesystem.RobotFrameSystem
+	arm        arm.Arm
+	gantry     gantry.Gantry
+	sensorName string
+	config     CalibrationConfig
+}
+
+// NewSensorMover returns a mover for the sensor named sensorName, splitting moves as config.Hardware.SensorMove says
+func NewSensorMover(fs framesystem.RobotFrameSystem, arm arm.Arm, gantry gantry.Gantry, sensorName string,
+	config CalibrationConfig) *SensorMover {
+	return &SensorMover{fs: fs, arm: arm, gantry: gantry, sensorName: sensorName, config: config}
+}
+
+// MoveSensorTo moves the sensor to worldPose, in the world frame
+// The pose is reached by the end effector pose that puts the sensor there, with the gantry taking some of the move
+// along its travel as the split strategy says. Returns where the gantry and arm ended up.
+func (m *SensorMover) MoveSensorTo(ctx context.Context, worldPose spatialmath.Pose) (SensorMove, error) {
+	if m.config.Hardware.Topology == TopologyMovingMonitor {
+		return SensorMove{}, fmt.Errorf("the sensor only moves with the %s topology", TopologyMovingSensor)
+	}
+	frame := m.config.Hardware.WorldFrame
+	sensorWorld, err := m.fs.GetPose(ctx, m.sensorName, frame, nil, nil)
+	if err != nil {
+		return SensorMove{}, fmt.Errorf("failed to get sensor pose in %s frame: %w", frame, err)
+	}
+	endWorld, err := m.fs.GetPose(ctx, m.arm.Name().ShortName(), frame, nil, nil)
+	if err != nil {
+		return SensorMove{}, fmt.Errorf("failed to get arm pose in %s frame: %w", frame, err)
+	}
+	armPose, err := m.arm.EndPosition(ctx, nil)
+	if err != nil {
+		return SensorMove{}, fmt.Errorf("failed to get arm position: %w", err)
+	}
+	positions, err := m.gantry.Position(ctx, nil)
+	if err != nil {
+		return SensorMove{}, fmt.Errorf("failed to get gantry position: %w", err)
+	}
+	lengths, err := m.gantry.Lengths(ctx, nil)
+	if err != nil {
+		return SensorMove{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	if len(positions) == 0 || len(lengths) == 0 {
+		return SensorMove{}, fmt.Errorf("gantry has no axes")
+	}
+	gantryPos := positions[0]
+	minPos, maxPos, err := m.config.Scanning.GantryLimits(lengths)
+	if err != nil {
+		return SensorMove{}, err
+	}
+
+	// The end effector pose that puts the sensor at worldPose, and where the arm's base is now
+	mount := spatialmath.PoseBetween(endWorld.Pose(), m.config.Hardware.Transducer(sensorWorld.Pose()))
+	target := spatialmath.Compose(worldPose, spatialmath.PoseInverse(mount))
+	base := endWorld.Pose().Point().Sub(armPose.Point())
+	armTarget := func(position float64) spatialmath.Pose {
+		shifted := base.Add(r3.Vector{X: position - gantryPos})
+		return spatialmath.NewPose(target.Point().Sub(shifted), target.Orientation())
+	}
+	clamp := func(position float64) float64 {
+		return math.Max(minPos, math.Min(maxPos, position))
+	}
+
+	var position float64
+	switch m.config.Hardware.SensorMove {
+	case SplitArmFirst:
+		move := SensorMove{GantryPosition: gantryPos, ArmPose: armTarget(gantryPos)}
+		err := m.arm.MoveToPosition(ctx, move.ArmPose, nil)
+		if err == nil {
+			return move, nil
+		}
+		// A cancelled ctx or a failing arm would fail the gantry's move too, so only fall back when out of reach
+		if !IsUnreachable(err) {
+			return SensorMove{}, fmt.Errorf("failed to move arm to the sensor pose with the gantry at %.1f: %w", gantryPos, err)
+		}
+		// Out of reach from here, so let the gantry take the move along its travel
+		position = clamp(gantryPos + target.Point().X - endWorld.Pose().Point().X)
+	case SplitOptimizeReach:
+		position = clamp(gantryPos + target.Point().X - base.X)
+	case "", SplitGantryFirst:
+		position = clamp(gantryPos + target.Point().X - endWorld.Pose().Point().X)
+	default:
+		return SensorMove{}, fmt.Errorf("unknown sensor move split %q", m.config.Hardware.SensorMove)
+	}
+
+	move := SensorMove{GantryPosition: position, ArmPose: armTarget(position)}
+	if position != gantryPos {
+		if err := m.gantry.MoveToPosition(ctx, []float64{position}, []float64{m.config.Scanning.GantrySpeed}, nil); err != nil {
+			return SensorMove{}, fmt.Errorf("failed to move gantry: %w", err)
+		}
+	}
+	if err := m.arm.MoveToPosition(ctx, move.ArmPose, nil); err != nil {
+		if IsUnreachable(err) {
+			return SensorMove{}, fmt.Errorf("arm cannot reach the sensor pose with the gantry at %.1f: %w: %w", position, ErrWorkspaceExceeded, err)
+		}
+		return SensorMove{}, fmt.Errorf("failed to move arm to the sensor pose with the gantry at %.1f: %w", position, err)
+	}
+	return move, nil
+}
@@ -0,0 +1,271 @@
+package calibrationhelpers
+
+import (
+	"bufio"
+	"bytes"
+	"calibration/scanpath"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// SessionWaypoint is a scan waypoint a calibration session has visited
+type SessionWaypoint struct {
+	Label         string  `json:"label"`
+	Index         int     `json:"index"`
+	X             float64 `json:"x"`            // mm - gantry position
+	Z             float64 `json:"z"`            // mm - arm height offset from the home pose
+	ID            string  `json:"id,omitempty"` // lattice ID of grid waypoints
+	Status        string  `json:"status"`
+	Detail        string  `json:"detail,omitempty"`
+	Point         Point3D `json:"point"`
+	Sensor        Point3D `json:"sensor"` // sensor position the point was read from
+	Spread        float64 `json:"spread"`
+	PositionError Point3D `json:"position_error"`
+}
+
+// ScanSession is the scan progress of a calibration run, saved as it goes so an interrupted run can resume
+// from the last completed waypoint instead of starting over. It is safe for concurrent use.
+type ScanSession struct {
+	mu sync.Mutex
+
+	Started   time.Time         `json:"started"`
+	Target    string            `json:"target,omitempty"`
+	SessionID string            `json:"session_id,omitempty"` // calibration session of the run, kept when it resumes
+	Config    string            `json:"config,omitempty"`     // fingerprint of the scan config the run planned from
+	Frame     string            `json:"frame,omitempty"`      // reference frame of the session's points
+	Waypoints []SessionWaypoint `json:"waypoints"`
+}
+
+// NewScanSession starts an empty session for a run of target, "" for the configured monitor, planned from config
+func NewScanSession(target, sessionID string, config CalibrationConfig) *ScanSession {
+	return &ScanSession{
+		Started:   time.Now().UTC(),
+		Target:    target,
+		SessionID: sessionID,
+		Config:    sessionFingerprint(config),
+		Frame:     config.Hardware.ReferenceFrame(),
+		Waypoints: []SessionWaypoint{},
+	}
+}
+
+// sessionFingerprint hashes the parts of config that decide where the scan goes and what frame its points are in
+// Speeds, timeouts and the scan pipeline only change how the waypoints are visited, so they are left out.
+func sessionFingerprint(config CalibrationConfig) string {
+	scan := config.Scanning
+	data, err := json.Marshal([]interface{}{
+		config.Hardware.Topology, config.Hardware.WorldFrame, config.Hardware.MonitorFrame,
+		scan.Mode, scan.ZStepSize, scan.ZMin, scan.ZNumSteps, scan.XNumSteps, scan.GantryMin, scan.GantryMax,
+		scan.Pattern, scan.XSpacing, scan.ZSpacing, scan.CoarseFactor, scan.RefineResidual,
+		scan.AngularHolds, scan.WristJoint, scan.WristSweepAngle, scan.WristSweepSteps,
+		config.ArmPositions.Home, config.Hint,
+	})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Check returns an error when the session can't be resumed with config: it started more than maxAge ago, zero
+// for no limit, or was planned from a different scan config or reference frame
+// Sessions saved before they recorded their config and frame are only checked for age.
+func (s *ScanSession) Check(config CalibrationConfig, maxAge time.Duration) error {
+	if age := time.Since(s.Started); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("calibration session from %s is %s old, older than %s",
+			s.Started.Format("2006-01-02 15:04:05"), age.Round(time.Minute), maxAge)
+	}
+	if frame := config.Hardware.ReferenceFrame(); s.Frame != "" && s.Frame != frame {
+		return fmt.Errorf("calibration session points are in frame %q, the calibration's are in %q", s.Frame, frame)
+	}
+	if s.Config != "" && s.Config != sessionFingerprint(config) {
+		return fmt.Errorf("calibration session was planned from a different scan path config")
+	}
+	return nil
+}
+
+// Record adds a visited scan waypoint to the session, returning it as it was recorded
+func (s *ScanSession) Record(progress ScanProgress) SessionWaypoint {
+	wp := SessionWaypoint{
+		Label:         progress.Label,
+		Index:         progress.Index,
+		X:             progress.Waypoint.X,
+		Z:             progress.Waypoint.Z,
+		ID:            progress.Waypoint.ID,
+		Status:        progress.Status,
+		Detail:        progress.Detail,
+		Point:         progress.Point,
+		Spread:        progress.Spread,
+		PositionError: progress.PositionError,
+	}
+	if progress.Reading.SensorPose != nil {
+		p := progress.Reading.SensorPose.Point()
+		wp.Sensor = Point3D{X: p.X, Y: p.Y, Z: p.Z}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Waypoints = append(s.Waypoints, wp)
+	return wp
+}
+
+// Len returns how many waypoints the session has visited
+func (s *ScanSession) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.Waypoints)
+}
+
+// visited returns the waypoints of the named scan the session visited, in visit order
+func (s *ScanSession) visited(label string) []SessionWaypoint {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var visited []SessionWaypoint
+	for _, wp := range s.Waypoints {
+		if wp.Label == label {
+			visited = append(visited, wp)
+		}
+	}
+	return visited
+}
+
+// matches reports whether the session waypoint was planned at planned, the index'th waypoint of its scan
+// Grid waypoints match by lattice ID, others by index and position, so a scan replanned since doesn't match.
+func (wp SessionWaypoint) matches(index int, planned scanpath.Waypoint) bool {
+	if wp.ID != "" || planned.ID != "" {
+		return wp.ID == planned.ID
+	}
+	return wp.Index == index && wp.X == planned.X && wp.Z == planned.Z
+}
+
+// progress returns the waypoint as the progress it was first reported with, for the waypoint it matches
+// Only the sensor position of the reading is kept, enough to recover the beam direction of the point.
+func (wp SessionWaypoint) progress(index int, planned scanpath.Waypoint, total int) ScanProgress {
+	progress := ScanProgress{
+		Label:         wp.Label,
+		Index:         index,
+		Total:         total,
+		Waypoint:      planned,
+		Replayed:      true,
+		Status:        wp.Status,
+		Detail:        wp.Detail,
+		Point:         wp.Point,
+		Spread:        wp.Spread,
+		PositionError: wp.PositionError,
+	}
+	if wp.Status == WaypointOK {
+		progress.Reading = SensorReading{
+			SensorPose:   spatialmath.NewPoseFromPoint(r3.Vector{X: wp.Sensor.X, Y: wp.Sensor.Y, Z: wp.Sensor.Z}),
+			SurfacePoint: wp.Point,
+		}
+	}
+	return progress
+}
+
+// SaveSession writes the session to path, replacing it atomically
+// The file is JSON Lines: the session header, then one line per waypoint, so AppendSession can add a waypoint
+// without rewriting the ones before it.
+func SaveSession(path string, session *ScanSession) error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	if err := encoder.Encode(session.header()); err != nil {
+		return fmt.Errorf("failed to encode calibration session: %w", err)
+	}
+	for _, wp := range session.Waypoints {
+		if err := encoder.Encode(wp); err != nil {
+			return fmt.Errorf("failed to encode calibration session: %w", err)
+		}
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// AppendSession adds a waypoint to a session saved by SaveSession
+func AppendSession(path string, wp SessionWaypoint) error {
+	data, err := json.Marshal(wp)
+	if err != nil {
+		return fmt.Errorf("failed to encode calibration session waypoint: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open calibration session: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to calibration session %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+// sessionHeader is the first line of a saved session, the session without its waypoints
+type sessionHeader struct {
+	Started   time.Time `json:"started"`
+	Target    string    `json:"target,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Config    string    `json:"config,omitempty"`
+	Frame     string    `json:"frame,omitempty"`
+}
+
+// header returns the session's header line
+// The caller must hold s.mu
+func (s *ScanSession) header() sessionHeader {
+	return sessionHeader{Started: s.Started, Target: s.Target, SessionID: s.SessionID, Config: s.Config, Frame: s.Frame}
+}
+
+// LoadSession reads a session saved by SaveSession and AppendSession
+// A waypoint line cut short by a crash mid-append is dropped. A missing file returns an error wrapping
+// os.ErrNotExist.
+func LoadSession(path string) (*ScanSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calibration session: %w", err)
+	}
+	// Sessions saved before they were appended to are a single JSON object
+	var legacy ScanSession
+	if err := json.Unmarshal(data, &legacy); err == nil && legacy.Waypoints != nil {
+		return &legacy, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("failed to decode calibration session %s: empty file", path)
+	}
+	var header sessionHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("failed to decode calibration session %s: %w", path, err)
+	}
+	session := &ScanSession{
+		Started:   header.Started,
+		Target:    header.Target,
+		SessionID: header.SessionID,
+		Config:    header.Config,
+		Frame:     header.Frame,
+		Waypoints: []SessionWaypoint{},
+	}
+	for line := 2; scanner.Scan(); line++ {
+		var wp SessionWaypoint
+		if err := json.Unmarshal(scanner.Bytes(), &wp); err != nil {
+			torn := !bytes.HasSuffix(data, []byte("\n")) && line == bytes.Count(data, []byte("\n"))+1
+			if !torn {
+				return nil, fmt.Errorf("failed to decode calibration session %s line %d: %w", path, line, err)
+			}
+			break // the last append didn't finish
+		}
+		session.Waypoints = append(session.Waypoints, wp)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calibration session %s: %w", path, err)
+	}
+	return session, nil
+}
@@ -0,0 +1,87 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// SoftLimits keep a tool from being jogged into a calibrated monitor
+// The limit is a plane parallel to the calibrated one, Margin in front of the frontmost glass: bowed glass and the
+// edges of a curved monitor stand proud of the fitted plane by up to Bulge. The tool tip is ToolLength along the
+// pose's orientation vector, so a pose is limited by where its tip is.
+type SoftLimits struct {
+	Margin     float64 // mm - closest the tool tip may come to the glass
+	ToolLength float64 // mm - from the pose to the tool tip
+	Bulge      float64 // mm - how far the glass may stand in front of the calibrated plane
+
+	center r3.Vector
+	normal r3.Vector // unit plane normal pointing towards the viewer
+}
+
+// NewSoftLimits builds the soft limits around the monitor calibrated as result
+// front is any point on the viewer's side of the glass, such as the tool tip before jogging. The monitor frame's
+// normal points out of the glass by convention, but the limits don't rely on it for which side is safe.
+func NewSoftLimits(result CalibrationResult, front r3.Vector, margin, toolLength float64) (*SoftLimits, error) {
+	if margin < 0 || toolLength < 0 {
+		return nil, fmt.Errorf("soft limit margin and tool length must not be negative, got %.1f and %.1f mm", margin, toolLength)
+	}
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build monitor geometry: %w", err)
+	}
+	normal := geometry.LocalY
+	if front.Sub(geometry.Center).Dot(normal) < 0 {
+		normal = normal.Mul(-1)
+	}
+
+	// The fitted deviation surface extrapolates poorly past sparse scan lines, so rely on the raw residuals instead:
+	// they span both sides of the plane, so no glass sample stood further in front of it than their range
+	bulge := result.Deviation.ResidualPeakToValley
+	if c := result.Cylinder; c != nil && c.Radius > 0 {
+		// The glass curves towards the viewer, so its edges stand in front of the tangent plane by the sagitta
+		halfArc := math.Max(math.Abs(c.ArcMin), math.Abs(c.ArcMax))
+		bulge += c.Radius * (1 - math.Cos(math.Min(halfArc/c.Radius, math.Pi/2)))
+	}
+	return &SoftLimits{Margin: margin, ToolLength: toolLength, Bulge: bulge, center: geometry.Center, normal: normal}, nil
+}
+
+// Clearance returns the distance of a point in front of the frontmost glass, negative behind it
+func (l *SoftLimits) Clearance(p r3.Vector) float64 {
+	return p.Sub(l.center).Dot(l.normal) - l.Bulge
+}
+
+// ToolTip returns the tool tip of a pose
+func (l *SoftLimits) ToolTip(pose spatialmath.Pose) r3.Vector {
+	direction := pose.Orientation().OrientationVectorRadians().Vector().Normalize()
+	return pose.Point().Add(direction.Mul(l.ToolLength))
+}
+
+// Clamp limits a jog of a point from from to to, returning where the jog may end and whether it was limited
+// A limited jog is pushed straight back out along the normal, so motion along the glass is kept and an
+// operator can still slide the tool across the screen at the margin. A point already inside the margin may move
+// along or away from the glass but no closer.
+func (l *SoftLimits) Clamp(from, to r3.Vector) (r3.Vector, bool) {
+	floor := l.Margin
+	if current := l.Clearance(from); current < floor {
+		floor = current
+	}
+	clearance := l.Clearance(to)
+	if clearance >= floor {
+		return to, false
+	}
+	return to.Add(l.normal.Mul(floor - clearance)), true
+}
+
+// ClampPose limits a jog from the current pose to target by where their tool tips are, see Clamp
+// The target's orientation is kept; only its position is pushed back.
+func (l *SoftLimits) ClampPose(current, target spatialmath.Pose) (spatialmath.Pose, bool) {
+	tip := l.ToolTip(target)
+	clamped, limited := l.Clamp(l.ToolTip(current), tip)
+	if !limited {
+		return target, false
+	}
+	return spatialmath.NewPose(target.Point().Add(clamped.Sub(tip)), target.Orientation()), true
+}
@@ -0,0 +1,214 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// CombinedComponentName is the default name of the component carrying the combined frame of stitched monitors
+const CombinedComponentName = "combined-monitors"
+
+// StitchedMonitor is one calibrated monitor of a stitched pair
+type StitchedMonitor struct {
+	Name   string                 `json:"name"`
+	Pose   map[string]interface{} `json:"pose"` // monitor frame in the reference frame
+	Width  float64                `json:"width_mm"`
+	Height float64                `json:"height_mm"`
+
+	geometry monitorGeometry
+}
+
+// StitchBounds is the combined frame of a stitched pair and the box covering both screens in it
+// The frame faces out of both screens, along the mean of their normals, with Z up their mean height direction and
+// X across them, like a monitor frame. Depth is how far an angled pair reaches along the normal.
+type StitchBounds struct {
+	Pose   map[string]interface{} `json:"pose"` // combined frame in the reference frame, at the center of the box
+	Width  float64                `json:"width_mm"`
+	Height float64                `json:"height_mm"`
+	Depth  float64                `json:"depth_mm"`
+
+	geometry monitorGeometry
+}
+
+// StitchResult relates two monitors calibrated in the same frame, as on a dual-monitor stand
+type StitchResult struct {
+	Frame    string            `json:"frame"`
+	Monitors []StitchedMonitor `json:"monitors"`
+	Bounds   StitchBounds      `json:"bounds"`
+
+	// RelativePose is the second monitor's frame in the first's
+	RelativePose   map[string]interface{} `json:"relative_pose"`
+	CenterDistance float64                `json:"center_distance_mm"`
+	// Gap is the space between the facing side edges across the combined frame, negative where the screens overlap
+	Gap            float64 `json:"gap_mm"`
+	NormalAngle    float64 `json:"normal_angle_deg"`   // angle between the screens' normals, the fold of an angled pair
+	VerticalOffset float64 `json:"vertical_offset_mm"` // height of the second center over the first, along the combined Z
+}
+
+// ToMap converts the stitch result to a map of JSON values, suitable for returning from DoCommand
+func (r StitchResult) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// StitchMonitors combines the calibrations of two monitors into one frame covering both, and the transform between
+// them. Both must be in the same reference frame.
+func StitchMonitors(firstName string, first CalibrationResult, secondName string, second CalibrationResult) (StitchResult, error) {
+	if first.Frame != "" && second.Frame != "" && first.Frame != second.Frame {
+		return StitchResult{}, fmt.Errorf("cannot stitch calibrations in the %q and %q frames", first.Frame, second.Frame)
+	}
+	frame := first.Frame
+	if frame == "" {
+		frame = second.Frame
+	}
+	a, err := monitorGeometryFromResult(first)
+	if err != nil {
+		return StitchResult{}, fmt.Errorf("invalid calibration of %s: %w", firstName, err)
+	}
+	b, err := monitorGeometryFromResult(second)
+	if err != nil {
+		return StitchResult{}, fmt.Errorf("invalid calibration of %s: %w", secondName, err)
+	}
+	aPose, err := a.pose()
+	if err != nil {
+		return StitchResult{}, err
+	}
+	bPose, err := b.pose()
+	if err != nil {
+		return StitchResult{}, err
+	}
+
+	// The combined axes average the screens', so neither is favored
+	normal := a.LocalY.Add(b.LocalY)
+	if normal.Norm() < 1e-9 {
+		return StitchResult{}, fmt.Errorf("monitors %s and %s face opposite ways", firstName, secondName)
+	}
+	normal = normal.Normalize()
+	up := a.LocalZ.Add(b.LocalZ)
+	up = up.Sub(normal.Mul(up.Dot(normal)))
+	if up.Norm() < 1e-9 {
+		return StitchResult{}, fmt.Errorf("monitors %s and %s have no common up direction", firstName, secondName)
+	}
+	up = up.Normalize()
+	combined := monitorGeometry{LocalX: normal.Cross(up), LocalY: normal, LocalZ: up}
+
+	// Bound the corners of both screens in the combined axes, from the midpoint of their centers
+	origin := a.Center.Add(b.Center).Mul(0.5)
+	var extents [2][3][2]float64 // per monitor, per combined axis: min and max
+	for i, g := range []monitorGeometry{a, b} {
+		for j, corner := range g.corners() {
+			d := corner.Sub(origin)
+			for k, axis := range []r3.Vector{combined.LocalX, combined.LocalY, combined.LocalZ} {
+				value := d.Dot(axis)
+				if j == 0 || value < extents[i][k][0] {
+					extents[i][k][0] = value
+				}
+				if j == 0 || value > extents[i][k][1] {
+					extents[i][k][1] = value
+				}
+			}
+		}
+	}
+	var low, high [3]float64
+	for k := range low {
+		low[k] = math.Min(extents[0][k][0], extents[1][k][0])
+		high[k] = math.Max(extents[0][k][1], extents[1][k][1])
+	}
+	combined.Center = origin.Add(combined.LocalX.Mul((low[0] + high[0]) / 2)).
+		Add(combined.LocalY.Mul((low[1] + high[1]) / 2)).
+		Add(combined.LocalZ.Mul((low[2] + high[2]) / 2))
+	combined.Width, combined.Height = high[0]-low[0], high[2]-low[2]
+	combinedPose, err := combined.pose()
+	if err != nil {
+		return StitchResult{}, err
+	}
+
+	// The screens' spans across the combined frame either leave a gap between them or overlap
+	gap := math.Max(extents[1][0][0]-extents[0][0][1], extents[0][0][0]-extents[1][0][1])
+	cos := a.LocalY.Dot(b.LocalY)
+	return StitchResult{
+		Frame: frame,
+		Monitors: []StitchedMonitor{
+			{Name: firstName, Pose: PoseToMap(aPose), Width: a.Width, Height: a.Height, geometry: a},
+			{Name: secondName, Pose: PoseToMap(bPose), Width: b.Width, Height: b.Height, geometry: b},
+		},
+		Bounds: StitchBounds{
+			Pose:     PoseToMap(combinedPose),
+			Width:    combined.Width,
+			Height:   combined.Height,
+			Depth:    high[1] - low[1],
+			geometry: combined,
+		},
+		RelativePose:   PoseToMap(spatialmath.PoseBetween(aPose, bPose)),
+		CenterDistance: b.Center.Sub(a.Center).Norm(),
+		Gap:            gap,
+		NormalAngle:    utils.RadToDeg(math.Acos(math.Max(-1, math.Min(1, cos)))),
+		VerticalOffset: b.Center.Sub(a.Center).Dot(up),
+	}, nil
+}
+
+// GenerateStitchedFragment builds a Viam fragment holding the combined frame of a stitched pair and both monitors
+// The combined component's frame is attached to config.Parent, the stitch's frame if empty, and carries the box
+// covering both screens. Each monitor is a component named after it whose frame is attached to the combined frame,
+// so moving the pair only takes editing one frame. config.ComponentName names the combined component,
+// CombinedComponentName if empty, and config.Thickness is the depth of the monitor boxes.
+func GenerateStitchedFragment(stitch StitchResult, config FragmentConfig) (map[string]interface{}, error) {
+	name := config.ComponentName
+	if name == "" {
+		name = CombinedComponentName
+	}
+	parent := config.Parent
+	if parent == "" {
+		parent = stitch.Frame
+	}
+	if parent == "" {
+		return nil, fmt.Errorf("stitched calibration has no frame, set a parent frame")
+	}
+	if config.Thickness <= 0 {
+		return nil, fmt.Errorf("monitor thickness must be positive")
+	}
+	if len(stitch.Monitors) != 2 {
+		return nil, fmt.Errorf("stitched calibration has %d monitors, expected 2", len(stitch.Monitors))
+	}
+
+	combined := stitch.Bounds.geometry
+	combinedPose, err := combined.pose()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build combined frame: %w", err)
+	}
+	component, err := monitorComponent(combined, name, parent, math.Max(stitch.Bounds.Depth, config.Thickness))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build combined frame: %w", err)
+	}
+	components := []interface{}{component}
+	toCombined := spatialmath.PoseInverse(combinedPose)
+	for _, monitor := range stitch.Monitors {
+		if monitor.Name == "" || monitor.Name == name {
+			return nil, fmt.Errorf("monitor component name %q must be set and differ from the combined frame's", monitor.Name)
+		}
+		component, err := monitorComponent(monitor.geometry.transform(toCombined), monitor.Name, name, config.Thickness)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build frame of %s: %w", monitor.Name, err)
+		}
+		components = append(components, component)
+	}
+	return map[string]interface{}{"components": components}, nil
+}
+
+// pose returns the monitor frame in the reference frame
+func (g monitorGeometry) pose() (spatialmath.Pose, error) {
+	rotation, err := g.orientation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rotation matrix: %w", err)
+	}
+	return spatialmath.NewPose(g.Center, rotation), nil
+}
+
+// corners returns the four corners of the monitor rectangle in the reference frame
+func (g monitorGeometry) corners() []r3.Vector {
+	w, h := g.Width/2, g.Height/2
+	return []r3.Vector{g.toWorld(-w, h, 0), g.toWorld(w, h, 0), g.toWorld(w, -h, 0), g.toWorld(-w, -h, 0)}
+}
@@ -0,0 +1,187 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Quality grades, from best to worst
+const (
+	GradeA = "A"
+	GradeB = "B"
+	GradeC = "C"
+	GradeD = "D"
+)
+
+// gradeLimit is the worst plane uncertainty, residual and scan coverage a grade allows
+type gradeLimit struct {
+	grade        string
+	normalStdDev float64 // degrees
+	residualRMS  float64 // mm
+	coverage     float64 // share of waypoints that produced a point
+}
+
+// gradeLimits are checked in order, a result gets the first grade whose limits it meets
+var gradeLimits = []gradeLimit{
+	{grade: GradeA, normalStdDev: 0.1, residualRMS: 1, coverage: 0.9},
+	{grade: GradeB, normalStdDev: 0.25, residualRMS: 2, coverage: 0.75},
+	{grade: GradeC, normalStdDev: 0.5, residualRMS: 4, coverage: 0.5},
+}
+
+// ResultSummary is a short human-readable account of a calibration, for maintenance tickets
+type ResultSummary struct {
+	Timestamp time.Time
+	Frame     string
+	Grade     string
+	Center    Point3D
+	Width     float64 // mm
+	Height    float64 // mm
+	ArcWidth  float64 // mm - width along the glass of a curved monitor, zero for flat ones
+	Radius    float64 // mm - curve radius, zero for flat monitors
+
+	// Tilt of the monitor frame in degrees: Pitch leans the screen to face up, Yaw turns its normal from
+	// the frame's +Y towards +X, and Roll turns the width axis up from horizontal
+	Pitch, Yaw, Roll float64
+
+	Flatness     float64 // mm - peak-to-valley, zero when no deviation surface was fit
+	NormalStdDev float64 // degrees
+	OffsetStdDev float64 // mm
+	ResidualRMS  float64 // mm
+	Samples      int
+	Coverage     float64 // share of waypoints that produced a point, negative when unknown
+	Advice       string  // from the error budget, if any
+	Coarse       bool
+}
+
+// Summarize builds the summary of a calibration result
+func Summarize(result CalibrationResult) (ResultSummary, error) {
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return ResultSummary{}, fmt.Errorf("failed to summarize calibration: %w", err)
+	}
+	degrees := func(radians float64) float64 { return radians * 180 / math.Pi }
+	cov := result.PlaneUncertainty
+	summary := ResultSummary{
+		Timestamp:    result.Timestamp,
+		Frame:        result.Frame,
+		Center:       Point3D{X: geometry.Center.X, Y: geometry.Center.Y, Z: geometry.Center.Z},
+		Width:        math.Abs(geometry.Width),
+		Height:       math.Abs(geometry.Height),
+		Pitch:        degrees(math.Asin(min(1, max(-1, geometry.LocalY.Z)))),
+		Yaw:          degrees(math.Atan2(geometry.LocalY.X, geometry.LocalY.Y)),
+		Roll:         degrees(math.Asin(min(1, max(-1, geometry.LocalX.Z)))),
+		Flatness:     result.Deviation.PeakToValley,
+		NormalStdDev: cov.NormalStdDev,
+		OffsetStdDev: cov.OffsetStdDev,
+		ResidualRMS:  cov.ResidualRMS,
+		Samples:      cov.Samples,
+		Coverage:     -1,
+		Coarse:       result.Coarse,
+	}
+	if result.Cylinder != nil {
+		summary.ArcWidth = result.Cylinder.ArcMax - result.Cylinder.ArcMin
+		summary.Radius = result.Cylinder.Radius
+	}
+	if result.Scan != nil && result.Scan.Waypoints > 0 {
+		summary.Coverage = result.Scan.Coverage
+	}
+	if result.ErrorBudget != nil {
+		summary.Advice = result.ErrorBudget.Advice
+	}
+	summary.Grade = summary.grade()
+	return summary, nil
+}
+
+// grade rates the summary against gradeLimits
+// Coarse vision results have no scan to judge, so they are never graded above C
+func (s ResultSummary) grade() string {
+	for _, limit := range gradeLimits {
+		if s.Coarse && limit.grade != GradeC {
+			continue
+		}
+		if s.Samples == 0 || s.NormalStdDev > limit.normalStdDev || s.ResidualRMS > limit.residualRMS {
+			continue
+		}
+		if s.Coverage >= 0 && s.Coverage < limit.coverage {
+			continue
+		}
+		return limit.grade
+	}
+	return GradeD
+}
+
+// lines returns the summary as label and value pairs, shared by both formats
+func (s ResultSummary) lines() [][2]string {
+	size := fmt.Sprintf("%.1f x %.1f mm", s.Width, s.Height)
+	if s.Radius > 0 {
+		size += fmt.Sprintf(" (%.1f mm along the glass, curve radius %.0f mm)", s.ArcWidth, s.Radius)
+	}
+	quality := fmt.Sprintf("%s - normal ±%.3f°, offset ±%.2f mm, residual RMS %.2f mm from %d samples",
+		s.Grade, s.NormalStdDev, s.OffsetStdDev, s.ResidualRMS, s.Samples)
+	if s.Coverage >= 0 {
+		quality += fmt.Sprintf(", %.0f%% scan coverage", 100*s.Coverage)
+	}
+	if s.Coarse {
+		quality += ", coarse vision estimate"
+	}
+	lines := [][2]string{
+		{"Quality", quality},
+		{"Size", size},
+		{"Center", fmt.Sprintf("(%.1f, %.1f, %.1f) mm in %s, %s", s.Center.X, s.Center.Y, s.Center.Z, s.Frame, ConventionViam)},
+		{"Tilt", fmt.Sprintf("pitch %.2f°, yaw %.2f°, roll %.2f°", s.Pitch, s.Yaw, s.Roll)},
+	}
+	if s.Flatness > 0 {
+		lines = append(lines, [2]string{"Flatness", fmt.Sprintf("%.2f mm peak-to-valley", s.Flatness)})
+	}
+	if s.Advice != "" {
+		lines = append(lines, [2]string{"Advice", s.Advice})
+	}
+	return lines
+}
+
+// title heads both formats
+func (s ResultSummary) title() string {
+	return "Monitor calibration " + s.Timestamp.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// Text renders the summary as plain text
+func (s ResultSummary) Text() string {
+	var b strings.Builder
+	b.WriteString(s.title() + "\n")
+	for _, line := range s.lines() {
+		fmt.Fprintf(&b, "%-9s %s\n", line[0]+":", line[1])
+	}
+	return b.String()
+}
+
+// Markdown renders the summary as a Markdown heading and table
+func (s ResultSummary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n| | |\n|---|---|\n", s.title())
+	for _, line := range s.lines() {
+		fmt.Fprintf(&b, "| **%s** | %s |\n", line[0], strings.ReplaceAll(line[1], "|", "\\|"))
+	}
+	return b.String()
+}
+
+// SummaryPaths returns where the text and Markdown summaries of a result saved at path are written
+func SummaryPaths(path string) (string, string) {
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	return stem + ".summary.txt", stem + ".summary.md"
+}
+
+// writeSummaries writes the text and Markdown summaries next to a result saved at path
+func writeSummaries(path string, result CalibrationResult) error {
+	summary, err := Summarize(result)
+	if err != nil {
+		return err
+	}
+	textPath, markdownPath := SummaryPaths(path)
+	if err := writeFileAtomic(textPath, []byte(summary.Text())); err != nil {
+		return err
+	}
+	return writeFileAtomic(markdownPath, []byte(summary.Markdown()))
+}
@@ -0,0 +1,49 @@
+package calibrationhelpers
+
+import "fmt"
+
+// Millimeters is a length in mm, the unit of every position, distance and tolerance inside the calibration
+type Millimeters float64
+
+// Meters is a length in meters, the unit Viam distance sensors report
+// Sensor distances are Meters up to the reading boundary and converted once there, so a bare float64 of the wrong
+// unit can't slip through as a depth 1000 times off.
+type Meters float64
+
+// Millimeters converts the length to mm
+func (m Meters) Millimeters() Millimeters {
+	return Millimeters(m * 1000)
+}
+
+// Meters converts the length to meters
+func (mm Millimeters) Meters() Meters {
+	return Meters(mm / 1000)
+}
+
+// Units of a sensor's distance readings
+const (
+	UnitsMeters      = "m"
+	UnitsMillimeters = "mm"
+)
+
+// ToMeters converts a reading in the given units, "" for UnitsMeters, to meters
+func ToMeters(value float64, units string) (Meters, error) {
+	switch units {
+	case "", UnitsMeters:
+		return Meters(value), nil
+	case UnitsMillimeters:
+		return Millimeters(value).Meters(), nil
+	}
+	return 0, fmt.Errorf("unknown units %q, expected %s or %s", units, UnitsMeters, UnitsMillimeters)
+}
+
+// FromMeters converts a distance to the given units, "" for UnitsMeters
+func FromMeters(distance Meters, units string) (float64, error) {
+	switch units {
+	case "", UnitsMeters:
+		return float64(distance), nil
+	case UnitsMillimeters:
+		return float64(distance.Millimeters()), nil
+	}
+	return 0, fmt.Errorf("unknown units %q, expected %s or %s", units, UnitsMeters, UnitsMillimeters)
+}
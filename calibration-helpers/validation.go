@@ -0,0 +1,151 @@
+package calibrationhelpers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"go.viam.com/rdk/utils"
+)
+
+// Remediation hints attached to failed validation checks, stable for use by alerting pipelines
+const (
+	// HintIncreaseScanDensity means the plane is under-determined: collect more or more widely spread points
+	HintIncreaseScanDensity = "increase_scan_density"
+	// HintCheckSensorMounting means readings scatter more than the sensor's noise explains
+	HintCheckSensorMounting = "check_sensor_mounting"
+	// HintInspectMonitorSurface means the glass deviates from a plane, e.g. a warped panel or debris
+	HintInspectMonitorSurface = "inspect_monitor_surface"
+	// HintMonitorMoved means the monitor is far from where the previous calibration put it
+	HintMonitorMoved = "monitor_moved"
+)
+
+// AlertConfig holds the limits a calibration result is validated against, zero disables a check
+type AlertConfig struct {
+	MinSamples      int     // minimum number of points in the plane fit
+	MinScanSpan     float64 // min share of the detected width and height the scan points must span, 0 to 1
+	MaxNormalStdDev float64 // degrees - max 1-sigma uncertainty of the plane normal
+	MaxOffsetStdDev float64 // mm - max 1-sigma uncertainty of the plane offset
+	MaxResidualRMS  float64 // mm - max RMS distance of the samples from the plane
+	MaxFlatness     float64 // mm - max peak-to-valley of the deviation surface
+	MaxShift        float64 // mm - max movement of the monitor center since the previous calibration
+	MaxTilt         float64 // degrees - max rotation of the monitor normal since the previous calibration
+}
+
+// ValidationFailure describes one metric outside its limit and what to do about it
+type ValidationFailure struct {
+	Metric  string  `json:"metric"`
+	Value   float64 `json:"value"`
+	Limit   float64 `json:"limit"`
+	Hint    string  `json:"hint"`    // one of the Hint* constants
+	Message string  `json:"message"` // human readable remediation
+}
+
+func (f ValidationFailure) String() string {
+	return fmt.Sprintf("%s %.3g outside limit %.3g [%s: %s]", f.Metric, f.Value, f.Limit, f.Hint, f.Message)
+}
+
+// ValidationError is returned when a calibration result fails one or more alert checks
+type ValidationError struct {
+	Failures []ValidationFailure
+}
+
+func (e *ValidationError) Error() string {
+	failures := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		failures[i] = f.String()
+	}
+	return "calibration failed validation: " + strings.Join(failures, "; ")
+}
+
+// Is reports a failed sample count check as ErrInsufficientSamples
+func (e *ValidationError) Is(target error) bool {
+	if target != ErrInsufficientSamples {
+		return false
+	}
+	for _, f := range e.Failures {
+		if f.Metric == "samples" {
+			return true
+		}
+	}
+	return false
+}
+
+// Hints returns the distinct remediation hints, in the order the checks failed
+func (e *ValidationError) Hints() []string {
+	var hints []string
+	seen := map[string]bool{}
+	for _, f := range e.Failures {
+		if !seen[f.Hint] {
+			seen[f.Hint] = true
+			hints = append(hints, f.Hint)
+		}
+	}
+	return hints
+}
+
+// ValidateResult checks the result against the alert limits
+// previous is the last result for the same monitor, nil to skip the movement checks
+// Returns a *ValidationError listing every failed check, or nil if the result passes
+func ValidateResult(result CalibrationResult, previous *CalibrationResult, alerts AlertConfig) error {
+	var failures []ValidationFailure
+	fail := func(metric string, value, limit float64, hint, message string) {
+		failures = append(failures, ValidationFailure{Metric: metric, Value: value, Limit: limit, Hint: hint, Message: message})
+	}
+
+	cov := result.PlaneUncertainty
+	if alerts.MinSamples > 0 && cov.Samples < alerts.MinSamples {
+		fail("samples", float64(cov.Samples), float64(alerts.MinSamples), HintIncreaseScanDensity,
+			"too few points landed on the monitor, increase the scan steps or widen the scan region")
+	}
+	if alerts.MinScanSpan > 0 && result.Scan != nil {
+		if span := math.Min(result.Scan.WidthSpan, result.Scan.HeightSpan); span < alerts.MinScanSpan {
+			fail("scan_span", span, alerts.MinScanSpan, HintIncreaseScanDensity,
+				fmt.Sprintf("the scan points span %.0f%% of the width and %.0f%% of the height, widen the scan region",
+					100*result.Scan.WidthSpan, 100*result.Scan.HeightSpan))
+		}
+	}
+	if alerts.MaxNormalStdDev > 0 && cov.NormalStdDev > alerts.MaxNormalStdDev {
+		fail("normal_std_dev", cov.NormalStdDev, alerts.MaxNormalStdDev, HintIncreaseScanDensity,
+			"the plane orientation is uncertain, scan more points spread across the whole screen")
+	}
+	if alerts.MaxOffsetStdDev > 0 && cov.OffsetStdDev > alerts.MaxOffsetStdDev {
+		fail("offset_std_dev", cov.OffsetStdDev, alerts.MaxOffsetStdDev, HintIncreaseScanDensity,
+			"the plane position is uncertain, scan more points")
+	}
+	if alerts.MaxResidualRMS > 0 && cov.ResidualRMS > alerts.MaxResidualRMS {
+		fail("residual_rms", cov.ResidualRMS, alerts.MaxResidualRMS, HintCheckSensorMounting,
+			"readings scatter more than expected, check the sensor is rigidly mounted and facing the screen")
+	}
+	if alerts.MaxFlatness > 0 && result.Deviation.PeakToValley > alerts.MaxFlatness {
+		fail("flatness", result.Deviation.PeakToValley, alerts.MaxFlatness, HintInspectMonitorSurface,
+			"the screen is not flat, check for a warped panel, a loose bezel or debris on the glass")
+	}
+
+	if previous != nil && (alerts.MaxShift > 0 || alerts.MaxTilt > 0) {
+		current, err := monitorGeometryFromResult(result)
+		if err != nil {
+			return err
+		}
+		before, err := monitorGeometryFromResult(*previous)
+		if err != nil {
+			return fmt.Errorf("failed to compare with the previous calibration: %w", err)
+		}
+
+		shift := current.Center.Distance(before.Center)
+		if alerts.MaxShift > 0 && shift > alerts.MaxShift {
+			fail("shift", shift, alerts.MaxShift, HintMonitorMoved,
+				fmt.Sprintf("monitor likely moved >%.0fmm since the previous calibration, check its mount", alerts.MaxShift))
+		}
+		tilt := utils.RadToDeg(math.Acos(math.Min(1, math.Abs(current.LocalY.Dot(before.LocalY)))))
+		if alerts.MaxTilt > 0 && tilt > alerts.MaxTilt {
+			fail("tilt", tilt, alerts.MaxTilt, HintMonitorMoved,
+				fmt.Sprintf("monitor likely tilted >%.1f° since the previous calibration, check its mount", alerts.MaxTilt))
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ValidationError{Failures: failures}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package calibrationhelpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+// VisionConfig describes the AprilTags taped to the monitor corners for vision calibration
+type VisionConfig struct {
+	TagSize  float64 // mm - printed side length of the tags, excluding the white border
+	TagInset float64 // mm - distance from each tag center to the adjacent monitor edges, zero for TagSize/2
+	MinScore float64 // detections scoring below this are ignored
+
+	// Detection labels of the tag at each corner, as reported by the tag detector
+	TopLeft     string
+	TopRight    string
+	BottomRight string
+	BottomLeft  string
+}
+
+// inset returns the distance from each tag center to the monitor edges
+func (c VisionConfig) inset() float64 {
+	if c.TagInset > 0 {
+		return c.TagInset
+	}
+	return c.TagSize / 2
+}
+
+// CalibrateFromTags locates the monitor from AprilTags at its corners, seen by a camera through a tag detector
+// The tag distances come from their apparent size, so the result is coarse: good enough to seed an ultrasonic
+// calibration, which refines it. cameraPose is the camera's pose in frame, where the result is expressed.
+func CalibrateFromTags(ctx context.Context, logger logging.Logger, cam camera.Camera, detector vision.Service,
+	cameraPose spatialmath.Pose, frame string, config VisionConfig) (CalibrationResult, error) {
+	if config.TagSize <= 0 {
+		return CalibrationResult{}, fmt.Errorf("tag size must be positive")
+	}
+	props, err := cam.Properties(ctx)
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to get camera properties: %w", err)
+	}
+	intrinsics := props.IntrinsicParams
+	if intrinsics == nil || intrinsics.Fx <= 0 || intrinsics.Fy <= 0 {
+		return CalibrationResult{}, fmt.Errorf("camera %s has no intrinsic parameters", cam.Name().ShortName())
+	}
+
+	detections, err := detector.DetectionsFromCamera(ctx, cam.Name().ShortName(), nil)
+	if err != nil {
+		return CalibrationResult{}, fmt.Errorf("failed to detect tags: %w", err)
+	}
+
+	// Tag centers in the reference frame, by corner
+	labels := map[string]string{
+		"top_left":     config.TopLeft,
+		"top_right":    config.TopRight,
+		"bottom_right": config.BottomRight,
+		"bottom_left":  config.BottomLeft,
+	}
+	tags := map[string]r3.Vector{}
+	for corner, label := range labels {
+		best := bestDetection(detections, label, config.MinScore)
+		if best == nil {
+			logger.Warnf("Tag %q at the %s corner was not detected", label, corner)
+			continue
+		}
+		box := best.BoundingBox()
+		if box.Dx() <= 0 || box.Dy() <= 0 {
+			continue
+		}
+		// A tilted tag shrinks along one image axis only, so the larger extent gives the better distance
+		depth := config.TagSize / math.Max(float64(box.Dx())/intrinsics.Fx, float64(box.Dy())/intrinsics.Fy)
+		u := float64(box.Min.X+box.Max.X) / 2
+		v := float64(box.Min.Y+box.Max.Y) / 2
+		x, y, z := intrinsics.PixelToPoint(u, v, depth)
+		tags[corner] = spatialmath.Compose(cameraPose, spatialmath.NewPoseFromPoint(r3.Vector{X: x, Y: y, Z: z})).Point()
+		logger.Debugf("Tag %q (%s) at %.0f mm from the camera, score %.2f", label, corner, depth, best.Score())
+	}
+
+	// The tags form a parallelogram, so a single missing corner can be inferred from the other three
+	if len(tags) == 3 {
+		opposite := map[string][3]string{
+			"top_left":     {"top_right", "bottom_left", "bottom_right"},
+			"top_right":    {"top_left", "bottom_right", "bottom_left"},
+			"bottom_right": {"top_right", "bottom_left", "top_left"},
+			"bottom_left":  {"top_left", "bottom_right", "top_right"},
+		}
+		for corner, others := range opposite {
+			if _, ok := tags[corner]; !ok {
+				tags[corner] = tags[others[0]].Add(tags[others[1]]).Sub(tags[others[2]])
+				logger.Infof("Inferred the %s tag from the other three", corner)
+			}
+		}
+	}
+	if len(tags) < 4 {
+		return CalibrationResult{}, fmt.Errorf("found %d of the 4 corner tags, at least 3 are needed", len(tags))
+	}
+
+	geometry, err := geometryFromTags(tags["top_left"], tags["top_right"], tags["bottom_right"], tags["bottom_left"], config.inset())
+	if err != nil {
+		return CalibrationResult{}, err
+	}
+	result := resultFromGeometry(geometry)
+	result.Timestamp = time.Now().UTC()
+	result.Frame = frame
+	result.Coarse = true
+	return result, nil
+}
+
+// bestDetection returns the highest scoring detection with the label, nil if there is none above minScore
+func bestDetection(detections []objectdetection.Detection, label string, minScore float64) objectdetection.Detection {
+	var best objectdetection.Detection
+	for _, d := range detections {
+		if d.Label() != label || d.Score() < minScore || d.BoundingBox() == nil {
+			continue
+		}
+		if best == nil || d.Score() > best.Score() {
+			best = d
+		}
+	}
+	return best
+}
+
+// geometryFromTags builds the monitor geometry from the tag centers, which sit inset from each corner
+// Left is towards +X and the normal is Z x X, so a camera facing the screen sees the outward normal
+func geometryFromTags(topLeft, topRight, bottomRight, bottomLeft r3.Vector, inset float64) (monitorGeometry, error) {
+	left := topLeft.Add(bottomLeft).Mul(0.5)
+	right := topRight.Add(bottomRight).Mul(0.5)
+	top := topLeft.Add(topRight).Mul(0.5)
+	bottom := bottomLeft.Add(bottomRight).Mul(0.5)
+
+	across, up := left.Sub(right), top.Sub(bottom)
+	if across.Norm() < 1e-6 || up.Norm() < 1e-6 || across.Normalize().Cross(up.Normalize()).Norm() < 0.1 {
+		return monitorGeometry{}, fmt.Errorf("corner tags are degenerate, check the tag labels")
+	}
+	normal := up.Cross(across).Normalize()
+	localX, localY, localZ, err := PlaneAxes(Plane{A: normal.X, B: normal.Y, C: normal.Z}, across)
+	if err != nil {
+		return monitorGeometry{}, fmt.Errorf("corner tags are degenerate: %w", err)
+	}
+
+	return monitorGeometry{
+		Center: topLeft.Add(topRight).Add(bottomRight).Add(bottomLeft).Mul(0.25),
+		LocalX: localX,
+		LocalY: localY,
+		LocalZ: localZ,
+		Width:  across.Norm() + 2*inset,
+		Height: up.Dot(localZ) + 2*inset,
+	}, nil
+}
@@ -1,7 +1,9 @@
 package calibrationhelpers
 
 import (
+	"calibration/types"
 	"encoding/json"
+	"fmt"
 	"math"
 
 	"github.com/golang/geo/r3"
@@ -10,101 +12,334 @@ import (
 )
 
 // CalibrationResult holds the final calibration data
-type CalibrationResult struct {
-	Plane         Plane
-	BottomZ       float64
-	TopZ          float64
-	LeftX         float64
-	RightX        float64
-	MonitorWidth  float64
-	MonitorHeight float64
-
-	// 3 Points for orientation calculation
-	XPoint1 Point3D
-	XPoint2 Point3D
-	ZPoint1 Point3D
-}
+//
+// Deprecated: use types.CalibrationResult.
+type CalibrationResult = types.CalibrationResult
 
 // GenerateVisualizationConfig creates a Viam robot config snippet for visualizing the monitor
-// NOTE: does not work with rotations about the Y axis
 func GenerateVisualizationConfig(logger logging.Logger, result CalibrationResult, worldFrame string) map[string]interface{} {
-	// Calculate center of monitor
-	centerX := (result.LeftX + result.RightX) / 2
-	centerZ := (result.BottomZ + result.TopZ) / 2
-	width := result.LeftX - result.RightX
-	height := result.TopZ - result.BottomZ
-
-	// Calculate Y position on the plane at the center
-	// From plane equation: A*x + B*y + C*z = D
-	// Solving for y: y = (D - A*centerX - C*centerZ) / B
-	centerY := (result.Plane.D - result.Plane.A*centerX - result.Plane.C*centerZ) / result.Plane.B
-
-	// Build orientation using the plane normal as Y-axis (perpendicular to monitor surface)
-
-	// Step 1: Calculate normalized plane normal (this becomes localY)
-	normalLength := math.Sqrt(result.Plane.A*result.Plane.A + result.Plane.B*result.Plane.B + result.Plane.C*result.Plane.C)
-	localY := r3.Vector{
-		X: result.Plane.A / normalLength,
-		Y: result.Plane.B / normalLength,
-		Z: result.Plane.C / normalLength,
+	config, err := MonitorVisualization(result, FragmentConfig{Parent: worldFrame, Thickness: 1.0})
+	if err != nil {
+		logger.Errorf("Error building monitor geometry: %v", err)
+		return nil
 	}
+	jsonData, _ := json.MarshalIndent(config, "", "  ")
+	logger.Infof("Generated monitor visualization config:\n%+v", string(jsonData))
+	return config
+}
 
-	// Convert calibration points to r3.Vector
-	xPt1 := r3.Vector{X: result.XPoint1.X, Y: result.XPoint1.Y, Z: result.XPoint1.Z}
-	xPt2 := r3.Vector{X: result.XPoint2.X, Y: result.XPoint2.Y, Z: result.XPoint2.Z}
-
-	// Step 2: Get the direction from XPoint1 to XPoint2 (width direction on monitor)
-	xDir := xPt2.Sub(xPt1).Normalize()
+// MonitorVisualization builds the generic component config carrying the calibrated monitor frame and box, as
+// configured, the component GenerateFragment wraps
+// Defaults are as for GenerateFragment.
+func MonitorVisualization(result CalibrationResult, config FragmentConfig) (map[string]interface{}, error) {
+	name := config.ComponentName
+	if name == "" {
+		name = MonitorComponentName
+	}
+	parent := config.Parent
+	if parent == "" {
+		parent = result.Frame
+	}
+	if parent == "" {
+		return nil, fmt.Errorf("calibration result has no frame, set a parent frame")
+	}
+	if config.Thickness <= 0 {
+		return nil, fmt.Errorf("monitor thickness must be positive")
+	}
 
-	// Step 3: Local Z axis perpendicular to both Y and X direction
-	// Z = xDir × Y (this will be roughly "up" on the monitor)
-	localZ := xDir.Cross(localY).Normalize()
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build monitor frame: %w", err)
+	}
+	if config.AlignAxis.Norm() > 0 {
+		if geometry, _, err = geometry.alignedTo(config.AlignAxis); err != nil {
+			return nil, fmt.Errorf("failed to align monitor frame: %w", err)
+		}
+	}
+	component, err := monitorComponent(geometry, name, parent, config.Thickness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build monitor frame: %w", err)
+	}
+	return component, nil
+}
 
-	// Step 4: Local X axis perpendicular to Y and Z (ensures perfect orthogonality)
-	// X = Y × Z (to stay same direction as xDir)
-	localX := localY.Cross(localZ).Normalize()
+// monitorComponent creates a generic component config whose frame is the calibrated monitor
+// The frame origin is the center of the glass and the box geometry reaches thickness/2 either side of it
+func monitorComponent(geometry monitorGeometry, name, parent string, thickness float64) (map[string]interface{}, error) {
+	center, width, height := geometry.Center, geometry.Width, geometry.Height
 
-	// Convert rotation matrix to quaternion
-	rotMatrix, err := spatialmath.NewRotationMatrix([]float64{
-		localX.X, localX.Y, localX.Z,
-		localY.X, localY.Y, localY.Z,
-		localZ.X, localZ.Y, localZ.Z,
-	})
+	orientation, err := geometry.orientation()
 	if err != nil {
-		logger.Errorf("Error creating rotation matrix: %v", err)
-		return nil
+		return nil, fmt.Errorf("failed to create rotation matrix: %w", err)
 	}
-	quaternion := rotMatrix.Quaternion()
 
-	config := map[string]any{
-		"name":  "calibrated-monitor",
+	return map[string]any{
+		"name":  name,
 		"type":  "generic",
 		"model": "fake",
 		"frame": map[string]any{
-			"parent": worldFrame,
+			"parent": parent,
 			"translation": map[string]any{
-				"x": centerX,
-				"y": centerY,
-				"z": centerZ,
-			},
-			"orientation": map[string]any{
-				"type": "quaternion",
-				"value": map[string]any{
-					"x": quaternion.Imag,
-					"y": quaternion.Jmag,
-					"z": quaternion.Kmag,
-					"w": quaternion.Real,
-				},
+				"x": center.X,
+				"y": center.Y,
+				"z": center.Z,
 			},
+			"orientation": QuaternionConfig(orientation),
 			"geometry": map[string]any{
 				"type": "box",
 				"x":    width,
-				"y":    1.0,
+				"y":    thickness,
 				"z":    height,
 			},
 		},
+	}, nil
+}
+
+// monitorGeometry is the calibrated monitor rectangle in the reference frame
+type monitorGeometry struct {
+	Center                 r3.Vector
+	LocalX, LocalY, LocalZ r3.Vector // width direction, plane normal, height direction
+	Width, Height          float64   // mm
+
+	// Deviation bends the rectangle onto the measured glass, nil for a flat monitor
+	Deviation *DeviationSurface
+	// Curve bends it onto a curved monitor's cylinder, the deviation then measured from the cylinder
+	Curve *Cylinder
+}
+
+// monitorGeometryFromResult derives the monitor rectangle from the calibration result
+// Detected corners outline a monitor rolled in its plane, which the edges measured along world X and Z can't, so
+// they take precedence for flat monitors.
+func monitorGeometryFromResult(result CalibrationResult) (monitorGeometry, error) {
+	if c := result.Corners; c != nil && result.Cylinder == nil {
+		vector := func(p Point3D) r3.Vector { return r3.Vector{X: p.X, Y: p.Y, Z: p.Z} }
+		// The corners are on the fitted plane, so they are the tags of a vision calibration without an inset
+		return geometryFromTags(vector(c.TopLeft), vector(c.TopRight), vector(c.BottomRight), vector(c.BottomLeft), 0)
 	}
-	jsonData, _ := json.MarshalIndent(config, "", "  ")
-	logger.Infof("Generated monitor visualization config:\n%+v", string(jsonData))
-	return config
+	localX, localY, localZ, err := monitorAxes(result)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+
+	// Calculate center of monitor
+	centerX := (result.LeftX + result.RightX) / 2
+	centerZ := (result.BottomZ + result.TopZ) / 2
+
+	return monitorGeometry{
+		Center: pointOnPlane(result, centerX, centerZ, localY),
+		LocalX: localX,
+		LocalY: localY,
+		LocalZ: localZ,
+		// The edges are measured along world X and Z, so scale the spans back onto the (possibly tilted) monitor axes
+		Width:  alongAxis(result.LeftX-result.RightX, localX.X),
+		Height: alongAxis(result.TopZ-result.BottomZ, localZ.Z),
+	}, nil
+}
+
+// monitorSurfaceFromResult derives the monitor geometry for the given surface model
+// SurfaceCorrected follows the fitted deviation surface, SurfaceFlat uses the plane alone
+func monitorSurfaceFromResult(result CalibrationResult, surface string) (monitorGeometry, error) {
+	geometry, err := monitorGeometryFromResult(result)
+	if err != nil {
+		return monitorGeometry{}, err
+	}
+	switch surface {
+	case SurfaceFlat, "":
+	case SurfaceCorrected:
+		if len(result.Deviation.Coefficients) > 0 {
+			deviation := result.Deviation
+			geometry.Deviation = &deviation
+		}
+		geometry.Curve = result.Cylinder
+	default:
+		return monitorGeometry{}, fmt.Errorf("unknown surface model %q", surface)
+	}
+	return geometry, nil
+}
+
+// surfaceFrame returns the point on the glass at monitor-local (u, v) and the outward surface normal there
+func (g monitorGeometry) surfaceFrame(u, v float64) (r3.Vector, r3.Vector) {
+	point := g.toWorld(u, v, 0)
+	if g.Curve != nil {
+		return g.curvedSurfaceFrame(point)
+	}
+	if g.Deviation == nil {
+		return point, g.LocalY
+	}
+	p := Point3D{X: point.X, Y: point.Y, Z: point.Z}
+	// Deviations run along the fitted plane normal, which may point into the glass rather than out like LocalY
+	offset := r3.Vector{X: g.Deviation.Normal.X, Y: g.Deviation.Normal.Y, Z: g.Deviation.Normal.Z}
+	n := g.Deviation.SurfaceNormal(p)
+	normal := r3.Vector{X: n.X, Y: n.Y, Z: n.Z}
+	if normal.Dot(g.LocalY) < 0 {
+		normal = normal.Mul(-1)
+	}
+	return point.Add(offset.Mul(g.Deviation.Deviation(p))), normal
+}
+
+// bent reports whether the surface departs from the flat rectangle
+func (g monitorGeometry) bent() bool {
+	return g.Deviation != nil || g.Curve != nil
+}
+
+// curvedSurfaceFrame moves a point on the tangent plane along its normal onto the cylinder, then by the deviation
+// along the cylinder's radius, and returns it with the outward normal of the cylinder there
+func (g monitorGeometry) curvedSurfaceFrame(point r3.Vector) (r3.Vector, r3.Vector) {
+	axis := r3.Vector{X: g.Curve.Axis.X, Y: g.Curve.Axis.Y, Z: g.Curve.Axis.Z}.Normalize()
+	offset := point.Sub(r3.Vector{X: g.Curve.Origin.X, Y: g.Curve.Origin.Y, Z: g.Curve.Origin.Z})
+	radial := offset.Sub(axis.Mul(offset.Dot(axis)))
+	along := g.LocalY.Sub(axis.Mul(g.LocalY.Dot(axis)))
+
+	// |radial + t*along| = Radius, taking the root nearest the plane
+	a, b, c := along.Norm2(), 2*radial.Dot(along), radial.Norm2()-g.Curve.Radius*g.Curve.Radius
+	disc := b*b - 4*a*c
+	if a < 1e-12 || disc < 0 {
+		return point, g.LocalY
+	}
+	t := (-b + math.Sqrt(disc)) / (2 * a)
+	if other := (-b - math.Sqrt(disc)) / (2 * a); math.Abs(other) < math.Abs(t) {
+		t = other
+	}
+	point = point.Add(along.Mul(t))
+	outward := radial.Add(along.Mul(t)).Normalize()
+
+	normal := outward
+	if normal.Dot(g.LocalY) < 0 {
+		normal = normal.Mul(-1)
+	}
+	if g.Deviation != nil {
+		// Deviations are residuals from the cylinder, signed along the tangent plane normal
+		direction := outward
+		if direction.Dot(r3.Vector{X: g.Deviation.Normal.X, Y: g.Deviation.Normal.Y, Z: g.Deviation.Normal.Z}) < 0 {
+			direction = direction.Mul(-1)
+		}
+		point = point.Add(direction.Mul(g.Deviation.Deviation(Point3D{X: point.X, Y: point.Y, Z: point.Z})))
+	}
+	return point, normal
+}
+
+// MonitorLocalToWorld converts monitor-local coordinates (u along the width and v along the height from the
+// center, w out of the glass, all in mm) to a pose in the reference frame whose orientation vector is the
+// outward surface normal. With SurfaceCorrected, the pose follows the fitted deviation surface.
+func MonitorLocalToWorld(result CalibrationResult, u, v, w float64, surface string) (spatialmath.Pose, error) {
+	geometry, err := monitorSurfaceFromResult(result, surface)
+	if err != nil {
+		return nil, err
+	}
+	point, normal := geometry.surfaceFrame(u, v)
+	orientation := &spatialmath.OrientationVector{OX: normal.X, OY: normal.Y, OZ: normal.Z}
+	orientation.Normalize()
+	return spatialmath.NewPose(point.Add(normal.Mul(w)), orientation), nil
+}
+
+// plane returns the plane of the glass, its normal LocalY out of the glass
+func (g monitorGeometry) plane() Plane {
+	return Plane{A: g.LocalY.X, B: g.LocalY.Y, C: g.LocalY.Z, D: g.LocalY.Dot(g.Center)}
+}
+
+// orientation returns the rotation from the reference frame to the monitor frame
+func (g monitorGeometry) orientation() (spatialmath.Orientation, error) {
+	return PlaneOrientation(g.plane(), g.LocalX)
+}
+
+// alignedTo turns the monitor frame about the plane normal so LocalX is the projection of axis onto the plane
+// Width and Height grow to the bounding box of the monitor in the turned axes, so the box still covers the screen.
+// Also returns the turn in degrees, positive from the measured LocalX towards LocalZ.
+func (g monitorGeometry) alignedTo(axis r3.Vector) (monitorGeometry, float64, error) {
+	x := axis.Sub(g.LocalY.Mul(axis.Dot(g.LocalY)))
+	if x.Norm() < 1e-9 {
+		return monitorGeometry{}, 0, fmt.Errorf("axis %v is perpendicular to the monitor", axis)
+	}
+	x = x.Normalize()
+	if x.Dot(g.LocalX) < 0 {
+		x = x.Mul(-1)
+	}
+	angle := math.Atan2(x.Dot(g.LocalZ), x.Dot(g.LocalX))
+	cos, sin := math.Abs(math.Cos(angle)), math.Abs(math.Sin(angle))
+
+	aligned := g
+	aligned.LocalX = x
+	aligned.LocalZ = x.Cross(g.LocalY).Normalize()
+	aligned.Width = g.Width*cos + g.Height*sin
+	aligned.Height = g.Width*sin + g.Height*cos
+	return aligned, angle * 180 / math.Pi, nil
+}
+
+// toWorld converts monitor-local (u along width, v along height, w along the normal) coordinates to the reference frame
+func (g monitorGeometry) toWorld(u, v, w float64) r3.Vector {
+	return g.Center.Add(g.LocalX.Mul(u)).Add(g.LocalZ.Mul(v)).Add(g.LocalY.Mul(w))
+}
+
+// monitorAxes builds a right-handed orthonormal monitor frame from the calibration measurements
+// localX runs from XPoint1 to XPoint2 towards the left edge and localZ towards ZPoint1, both projected onto the
+// plane, after the corrections of CorrectReferencePoints. localY is the plane normal turned out of the glass,
+// Z × X like the vision geometry, since a fitted plane's normal may point either way.
+func monitorAxes(result CalibrationResult) (localX, localY, localZ r3.Vector, err error) {
+	plane := result.Plane
+	normal, err := NormalFromPlane(plane)
+	if err != nil {
+		return r3.Vector{}, r3.Vector{}, r3.Vector{}, err
+	}
+	across, up, _, err := referenceAxes(result, normal)
+	if err != nil {
+		return r3.Vector{}, r3.Vector{}, r3.Vector{}, err
+	}
+	if up.Cross(across).Dot(normal) < 0 {
+		plane = Plane{A: -plane.A, B: -plane.B, C: -plane.C, D: -plane.D}
+	}
+	return PlaneAxes(plane, across)
+}
+
+// pointOnPlane returns the point on the calibrated plane at world (x, z)
+// Falls back to projecting along the normal when the plane is nearly parallel to world Y
+func pointOnPlane(result CalibrationResult, x, z float64, normal r3.Vector) r3.Vector {
+	plane := result.Plane
+	if math.Abs(normal.Y) >= 0.1 {
+		// From plane equation: A*x + B*y + C*z = D
+		// Solving for y: y = (D - A*x - C*z) / B
+		return r3.Vector{X: x, Y: (plane.D - plane.A*x - plane.C*z) / plane.B, Z: z}
+	}
+
+	y := (result.XPoint1.Y + result.XPoint2.Y) / 2
+	p := r3.Vector{X: x, Y: y, Z: z}
+	length := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+	dist := (plane.A*x + plane.B*y + plane.C*z - plane.D) / length
+	return p.Sub(normal.Mul(dist))
+}
+
+// alongAxis converts a span measured along a world axis into a length along a monitor axis,
+// given that axis' component along the world axis
+func alongAxis(span, axisComponent float64) float64 {
+	if math.Abs(axisComponent) < 0.1 {
+		return span // monitor axis nearly perpendicular to the measurement, scaling would blow up
+	}
+	return span / math.Abs(axisComponent)
+}
+
+// PoseToMap converts a pose to the field names of Viam's Pose message (orientation vector, theta in degrees)
+func PoseToMap(pose spatialmath.Pose) map[string]interface{} {
+	ov := pose.Orientation().OrientationVectorDegrees()
+	return map[string]interface{}{
+		"x":     pose.Point().X,
+		"y":     pose.Point().Y,
+		"z":     pose.Point().Z,
+		"o_x":   ov.OX,
+		"o_y":   ov.OY,
+		"o_z":   ov.OZ,
+		"theta": ov.Theta,
+	}
+}
+
+// PoseFromMap converts the field names written by PoseToMap back to a pose
+func PoseFromMap(m map[string]interface{}) (spatialmath.Pose, error) {
+	var values [7]float64
+	for i, key := range []string{"x", "y", "z", "o_x", "o_y", "o_z", "theta"} {
+		v, ok := m[key].(float64)
+		if !ok {
+			return nil, fmt.Errorf("pose is missing a numeric %q", key)
+		}
+		values[i] = v
+	}
+	return spatialmath.NewPose(r3.Vector{X: values[0], Y: values[1], Z: values[2]},
+		&spatialmath.OrientationVectorDegrees{OX: values[3], OY: values[4], OZ: values[5], Theta: values[6]}), nil
 }
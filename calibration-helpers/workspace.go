@@ -0,0 +1,220 @@
+package calibrationhelpers
+
+import (
+	"calibration/scanpath"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// reachSamples is how many joint configurations the arm's reach is estimated from
+const reachSamples = 5000
+
+// reaches caches estimateReach by the kinematics it sampled, a model's Hash -> mm
+var reaches sync.Map
+
+// UnreachableWaypoint is a planned scan waypoint the gantry or arm can't get to
+type UnreachableWaypoint struct {
+	Scan   string  `json:"scan"`
+	X      float64 `json:"x"` // mm - gantry position
+	Z      float64 `json:"z"` // mm - arm height offset from the home pose
+	Reason string  `json:"reason"`
+}
+
+// WorkspaceReport is what CheckWorkspace found
+type WorkspaceReport struct {
+	Waypoints    int                   `json:"waypoints"`     // planned waypoints checked
+	GantryTravel float64               `json:"gantry_travel"` // mm - length of the gantry's travel
+	ArmReach     float64               `json:"arm_reach_mm"`  // mm - reach the arm was checked against, 0 if it wasn't
+	Unreachable  []UnreachableWaypoint `json:"unreachable"`
+}
+
+// ToMap converts the report to a map of JSON values, suitable for returning from DoCommand
+func (r WorkspaceReport) ToMap() (map[string]interface{}, error) {
+	return jsonToMap(r)
+}
+
+// Err returns an ErrWorkspaceExceeded error listing the first unreachable waypoints, nil if every one is reachable
+func (r WorkspaceReport) Err() error {
+	if len(r.Unreachable) == 0 {
+		return nil
+	}
+	const listed = 5
+	var reasons []string
+	for _, wp := range r.Unreachable[:min(listed, len(r.Unreachable))] {
+		reasons = append(reasons, fmt.Sprintf("%s scan gantry=%.1f z=%.1f: %s", wp.Scan, wp.X, wp.Z, wp.Reason))
+	}
+	if len(r.Unreachable) > listed {
+		reasons = append(reasons, fmt.Sprintf("and %d more", len(r.Unreachable)-listed))
+	}
+	return fmt.Errorf("%d of %d planned scan waypoints are out of reach (%s): %w",
+		len(r.Unreachable), r.Waypoints, strings.Join(reasons, "; "), ErrWorkspaceExceeded)
+}
+
+// plannedScan is the waypoints of one scan and whether the scan moves the gantry to them
+type plannedScan struct {
+	label       string
+	waypoints   []scanpath.Waypoint
+	gantryMoves bool
+}
+
+// planScans returns the waypoints the configured scan mode will visit, without moving anything
+// Adaptive scans may refine anywhere on their lattice, so all of it is planned. Angular scans hold the arm at a few
+// heights and sweep the wrist there, so only the holds are planned.
+func planScans(ctx context.Context, gantry gantry.Gantry, config ScanningConfig) ([]plannedScan, error) {
+	switch config.Mode {
+	case ScanModeAngular:
+		if config.AngularHolds < 2 {
+			return nil, fmt.Errorf("angular scan needs at least 2 holds")
+		}
+		zMin, zMax := config.ZRange()
+		holdSpacing := (zMax - zMin) / float64(config.AngularHolds-1)
+		holds := make([]scanpath.Waypoint, config.AngularHolds)
+		for i := range holds {
+			holds[i] = scanpath.Waypoint{Z: zMin + float64(i)*holdSpacing, Row: i}
+		}
+		return []plannedScan{{label: "Angular", waypoints: holds}}, nil
+	case ScanModeGrid:
+		waypoints, err := PlanGridScan(ctx, gantry, config)
+		if err != nil {
+			return nil, err
+		}
+		return []plannedScan{{label: "Grid", waypoints: waypoints, gantryMoves: true}}, nil
+	case ScanModeAdaptive:
+		region, xSpacing, zSpacing, err := gridRegion(ctx, gantry, config)
+		if err != nil {
+			return nil, err
+		}
+		lattice, err := scanpath.NewLattice(region, xSpacing, zSpacing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan adaptive scan: %w", err)
+		}
+		waypoints, err := lattice.Generate(scanpath.Every(len(lattice.Zs), 1), scanpath.Every(len(lattice.Xs), 1), scanpath.Raster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan adaptive scan: %w", err)
+		}
+		return []plannedScan{{label: "Adaptive", waypoints: waypoints, gantryMoves: true}}, nil
+	default:
+		zWaypoints, err := planZScan(config)
+		if err != nil {
+			return nil, err
+		}
+		gantryLengths, err := gantry.Lengths(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gantry lengths: %w", err)
+		}
+		xWaypoints, err := planXScan(gantryLengths, config)
+		if err != nil {
+			return nil, err
+		}
+		return []plannedScan{
+			{label: "Z", waypoints: zWaypoints},
+			{label: "X", waypoints: xWaypoints, gantryMoves: true},
+		}, nil
+	}
+}
+
+// CheckWorkspace checks every waypoint the configured scan will visit against the gantry's travel and the arm's
+// reach, before anything moves
+// The arm's home pose comes from forward kinematics of ArmPositions.Home, and each waypoint raises it by the
+// waypoint's height offset. The arm's reach is the farthest its end effector gets from its base: the configured
+// Hardware.ArmReach, or an estimate from its kinematic model when that is zero. A pose within reach may still fail
+// IK, so this catches plans that can't work rather than proving the rest will. Arms without a kinematic model, or
+// whose model has no joints, only have the gantry checked.
+func CheckWorkspace(ctx context.Context, logger logging.Logger, arm arm.Arm, gantry gantry.Gantry,
+	config CalibrationConfig) (WorkspaceReport, error) {
+	gantryLengths, err := gantry.Lengths(ctx, nil)
+	if err != nil {
+		return WorkspaceReport{}, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	if len(gantryLengths) == 0 {
+		return WorkspaceReport{}, fmt.Errorf("gantry has no axes")
+	}
+	scans, err := planScans(ctx, gantry, config.Scanning)
+	if err != nil {
+		return WorkspaceReport{}, err
+	}
+	report := WorkspaceReport{GantryTravel: gantryLengths[0], Unreachable: []UnreachableWaypoint{}}
+
+	var home spatialmath.Pose
+	model, err := arm.Kinematics(ctx)
+	if err == nil && model != nil && len(model.DoF()) > 0 {
+		if home, err = model.Transform(config.ArmPositions.Home); err != nil {
+			return WorkspaceReport{}, fmt.Errorf("home joint positions are outside the arm's limits: %w", err)
+		}
+		report.ArmReach = config.Hardware.ArmReach
+		if report.ArmReach <= 0 {
+			report.ArmReach = estimateReach(model)
+		}
+	} else {
+		logger.Infof("Arm %s has no kinematic model, checking the scan against the gantry travel only", arm.Name().ShortName())
+	}
+
+	for _, scan := range scans {
+		for _, wp := range scan.waypoints {
+			report.Waypoints++
+			unreachable := func(reason string) {
+				report.Unreachable = append(report.Unreachable, UnreachableWaypoint{Scan: scan.label, X: wp.X, Z: wp.Z, Reason: reason})
+			}
+			if scan.gantryMoves && (wp.X < 0 || wp.X > report.GantryTravel) {
+				unreachable(fmt.Sprintf("gantry travel is 0 to %.1f mm", report.GantryTravel))
+				continue
+			}
+			if home == nil {
+				continue
+			}
+			target := home.Point()
+			target.Z += wp.Z
+			if distance := target.Norm(); distance > report.ArmReach {
+				unreachable(fmt.Sprintf("%.1f mm from the arm base, beyond its %.1f mm reach", distance, report.ArmReach))
+			}
+		}
+	}
+	return report, nil
+}
+
+// estimateReach returns the farthest the model's end effector gets from its base, sampled over its joint limits
+// Sampling can only underestimate, so the result is padded by 2%. The sampling is seeded, so each model's
+// kinematics are only sampled once.
+func estimateReach(model referenceframe.Model) float64 {
+	key := model.Hash()
+	if reach, ok := reaches.Load(key); ok {
+		return reach.(float64)
+	}
+	reach := sampleReach(model)
+	reaches.Store(key, reach)
+	return reach
+}
+
+// sampleReach samples the model's end effector distance from its base over reachSamples joint configurations
+func sampleReach(model referenceframe.Model) float64 {
+	limits := model.DoF()
+	rng := rand.New(rand.NewSource(1))
+	inputs := make([]referenceframe.Input, len(limits))
+	reach := 0.0
+	for i := 0; i < reachSamples; i++ {
+		for j, limit := range limits {
+			// Unbounded joints are revolute joints that turn all the way round
+			lo, hi := limit.Min, limit.Max
+			if math.IsInf(lo, -1) || math.IsInf(hi, 1) {
+				lo, hi = -math.Pi, math.Pi
+			}
+			inputs[j] = lo + rng.Float64()*(hi-lo)
+		}
+		pose, err := model.Transform(inputs)
+		if err != nil {
+			continue
+		}
+		reach = math.Max(reach, pose.Point().Norm())
+	}
+	return reach * 1.02
+}
@@ -0,0 +1,92 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// defaultReferenceToleranceMM is how far the reference target may read from its expected distance unless
+// tolerance_mm is set
+const defaultReferenceToleranceMM = 5.0
+
+// HomingConfig homes the gantry at the start of every run, before the scan is planned
+type HomingConfig struct {
+	// RestPosition is the arm position moved to after homing: "home", "bottom_scan" or "top_scan". Unset leaves
+	// the arm where it is.
+	RestPosition string `json:"rest_position,omitempty"`
+	// Reference is a fixed target read after homing to check the gantry's new zero, unset to skip the check
+	Reference *ReferenceTargetConfig `json:"reference,omitempty"`
+}
+
+// ReferenceTargetConfig is a fixed target the sensor sees at a known distance from the rest position
+type ReferenceTargetConfig struct {
+	GantryMM           float64 `json:"gantry_mm"`              // gantry position to read the target from
+	ExpectedDistanceMM float64 `json:"expected_distance_mm"`   // reading expected there
+	ToleranceMM        float64 `json:"tolerance_mm,omitempty"` // largest accepted difference, default 5
+}
+
+// Validate checks the homing configuration
+func (cfg *HomingConfig) Validate(path string) error {
+	if cfg.RestPosition != "" {
+		if _, err := calibrationhelpers.DefaultArmPositions.Named(cfg.RestPosition); err != nil {
+			return fmt.Errorf("invalid 'rest_position' in %s: %w", path, err)
+		}
+	}
+	if ref := cfg.Reference; ref != nil {
+		if ref.GantryMM < 0 {
+			return fmt.Errorf("reference 'gantry_mm' must not be negative in %s", path)
+		}
+		if ref.ExpectedDistanceMM <= 0 {
+			return fmt.Errorf("reference 'expected_distance_mm' must be positive in %s", path)
+		}
+		if ref.ToleranceMM < 0 {
+			return fmt.Errorf("reference 'tolerance_mm' must not be negative in %s", path)
+		}
+	}
+	return nil
+}
+
+// homing converts the config to the homing step the calibration runs
+func (cfg *HomingConfig) homing() *calibrationhelpers.HomingConfig {
+	homing := &calibrationhelpers.HomingConfig{RestPosition: cfg.RestPosition}
+	if ref := cfg.Reference; ref != nil {
+		tolerance := ref.ToleranceMM
+		if tolerance == 0 {
+			tolerance = defaultReferenceToleranceMM
+		}
+		homing.Reference = &calibrationhelpers.ReferenceTarget{
+			Gantry:    ref.GantryMM,
+			Distance:  ref.ExpectedDistanceMM,
+			Tolerance: tolerance,
+		}
+	}
+	return homing
+}
+
+// homeGantry runs the homing phase of a run when config.Homing is set, and logs the reference check
+func (s *monitorCalibration) homeGantry(ctx context.Context, config calibrationhelpers.CalibrationConfig) error {
+	logger := s.runLogger(config)
+	if config.Homing == nil {
+		return nil
+	}
+	if err := s.enterPhase(ctx, config, phaseHoming); err != nil {
+		return err
+	}
+	logger.Info("Homing gantry...")
+	report, err := calibrationhelpers.HomeGantry(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, config)
+	if check := report.Reference; check != nil {
+		if check.Pass {
+			logger.Infof("✓ Reference target read %.1f mm after homing (expected %.1f ± %.1f mm)",
+				check.DistanceMM, check.ExpectedMM, check.Tolerance)
+		} else {
+			logger.Errorf("✗ Reference target read %.1f mm after homing, %+.1f mm from the expected %.1f mm",
+				check.DistanceMM, check.ErrorMM, check.ExpectedMM)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("✓ Gantry homed")
+	return s.enterPhase(ctx, config, phaseCentering)
+}
@@ -0,0 +1,59 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+	"os"
+)
+
+// importCalibration handles the "import_calibration" command, adopting a calibration made with external tools
+func (s *monitorCalibration) importCalibration(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	format, ok := cmd["format"].(string)
+	if !ok || format == "" {
+		return nil, fmt.Errorf("import_calibration requires a 'format' (%q, %q or %q)",
+			calibrationhelpers.ImportOpenCV, calibrationhelpers.ImportPLY, calibrationhelpers.ImportMatrix)
+	}
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("import_calibration requires a 'path'")
+	}
+
+	frame := s.calibrationConfig.Hardware.ReferenceFrame()
+	opts := calibrationhelpers.ImportOptions{Frame: frame}
+	opts.Width, _ = cmd["width_mm"].(float64)
+	opts.Height, _ = cmd["height_mm"].(float64)
+	opts.OriginAtCorner, _ = cmd["origin_at_corner"].(bool)
+	opts.Convention, _ = cmd["convention"].(string)
+
+	// Data expressed in another frame, e.g. the camera that saw the calibration board
+	if sourceFrame, ok := cmd["source_frame"].(string); ok && sourceFrame != "" && sourceFrame != frame {
+		sourcePose, err := s.fs.GetPose(ctx, sourceFrame, frame, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s pose in %s frame: %w", sourceFrame, frame, err)
+		}
+		opts.SourcePose = sourcePose.Pose()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open calibration file: %w", err)
+	}
+	defer f.Close()
+
+	result, err := calibrationhelpers.ImportCalibration(f, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := calibrationhelpers.RunPostProcessors(&result); err != nil {
+		return nil, err
+	}
+	if err := s.correctReferencePoints(&result, "imported calibration"); err != nil {
+		return nil, err
+	}
+
+	s.lastResult = &result
+	s.saveLastResult(ctx)
+	s.logger.Infof("✓ Imported %s calibration from %s: %.1f x %.1f mm monitor", format, path, result.MonitorWidth, result.MonitorHeight)
+	return calibrationhelpers.GenerateVisualizationConfig(s.logger, result, frame), nil
+}
@@ -0,0 +1,149 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Recalibration job states reported by "recalibrate_now" and "get_job"
+const (
+	jobQueued    = "queued" // waiting for another command or a scheduled calibration to finish
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// maxJobs bounds how many finished jobs "get_job" remembers, older ones are dropped first
+const maxJobs = 50
+
+// recalibrationJob is one calibration started by "recalibrate_now"
+type recalibrationJob struct {
+	id       string
+	state    string
+	created  time.Time
+	finished time.Time
+	err      string
+	class    string // calibrationhelpers.ErrorClass of the failure, "" when it has none
+}
+
+func (j *recalibrationJob) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"job_id":  j.id,
+		"state":   j.state,
+		"created": j.created.Format(time.RFC3339),
+	}
+	if !j.finished.IsZero() {
+		m["finished"] = j.finished.Format(time.RFC3339)
+	}
+	if j.err != "" {
+		m["error"] = j.err
+	}
+	if j.class != "" {
+		m["error_class"] = j.class
+	}
+	return m
+}
+
+// recalibrationJobs tracks one-tap recalibrations so dashboard buttons get an answer immediately
+// It has its own lock, like progressTracker, so taps and polls never wait for a running calibration
+type recalibrationJobs struct {
+	mu      sync.Mutex
+	jobs    []*recalibrationJob // oldest first
+	active  *recalibrationJob   // queued or running, nil when idle
+	nextSeq int
+}
+
+func newRecalibrationJobs() *recalibrationJobs {
+	return &recalibrationJobs{nextSeq: 1}
+}
+
+// recalibrateNow handles the "recalibrate_now" command, which takes no arguments
+// It starts an untargeted calibration in the background and returns its job_id. Taps while a job is queued or
+// running are debounced: they return the same job with "debounced" set instead of queueing another run.
+func (s *monitorCalibration) recalibrateNow() map[string]interface{} {
+	j := s.jobs
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.active != nil {
+		response := j.active.toMap()
+		response["debounced"] = true
+		return response
+	}
+
+	job := &recalibrationJob{
+		id:      fmt.Sprintf("recal-%s-%d", time.Now().UTC().Format("20060102T150405Z"), j.nextSeq),
+		state:   jobQueued,
+		created: time.Now(),
+	}
+	j.nextSeq++
+	j.active = job
+	j.jobs = append(j.jobs, job)
+	if len(j.jobs) > maxJobs {
+		j.jobs = j.jobs[len(j.jobs)-maxJobs:]
+	}
+	s.logger.Infof("Recalibration job %s requested", job.id)
+
+	s.activeBackgroundWorkers.Add(1)
+	go s.runRecalibrationJob(job)
+
+	response := job.toMap()
+	response["debounced"] = false
+	return response
+}
+
+// runRecalibrationJob waits its turn behind other commands, then calibrates like "calibrate" with no arguments
+func (s *monitorCalibration) runRecalibrationJob(job *recalibrationJob) {
+	defer s.activeBackgroundWorkers.Done()
+
+	s.doCommandLock.Lock()
+	s.jobs.setState(job, jobRunning, nil)
+	_, err := s.audited("calibrate", calibrationhelpers.AuditTriggerRecalibrate, map[string]interface{}{"job_id": job.id},
+		func() (map[string]interface{}, error) {
+			return s.calibrateCommand(s.cancelCtx, map[string]interface{}{})
+		})
+	s.doCommandLock.Unlock()
+
+	if err != nil {
+		s.logger.Errorf("Recalibration job %s failed: %v", job.id, err)
+		s.jobs.setState(job, jobFailed, err)
+		return
+	}
+	s.logger.Infof("✓ Recalibration job %s finished", job.id)
+	s.jobs.setState(job, jobSucceeded, nil)
+}
+
+// setState moves a job to the next state, freeing the active slot once it finishes
+func (j *recalibrationJobs) setState(job *recalibrationJob, state string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job.state = state
+	if err != nil {
+		job.err = err.Error()
+		job.class = calibrationhelpers.ErrorClass(err)
+	}
+	if state == jobSucceeded || state == jobFailed {
+		job.finished = time.Now()
+		if j.active == job {
+			j.active = nil
+		}
+	}
+}
+
+// get handles the "get_job" command, returning the state of the job with the given "job_id"
+// Without a job_id it returns the most recent job
+func (j *recalibrationJobs) get(cmd map[string]interface{}) (map[string]interface{}, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	id, _ := cmd["job_id"].(string)
+	for i := len(j.jobs) - 1; i >= 0; i-- {
+		if id == "" || j.jobs[i].id == id {
+			return j.jobs[i].toMap(), nil
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("no recalibration jobs yet")
+	}
+	return nil, fmt.Errorf("unknown job %q", id)
+}
@@ -0,0 +1,82 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// liveScanFields maps the "update_scan" arguments to the scan parameters they change
+var liveScanFields = map[string]func(*calibrationhelpers.ScanningConfig, float64){
+	// Speed
+	"gantry_speed_mm_per_sec": func(c *calibrationhelpers.ScanningConfig, v float64) { c.GantrySpeed = v },
+	"dwell_time_ms": func(c *calibrationhelpers.ScanningConfig, v float64) {
+		c.Dwell = time.Duration(v * float64(time.Millisecond))
+	},
+	"samples_per_point": func(c *calibrationhelpers.ScanningConfig, v float64) { c.SamplesPerPoint = int(v) },
+	// Density
+	"x_num_steps":    func(c *calibrationhelpers.ScanningConfig, v float64) { c.XNumSteps = int(v) },
+	"z_num_steps":    func(c *calibrationhelpers.ScanningConfig, v float64) { c.ZNumSteps = int(v) },
+	"x_spacing_mm":   func(c *calibrationhelpers.ScanningConfig, v float64) { c.XSpacing = v },
+	"z_spacing_mm":   func(c *calibrationhelpers.ScanningConfig, v float64) { c.ZSpacing = v },
+	"z_step_size_mm": func(c *calibrationhelpers.ScanningConfig, v float64) { c.ZStepSize = v },
+	// Region
+	"gantry_min_mm": func(c *calibrationhelpers.ScanningConfig, v float64) { c.GantryMin = v },
+	"gantry_max_mm": func(c *calibrationhelpers.ScanningConfig, v float64) { c.GantryMax = v },
+}
+
+// liveScanCounts are the "update_scan" arguments that count something, so only take whole numbers
+var liveScanCounts = map[string]bool{"samples_per_point": true, "x_num_steps": true, "z_num_steps": true}
+
+// updateScan handles the "update_scan" command, changing the running scan from its next waypoint
+// Changes that leave the scan invalid (e.g. fewer than 2 steps) are discarded by the scan with a warning
+func (p *progressTracker) updateScan(cmd map[string]interface{}) (map[string]interface{}, error) {
+	values := map[string]float64{}
+	for key, raw := range cmd {
+		if key == "command" {
+			continue
+		}
+		if _, ok := liveScanFields[key]; !ok {
+			return nil, fmt.Errorf("update_scan cannot change %q", key)
+		}
+		v, ok := raw.(float64)
+		if !ok || v < 0 {
+			return nil, fmt.Errorf("update_scan '%s' must be a non-negative number", key)
+		}
+		if liveScanCounts[key] && v != math.Trunc(v) {
+			return nil, fmt.Errorf("update_scan '%s' must be a whole number", key)
+		}
+		values[key] = v
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("update_scan requires at least one of the scan parameters to change")
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	queued := make([]interface{}, len(keys))
+	for i, key := range keys {
+		queued[i] = key
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.control == nil {
+		return nil, fmt.Errorf("no calibration is running")
+	}
+	p.control.Update(func(c *calibrationhelpers.ScanningConfig) {
+		for _, key := range keys {
+			liveScanFields[key](c, values[key])
+		}
+	})
+	p.recordLocked("info", "scan change queued: "+strings.Join(keys, ", "))
+	return map[string]interface{}{
+		"queued": queued,
+		"phase":  p.phase,
+	}, nil
+}
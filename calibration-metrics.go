@@ -0,0 +1,249 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Upper bounds of the metrics' histogram buckets
+var (
+	scanDurationBuckets = []float64{30, 60, 120, 300, 600, 1200, 1800} // seconds
+	fitResidualBuckets  = []float64{0.25, 0.5, 1, 2, 5, 10}            // mm
+	runSamplesBuckets   = []float64{10, 25, 50, 100, 250, 500, 1000}
+)
+
+// histogram counts observations into buckets like a Prometheus histogram
+type histogram struct {
+	bounds []float64 // upper bounds, ascending
+	counts []int     // observations per bucket, the last one above every bound
+	sum    float64
+	count  int
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]int, len(bounds)+1)}
+}
+
+func (h *histogram) observe(value float64) {
+	i, _ := slices.BinarySearch(h.bounds, value)
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+// cumulative returns the observations at or below each bound, as Prometheus reports them
+func (h *histogram) cumulative() []int {
+	cumulative := make([]int, len(h.bounds))
+	total := 0
+	for i := range h.bounds {
+		total += h.counts[i]
+		cumulative[i] = total
+	}
+	return cumulative
+}
+
+func (h *histogram) toMap() map[string]interface{} {
+	buckets := []interface{}{}
+	for i, count := range h.cumulative() {
+		buckets = append(buckets, map[string]interface{}{"le": h.bounds[i], "count": count})
+	}
+	return map[string]interface{}{"buckets": buckets, "sum": h.sum, "count": h.count}
+}
+
+// writeText writes the histogram in the Prometheus text exposition format
+func (h *histogram) writeText(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, count := range h.cumulative() {
+		fmt.Fprintf(b, "%s_bucket{le=\"%g\"} %d\n", name, h.bounds[i], count)
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n", name, h.count, name, h.sum, name, h.count)
+}
+
+// calibrationMetrics accumulates calibration health over the service's lifetime for "get_metrics"
+// It has its own lock so dashboards can scrape while DoCommand is busy calibrating
+type calibrationMetrics struct {
+	mu sync.Mutex
+
+	runs           int
+	failures       map[string]int // by error class, "unclassified" when the error has none
+	samples        int            // scan points collected
+	skipped        map[string]int // scan waypoints that produced no point, by status
+	sensorTimeouts int
+	sensorRetries  int // failed sensor reads retried at scan waypoints
+	moves          int // arm and gantry moves commanded by the service
+
+	runStarted time.Time
+	runSamples int // scan points collected by the current run
+
+	scanDuration *histogram
+	fitResidual  *histogram
+	samplesByRun *histogram
+}
+
+func newCalibrationMetrics() *calibrationMetrics {
+	return &calibrationMetrics{
+		failures:     map[string]int{},
+		skipped:      map[string]int{},
+		scanDuration: newHistogram(scanDurationBuckets),
+		fitResidual:  newHistogram(fitResidualBuckets),
+		samplesByRun: newHistogram(runSamplesBuckets),
+	}
+}
+
+// begin starts timing a calibration run
+func (m *calibrationMetrics) begin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runStarted = time.Now()
+	m.runSamples = 0
+}
+
+// scanPoint counts a visited scan waypoint
+func (m *calibrationMetrics) scanPoint(progress calibrationhelpers.ScanProgress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sensorRetries += progress.Retries
+	if progress.Status == types.WaypointOK {
+		m.samples++
+		m.runSamples++
+		return
+	}
+	m.skipped[progress.Status]++
+	if progress.Status == types.WaypointReadTimeout {
+		m.sensorTimeouts++
+	}
+}
+
+// move counts an arm or gantry move
+func (m *calibrationMetrics) move() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.moves++
+}
+
+// finish records the outcome of the run started by begin
+func (m *calibrationMetrics) finish(result types.CalibrationResult, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+	m.scanDuration.observe(time.Since(m.runStarted).Seconds())
+	m.samplesByRun.observe(float64(m.runSamples))
+	if err != nil {
+		class := calibrationhelpers.ErrorClass(err)
+		if class == "sensor_timeout" {
+			m.sensorTimeouts++
+		}
+		if class == "" {
+			class = "unclassified"
+		}
+		m.failures[class]++
+		return
+	}
+	m.fitResidual.observe(result.PlaneUncertainty.ResidualRMS)
+}
+
+// getMetrics handles the "get_metrics" command, returning the metrics as values and as Prometheus text
+func (m *calibrationMetrics) getMetrics() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failed := 0
+	failures := map[string]interface{}{}
+	for class, count := range m.failures {
+		failed += count
+		failures[class] = count
+	}
+	skipped := map[string]interface{}{}
+	for status, count := range m.skipped {
+		skipped[status] = count
+	}
+
+	var b strings.Builder
+	writeCounter := func(name, help string, values map[string]int, label string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			if label == "" {
+				fmt.Fprintf(&b, "%s %d\n", name, values[key])
+			} else {
+				fmt.Fprintf(&b, "%s{%s=%q} %d\n", name, label, key, values[key])
+			}
+		}
+	}
+	writeCounter("calibration_runs_total", "Calibration runs by result.",
+		map[string]int{"success": m.runs - failed, "failure": failed}, "result")
+	writeCounter("calibration_failures_total", "Failed calibration runs by error class.", m.failures, "error_class")
+	writeCounter("calibration_samples_collected_total", "Scan points collected.", map[string]int{"": m.samples}, "")
+	writeCounter("calibration_waypoints_skipped_total", "Scan waypoints that produced no point, by status.",
+		m.skipped, "status")
+	writeCounter("calibration_sensor_timeouts_total", "Sensor readings that timed out.",
+		map[string]int{"": m.sensorTimeouts}, "")
+	writeCounter("calibration_sensor_retries_total", "Failed sensor reads retried at scan waypoints.",
+		map[string]int{"": m.sensorRetries}, "")
+	writeCounter("calibration_moves_total", "Arm and gantry moves commanded.", map[string]int{"": m.moves}, "")
+	m.scanDuration.writeText(&b, "calibration_scan_duration_seconds", "Duration of calibration runs.")
+	m.fitResidual.writeText(&b, "calibration_fit_residual_mm", "RMS residual of successful plane fits.")
+	m.samplesByRun.writeText(&b, "calibration_samples_per_run", "Scan points collected per calibration run.")
+
+	return map[string]interface{}{
+		"runs":              m.runs,
+		"failures":          failures,
+		"samples_collected": m.samples,
+		"waypoints_skipped": skipped,
+		"sensor_timeouts":   m.sensorTimeouts,
+		"sensor_retries":    m.sensorRetries,
+		"moves":             m.moves,
+		"histograms": map[string]interface{}{
+			"scan_duration_seconds": m.scanDuration.toMap(),
+			"fit_residual_mm":       m.fitResidual.toMap(),
+			"samples_per_run":       m.samplesByRun.toMap(),
+		},
+		"prometheus": b.String(),
+	}
+}
+
+// meteredArm counts the moves the service commands the arm to make
+type meteredArm struct {
+	arm.Arm
+	metrics *calibrationMetrics
+}
+
+func (a *meteredArm) MoveToPosition(ctx context.Context, pose spatialmath.Pose, extra map[string]interface{}) error {
+	a.metrics.move()
+	return a.Arm.MoveToPosition(ctx, pose, extra)
+}
+
+func (a *meteredArm) MoveToJointPositions(ctx context.Context, positions []referenceframe.Input,
+	extra map[string]interface{}) error {
+	a.metrics.move()
+	return a.Arm.MoveToJointPositions(ctx, positions, extra)
+}
+
+func (a *meteredArm) MoveThroughJointPositions(ctx context.Context, positions [][]referenceframe.Input,
+	options *arm.MoveOptions, extra map[string]any) error {
+	a.metrics.move()
+	return a.Arm.MoveThroughJointPositions(ctx, positions, options, extra)
+}
+
+// meteredGantry counts the moves the service commands the gantry to make
+type meteredGantry struct {
+	gantry.Gantry
+	metrics *calibrationMetrics
+}
+
+func (g *meteredGantry) MoveToPosition(ctx context.Context, positionsMm, speedsMmPerSec []float64,
+	extra map[string]interface{}) error {
+	g.metrics.move()
+	return g.Gantry.MoveToPosition(ctx, positionsMm, speedsMmPerSec, extra)
+}
@@ -0,0 +1,116 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// defaultHintPaddingMM is how far a monitor hint may be off unless padding_mm is set
+const defaultHintPaddingMM = 50.0
+
+// errArmNotHome means a monitor hint couldn't be planned without moving the arm to its home pose
+var errArmNotHome = errors.New("the arm isn't at its home pose")
+
+// MonitorHintConfig is a rough monitor position and size to plan the scan region from, instead of configuring it
+type MonitorHintConfig struct {
+	Center    *Vector3 `json:"center"` // mm - in the world frame
+	WidthMM   float64  `json:"width_mm"`
+	HeightMM  float64  `json:"height_mm"`
+	PaddingMM float64  `json:"padding_mm,omitempty"` // how far the hint may be off, default 50
+}
+
+// Validate checks the monitor hint
+func (cfg *MonitorHintConfig) Validate(path string) error {
+	if cfg.Center == nil {
+		return fmt.Errorf("missing monitor_hint 'center' in %s", path)
+	}
+	if cfg.WidthMM <= 0 || cfg.HeightMM <= 0 {
+		return fmt.Errorf("monitor_hint 'width_mm' and 'height_mm' must be positive in %s", path)
+	}
+	if cfg.PaddingMM < 0 {
+		return fmt.Errorf("monitor_hint 'padding_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// hint converts the config to the hint the calibration plans from
+func (cfg *MonitorHintConfig) hint() *calibrationhelpers.MonitorHint {
+	padding := cfg.PaddingMM
+	if padding == 0 {
+		padding = defaultHintPaddingMM
+	}
+	return &calibrationhelpers.MonitorHint{
+		Center:  calibrationhelpers.Point3D{X: cfg.Center.X, Y: cfg.Center.Y, Z: cfg.Center.Z},
+		Width:   cfg.WidthMM,
+		Height:  cfg.HeightMM,
+		Padding: padding,
+	}
+}
+
+// applyMonitorHint plans the gantry window and Z scan of a run from config.Hint, if it has one
+// The plan needs the sensor's pose at home, so move allows moving the arm there first. Without it, the arm must
+// already be at home, or the returned error wraps errArmNotHome.
+func (s *monitorCalibration) applyMonitorHint(ctx context.Context, config calibrationhelpers.CalibrationConfig,
+	move bool) (calibrationhelpers.CalibrationConfig, *calibrationhelpers.HintPlan, error) {
+	if config.Hint == nil {
+		return config, nil, nil
+	}
+	if move {
+		if err := s.arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil); err != nil {
+			return config, nil, fmt.Errorf("failed to reset arm: %w", err)
+		}
+	} else {
+		joints, err := s.arm.JointPositions(ctx, nil)
+		if err != nil {
+			return config, nil, fmt.Errorf("failed to get arm joint positions: %w", err)
+		}
+		if len(joints) != len(config.ArmPositions.Home) {
+			return config, nil, errArmNotHome
+		}
+		for i, joint := range joints {
+			if math.Abs(joint-config.ArmPositions.Home[i]) > 1e-3 {
+				return config, nil, errArmNotHome
+			}
+		}
+	}
+
+	frame := config.Hardware.WorldFrame
+	sensorPose, err := s.fs.GetPose(ctx, s.cfg.Sensor, frame, nil, nil)
+	if err != nil {
+		return config, nil, fmt.Errorf("failed to get sensor pose in %s frame: %w", frame, err)
+	}
+	positions, err := s.gantry.Position(ctx, nil)
+	if err != nil {
+		return config, nil, fmt.Errorf("failed to get gantry position: %w", err)
+	}
+	lengths, err := s.gantry.Lengths(ctx, nil)
+	if err != nil {
+		return config, nil, fmt.Errorf("failed to get gantry lengths: %w", err)
+	}
+	if len(positions) == 0 {
+		return config, nil, fmt.Errorf("gantry has no axes")
+	}
+	plan, err := calibrationhelpers.PlanFromHint(*config.Hint, config.Hardware.Transducer(sensorPose.Pose()), positions[0], lengths,
+		config.Hardware.MotionSign(), config.Scanning)
+	if err != nil {
+		return config, nil, err
+	}
+	config.Scanning = plan.Apply(config.Scanning)
+	return config, &plan, nil
+}
+
+// hintWithoutMoving plans config.Hint like applyMonitorHint when the arm is already at home
+// Otherwise it warns and leaves the configured scan region, for commands that promise not to move anything.
+func (s *monitorCalibration) hintWithoutMoving(ctx context.Context,
+	config calibrationhelpers.CalibrationConfig) (calibrationhelpers.CalibrationConfig, *calibrationhelpers.HintPlan, error) {
+	logger := s.runLogger(config)
+	planned, plan, err := s.applyMonitorHint(ctx, config, false)
+	if errors.Is(err, errArmNotHome) {
+		logger.Warnf("Monitor hint not planned, %v: using the configured scan region", err)
+		return config, nil, nil
+	}
+	return planned, plan, err
+}
@@ -0,0 +1,47 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+)
+
+// defaultMonitorThicknessMM is how far behind the glass the calibrated monitor obstacle reaches
+const defaultMonitorThicknessMM = 50.0
+
+// MotionConfig plans scan moves with the motion service, around obstacles and within joint limits
+type MotionConfig struct {
+	Service            string  `json:"service"` // motion service name, usually "builtin"
+	MonitorThicknessMM float64 `json:"monitor_thickness_mm,omitempty"`
+}
+
+// Validate checks the motion configuration
+func (cfg *MotionConfig) Validate(path string) error {
+	if cfg.Service == "" {
+		return fmt.Errorf("missing 'service' field in %s", path)
+	}
+	if cfg.MonitorThicknessMM < 0 {
+		return fmt.Errorf("'monitor_thickness_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// thickness returns the configured monitor obstacle thickness or the default
+func (cfg *MotionConfig) thickness() float64 {
+	if cfg.MonitorThicknessMM > 0 {
+		return cfg.MonitorThicknessMM
+	}
+	return defaultMonitorThicknessMM
+}
+
+// motionConfig builds the motion planning config for the next calibration, nil when motion planning is off
+// The last calibrated monitor, if any, is added as an obstacle so the sensor never plans through the screen
+func (s *monitorCalibration) motionConfig() (*calibrationhelpers.MotionConfig, error) {
+	if s.motion == nil {
+		return nil, nil
+	}
+	worldState, err := calibrationhelpers.BuildWorldState(s.lastResult, s.cfg.Motion.thickness(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &calibrationhelpers.MotionConfig{Service: s.motion, WorldState: worldState}, nil
+}
@@ -0,0 +1,34 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// moveSensor handles the "move_sensor" command, moving the sensor to "pose" in the world frame with the gantry
+// and arm together, split as "split" or the configured 'sensor_move' says
+// The caller must hold doCommandLock
+func (s *monitorCalibration) moveSensor(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	poseMap, ok := cmd["pose"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("move_sensor needs a 'pose' with x, y, z, o_x, o_y, o_z and theta")
+	}
+	pose, err := calibrationhelpers.PoseFromMap(poseMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid 'pose': %w", err)
+	}
+	config := s.calibrationConfig
+	if split, ok := cmd["split"].(string); ok {
+		config.Hardware.SensorMove = split
+	}
+	move, err := calibrationhelpers.NewSensorMover(s.fs, s.arm, s.gantry, s.cfg.Sensor, config).MoveSensorTo(ctx, pose)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("✓ Moved sensor with the gantry at %.1f mm", move.GantryPosition)
+	return map[string]interface{}{
+		"gantry_position": move.GantryPosition,
+		"arm_pose":        calibrationhelpers.PoseToMap(move.ArmPose),
+	}, nil
+}
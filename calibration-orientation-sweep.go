@@ -0,0 +1,109 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"fmt"
+)
+
+// Orientation sweep defaults, used unless the config sets them
+const (
+	defaultSweepWaypoints        = 3
+	defaultSweepDeg              = 10.0
+	defaultSweepSteps            = 11
+	defaultMaxSweepCorrectionDeg = 2.0
+)
+
+// OrientationSweepConfig refines the fitted plane normal after the plane fit by sweeping wrist joints and finding
+// where the sensor reads the shortest distance, with its beam perpendicular to the glass
+type OrientationSweepConfig struct {
+	Waypoints        int     `json:"waypoints,omitempty"`          // gantry positions to sweep at, default 3
+	Joints           []int   `json:"joints,omitempty"`             // joint indices to sweep, default the wrist_joint
+	SweepDeg         float64 `json:"sweep_deg,omitempty"`          // half-angle of each sweep, default 10
+	Steps            int     `json:"steps,omitempty"`              // readings per sweep, default 11
+	MaxCorrectionDeg float64 `json:"max_correction_deg,omitempty"` // largest accepted normal change, default 2
+}
+
+// Validate checks the orientation sweep configuration
+func (cfg *OrientationSweepConfig) Validate(path string) error {
+	if cfg.Waypoints < 0 {
+		return fmt.Errorf("'waypoints' must not be negative in %s", path)
+	}
+	for _, joint := range cfg.Joints {
+		if joint < 0 {
+			return fmt.Errorf("'joints' must not be negative in %s", path)
+		}
+	}
+	if cfg.SweepDeg < 0 || cfg.SweepDeg >= 45 {
+		return fmt.Errorf("'sweep_deg' must be between 0 and 45 in %s", path)
+	}
+	if cfg.Steps != 0 && cfg.Steps < 3 {
+		return fmt.Errorf("'steps' must be at least 3 in %s", path)
+	}
+	if cfg.MaxCorrectionDeg < 0 {
+		return fmt.Errorf("'max_correction_deg' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// sweep converts the config to the orientation sweep the calibration runs, sweeping wristJoint by default
+func (cfg *OrientationSweepConfig) sweep(wristJoint int) *calibrationhelpers.OrientationSweepConfig {
+	sweep := &calibrationhelpers.OrientationSweepConfig{
+		Waypoints:     cfg.Waypoints,
+		Joints:        cfg.Joints,
+		SweepAngle:    cfg.SweepDeg,
+		Steps:         cfg.Steps,
+		MaxCorrection: cfg.MaxCorrectionDeg,
+	}
+	if sweep.Waypoints == 0 {
+		sweep.Waypoints = defaultSweepWaypoints
+	}
+	if len(sweep.Joints) == 0 {
+		sweep.Joints = []int{wristJoint}
+	}
+	if sweep.SweepAngle == 0 {
+		sweep.SweepAngle = defaultSweepDeg
+	}
+	if sweep.Steps == 0 {
+		sweep.Steps = defaultSweepSteps
+	}
+	if sweep.MaxCorrection == 0 {
+		sweep.MaxCorrection = defaultMaxSweepCorrectionDeg
+	}
+	return sweep
+}
+
+// refineOrientation runs the orientation sweeps when config.OrientationSweep is set, returning the plane with the
+// refined normal, or the fitted plane when the sweeps couldn't refine it
+func (s *monitorCalibration) refineOrientation(ctx context.Context, config calibrationhelpers.CalibrationConfig,
+	plane types.Plane, points []types.Point3D) (types.Plane, error) {
+	logger := s.runLogger(config)
+	if config.OrientationSweep == nil {
+		return plane, nil
+	}
+	if config.Detection.Curved {
+		logger.Warn("Skipping orientation sweeps, curved monitors are oriented by the cylinder fit")
+		return plane, nil
+	}
+	logger.Info("Refining plane normal with orientation sweeps...")
+	refined, report, err := calibrationhelpers.RefineOrientation(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, config, plane, points)
+	if err != nil {
+		return types.Plane{}, err
+	}
+	used := 0
+	for _, sweep := range report.Sweeps {
+		if sweep.Error == "" {
+			used++
+		}
+	}
+	if !report.Applied {
+		logger.Warnf("Orientation sweeps not applied, keeping the fitted normal: %s", report.Reason)
+		s.progress.event("warn", "orientation sweeps not applied: "+report.Reason)
+		return plane, nil
+	}
+	logger.Infof("✓ Orientation sweeps moved the normal %.3f° (%d of %d sweeps, %d constrained directions)",
+		report.CorrectionDeg, used, len(report.Sweeps), report.Constraints)
+	logger.Infof("  Refined plane equation: %f*x + %f*y + %f*z = %f", refined.A, refined.B, refined.C, refined.D)
+	return refined, nil
+}
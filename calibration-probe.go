@@ -0,0 +1,62 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// probePoint handles the "probe_point" command, measuring the world "point" on the calibrated monitor with
+// "samples" filtered readings from "standoff_mm" out along the surface normal
+// The caller must hold doCommandLock
+func (s *monitorCalibration) probePoint(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration to probe, run calibrate first")
+	}
+	if s.lastResult.Frame != s.calibrationConfig.Hardware.ReferenceFrame() {
+		return nil, fmt.Errorf("last calibration is in the %s frame, not %s",
+			s.lastResult.Frame, s.calibrationConfig.Hardware.ReferenceFrame())
+	}
+	pointMap, ok := cmd["point"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("probe_point needs a 'point' with x, y and z in the world frame")
+	}
+	var target calibrationhelpers.Point3D
+	for key, v := range map[string]*float64{"x": &target.X, "y": &target.Y, "z": &target.Z} {
+		if *v, ok = pointMap[key].(float64); !ok {
+			return nil, fmt.Errorf("probe_point 'point' is missing %q", key)
+		}
+	}
+	standoff := 0.0
+	if raw, ok := cmd["standoff_mm"]; ok {
+		if standoff, ok = raw.(float64); !ok || standoff <= 0 {
+			return nil, fmt.Errorf("probe_point 'standoff_mm' must be a positive number")
+		}
+	}
+
+	config := s.calibrationConfig
+	if raw, ok := cmd["samples"]; ok {
+		samples, ok := raw.(float64)
+		if !ok || samples < 1 || samples != float64(int(samples)) {
+			return nil, fmt.Errorf("probe_point 'samples' must be a positive integer")
+		}
+		config.Scanning.SamplesPerPoint = int(samples)
+	}
+	// Without a configured filter the readings are still combined, by the Kalman filter's running estimate
+	if config.Filter.Mode == calibrationhelpers.FilterNone {
+		config.Filter.Mode = calibrationhelpers.FilterKalman
+	}
+
+	probe, err := calibrationhelpers.ProbePoint(ctx, s.logger, s.fs, s.sensor, s.arm, s.gantry, *s.lastResult, config,
+		target, standoff)
+	if err != nil {
+		return nil, err
+	}
+	if probe.Hit {
+		s.logger.Infof("✓ Probed (%.1f, %.1f, %.1f): residual %+.2f mm, %.2f mm from the target",
+			target.X, target.Y, target.Z, probe.Residual, probe.TargetOffset)
+	} else {
+		s.logger.Warnf("Probe at (%.1f, %.1f, %.1f) missed the monitor", target.X, target.Y, target.Z)
+	}
+	return probe.ToMap()
+}
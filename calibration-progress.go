@@ -0,0 +1,366 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Calibration phases reported by the "status" command, in run order
+const (
+	phaseIdle            = "idle"
+	phaseHoming          = "homing"
+	phaseCentering       = "centering"
+	phaseScanning        = "scanning"
+	phasePlaneFit        = "plane_fit"
+	phaseVerticalEdges   = "vertical_edges"
+	phaseHorizontalEdges = "horizontal_edges"
+	phaseCorners         = "corners"
+	phaseValidation      = "validation"
+	phaseDone            = "done"
+	phaseFailed          = "failed"
+)
+
+// phaseStart is the share of a calibration run completed when each phase begins
+// Scanning moves the hardware the most, so it covers half of the estimate and advances with every point
+var phaseStart = map[string]float64{
+	phaseHoming:          0,
+	phaseCentering:       0,
+	phaseScanning:        0.05,
+	phasePlaneFit:        0.55,
+	phaseVerticalEdges:   0.6,
+	phaseHorizontalEdges: 0.75,
+	phaseCorners:         0.9,
+	phaseValidation:      0.98,
+	phaseDone:            1,
+}
+
+// maxEvents bounds the in-memory event log, older events are dropped first
+const maxEvents = 1000
+
+// calibrationEvent is one entry of the event log returned by "get_events"
+type calibrationEvent struct {
+	Seq     int       `json:"seq"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Phase   string    `json:"phase"`
+	Session string    `json:"session_id,omitempty"` // the calibration session the event belongs to
+	Message string    `json:"message"`
+}
+
+// progressTracker records the state of the running calibration for "status" and "get_events"
+// It has its own lock so operators can poll while DoCommand is busy calibrating
+type progressTracker struct {
+	mu sync.Mutex
+
+	running   bool
+	target    string
+	session   string // ID of the current or last calibration session
+	phase     string
+	started   time.Time
+	finished  time.Time
+	lastError string
+	// errorClass is calibrationhelpers.ErrorClass of the last error, "" when it has none
+	errorClass string
+
+	visited   int                              // scan waypoints visited this run
+	points    int                              // scan points collected this run
+	scanTotal int                              // expected scan waypoints, zero if unknown
+	scanOpen  bool                             // scanTotal grows as scans start, adaptive rounds not being planned up front
+	scanLabel string                           // scan of the most recent waypoint
+	scanIndex int                              // index of the most recent waypoint in its scan
+	scanPlan  int                              // waypoints planned for the most recent waypoint's scan
+	waypoint  *calibrationhelpers.ScanProgress // most recent scan waypoint
+	fit       *types.Covariance                // running plane fit of the current scan, nil until it spans a plane
+
+	control *calibrationhelpers.ScanControl // scan changes, pauses and aborts for the running calibration, nil when idle
+	cancel  context.CancelFunc              // cancels the running calibration, nil when idle
+
+	events  []calibrationEvent
+	nextSeq int
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{phase: phaseIdle, nextSeq: 1}
+}
+
+// begin resets the progress for a new run, whose scan takes live changes through control and is aborted by cancel
+func (p *progressTracker) begin(target, session string, control *calibrationhelpers.ScanControl, cancel context.CancelFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = true
+	p.target = target
+	p.session = session
+	p.control = control
+	p.cancel = cancel
+	p.phase = phaseCentering
+	p.started = time.Now()
+	p.finished = time.Time{}
+	p.lastError, p.errorClass = "", ""
+	p.visited, p.points, p.scanTotal, p.waypoint, p.fit = 0, 0, 0, nil, nil
+	p.scanOpen, p.scanLabel, p.scanIndex, p.scanPlan = false, "", 0, 0
+	if target != "" {
+		p.recordLocked("info", "calibration of target "+target+" started")
+	} else {
+		p.recordLocked("info", "calibration started")
+	}
+}
+
+// setPhase moves the run to the next phase
+func (p *progressTracker) setPhase(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = phase
+	p.recordLocked("info", "entering phase "+phase)
+}
+
+// setScanTotal records how many scan waypoints the scanning phase is expected to visit, zero when that is only
+// known scan by scan
+func (p *progressTracker) setScanTotal(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scanTotal, p.scanOpen = total, total == 0
+}
+
+// scanPoint records a visited scan waypoint, logging an event if it didn't produce a point
+func (p *progressTracker) scanPoint(progress calibrationhelpers.ScanProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Keep the expected total in step with the plan: a scan replanned by "update_scan" has a different number of
+	// waypoints left, and the rounds of an adaptive scan are only known as each one starts
+	if progress.Label != p.scanLabel {
+		if p.scanOpen {
+			p.scanTotal = p.visited + progress.Total - progress.Index
+		}
+	} else if progress.Total != p.scanPlan && p.scanTotal > 0 {
+		p.scanTotal += (progress.Total - progress.Index) - (p.scanPlan - p.scanIndex - 1)
+	}
+	p.scanLabel, p.scanIndex, p.scanPlan = progress.Label, progress.Index, progress.Total
+	p.visited++
+	p.waypoint = &progress
+	if progress.Fit != nil {
+		p.fit = progress.Fit
+	}
+	if progress.Status == types.WaypointOK {
+		p.points++
+		return
+	}
+	p.recordLocked("warn", fmt.Sprintf("%s scan waypoint %d skipped, %s: %s",
+		progress.Label, progress.Index+1, progress.Status, progress.Detail))
+}
+
+// event appends a message to the event log
+func (p *progressTracker) event(level, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recordLocked(level, message)
+}
+
+// finish ends the run, recording the error if it failed
+func (p *progressTracker) finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+	p.control = nil
+	p.cancel = nil
+	p.finished = time.Now()
+	if err != nil {
+		p.phase = phaseFailed
+		p.lastError = err.Error()
+		p.errorClass = calibrationhelpers.ErrorClass(err)
+		p.recordLocked("error", "calibration failed: "+err.Error())
+		return
+	}
+	p.phase = phaseDone
+	p.recordLocked("info", "calibration finished")
+}
+
+func (p *progressTracker) recordLocked(level, message string) {
+	p.events = append(p.events, calibrationEvent{
+		Seq:     p.nextSeq,
+		Time:    time.Now().UTC(),
+		Level:   level,
+		Phase:   p.phase,
+		Session: p.session,
+		Message: message,
+	})
+	p.nextSeq++
+	if len(p.events) > maxEvents {
+		p.events = append([]calibrationEvent{}, p.events[len(p.events)-maxEvents:]...)
+	}
+}
+
+// percentCompleteLocked estimates the share of the run that is done, from the phase and the scan points
+func (p *progressTracker) percentCompleteLocked() float64 {
+	if p.phase == phaseFailed || p.phase == phaseIdle {
+		return 0
+	}
+	fraction := phaseStart[p.phase]
+	if p.phase == phaseScanning && p.scanTotal > 0 {
+		scanned := min(1, float64(p.visited)/float64(p.scanTotal))
+		fraction += scanned * (phaseStart[phasePlaneFit] - phaseStart[phaseScanning])
+	}
+	return 100 * fraction
+}
+
+// status handles the "status" command
+func (p *progressTracker) status() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent := p.percentCompleteLocked()
+	status := map[string]interface{}{
+		"running":          p.running,
+		"phase":            p.phase,
+		"target":           p.target,
+		"session_id":       p.session,
+		"percent_complete": percent,
+		"points_collected": p.points,
+		"paused":           p.control != nil && p.control.Paused(),
+	}
+	if p.waypoint != nil {
+		status["current_waypoint"] = map[string]interface{}{
+			"scan":        p.waypoint.Label,
+			"index":       p.waypoint.Index,
+			"total":       p.waypoint.Total,
+			"id":          p.waypoint.Waypoint.ID,
+			"gantry_mm":   p.waypoint.Waypoint.X,
+			"z_offset_mm": p.waypoint.Waypoint.Z,
+			"status":      p.waypoint.Status,
+		}
+	}
+	if p.fit != nil {
+		status["running_fit"] = map[string]interface{}{
+			"normal_std_dev": p.fit.NormalStdDev,
+			"offset_std_dev": p.fit.OffsetStdDev,
+			"residual_rms":   p.fit.ResidualRMS,
+			"samples":        p.fit.Samples,
+		}
+	}
+	if !p.started.IsZero() {
+		status["started"] = p.started.UTC().Format(time.RFC3339)
+		end := p.finished
+		if p.running {
+			end = time.Now()
+		}
+		elapsed := end.Sub(p.started)
+		status["elapsed_sec"] = elapsed.Seconds()
+		if p.running && percent > 0 {
+			status["eta_sec"] = elapsed.Seconds() * (100 - percent) / percent
+		}
+	}
+	if p.lastError != "" {
+		status["error"] = p.lastError
+	}
+	if p.errorClass != "" {
+		status["error_class"] = p.errorClass
+	}
+	return status
+}
+
+// getEvents handles the "get_events" command, returning events after the optional "since" sequence number
+func (p *progressTracker) getEvents(cmd map[string]interface{}) map[string]interface{} {
+	since, _ := cmd["since"].(float64)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := []interface{}{}
+	for _, e := range p.events {
+		if float64(e.Seq) <= since {
+			continue
+		}
+		events = append(events, map[string]interface{}{
+			"seq":        e.Seq,
+			"time":       e.Time.Format(time.RFC3339Nano),
+			"level":      e.Level,
+			"phase":      e.Phase,
+			"session_id": e.Session,
+			"message":    e.Message,
+		})
+	}
+	return map[string]interface{}{
+		"events":   events,
+		"last_seq": p.nextSeq - 1, // pass back as "since" to poll for newer events
+	}
+}
+
+// runCalibration calibrates with progress tracking, target names the configured target or is empty
+func (s *monitorCalibration) runCalibration(ctx context.Context, target string, config calibrationhelpers.CalibrationConfig) (types.CalibrationResult, error) {
+	motionConfig, err := s.motionConfig()
+	if err != nil {
+		return types.CalibrationResult{}, err
+	}
+	config.Motion = motionConfig
+	config.Control = calibrationhelpers.NewScanControl()
+	// A resumed run continues the interrupted run's session
+	resumed := ""
+	if config.Resume != nil {
+		resumed = config.Resume.SessionID
+	}
+	config.SessionID = s.newSession(resumed)
+	logger := s.runLogger(config)
+	logger.Infof("Calibration session %s", config.SessionID)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.progress.begin(target, config.SessionID, config.Control, cancel)
+	s.metrics.begin()
+	recorder, logPath := s.openScanLog(logger, target, config.SessionID)
+	// A resumed run keeps adding to the interrupted run's session, so it can be resumed again
+	session := config.Resume
+	if session == nil {
+		session = calibrationhelpers.NewScanSession(target, config.SessionID, config)
+	}
+	s.startSession(session)
+	if s.captureBuffer != nil {
+		s.captureBuffer.Begin(config.SessionID, target)
+	}
+	s.scanTrace.Begin(target)
+	var waypoints []calibrationhelpers.ScanLogEntry
+	config.Progress = func(progress calibrationhelpers.ScanProgress) {
+		waypoints = append(waypoints, calibrationhelpers.ScanLogEntryFromProgress(progress))
+		s.progress.scanPoint(progress)
+		if !progress.Replayed {
+			s.metrics.scanPoint(progress)
+		}
+		s.recordSession(session, progress)
+		if recorder != nil {
+			recorder.Record(progress)
+		}
+		if s.captureBuffer != nil {
+			s.captureBuffer.Record(progress)
+		}
+		s.scanTrace.Record(progress)
+	}
+	result, err := s.calibrate(ctx, config)
+	s.lastWaypoints, s.lastWaypointsSession = waypoints, config.SessionID
+	err = s.finishSession(config, err)
+	s.closeScanLog(logger, recorder, logPath)
+	if err == nil {
+		s.writeSessionReport(target, result)
+	}
+	s.progress.finish(err)
+	s.metrics.finish(result, err)
+	return result, err
+}
+
+// expectedScanPoints estimates how many waypoints the configured scan visits, zero if unknown
+// Adaptive scans decide how far to refine as they go, so their total is unknown
+func (s *monitorCalibration) expectedScanPoints(ctx context.Context, config calibrationhelpers.CalibrationConfig) int {
+	switch config.Scanning.Mode {
+	case calibrationhelpers.ScanModeAngular:
+		return config.Scanning.AngularHolds * config.Scanning.WristSweepSteps
+	case calibrationhelpers.ScanModeGrid:
+		waypoints, err := calibrationhelpers.PlanGridScan(ctx, s.gantry, config.Scanning)
+		if err != nil {
+			return 0
+		}
+		return len(waypoints)
+	case calibrationhelpers.ScanModeAdaptive:
+		return 0
+	default:
+		return config.Scanning.ZNumSteps + config.Scanning.XNumSteps
+	}
+}
@@ -0,0 +1,44 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"fmt"
+	"path/filepath"
+)
+
+// writeSessionReport writes the HTML report of a successful calibration of the named target, empty for an
+// untargeted run, to report_dir
+// A report that can't be written is only logged, since the calibration itself succeeded
+func (s *monitorCalibration) writeSessionReport(target string, result types.CalibrationResult) {
+	if s.cfg.ReportDir == "" {
+		return
+	}
+	name := s.name.Name
+	if target != "" {
+		name += "-" + target
+	}
+	path := filepath.Join(s.cfg.ReportDir,
+		fmt.Sprintf("%s-%s.html", name, result.Timestamp.UTC().Format("20060102T150405Z")))
+	if err := calibrationhelpers.WriteReport(path, target, result, s.lastScan); err != nil {
+		s.logger.Warnf("Failed to write calibration report: %v", err)
+		return
+	}
+	s.logger.Infof("✓ Calibration report written to %s", path)
+}
+
+// exportReport handles the "export_report" command, writing the HTML report of the last calibration to "path"
+func (s *monitorCalibration) exportReport(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("export_report requires a 'path'")
+	}
+	if err := calibrationhelpers.WriteReport(path, "", *s.lastResult, s.lastScan); err != nil {
+		return nil, err
+	}
+	s.logger.Infof("✓ Exported calibration report to %s", path)
+	return map[string]interface{}{"path": path, "session_id": s.lastResult.SessionID}, nil
+}
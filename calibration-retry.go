@@ -0,0 +1,50 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"time"
+)
+
+// ReadRetryConfig retries failed sensor reads at scan waypoints and edge search steps with exponential backoff
+type ReadRetryConfig struct {
+	MaxAttempts    int    `json:"max_attempts,omitempty"`    // reads tried per sample, 1 to never retry
+	Backoff        string `json:"backoff,omitempty"`         // wait before the first retry, e.g. "100ms"
+	MaxBackoff     string `json:"max_backoff,omitempty"`     // longest wait between retries
+	AttemptTimeout string `json:"attempt_timeout,omitempty"` // max time for a single read attempt
+}
+
+// Validate checks the retry configuration
+func (cfg *ReadRetryConfig) Validate(path string) error {
+	if cfg.MaxAttempts < 0 {
+		return fmt.Errorf("'max_attempts' must not be negative in %s", path)
+	}
+	for name, value := range map[string]string{
+		"backoff": cfg.Backoff, "max_backoff": cfg.MaxBackoff, "attempt_timeout": cfg.AttemptTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("'%s' must be a non-negative duration in %s", name, path)
+		}
+	}
+	return nil
+}
+
+// apply copies the configured retries into the calibration retry config, keeping defaults for unset fields
+// The durations were checked by Validate.
+func (cfg *ReadRetryConfig) apply(config *calibrationhelpers.RetryConfig) {
+	if cfg.MaxAttempts > 0 {
+		config.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.Backoff != "" {
+		config.Backoff, _ = time.ParseDuration(cfg.Backoff)
+	}
+	if cfg.MaxBackoff != "" {
+		config.MaxBackoff, _ = time.ParseDuration(cfg.MaxBackoff)
+	}
+	if cfg.AttemptTimeout != "" {
+		config.AttemptTimeout, _ = time.ParseDuration(cfg.AttemptTimeout)
+	}
+}
@@ -0,0 +1,94 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.viam.com/rdk/logging"
+)
+
+// ScanLogConfig records every scan waypoint of each calibration to its own file, for post-mortem analysis of
+// bad calibrations
+type ScanLogConfig struct {
+	Dir    string `json:"dir,omitempty"`    // directory for the logs, defaults to scan-logs in the module data directory
+	Format string `json:"format,omitempty"` // "csv" (default) or "jsonl"
+
+	// Compression ("gzip" or "zstd") and ChunkEntries keep long continuous-motion logs from bloating the data
+	// directory. With ChunkEntries set each log is split into files of that many waypoints, listed in an index.
+	Compression  string `json:"compression,omitempty"`
+	ChunkEntries int    `json:"chunk_entries,omitempty"`
+}
+
+// Validate checks the scan log configuration
+func (cfg *ScanLogConfig) Validate(path string) error {
+	if err := cfg.options().Validate(); err != nil {
+		return fmt.Errorf("invalid scan log in %s: %w", path, err)
+	}
+	if cfg.Dir == "" && os.Getenv("VIAM_MODULE_DATA") == "" {
+		return fmt.Errorf("missing 'dir' field in %s, required when there is no module data directory", path)
+	}
+	return nil
+}
+
+// dir returns the directory the scan logs are written to
+func (cfg *ScanLogConfig) dir() string {
+	if cfg.Dir == "" {
+		return filepath.Join(os.Getenv("VIAM_MODULE_DATA"), "scan-logs")
+	}
+	return cfg.Dir
+}
+
+// options returns how the scan logs are stored
+func (cfg *ScanLogConfig) options() calibrationhelpers.ScanLogOptions {
+	opts := calibrationhelpers.ScanLogOptions{
+		Format:       cfg.Format,
+		Compression:  cfg.Compression,
+		ChunkEntries: cfg.ChunkEntries,
+	}
+	if opts.Format == "" {
+		opts.Format = calibrationhelpers.ScanLogCSV
+	}
+	return opts
+}
+
+// openScanLog starts the scan log for a calibration of the named target, empty for an untargeted run, named after
+// the run's session
+// Returns nil without a scan log configured, or when the log can't be created, since a missing log shouldn't stop
+// the calibration
+func (s *monitorCalibration) openScanLog(logger logging.Logger, target, sessionID string) (*calibrationhelpers.ScanRecorder, string) {
+	scanLog := s.cfg.ScanLog
+	if scanLog == nil {
+		return nil, ""
+	}
+	name := s.name.Name
+	if target != "" {
+		name += "-" + target
+	}
+	opts := scanLog.options()
+	path := filepath.Join(scanLog.dir(),
+		fmt.Sprintf("%s-%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), sessionID, opts.Format))
+	recorder, err := calibrationhelpers.CreateScanRecorder(path, opts)
+	if err != nil {
+		logger.Warnf("Not recording this calibration's scan: %v", err)
+		s.progress.event("warn", "scan log disabled: "+err.Error())
+		return nil, ""
+	}
+	logger.Infof("Recording scan to %s", recorder.Path())
+	return recorder, recorder.Path()
+}
+
+// closeScanLog finishes a scan log opened by openScanLog
+func (s *monitorCalibration) closeScanLog(logger logging.Logger, recorder *calibrationhelpers.ScanRecorder, path string) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		logger.Warnf("Scan log %s is incomplete: %v", path, err)
+		s.progress.event("warn", "scan log incomplete: "+err.Error())
+		return
+	}
+	logger.Infof("✓ Recorded %d scan waypoints to %s", recorder.Entries(), path)
+}
@@ -0,0 +1,12 @@
+package calibration
+
+// defaultScanTraceSize is how many scan waypoints the trace keeps unless scan_trace_size is set
+const defaultScanTraceSize = 500
+
+// getScanTrace handles the "get_scan_trace" command, returning the traced scan waypoints after the optional
+// "since" sequence number
+// It answers while a calibration runs, so a UI can poll it to draw the scan trajectory live.
+func (s *monitorCalibration) getScanTrace(cmd map[string]interface{}) (map[string]interface{}, error) {
+	since, _ := cmd["since"].(float64)
+	return s.scanTrace.Since(int(since)).ToMap()
+}
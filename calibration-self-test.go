@@ -0,0 +1,24 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+)
+
+// selfTest handles the "self_test" command, checking the arm, gantry, sensor and frame system before calibrating
+// Nothing moves, so it is safe to run on a rig that was just assembled.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) selfTest(ctx context.Context) (map[string]interface{}, error) {
+	report := calibrationhelpers.SelfTest(ctx, s.fs, s.sensor, s.arm, s.gantry, s.calibrationConfig)
+	for _, check := range report.Checks {
+		switch check.Status {
+		case calibrationhelpers.SelfTestPass:
+			s.logger.Infof("✓ Self-test %s: %s", check.Name, check.Message)
+		case calibrationhelpers.SelfTestSkip:
+			s.logger.Warnf("Self-test %s skipped: %s", check.Name, check.Message)
+		default:
+			s.logger.Errorf("✗ Self-test %s: %s", check.Name, check.Message)
+		}
+	}
+	return report.ToMap()
+}
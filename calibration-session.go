@@ -0,0 +1,220 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// sessionPath is where the running calibration's scan session is saved, next to the results, "" when there is
+// nowhere to save it
+func (s *monitorCalibration) sessionPath() string {
+	resultsPath := s.cfg.ResultsPath
+	if resultsPath == "" {
+		resultsPath = defaultResultsPath(s.name.Name)
+	}
+	if resultsPath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(resultsPath, ".json") + "-session.json"
+}
+
+// maxSessionAge is how long after it started an interrupted calibration can still be resumed, after which the
+// monitor or rig may well have moved
+const maxSessionAge = 24 * time.Hour
+
+// startSession saves the session a run starts with, so its waypoints can be appended as they are visited
+// A session that can't be saved only costs the ability to resume, so it doesn't stop the calibration
+func (s *monitorCalibration) startSession(session *calibrationhelpers.ScanSession) {
+	path := s.sessionPath()
+	if path == "" {
+		return
+	}
+	if err := calibrationhelpers.SaveSession(path, session); err != nil {
+		s.logger.Warnf("Failed to save calibration session: %v", err)
+	}
+}
+
+// recordSession adds a scan waypoint to the running session and appends it to the saved one, so a restart can
+// resume from it
+func (s *monitorCalibration) recordSession(session *calibrationhelpers.ScanSession, progress calibrationhelpers.ScanProgress) {
+	if progress.Replayed {
+		return // already in the resumed session
+	}
+	wp := session.Record(progress)
+	path := s.sessionPath()
+	if path == "" {
+		return
+	}
+	if err := calibrationhelpers.AppendSession(path, wp); err != nil {
+		s.logger.Warnf("Failed to save calibration session: %v", err)
+	}
+}
+
+// discardSession deletes the saved session, reporting whether there was one
+func (s *monitorCalibration) discardSession() bool {
+	path := s.sessionPath()
+	if path == "" {
+		return false
+	}
+	if err := os.Remove(path); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warnf("Failed to discard calibration session: %v", err)
+		}
+		return false
+	}
+	return true
+}
+
+// logSavedSession reports a session left behind by a calibration that didn't finish, on startup
+func (s *monitorCalibration) logSavedSession() {
+	path := s.sessionPath()
+	if path == "" {
+		return
+	}
+	session, err := calibrationhelpers.LoadSession(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warnf("Ignoring unreadable calibration session: %v", err)
+		}
+		return
+	}
+	s.logger.Infof("Found an interrupted calibration from %s with %d scan waypoints, \"resume\" to continue it or \"abort\" to discard it",
+		session.Started.Format("2006-01-02 15:04:05"), session.Len())
+}
+
+// enterPhase moves the run to the next phase once any pause has ended
+// Phases start with the arm and gantry at rest, so they are where a pause takes hold between scans
+func (s *monitorCalibration) enterPhase(ctx context.Context, config calibrationhelpers.CalibrationConfig, phase string) error {
+	if err := config.Control.Checkpoint(ctx); err != nil {
+		return err
+	}
+	s.progress.setPhase(phase)
+	return nil
+}
+
+// pause handles the "pause" command, holding the running calibration at its next waypoint or phase
+func (p *progressTracker) pause() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.control == nil {
+		return nil, fmt.Errorf("no calibration is running")
+	}
+	if p.control.Pause() {
+		p.recordLocked("info", "calibration pausing after the current move")
+	}
+	return map[string]interface{}{
+		"paused": true,
+		"phase":  p.phase,
+	}, nil
+}
+
+// resumeRunning resumes a paused calibration, false when none is running
+func (p *progressTracker) resumeRunning() (map[string]interface{}, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.control == nil {
+		return nil, false, nil
+	}
+	if !p.control.Resume() {
+		return nil, true, fmt.Errorf("calibration is running and not paused")
+	}
+	p.recordLocked("info", "calibration resumed")
+	return map[string]interface{}{
+		"paused": false,
+		"phase":  p.phase,
+	}, true, nil
+}
+
+// abortRunning aborts the running calibration, cancelling the move in progress, false when none is running
+func (p *progressTracker) abortRunning() (map[string]interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.control == nil {
+		return nil, false
+	}
+	p.control.Abort()
+	p.cancel()
+	p.recordLocked("warn", "calibration aborted")
+	return map[string]interface{}{
+		"aborted": true,
+		"phase":   p.phase,
+	}, true
+}
+
+// abort handles the "abort" command, stopping the running calibration and discarding its session
+// With no calibration running it discards the session of an interrupted one
+func (s *monitorCalibration) abort(ctx context.Context) (map[string]interface{}, error) {
+	response, running := s.progress.abortRunning()
+	if !running {
+		if !s.discardSession() {
+			return nil, fmt.Errorf("no calibration session to abort")
+		}
+		s.logger.Info("Discarded the interrupted calibration session")
+		return map[string]interface{}{"aborted": true, "phase": phaseIdle}, nil
+	}
+
+	// Cancelling the context ends the move in progress, stopping makes sure the hardware does too
+	if err := s.arm.Stop(ctx, nil); err != nil {
+		s.logger.Warnf("Failed to stop arm: %v", err)
+	}
+	if err := s.gantry.Stop(ctx, nil); err != nil {
+		s.logger.Warnf("Failed to stop gantry: %v", err)
+	}
+	return response, nil
+}
+
+// resumeSession handles the "resume" command when no calibration is running, continuing the saved session of an
+// interrupted one from its last completed waypoint
+// The caller must hold doCommandLock
+func (s *monitorCalibration) resumeSession(ctx context.Context) (map[string]interface{}, error) {
+	path := s.sessionPath()
+	if path == "" {
+		return nil, fmt.Errorf("no calibration session to resume, calibration sessions need a 'results_path'")
+	}
+	session, err := calibrationhelpers.LoadSession(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no calibration session to resume")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := s.calibrationConfig
+	if session.Target != "" {
+		target, err := s.findTarget(session.Target)
+		if err != nil {
+			return nil, err
+		}
+		if config, err = target.apply(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := session.Check(config, maxSessionAge); err != nil {
+		return nil, fmt.Errorf("can't resume: %w, \"abort\" to discard it", err)
+	}
+	config.Resume = session
+	s.logger.Infof("Resuming the calibration from %s after %d scan waypoints",
+		session.Started.Format("2006-01-02 15:04:05"), session.Len())
+	result, err := s.runCalibration(ctx, session.Target, config)
+	if err != nil {
+		return nil, err
+	}
+	return s.calibrationReport(result, config)
+}
+
+// finishSession keeps the session of a run that failed, so it can be resumed, and discards it otherwise
+// A run aborted after its last checkpoint has already finished, so it still succeeds.
+func (s *monitorCalibration) finishSession(config calibrationhelpers.CalibrationConfig, err error) error {
+	if err == nil || config.Control.Aborted() {
+		s.discardSession()
+	}
+	if err != nil && config.Control.Aborted() && !errors.Is(err, calibrationhelpers.ErrCalibrationAborted) {
+		return fmt.Errorf("%w: %w", calibrationhelpers.ErrCalibrationAborted, err)
+	}
+	return err
+}
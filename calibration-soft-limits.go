@@ -0,0 +1,99 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Default closest approach of the tool tip to the glass during jogs
+const defaultSoftLimitMargin = 10.0 // mm
+
+// SoftLimitsConfig enables the "jog" command, which moves the arm for manual spot cleaning after calibration but
+// never lets the tool cross the calibrated monitor
+type SoftLimitsConfig struct {
+	MarginMM     *float64 `json:"margin_mm,omitempty"`      // closest the tool tip may come to the glass, 10 if unset
+	ToolLengthMM float64  `json:"tool_length_mm,omitempty"` // from the arm's end effector to the tool tip
+}
+
+// Validate checks the soft limits configuration
+func (cfg *SoftLimitsConfig) Validate(path string) error {
+	if cfg.MarginMM != nil && *cfg.MarginMM < 0 {
+		return fmt.Errorf("'margin_mm' must not be negative in %s", path)
+	}
+	if cfg.ToolLengthMM < 0 {
+		return fmt.Errorf("'tool_length_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// margin returns the closest the tool tip may come to the glass in mm, where an explicit zero lets it touch
+func (cfg *SoftLimitsConfig) margin() float64 {
+	if cfg.MarginMM == nil {
+		return defaultSoftLimitMargin
+	}
+	return *cfg.MarginMM
+}
+
+// jog handles the "jog" command, moving the arm by "x", "y" and "z" mm in the reference frame
+// The move is clamped to the soft limits around the last calibrated monitor.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) jog(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.cfg.SoftLimits == nil {
+		return nil, fmt.Errorf("jogging needs 'soft_limits' in the config")
+	}
+	if s.calibrationConfig.Hardware.Topology == calibrationhelpers.TopologyMovingMonitor {
+		return nil, fmt.Errorf("jogging is only supported with the %s topology", calibrationhelpers.TopologyMovingSensor)
+	}
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration to limit the jog, run calibrate first")
+	}
+	frame := s.calibrationConfig.Hardware.ReferenceFrame()
+	if s.lastResult.Frame != frame {
+		return nil, fmt.Errorf("last calibration is in the %s frame, not %s", s.lastResult.Frame, frame)
+	}
+	var requested r3.Vector
+	requested.X, _ = cmd["x"].(float64)
+	requested.Y, _ = cmd["y"].(float64)
+	requested.Z, _ = cmd["z"].(float64)
+
+	current, err := s.fs.GetPose(ctx, s.cfg.Arm, frame, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get arm pose: %w", err)
+	}
+	// The tool works from in front of the glass, so where it is now tells the limits which side that is
+	limits, err := calibrationhelpers.NewSoftLimits(*s.lastResult, current.Pose().Point(), s.cfg.SoftLimits.margin(),
+		s.cfg.SoftLimits.ToolLengthMM)
+	if err != nil {
+		return nil, err
+	}
+	target := spatialmath.NewPose(current.Pose().Point().Add(requested), current.Pose().Orientation())
+	target, clamped := limits.ClampPose(current.Pose(), target)
+	applied := target.Point().Sub(current.Pose().Point())
+	if clamped {
+		s.logger.Infof("Jog limited to %.1f mm from the monitor: requested (%.1f, %.1f, %.1f) mm, moving (%.1f, %.1f, %.1f) mm",
+			limits.Margin, requested.X, requested.Y, requested.Z, applied.X, applied.Y, applied.Z)
+	}
+
+	// Like the scans, the arm's base frame is taken to be aligned with the reference frame
+	armPose, err := s.arm.EndPosition(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get arm position: %w", err)
+	}
+	if err := s.arm.MoveToPosition(ctx, spatialmath.NewPose(armPose.Point().Add(applied), armPose.Orientation()), nil); err != nil {
+		if calibrationhelpers.IsUnreachable(err) {
+			return nil, fmt.Errorf("failed to jog arm: %w: %w", calibrationhelpers.ErrWorkspaceExceeded, err)
+		}
+		return nil, fmt.Errorf("failed to jog arm: %w", err)
+	}
+	return map[string]interface{}{
+		"requested":    map[string]interface{}{"x": requested.X, "y": requested.Y, "z": requested.Z},
+		"applied":      map[string]interface{}{"x": applied.X, "y": applied.Y, "z": applied.Z},
+		"clamped":      clamped,
+		"clearance_mm": limits.Clearance(limits.ToolTip(target)),
+		"margin_mm":    limits.Margin,
+	}, nil
+}
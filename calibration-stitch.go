@@ -0,0 +1,85 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// stitchMonitors handles the "stitch_monitors" command, calibrating the two "targets" of a dual-monitor setup and
+// combining them into one frame, returned as a fragment with both monitors
+// Without "targets" the configured targets are used when there are exactly two. "path", "name", "parent" and
+// "thickness_mm" are as for "export_fragment", with "name" naming the combined frame.
+// The caller must hold doCommandLock
+func (s *monitorCalibration) stitchMonitors(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	var names []string
+	if raw, ok := cmd["targets"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("stitch_monitors 'targets' must be a list of two target names")
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("stitch_monitors 'targets' must be a list of two target names")
+			}
+			names = append(names, name)
+		}
+	} else {
+		for _, target := range s.cfg.Targets {
+			names = append(names, target.Name)
+		}
+	}
+	if len(names) != 2 || names[0] == names[1] {
+		return nil, fmt.Errorf("stitch_monitors needs two different targets, got %v", names)
+	}
+	config := calibrationhelpers.FragmentConfig{Thickness: defaultFragmentThicknessMM}
+	config.ComponentName, _ = cmd["name"].(string)
+	config.Parent, _ = cmd["parent"].(string)
+	if thickness, ok := cmd["thickness_mm"].(float64); ok {
+		config.Thickness = thickness
+	}
+
+	// Both screens are needed, so the first failure ends the run
+	var results []calibrationhelpers.CalibrationResult
+	for _, name := range names {
+		target, err := s.findTarget(name)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Infof("=== CALIBRATING TARGET %q ===", target.Name)
+		config, err := target.apply(s.calibrationConfig)
+		if err != nil {
+			return nil, err
+		}
+		result, err := s.runCalibration(ctx, target.Name, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calibrate target %q: %w", target.Name, err)
+		}
+		results = append(results, result)
+	}
+
+	stitch, err := calibrationhelpers.StitchMonitors(names[0], results[0], names[1], results[1])
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("✓ Stitched %s and %s: %.1f mm apart, %.1f mm gap, %.2f° between normals, %.1f x %.1f mm combined",
+		names[0], names[1], stitch.CenterDistance, stitch.Gap, stitch.NormalAngle, stitch.Bounds.Width, stitch.Bounds.Height)
+	fragment, err := calibrationhelpers.GenerateStitchedFragment(stitch, config)
+	if err != nil {
+		return nil, err
+	}
+	response, err := stitch.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["fragment"] = fragment
+	if path, _ := cmd["path"].(string); path != "" {
+		if err := calibrationhelpers.WriteFragment(path, fragment); err != nil {
+			return nil, err
+		}
+		s.logger.Infof("✓ Exported stitched monitors fragment to %s", path)
+		response["path"] = path
+	}
+	return response, nil
+}
@@ -0,0 +1,209 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// scheduleCheckInterval is how often the scheduler looks for targets that are due
+const scheduleCheckInterval = time.Minute
+
+// TargetConfig describes one monitor reachable by the gantry, for rigs that calibrate several screens
+type TargetConfig struct {
+	Name string `json:"name"`
+
+	// Initial guess: the gantry travel window and arm home pose in front of this monitor
+	GantryMinMM        float64   `json:"gantry_min_mm,omitempty"`
+	GantryMaxMM        float64   `json:"gantry_max_mm,omitempty"`
+	HomeJointPositions []float64 `json:"home_joint_positions,omitempty"` // radians
+
+	// Profile: scan settings for this monitor, defaults to the service settings
+	ScanMode    string  `json:"scan_mode,omitempty"`
+	ZNumSteps   int     `json:"z_num_steps,omitempty"`
+	XNumSteps   int     `json:"x_num_steps,omitempty"`
+	ZStepSizeMM float64 `json:"z_step_size_mm,omitempty"`
+
+	// Schedule: recalibrate automatically at this interval (e.g. "24h"), empty to only run on demand
+	Interval string `json:"interval,omitempty"`
+}
+
+// Validate checks a single target definition
+func (t *TargetConfig) Validate(path string) error {
+	if t.Name == "" {
+		return fmt.Errorf("missing 'name' field in %s", path)
+	}
+	if t.GantryMinMM < 0 || (t.GantryMaxMM > 0 && t.GantryMaxMM <= t.GantryMinMM) {
+		return fmt.Errorf("target %q in %s: gantry window must satisfy 0 <= gantry_min_mm < gantry_max_mm", t.Name, path)
+	}
+	switch t.ScanMode {
+	case "", calibrationhelpers.ScanModeLinear, calibrationhelpers.ScanModeAngular, calibrationhelpers.ScanModeGrid,
+		calibrationhelpers.ScanModeAdaptive:
+	default:
+		return fmt.Errorf("target %q in %s: unknown 'scan_mode' %q", t.Name, path, t.ScanMode)
+	}
+	if t.ZNumSteps == 1 || t.XNumSteps == 1 || t.ZNumSteps < 0 || t.XNumSteps < 0 {
+		return fmt.Errorf("target %q in %s: scan steps must be >= 2", t.Name, path)
+	}
+	if t.ZStepSizeMM < 0 {
+		return fmt.Errorf("target %q in %s: 'z_step_size_mm' must be positive", t.Name, path)
+	}
+	if t.Interval != "" {
+		interval, err := time.ParseDuration(t.Interval)
+		if err != nil {
+			return fmt.Errorf("target %q in %s: invalid 'interval': %w", t.Name, path, err)
+		}
+		if interval < scheduleCheckInterval {
+			return fmt.Errorf("target %q in %s: 'interval' must be at least %s", t.Name, path, scheduleCheckInterval)
+		}
+	}
+	return nil
+}
+
+// apply returns a copy of the base configuration with this target's overrides
+// The merged configuration is validated again, since overrides that are fine alone may not be with the service's
+// settings, such as a gantry window beyond the service's scan steps or a scan mode missing its parameters.
+func (t *TargetConfig) apply(base calibrationhelpers.CalibrationConfig) (calibrationhelpers.CalibrationConfig, error) {
+	config := base
+	config.Scanning.GantryMin = t.GantryMinMM
+	config.Scanning.GantryMax = t.GantryMaxMM
+	config.Hint = nil // the target's window is its own hint
+	if len(t.HomeJointPositions) > 0 {
+		config.ArmPositions.Home = append([]float64{}, t.HomeJointPositions...)
+	}
+	if t.ScanMode != "" {
+		config.Scanning.Mode = t.ScanMode
+	}
+	if t.ZNumSteps > 0 {
+		config.Scanning.ZNumSteps = t.ZNumSteps
+	}
+	if t.XNumSteps > 0 {
+		config.Scanning.XNumSteps = t.XNumSteps
+	}
+	if t.ZStepSizeMM > 0 {
+		config.Scanning.ZStepSize = t.ZStepSizeMM
+	}
+	if err := config.Validate(); err != nil {
+		return base, fmt.Errorf("target %q: %w", t.Name, err)
+	}
+	return config, nil
+}
+
+// interval returns the parsed schedule interval, or zero if the target only runs on demand
+func (t *TargetConfig) interval() time.Duration {
+	interval, err := time.ParseDuration(t.Interval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+func (s *monitorCalibration) findTarget(name string) (*TargetConfig, error) {
+	for i := range s.cfg.Targets {
+		if s.cfg.Targets[i].Name == name {
+			return &s.cfg.Targets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown target %q", name)
+}
+
+// calibrateTarget runs one target and summarizes the outcome for the consolidated report, returning the
+// calibration error too for callers without a report
+func (s *monitorCalibration) calibrateTarget(ctx context.Context, target *TargetConfig) (map[string]interface{}, error) {
+	s.logger.Infof("=== CALIBRATING TARGET %q ===", target.Name)
+	start := time.Now()
+	report := map[string]interface{}{"name": target.Name}
+	var result types.CalibrationResult
+	config, err := target.apply(s.calibrationConfig)
+	if err == nil {
+		result, err = s.runCalibration(ctx, target.Name, config)
+	}
+	report["duration_sec"] = time.Since(start).Seconds()
+	if err != nil {
+		s.logger.Errorf("Target %q failed: %v", target.Name, err)
+		report["success"] = false
+		report["error"] = err.Error()
+		if class := calibrationhelpers.ErrorClass(err); class != "" {
+			report["error_class"] = class
+		}
+		return report, err
+	}
+
+	report["session_id"] = result.SessionID
+	visualization, err := s.calibrationReport(result, config)
+	if err != nil {
+		report["success"] = false
+		report["error"] = err.Error()
+		return report, err
+	}
+	report["success"] = true
+	report["visualization"] = visualization
+	return report, nil
+}
+
+// calibrateAll runs every configured target sequentially and returns a consolidated report
+// A failing target does not stop the batch, so one bad screen doesn't block the rest of the wall
+func (s *monitorCalibration) calibrateAll(ctx context.Context) (map[string]interface{}, error) {
+	if len(s.cfg.Targets) == 0 {
+		return nil, fmt.Errorf("no targets configured")
+	}
+
+	reports := make([]interface{}, 0, len(s.cfg.Targets))
+	succeeded := 0
+	for i := range s.cfg.Targets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report, _ := s.calibrateTarget(ctx, &s.cfg.Targets[i])
+		if report["success"] == true {
+			succeeded++
+		}
+		reports = append(reports, report)
+	}
+
+	s.logger.Infof("✓ Batch calibration complete: %d/%d targets succeeded", succeeded, len(s.cfg.Targets))
+	return map[string]interface{}{
+		"targets":   reports,
+		"succeeded": succeeded,
+		"failed":    len(s.cfg.Targets) - succeeded,
+	}, nil
+}
+
+// runSchedule recalibrates targets with an interval whenever they come due, until the service is closed
+func (s *monitorCalibration) runSchedule() {
+	defer s.activeBackgroundWorkers.Done()
+
+	// Count from startup so a restart doesn't immediately move the hardware
+	lastRun := map[string]time.Time{}
+	for _, target := range s.cfg.Targets {
+		lastRun[target.Name] = time.Now()
+	}
+
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.cancelCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for i := range s.cfg.Targets {
+			target := &s.cfg.Targets[i]
+			interval := target.interval()
+			if interval == 0 || time.Since(lastRun[target.Name]) < interval {
+				continue
+			}
+
+			s.doCommandLock.Lock()
+			_, _ = s.audited("calibrate", calibrationhelpers.AuditTriggerSchedule, map[string]interface{}{"target": target.Name},
+				func() (map[string]interface{}, error) {
+					return s.calibrateTarget(s.cancelCtx, target)
+				})
+			s.doCommandLock.Unlock()
+			lastRun[target.Name] = time.Now()
+		}
+	}
+}
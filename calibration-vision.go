@@ -0,0 +1,110 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// VisionConfig enables vision calibration from AprilTags taped to the monitor corners
+type VisionConfig struct {
+	Camera      string   `json:"camera"`
+	TagDetector string   `json:"tag_detector"` // vision service that reports tags as detections labeled with their ID
+	TagSizeMM   float64  `json:"tag_size_mm"`
+	TagInsetMM  float64  `json:"tag_inset_mm,omitempty"`
+	MinScore    *float64 `json:"min_score,omitempty"` // 0.5 if unset, 0 keeps every detection
+
+	// Tag IDs at each corner, defaulting to 0 to 3 clockwise from the top left
+	TopLeft     string `json:"top_left,omitempty"`
+	TopRight    string `json:"top_right,omitempty"`
+	BottomRight string `json:"bottom_right,omitempty"`
+	BottomLeft  string `json:"bottom_left,omitempty"`
+}
+
+// Validate checks the vision configuration
+func (cfg *VisionConfig) Validate(path string) error {
+	if cfg.Camera == "" {
+		return fmt.Errorf("missing 'camera' field in %s", path)
+	}
+	if cfg.TagDetector == "" {
+		return fmt.Errorf("missing 'tag_detector' field in %s", path)
+	}
+	if cfg.TagSizeMM <= 0 {
+		return fmt.Errorf("'tag_size_mm' must be positive in %s", path)
+	}
+	if cfg.TagInsetMM < 0 {
+		return fmt.Errorf("'tag_inset_mm' must not be negative in %s", path)
+	}
+	if cfg.MinScore != nil && (*cfg.MinScore < 0 || *cfg.MinScore > 1) {
+		return fmt.Errorf("'min_score' must be between 0 and 1 in %s", path)
+	}
+	return nil
+}
+
+// apply copies the configured tag layout into the calibration vision config
+func (cfg *VisionConfig) apply(config *calibrationhelpers.VisionConfig) {
+	config.TagSize = cfg.TagSizeMM
+	config.TagInset = cfg.TagInsetMM
+	if cfg.MinScore != nil {
+		config.MinScore = *cfg.MinScore
+	}
+	for _, label := range []struct {
+		value string
+		dest  *string
+	}{
+		{cfg.TopLeft, &config.TopLeft},
+		{cfg.TopRight, &config.TopRight},
+		{cfg.BottomRight, &config.BottomRight},
+		{cfg.BottomLeft, &config.BottomLeft},
+	} {
+		if label.value != "" {
+			*label.dest = label.value
+		}
+	}
+}
+
+// visionCalibrate handles the "vision_calibrate" command, a fast coarse calibration from the corner tags
+// Pass "refine": true to follow it with an ultrasonic calibration seeded from the coarse result
+func (s *monitorCalibration) visionCalibrate(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.camera == nil || s.tagDetector == nil {
+		return nil, fmt.Errorf("vision calibration is not configured, set 'vision' in the config")
+	}
+	config := s.calibrationConfig
+	frame := config.Hardware.ReferenceFrame()
+
+	s.logger.Info("=== STARTING VISION CALIBRATION ===")
+	cameraPose, err := s.fs.GetPose(ctx, s.cfg.Vision.Camera, frame, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s pose in %s frame: %w", s.cfg.Vision.Camera, frame, err)
+	}
+	coarse, err := calibrationhelpers.CalibrateFromTags(ctx, s.logger, s.camera, s.tagDetector, cameraPose.Pose(), frame, config.Vision)
+	if err != nil {
+		return nil, fmt.Errorf("vision calibration failed: %w", err)
+	}
+	s.logger.Infof("✓ Vision calibration: %.1f x %.1f mm monitor", coarse.MonitorWidth, coarse.MonitorHeight)
+
+	if refine, _ := cmd["refine"].(bool); refine {
+		s.logger.Info("Refining the vision calibration with an ultrasonic scan")
+		config.Seed = &coarse
+		config.Scanning = config.Scanning.Chained()
+		config = s.baselineFromLastResult(cmd, config)
+		config = s.acceptLowSpan(cmd, config)
+		result, err := s.runCalibration(ctx, "", config)
+		if err != nil {
+			return nil, err
+		}
+		return s.calibrationReport(result, config)
+	}
+
+	// A coarse result saved as the calibration is a session of its own
+	coarse.SessionID = s.newSession("")
+	s.logger.Infof("Calibration session %s", coarse.SessionID)
+
+	// Coarse results have no scan statistics, so only the site post-processors check them
+	if err := calibrationhelpers.RunPostProcessors(&coarse); err != nil {
+		return nil, err
+	}
+	s.lastResult = &coarse
+	s.saveLastResult(ctx)
+	return calibrationhelpers.GenerateVisualizationConfig(s.logger, coarse, frame), nil
+}
@@ -0,0 +1,36 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+)
+
+// checkWorkspace checks the scan the config plans against the gantry travel and arm reach, before anything moves
+// Returns an error wrapping ErrWorkspaceExceeded that lists the unreachable waypoints, so a scan that can't finish
+// fails up front instead of stalling partway through.
+func (s *monitorCalibration) checkWorkspace(ctx context.Context, config calibrationhelpers.CalibrationConfig) error {
+	logger := s.runLogger(config)
+	report, err := calibrationhelpers.CheckWorkspace(ctx, logger, s.arm, s.gantry, config)
+	if err != nil {
+		return err
+	}
+	if err := report.Err(); err != nil {
+		return err
+	}
+	logger.Infof("✓ All %d planned scan waypoints are within the workspace", report.Waypoints)
+	return nil
+}
+
+// checkWorkspaceCommand handles the "check_workspace" command, reporting every planned scan waypoint out of reach
+// The caller must hold doCommandLock
+func (s *monitorCalibration) checkWorkspaceCommand(ctx context.Context) (map[string]interface{}, error) {
+	config, _, err := s.hintWithoutMoving(ctx, s.calibrationConfig)
+	if err != nil {
+		return nil, err
+	}
+	report, err := calibrationhelpers.CheckWorkspace(ctx, s.logger, s.arm, s.gantry, config)
+	if err != nil {
+		return nil, err
+	}
+	return report.ToMap()
+}
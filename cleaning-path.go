@@ -0,0 +1,131 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"fmt"
+)
+
+// CleaningConfig tunes the cleaning paths generated over the calibrated monitor
+type CleaningConfig struct {
+	StrokeSpacingMM float64 `json:"stroke_spacing_mm,omitempty"`
+	PadThicknessMM  float64 `json:"pad_thickness_mm,omitempty"` // thickness of a new pad
+
+	// Distances that may be zero, so unset keeps the default
+	EdgeMarginMM      *float64 `json:"edge_margin_mm,omitempty"`
+	PadCompressionMM  *float64 `json:"pad_compression_mm,omitempty"`   // compression for the target contact pressure
+	MinPadThicknessMM *float64 `json:"min_pad_thickness_mm,omitempty"` // refuse to generate paths for thinner pads
+
+	// Surface is "flat" (default) to clean the fitted plane, or "corrected" to follow the fitted deviation surface
+	Surface string `json:"surface,omitempty"`
+
+	// PadThicknessSensor is an optional sensor reporting the current pad thickness as "thickness_mm"
+	PadThicknessSensor string `json:"pad_thickness_sensor,omitempty"`
+}
+
+// Validate checks the cleaning configuration
+func (cfg *CleaningConfig) Validate(path string) error {
+	negative := func(v *float64) bool { return v != nil && *v < 0 }
+	if cfg.StrokeSpacingMM < 0 || cfg.PadThicknessMM < 0 ||
+		negative(cfg.EdgeMarginMM) || negative(cfg.PadCompressionMM) || negative(cfg.MinPadThicknessMM) {
+		return fmt.Errorf("cleaning distances must not be negative in %s", path)
+	}
+	switch cfg.Surface {
+	case "", types.SurfaceFlat, types.SurfaceCorrected:
+	default:
+		return fmt.Errorf("unknown 'surface' %q in %s, expected %q or %q",
+			cfg.Surface, path, types.SurfaceFlat, types.SurfaceCorrected)
+	}
+	return nil
+}
+
+// apply overrides the default cleaning parameters with the configured ones
+func (cfg *CleaningConfig) apply(config *types.CleaningConfig) {
+	if cfg.StrokeSpacingMM > 0 {
+		config.StrokeSpacing = cfg.StrokeSpacingMM
+	}
+	if cfg.EdgeMarginMM != nil {
+		config.EdgeMargin = *cfg.EdgeMarginMM
+	}
+	if cfg.PadThicknessMM > 0 {
+		config.PadThickness = cfg.PadThicknessMM
+	}
+	if cfg.PadCompressionMM != nil {
+		config.PadCompression = *cfg.PadCompressionMM
+	}
+	if cfg.MinPadThicknessMM != nil {
+		config.MinPadThickness = *cfg.MinPadThicknessMM
+	}
+	if cfg.Surface != "" {
+		config.Surface = cfg.Surface
+	}
+}
+
+// setPadThickness handles the "set_pad_thickness" command
+func (s *monitorCalibration) setPadThickness(cmd map[string]interface{}) (map[string]interface{}, error) {
+	thickness, ok := cmd["thickness_mm"].(float64)
+	if !ok || thickness <= 0 {
+		return nil, fmt.Errorf("set_pad_thickness requires a positive 'thickness_mm'")
+	}
+	s.padThickness = thickness
+	s.logger.Infof("Cleaning pad thickness updated to %.2f mm", thickness)
+	return map[string]interface{}{"pad_thickness_mm": thickness}, nil
+}
+
+// currentPadThickness returns the latest pad thickness, refreshing it from the pad sensor if one is configured
+func (s *monitorCalibration) currentPadThickness(ctx context.Context) (float64, error) {
+	if s.padSensor == nil {
+		return s.padThickness, nil
+	}
+
+	readings, err := s.padSensor.Readings(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pad thickness sensor: %w", err)
+	}
+	thickness, ok := readings["thickness_mm"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("pad thickness sensor did not return a numeric 'thickness_mm' reading")
+	}
+	s.padThickness = thickness
+	return thickness, nil
+}
+
+// getCleaningPath handles the "get_cleaning_path" command, generating strokes over the last calibrated monitor
+// An optional "surface" overrides the configured surface model
+func (s *monitorCalibration) getCleaningPath(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+
+	thickness, err := s.currentPadThickness(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := s.calibrationConfig.Cleaning
+	if surface, ok := cmd["surface"].(string); ok {
+		config.Surface = surface
+	}
+	path, err := calibrationhelpers.GenerateCleaningPath(*s.lastResult, thickness, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Surface == types.SurfaceCorrected && path.Surface != types.SurfaceCorrected {
+		s.logger.Warnf("No deviation surface was fitted for the last calibration, cleaning the flat plane")
+	}
+	s.logger.Infof("Generated cleaning path: %d poses, pad %.2f mm, standoff %.2f mm, %s surface",
+		len(path.Poses), path.PadThickness, path.Standoff, path.Surface)
+
+	poses := make([]interface{}, 0, len(path.Poses))
+	for _, pose := range path.Poses {
+		poses = append(poses, calibrationhelpers.PoseToMap(pose))
+	}
+	return map[string]interface{}{
+		"pad_thickness_mm": path.PadThickness,
+		"standoff_mm":      path.Standoff,
+		"surface":          path.Surface,
+		"frame":            s.calibrationConfig.Hardware.ReferenceFrame(),
+		"poses":            poses,
+	}, nil
+}
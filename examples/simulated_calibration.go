@@ -0,0 +1,296 @@
+// Simulated calibration runs the monitor calibration service end to end against the fake sensor, with no robot
+// attached. It builds a simulated gantry and arm, calibrates the fake sensor's virtual monitor, compares the result
+// with the monitor's true pose and writes every export artifact to a directory.
+//
+//	go run ./examples [output directory]
+//
+// It doubles as a smoke test of the public API: a failure anywhere in the loop exits non-zero.
+package main
+
+import (
+	"calibration"
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+// Simulated rig: a gantry along world X carrying a Cartesian arm, with the sensor on the arm's end effector
+// looking along -Y at the fake sensor's default monitor (500x300 mm, centered at X=250, Y=-400, Z=200)
+const (
+	gantryLengthMM = 800.0  // gantry travel
+	gantryOriginMM = -150.0 // world X of the arm base at gantry position 0
+	armReachMM     = 100.0  // max end effector X offset from the arm base
+	armMaxZMM      = 450.0  // max end effector height
+)
+
+// armHeights are the end effector heights the rig reaches at the calibration's named joint positions
+var armHeights = []struct {
+	joints []float64
+	z      float64
+}{
+	{calibrationhelpers.DefaultArmPositions.Home, 200},
+	{calibrationhelpers.DefaultArmPositions.BottomScan, 150},
+	{calibrationhelpers.DefaultArmPositions.TopScan, 250},
+}
+
+func main() {
+	outDir := filepath.Join(os.TempDir(), "simulated-calibration")
+	if len(os.Args) > 1 {
+		outDir = os.Args[1]
+	}
+	if err := realMain(outDir); err != nil {
+		fmt.Fprintln(os.Stderr, "simulated calibration failed:", err)
+		os.Exit(1)
+	}
+}
+
+func realMain(outDir string) error {
+	ctx := context.Background()
+	logger := logging.NewLogger("simulated-calibration")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Build the simulated world: gantry, arm and a frame system reporting where the sensor is
+	rig := newSimulatedRig()
+	deps := resource.Dependencies{
+		arm.Named("arm"):              rig.arm,
+		gantry.Named("gantry"):        rig.gantry,
+		framesystem.PublicServiceName: rig.fs,
+	}
+
+	fake, err := calibration.NewFakeSensor(ctx, deps, sensor.Named("sensor"),
+		&calibration.SensorConfig{Arm: "arm", Gantry: "gantry"}, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create fake sensor: %w", err)
+	}
+	defer fake.Close(ctx)
+	deps[sensor.Named("sensor")] = fake
+
+	conf := &calibration.Config{
+		Arm:         "arm",
+		Gantry:      "gantry",
+		Sensor:      "sensor",
+		ResultsPath: filepath.Join(outDir, "calibration.json"),
+		ScanLog:     &calibration.ScanLogConfig{Dir: outDir},
+	}
+	if _, _, err := conf.Validate("services.0"); err != nil {
+		return err
+	}
+	service, err := calibration.NewMonitorCalibration(ctx, deps, generic.Named("monitor-calibration"), conf, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create calibration service: %w", err)
+	}
+	defer service.Close(ctx)
+
+	// Check the rig is wired up before moving anything
+	selfTest, err := service.DoCommand(ctx, map[string]interface{}{"command": "self_test"})
+	if err != nil {
+		return err
+	}
+	if pass, _ := selfTest["pass"].(bool); !pass {
+		return fmt.Errorf("self test failed: %v", selfTest["checks"])
+	}
+
+	// Calibrate, then check the result against the simulated monitor's true pose
+	if _, err := service.DoCommand(ctx, map[string]interface{}{"command": "calibrate"}); err != nil {
+		return err
+	}
+	summary, err := service.DoCommand(ctx, map[string]interface{}{"command": "get_summary"})
+	if err != nil {
+		return err
+	}
+	fmt.Println(summary["text"])
+
+	report, err := service.DoCommand(ctx, map[string]interface{}{"command": "ground_truth_report"})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nAccuracy vs ground truth: center %.2f mm, normal %.3f°, width %+.2f mm, height %+.2f mm\n",
+		report["translation_error_mm"], report["normal_angle_error_deg"], report["width_error_mm"], report["height_error_mm"])
+
+	// Write every export artifact
+	fmt.Printf("\nArtifacts in %s:\n", outDir)
+	fmt.Println(" ", conf.ResultsPath)
+	for _, format := range []string{calibrationhelpers.PointCloudPLY, calibrationhelpers.PointCloudPCD} {
+		path := filepath.Join(outDir, "scan."+format)
+		if _, err := service.DoCommand(ctx, map[string]interface{}{"command": "export_point_cloud", "path": path}); err != nil {
+			return err
+		}
+		fmt.Println(" ", path)
+	}
+	for _, format := range []string{calibrationhelpers.MeshOBJ, calibrationhelpers.MeshSTL} {
+		path := filepath.Join(outDir, "monitor."+format)
+		if _, err := service.DoCommand(ctx, map[string]interface{}{"command": "export_mesh", "path": path}); err != nil {
+			return err
+		}
+		fmt.Println(" ", path)
+	}
+	for _, format := range []string{calibrationhelpers.HeatmapPNG, calibrationhelpers.HeatmapJSON} {
+		path := filepath.Join(outDir, "residuals."+format)
+		if _, err := service.DoCommand(ctx, map[string]interface{}{"command": "export_heatmap", "path": path}); err != nil {
+			return err
+		}
+		fmt.Println(" ", path)
+	}
+	fragmentPath := filepath.Join(outDir, "fragment.json")
+	if _, err := service.DoCommand(ctx, map[string]interface{}{"command": "export_fragment", "path": fragmentPath}); err != nil {
+		return err
+	}
+	fmt.Println(" ", fragmentPath)
+
+	artifacts := []struct {
+		file string
+		cmd  map[string]interface{}
+	}{
+		{"cleaning-path.json", map[string]interface{}{"command": "get_cleaning_path"}},
+		{"ground-truth-report.json", nil},
+		{"summary.md", nil},
+	}
+	for _, artifact := range artifacts {
+		var contents []byte
+		switch {
+		case artifact.file == "summary.md":
+			contents = []byte(summary["markdown"].(string))
+		case artifact.cmd == nil:
+			if contents, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return err
+			}
+		default:
+			resp, err := service.DoCommand(ctx, artifact.cmd)
+			if err != nil {
+				return err
+			}
+			if contents, err = json.MarshalIndent(resp, "", "  "); err != nil {
+				return err
+			}
+		}
+		path := filepath.Join(outDir, artifact.file)
+		if err := os.WriteFile(path, contents, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", artifact.file, err)
+		}
+		fmt.Println(" ", path)
+	}
+
+	if pass, _ := report["pass"].(bool); !pass {
+		return fmt.Errorf("calibration is outside the ground truth limits: %v", report["failures"])
+	}
+	return nil
+}
+
+// simulatedRig is a gantry carrying a Cartesian arm, built from the RDK's injectable fakes
+// The arm's end effector pose is relative to its base on the gantry carriage, always facing -Y
+type simulatedRig struct {
+	arm    *inject.Arm
+	gantry *inject.Gantry
+	fs     *inject.FrameSystemService
+
+	mu        sync.Mutex // the fake sensor reads the pose while the calibration moves the rig
+	gantryPos float64
+	effector  r3.Vector
+	joints    []float64
+}
+
+func newSimulatedRig() *simulatedRig {
+	rig := &simulatedRig{
+		arm:       inject.NewArm("arm"),
+		gantry:    inject.NewGantry("gantry"),
+		fs:        inject.NewFrameSystemService(framesystem.PublicServiceName.Name),
+		gantryPos: gantryLengthMM / 2,
+		effector:  r3.Vector{Z: armHeights[0].z},
+		joints:    armHeights[0].joints,
+	}
+	facing := &spatialmath.OrientationVectorDegrees{OY: -1}
+
+	rig.gantry.LengthsFunc = func(context.Context, map[string]interface{}) ([]float64, error) {
+		return []float64{gantryLengthMM}, nil
+	}
+	rig.gantry.PositionFunc = func(context.Context, map[string]interface{}) ([]float64, error) {
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		return []float64{rig.gantryPos}, nil
+	}
+	rig.gantry.MoveToPositionFunc = func(_ context.Context, positions, _ []float64, _ map[string]interface{}) error {
+		if len(positions) != 1 || positions[0] < 0 || positions[0] > gantryLengthMM {
+			return fmt.Errorf("gantry position %v outside [0, %.0f]", positions, gantryLengthMM)
+		}
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		rig.gantryPos = positions[0]
+		return nil
+	}
+
+	rig.arm.EndPositionFunc = func(context.Context, map[string]interface{}) (spatialmath.Pose, error) {
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		return spatialmath.NewPose(rig.effector, facing), nil
+	}
+	rig.arm.MoveToPositionFunc = func(_ context.Context, to spatialmath.Pose, _ map[string]interface{}) error {
+		p := to.Point()
+		if math.Abs(p.X) > armReachMM || p.Z < 0 || p.Z > armMaxZMM {
+			return fmt.Errorf("pose (%.1f, %.1f, %.1f) is out of reach", p.X, p.Y, p.Z)
+		}
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		rig.effector, rig.joints = p, nil
+		return nil
+	}
+	rig.arm.JointPositionsFunc = func(context.Context, map[string]interface{}) ([]referenceframe.Input, error) {
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		return rig.joints, nil
+	}
+	rig.arm.MoveToJointPositionsFunc = func(_ context.Context, joints []referenceframe.Input, _ map[string]interface{}) error {
+		for _, named := range armHeights {
+			if reflect.DeepEqual(joints, named.joints) {
+				rig.mu.Lock()
+				defer rig.mu.Unlock()
+				rig.effector, rig.joints = r3.Vector{Z: named.z}, joints
+				return nil
+			}
+		}
+		return fmt.Errorf("simulated arm has no pose for joints %v", joints)
+	}
+	// Simulated moves finish instantly, so there is never a move to stop
+	rig.arm.StopFunc = func(context.Context, map[string]interface{}) error { return nil }
+	rig.gantry.StopFunc = func(context.Context, map[string]interface{}) error { return nil }
+	rig.gantry.IsMovingFunc = func(context.Context) (bool, error) { return false, nil }
+
+	// The sensor is mounted at the end effector, so both report the same pose; the arm rides the gantry carriage
+	rig.fs.GetPoseFunc = func(_ context.Context, component, destination string, _ []*referenceframe.LinkInFrame,
+		_ map[string]interface{}) (*referenceframe.PoseInFrame, error) {
+		if destination != referenceframe.World {
+			return nil, fmt.Errorf("simulated frame system only knows the %s frame, not %q", referenceframe.World, destination)
+		}
+		rig.mu.Lock()
+		defer rig.mu.Unlock()
+		if component == "gantry" {
+			carriage := r3.Vector{X: gantryOriginMM + rig.gantryPos}
+			return referenceframe.NewPoseInFrame(referenceframe.World, spatialmath.NewPoseFromPoint(carriage)), nil
+		}
+		if component != "arm" && component != "sensor" {
+			return nil, fmt.Errorf("simulated frame system has no frame %q", component)
+		}
+		world := rig.effector.Add(r3.Vector{X: gantryOriginMM + rig.gantryPos})
+		return referenceframe.NewPoseInFrame(referenceframe.World, spatialmath.NewPose(world, facing)), nil
+	}
+	return rig
+}
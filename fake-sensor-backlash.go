@@ -0,0 +1,44 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"go.viam.com/rdk/components/gantry"
+)
+
+// gantryBacklash simulates a worn gantry whose carriage trails its reported position by half the backlash against
+// the direction it last moved, so where the sensor reads depends on which way the gantry approached
+// The direction is worked out from the gantry position at successive readings, so moves with no reading after them
+// go unseen. The first gantry axis is taken to move along world X, as in the simulated example rig.
+type gantryBacklash struct {
+	mm float64
+
+	mu        sync.Mutex
+	last      float64
+	seen      bool
+	direction float64 // sign of the last move, zero until the gantry has moved
+}
+
+// offset returns how far along world X the carriage is from the gantry's reported position
+func (b *gantryBacklash) offset(ctx context.Context, g gantry.Gantry) (float64, error) {
+	if b == nil {
+		return 0, nil
+	}
+	positions, err := g.Position(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get gantry position: %w", err)
+	}
+	if len(positions) == 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.seen && positions[0] != b.last {
+		b.direction = math.Copysign(1, positions[0]-b.last)
+	}
+	b.last, b.seen = positions[0], true
+	return -b.direction * b.mm / 2, nil
+}
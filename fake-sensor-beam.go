@@ -0,0 +1,64 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+const defaultBeamRays = 19
+
+// BeamConfig spreads the simulated ray into a cone like a real ultrasonic transducer
+type BeamConfig struct {
+	AngleDeg float64 `json:"angle_deg,omitempty"` // degrees - full cone angle, 0 casts a single ideal ray
+	Rays     int     `json:"rays,omitempty"`      // rays sampled within the cone, including the axis
+}
+
+// Validate checks the beam configuration
+func (cfg *BeamConfig) Validate(path string) error {
+	if cfg.AngleDeg < 0 || cfg.AngleDeg >= 180 {
+		return fmt.Errorf("beam 'angle_deg' must be in [0, 180) in %s", path)
+	}
+	if cfg.Rays < 0 {
+		return fmt.Errorf("beam 'rays' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// beamPattern holds ray directions relative to the beam axis
+// Each direction is (along axis, along first perpendicular, along second perpendicular)
+type beamPattern []r3.Vector
+
+// newBeamPattern samples rays evenly over the solid angle of the cone
+// The axis ray is always included, the rest follow a golden-angle spiral so the footprint has no preferred direction
+func newBeamPattern(cfg *BeamConfig) beamPattern {
+	pattern := beamPattern{{X: 1}}
+	if cfg.AngleDeg == 0 || cfg.Rays <= 1 {
+		return pattern
+	}
+
+	halfAngle := cfg.AngleDeg / 2 * math.Pi / 180
+	goldenAngle := math.Pi * (3 - math.Sqrt(5))
+	n := cfg.Rays - 1
+	for i := 0; i < n; i++ {
+		// Equal-area rings on the spherical cap, the last one on the cone edge
+		cosTheta := 1 - float64(i+1)/float64(n)*(1-math.Cos(halfAngle))
+		sinTheta := math.Sqrt(1 - cosTheta*cosTheta)
+		phi := float64(i) * goldenAngle
+		pattern = append(pattern, r3.Vector{X: cosTheta, Y: sinTheta * math.Cos(phi), Z: sinTheta * math.Sin(phi)})
+	}
+	return pattern
+}
+
+// appendDirections appends the beam's rays in world coordinates around the given axis to dst
+func (p beamPattern) appendDirections(dst []r3.Vector, axis r3.Vector) []r3.Vector {
+	axis = axis.Normalize()
+	u := axis.Ortho()
+	v := axis.Cross(u)
+
+	for _, d := range p {
+		dst = append(dst, axis.Mul(d.X).Add(u.Mul(d.Y)).Add(v.Mul(d.Z)))
+	}
+	return dst
+}
@@ -0,0 +1,192 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Drift models for the fake sensor's "drift" attribute and "set_drift" command
+const (
+	DriftLinear    = "linear"    // the monitors move at a constant rate, like a stand settling or warming up
+	DriftVibration = "vibration" // the monitors oscillate about their configured pose, like a shaking desk
+	DriftNone      = "none"      // "set_drift" only: the monitors return to their configured pose
+)
+
+// defaultDriftPeriodSec is the vibration period when none is configured
+const defaultDriftPeriodSec = 1.0
+
+// DriftConfig moves the virtual monitors over wall-clock time, so drift checks and automatic recalibration can be
+// exercised against a monitor that really moves
+// Rotations turn each monitor about the world axes through its center.
+type DriftConfig struct {
+	Model string `json:"model"` // "linear" or "vibration"
+	// TranslationMM and RotationDeg are per minute for linear drift, and the amplitude of the swing for vibration
+	TranslationMM *Vector3 `json:"translation_mm,omitempty"`
+	RotationDeg   *Vector3 `json:"rotation_deg,omitempty"`
+	PeriodSec     float64  `json:"period_sec,omitempty"` // vibration period, default 1
+}
+
+// Validate checks the drift configuration
+func (cfg *DriftConfig) Validate(path string) error {
+	switch cfg.Model {
+	case DriftLinear, DriftVibration:
+	case "":
+		return fmt.Errorf("missing 'model' field in %s", path)
+	default:
+		return fmt.Errorf("drift 'model' must be %s or %s in %s", DriftLinear, DriftVibration, path)
+	}
+	if cfg.PeriodSec < 0 {
+		return fmt.Errorf("'period_sec' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// monitorDrift moves the virtual monitors from their configured pose as time passes since start
+type monitorDrift struct {
+	model       string
+	translation r3.Vector // mm
+	rotation    r3.Vector // degrees about world X, Y and Z
+	period      float64   // seconds
+	start       time.Time
+}
+
+// newMonitorDrift builds the drift described by the config, starting now
+// Returns nil without a config.
+func newMonitorDrift(cfg *DriftConfig) *monitorDrift {
+	if cfg == nil {
+		return nil
+	}
+	d := &monitorDrift{model: cfg.Model, period: cfg.PeriodSec, start: time.Now()}
+	if cfg.TranslationMM != nil {
+		d.translation = r3.Vector{X: cfg.TranslationMM.X, Y: cfg.TranslationMM.Y, Z: cfg.TranslationMM.Z}
+	}
+	if cfg.RotationDeg != nil {
+		d.rotation = r3.Vector{X: cfg.RotationDeg.X, Y: cfg.RotationDeg.Y, Z: cfg.RotationDeg.Z}
+	}
+	if d.period == 0 {
+		d.period = defaultDriftPeriodSec
+	}
+	return d
+}
+
+// at returns how far the monitors have moved at the given time: the translation in mm and the rotation vector in
+// degrees. Without a drift they haven't.
+func (d *monitorDrift) at(now time.Time) (r3.Vector, r3.Vector) {
+	if d == nil {
+		return r3.Vector{}, r3.Vector{}
+	}
+	elapsed := now.Sub(d.start).Seconds()
+	scale := elapsed / 60
+	if d.model == DriftVibration {
+		scale = math.Sin(2 * math.Pi * elapsed / d.period)
+	}
+	return d.translation.Mul(scale), d.rotation.Mul(scale)
+}
+
+// apply returns the monitors where the drift has moved them at the given time
+// Without a drift the shared monitors are returned as they are.
+func (d *monitorDrift) apply(monitors []virtualMonitor, now time.Time) []virtualMonitor {
+	if d == nil {
+		return monitors
+	}
+	translation, rotation := d.at(now)
+	rotationRad := rotation.Mul(math.Pi / 180)
+	var turn *spatialmath.RotationMatrix
+	if rotationRad.Norm() > 0 {
+		turn = spatialmath.R3ToR4(rotationRad).RotationMatrix()
+	}
+	moved := make([]virtualMonitor, len(monitors))
+	for i, m := range monitors {
+		moved[i] = m.moved(translation, turn)
+	}
+	return moved
+}
+
+// moved returns a copy of the monitor turned about its center, nil for no turn, then translated
+func (m virtualMonitor) moved(translation r3.Vector, turn *spatialmath.RotationMatrix) virtualMonitor {
+	if turn != nil {
+		m.normal, m.upVector = turn.Mul(m.normal), turn.Mul(m.upVector)
+		m.unitNormal, m.right, m.up = turn.Mul(m.unitNormal), turn.Mul(m.right), turn.Mul(m.up)
+	}
+	m.center = m.center.Add(translation)
+	return m
+}
+
+// toMap reports the drift and how far it has moved the monitors, for the ground truth
+func (d *monitorDrift) toMap(now time.Time) map[string]interface{} {
+	translation, rotation := d.at(now)
+	return map[string]interface{}{
+		"model":          d.model,
+		"elapsed_sec":    now.Sub(d.start).Seconds(),
+		"translation_mm": vectorMap(translation),
+		"rotation_deg":   vectorMap(rotation),
+		"tilt_deg":       rotation.Norm(),
+	}
+}
+
+// setDrift handles a "set_drift" command, replacing the drift with one starting now, or stopping it with model
+// "none", until the next reconfigure that changes the drift attribute
+func (s *calibrationFakeSensor) setDrift(cmd map[string]interface{}) (map[string]interface{}, error) {
+	model, _ := cmd["model"].(string)
+	var drift *monitorDrift
+	if model != DriftNone {
+		cfg := &DriftConfig{Model: model}
+		cfg.PeriodSec, _ = cmd["period_sec"].(float64)
+		var err error
+		if cfg.TranslationMM, err = vectorArg(cmd, "translation_mm"); err != nil {
+			return nil, err
+		}
+		if cfg.RotationDeg, err = vectorArg(cmd, "rotation_deg"); err != nil {
+			return nil, err
+		}
+		if err := cfg.Validate("set_drift"); err != nil {
+			return nil, err
+		}
+		drift = newMonitorDrift(cfg)
+	}
+	s.mu.Lock()
+	s.drift = drift
+	s.mu.Unlock()
+	if drift == nil {
+		return map[string]interface{}{"model": DriftNone}, nil
+	}
+	response := map[string]interface{}{
+		"model":          drift.model,
+		"translation_mm": vectorMap(drift.translation),
+		"rotation_deg":   vectorMap(drift.rotation),
+	}
+	if drift.model == DriftVibration {
+		response["period_sec"] = drift.period
+	}
+	return response, nil
+}
+
+// vectorArg reads an optional {"x", "y", "z"} argument of a command, missing components being zero
+func vectorArg(cmd map[string]interface{}, key string) (*Vector3, error) {
+	raw, ok := cmd[key]
+	if !ok {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s' must be an object with x, y and z", key)
+	}
+	v := &Vector3{}
+	for axis, value := range map[string]*float64{"x": &v.X, "y": &v.Y, "z": &v.Z} {
+		if component, ok := m[axis]; ok {
+			if *value, ok = component.(float64); !ok {
+				return nil, fmt.Errorf("'%s.%s' must be a number", key, axis)
+			}
+		}
+	}
+	return v, nil
+}
+
+// vectorMap converts a vector to an {"x", "y", "z"} map for DoCommand responses
+func vectorMap(v r3.Vector) map[string]interface{} {
+	return map[string]interface{}{"x": v.X, "y": v.Y, "z": v.Z}
+}
@@ -0,0 +1,174 @@
+package calibration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fault types for the fake sensor's "simulate_failure" command
+const (
+	FaultStuck   = "stuck"   // every reading repeats the first one taken after the fault starts
+	FaultDropout = "dropout" // readings miss, as if the echo was lost
+	FaultError   = "error"   // readings fail with an error
+	FaultLatency = "latency" // readings are delayed by latency_ms
+	FaultNone    = "none"    // clears every fault
+)
+
+// errSimulatedFailure is returned by readings hit by the error fault
+var errSimulatedFailure = errors.New("simulated sensor failure")
+
+// sensorFault is one active fault
+type sensorFault struct {
+	probability float64       // chance of hitting each reading
+	latency     time.Duration // delay for FaultLatency
+	remaining   int           // readings left to hit, negative until cleared
+}
+
+// faultInjector simulates sensor faults on top of the measured distance
+type faultInjector struct {
+	mu     sync.Mutex
+	rng    *rand.Rand
+	faults map[string]*sensorFault
+	stuck  *fakeReading // the repeated reading while FaultStuck is active
+}
+
+func newFaultInjector() *faultInjector {
+	return &faultInjector{rng: rand.New(rand.NewSource(time.Now().UnixNano())), faults: map[string]*sensorFault{}}
+}
+
+// set handles a "simulate_failure" command, returning the faults now active
+// Each fault type is set independently, so a dropout and a latency spike can run together, and "clear" ends one
+func (f *faultInjector) set(cmd map[string]interface{}) (map[string]interface{}, error) {
+	fault, _ := cmd["fault"].(string)
+	switch fault {
+	case FaultStuck, FaultDropout, FaultError, FaultLatency, FaultNone:
+	case "":
+		return nil, fmt.Errorf("missing 'fault', one of %s, %s, %s, %s or %s",
+			FaultStuck, FaultDropout, FaultError, FaultLatency, FaultNone)
+	default:
+		return nil, fmt.Errorf("unknown fault %q", fault)
+	}
+	next := &sensorFault{probability: 1, remaining: -1}
+	if p, ok := cmd["probability"].(float64); ok {
+		if p <= 0 || p > 1 {
+			return nil, fmt.Errorf("'probability' must be in (0, 1]")
+		}
+		next.probability = p
+	}
+	if count, ok := cmd["count"].(float64); ok {
+		if count < 1 || count != math.Trunc(count) {
+			return nil, fmt.Errorf("'count' must be a whole number of at least 1")
+		}
+		next.remaining = int(count)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if clear, _ := cmd["clear"].(bool); clear && fault != FaultNone {
+		delete(f.faults, fault)
+		return f.status(), nil
+	}
+	switch fault {
+	case FaultNone:
+		f.faults = map[string]*sensorFault{}
+		f.stuck = nil
+		return f.status(), nil
+	case FaultLatency:
+		latency, _ := cmd["latency_ms"].(float64)
+		if latency <= 0 {
+			return nil, fmt.Errorf("'latency_ms' must be positive for the %s fault", FaultLatency)
+		}
+		next.latency = time.Duration(latency * float64(time.Millisecond))
+	case FaultStuck:
+		f.stuck = nil
+	}
+	f.faults[fault] = next
+	return f.status(), nil
+}
+
+// status lists the active faults, called with mu held
+func (f *faultInjector) status() map[string]interface{} {
+	names := make([]string, 0, len(f.faults))
+	for name := range f.faults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	active := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		fault := f.faults[name]
+		entry := map[string]interface{}{"fault": name, "probability": fault.probability}
+		if fault.remaining >= 0 {
+			entry["remaining"] = fault.remaining
+		}
+		if fault.latency > 0 {
+			entry["latency_ms"] = float64(fault.latency) / float64(time.Millisecond)
+		}
+		active = append(active, entry)
+	}
+	return map[string]interface{}{"active": active}
+}
+
+// hits reports whether the fault applies to this reading, using up one of its count. Called with mu held.
+func (f *faultInjector) hits(name string) (*sensorFault, bool) {
+	fault, ok := f.faults[name]
+	if !ok || f.rng.Float64() >= fault.probability {
+		return nil, false
+	}
+	if fault.remaining > 0 {
+		fault.remaining--
+		if fault.remaining == 0 {
+			delete(f.faults, name)
+		}
+	}
+	return fault, true
+}
+
+// measure takes a reading through the active faults
+func (f *faultInjector) measure(ctx context.Context, read func(context.Context) (fakeReading, error)) (fakeReading, error) {
+	f.mu.Lock()
+	var delay time.Duration
+	if fault, ok := f.hits(FaultLatency); ok {
+		delay = fault.latency
+	}
+	_, fail := f.hits(FaultError)
+	_, dropout := f.hits(FaultDropout)
+	_, stuck := f.hits(FaultStuck)
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return fakeReading{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	switch {
+	case fail:
+		return fakeReading{}, errSimulatedFailure
+	case dropout:
+		return fakeReading{monitor: -1}, nil
+	}
+
+	f.mu.Lock()
+	repeated := f.stuck
+	f.mu.Unlock()
+	if stuck && repeated != nil {
+		return *repeated, nil
+	}
+	reading, err := read(ctx)
+	if err != nil {
+		return fakeReading{}, err
+	}
+	if stuck {
+		f.mu.Lock()
+		f.stuck = &reading
+		f.mu.Unlock()
+	}
+	return reading, nil
+}
@@ -0,0 +1,70 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LatencyConfig delays every fake sensor reading, like the echo timing and bus transfer of a real ultrasonic
+// sensor, which take about 60 ms
+type LatencyConfig struct {
+	MeanMS   float64 `json:"mean_ms"`
+	JitterMS float64 `json:"jitter_ms,omitempty"` // standard deviation around the mean, delays are never negative
+	Seed     *int64  `json:"seed,omitempty"`      // RNG seed for reproducible runs, random if unset
+}
+
+// Validate checks the latency configuration
+func (cfg *LatencyConfig) Validate(path string) error {
+	if cfg.MeanMS < 0 || cfg.JitterMS < 0 {
+		return fmt.Errorf("latency 'mean_ms' and 'jitter_ms' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// readingLatency draws the delay of each reading from a normal distribution truncated at zero
+type readingLatency struct {
+	mean, jitter time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newReadingLatency builds the delay described by the config, returning the seed used
+// Returns nil without a config or when it delays nothing.
+func newReadingLatency(cfg *LatencyConfig) (*readingLatency, int64) {
+	seed := time.Now().UnixNano()
+	if cfg == nil || (cfg.MeanMS == 0 && cfg.JitterMS == 0) {
+		return nil, seed
+	}
+	if cfg.Seed != nil {
+		seed = *cfg.Seed
+	}
+	return &readingLatency{
+		mean:   time.Duration(cfg.MeanMS * float64(time.Millisecond)),
+		jitter: time.Duration(cfg.JitterMS * float64(time.Millisecond)),
+		rng:    rand.New(rand.NewSource(seed)),
+	}, seed
+}
+
+// sample draws the delay of one reading
+func (l *readingLatency) sample() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return max(0, l.mean+time.Duration(l.rng.NormFloat64()*float64(l.jitter)))
+}
+
+// wait holds a reading for its delay, or until ctx is done; without a latency it returns at once
+func (l *readingLatency) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(l.sample()):
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/r3"
+)
+
+// Noise models for the fake sensor
+const (
+	NoiseModelSine     = "sine"     // deterministic ±2 mm sine of position (default, legacy behavior)
+	NoiseModelGaussian = "gaussian" // normally distributed with standard deviation sigma_mm
+	NoiseModelUniform  = "uniform"  // uniformly distributed with standard deviation sigma_mm
+	NoiseModelNone     = "none"     // exact distances, bias only
+)
+
+const defaultNoiseSigmaMM = 2.0
+
+type NoiseConfig struct {
+	Model   string   `json:"model,omitempty"`    // one of the NoiseModel* constants
+	SigmaMM *float64 `json:"sigma_mm,omitempty"` // mm - standard deviation for gaussian and uniform noise, 2 if unset
+	BiasMM  float64  `json:"bias_mm,omitempty"`  // mm - constant offset added to every hit
+	Seed    *int64   `json:"seed,omitempty"`     // RNG seed for reproducible runs, random if unset
+
+	// Pose jitter perturbs the looked-up sensor pose before casting the beam, like encoder quantization and
+	// frame-tree rounding do on real hardware: the calibration sees one pose while the beam leaves from another
+	PoseJitterMM  float64 `json:"pose_jitter_mm,omitempty"`  // mm - standard deviation of each position axis
+	PoseJitterDeg float64 `json:"pose_jitter_deg,omitempty"` // degrees - standard deviation of the beam direction
+}
+
+// Validate checks the noise configuration
+func (cfg *NoiseConfig) Validate(path string) error {
+	switch cfg.Model {
+	case "", NoiseModelSine, NoiseModelGaussian, NoiseModelUniform, NoiseModelNone:
+	default:
+		return fmt.Errorf("unknown noise 'model' %q in %s", cfg.Model, path)
+	}
+	if cfg.SigmaMM != nil && *cfg.SigmaMM < 0 {
+		return fmt.Errorf("noise 'sigma_mm' must not be negative in %s", path)
+	}
+	if cfg.PoseJitterMM < 0 || cfg.PoseJitterDeg < 0 {
+		return fmt.Errorf("'pose_jitter_mm' and 'pose_jitter_deg' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// sigma returns the standard deviation of gaussian and uniform noise, where an explicit zero means none
+func (cfg *NoiseConfig) sigma() float64 {
+	if cfg.SigmaMM == nil {
+		return defaultNoiseSigmaMM
+	}
+	return *cfg.SigmaMM
+}
+
+// noiseModel perturbs a simulated distance reading
+type noiseModel interface {
+	// sample returns the noise in mm to add to a hit at the given sensor position
+	sample(sensorPos r3.Vector) float64
+}
+
+// newNoiseModel builds the noise model described by the config, returning the seed used for random models
+func newNoiseModel(cfg *NoiseConfig) (noiseModel, int64) {
+	sigma := cfg.sigma()
+	seed := time.Now().UnixNano()
+	if cfg.Seed != nil {
+		seed = *cfg.Seed
+	}
+
+	switch cfg.Model {
+	case NoiseModelGaussian:
+		return &randomNoise{bias: cfg.BiasMM, rng: rand.New(rand.NewSource(seed)), draw: func(rng *rand.Rand) float64 {
+			return rng.NormFloat64() * sigma
+		}}, seed
+	case NoiseModelUniform:
+		// Uniform on [-a, a] has standard deviation a/√3
+		halfWidth := sigma * math.Sqrt(3)
+		return &randomNoise{bias: cfg.BiasMM, rng: rand.New(rand.NewSource(seed)), draw: func(rng *rand.Rand) float64 {
+			return (2*rng.Float64() - 1) * halfWidth
+		}}, seed
+	case NoiseModelNone:
+		return constantNoise(cfg.BiasMM), seed
+	default:
+		return sineNoise{amplitude: 2.0, bias: cfg.BiasMM}, seed
+	}
+}
+
+// sineNoise is deterministic in sensor position, so repeated readings at a pose are identical
+type sineNoise struct {
+	amplitude float64
+	bias      float64
+}
+
+func (n sineNoise) sample(sensorPos r3.Vector) float64 {
+	return math.Sin(sensorPos.X+sensorPos.Z)*n.amplitude + n.bias
+}
+
+// randomNoise draws independent samples from a seeded RNG
+type randomNoise struct {
+	mu   sync.Mutex
+	rng  *rand.Rand
+	bias float64
+	draw func(*rand.Rand) float64
+}
+
+func (n *randomNoise) sample(_ r3.Vector) float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.draw(n.rng) + n.bias
+}
+
+// constantNoise only applies a bias
+type constantNoise float64
+
+func (n constantNoise) sample(_ r3.Vector) float64 {
+	return float64(n)
+}
+
+// poseJitter draws independent Gaussian perturbations of the sensor pose from a seeded RNG
+type poseJitter struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	sigmaMM  float64
+	sigmaRad float64
+}
+
+// newPoseJitter builds the pose jitter described by the config, nil when it is disabled
+// The seed is offset from the noise seed so the two sequences are independent but still reproducible
+func newPoseJitter(cfg *NoiseConfig, seed int64) *poseJitter {
+	if cfg.PoseJitterMM == 0 && cfg.PoseJitterDeg == 0 {
+		return nil
+	}
+	return &poseJitter{
+		rng:      rand.New(rand.NewSource(seed + 1)),
+		sigmaMM:  cfg.PoseJitterMM,
+		sigmaRad: cfg.PoseJitterDeg * math.Pi / 180,
+	}
+}
+
+// perturb returns the jittered sensor position and unit beam direction
+func (j *poseJitter) perturb(position, direction r3.Vector) (r3.Vector, r3.Vector) {
+	if j == nil {
+		return position, direction
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	position = position.Add(r3.Vector{
+		X: j.rng.NormFloat64() * j.sigmaMM,
+		Y: j.rng.NormFloat64() * j.sigmaMM,
+		Z: j.rng.NormFloat64() * j.sigmaMM,
+	})
+	// Tilt the direction by a small angle, split between two axes perpendicular to it
+	direction = direction.Normalize()
+	u := direction.Ortho()
+	v := direction.Cross(u)
+	direction = direction.
+		Add(u.Mul(math.Tan(j.rng.NormFloat64() * j.sigmaRad))).
+		Add(v.Mul(math.Tan(j.rng.NormFloat64() * j.sigmaRad))).
+		Normalize()
+	return position, direction
+}
@@ -0,0 +1,151 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// Obstacle shapes for the fake sensor's "obstacles" attribute
+const (
+	ObstacleBox    = "box"
+	ObstacleSphere = "sphere"
+)
+
+// surfaceObstacle is the surface reported in the hit details for a ray stopped by an obstacle
+const surfaceObstacle = "obstacle"
+
+// ObstacleConfig is an object in the simulated world that echoes like anything else in the beam, such as a cable or
+// bracket hanging in front of the screen
+// Boxes are aligned with the world axes. Obstacles stay put when the monitors drift.
+type ObstacleConfig struct {
+	Name     string   `json:"name,omitempty"`      // reported in the hit details, default "obstacle <index>"
+	Shape    string   `json:"shape"`               // "box" or "sphere"
+	Center   *Vector3 `json:"center"`              // mm - in the world frame
+	SizeMM   *Vector3 `json:"size_mm,omitempty"`   // box edge lengths along world X, Y and Z
+	RadiusMM float64  `json:"radius_mm,omitempty"` // sphere radius
+}
+
+// Validate checks the obstacle
+func (cfg *ObstacleConfig) Validate(path string) error {
+	if cfg.Center == nil {
+		return fmt.Errorf("missing 'center' field in %s", path)
+	}
+	switch cfg.Shape {
+	case ObstacleBox:
+		if cfg.SizeMM == nil || cfg.SizeMM.X <= 0 || cfg.SizeMM.Y <= 0 || cfg.SizeMM.Z <= 0 {
+			return fmt.Errorf("box 'size_mm' must be positive along x, y and z in %s", path)
+		}
+	case ObstacleSphere:
+		if cfg.RadiusMM <= 0 {
+			return fmt.Errorf("sphere 'radius_mm' must be positive in %s", path)
+		}
+	case "":
+		return fmt.Errorf("missing 'shape' field in %s", path)
+	default:
+		return fmt.Errorf("obstacle 'shape' must be %s or %s in %s", ObstacleBox, ObstacleSphere, path)
+	}
+	return nil
+}
+
+// obstacle is a configured obstacle in the form rays are cast against
+type obstacle struct {
+	name   string
+	shape  string
+	center r3.Vector
+	half   r3.Vector // box half extents
+	radius float64
+}
+
+// newObstacles converts the configured obstacles, nil for none
+func newObstacles(configs []ObstacleConfig) []obstacle {
+	var obstacles []obstacle
+	for i, cfg := range configs {
+		o := obstacle{
+			name:   cfg.Name,
+			shape:  cfg.Shape,
+			center: r3.Vector{X: cfg.Center.X, Y: cfg.Center.Y, Z: cfg.Center.Z},
+			radius: cfg.RadiusMM,
+		}
+		if o.name == "" {
+			o.name = fmt.Sprintf("obstacle %d", i)
+		}
+		if cfg.SizeMM != nil {
+			o.half = r3.Vector{X: cfg.SizeMM.X, Y: cfg.SizeMM.Y, Z: cfg.SizeMM.Z}.Mul(0.5)
+		}
+		obstacles = append(obstacles, o)
+	}
+	return obstacles
+}
+
+// rayIntersectsObstacle finds the nearest obstacle a ray from outside them strikes
+// A ray starting inside an obstacle passes through it, like a transducer buried in a cable bundle hears nothing
+// from it.
+func rayIntersectsObstacle(obstacles []obstacle, rayOrigin, rayDir r3.Vector) (monitorHit, bool) {
+	rayDir = rayDir.Normalize()
+	var nearest monitorHit
+	found := false
+	for _, o := range obstacles {
+		var t float64
+		var normal r3.Vector
+		var ok bool
+		if o.shape == ObstacleSphere {
+			t, normal, ok = o.sphereHit(rayOrigin, rayDir)
+		} else {
+			t, normal, ok = o.boxHit(rayOrigin, rayDir)
+		}
+		if ok && (!found || t < nearest.t) {
+			nearest, found = monitorHit{t: t, normal: normal, surface: surfaceObstacle, obstacle: o.name}, true
+		}
+	}
+	return nearest, found
+}
+
+// sphereHit intersects a unit ray with the sphere, returning the distance and outward normal where it enters
+func (o obstacle) sphereHit(rayOrigin, rayDir r3.Vector) (float64, r3.Vector, bool) {
+	toOrigin := rayOrigin.Sub(o.center)
+	b := toOrigin.Dot(rayDir)
+	c := toOrigin.Norm2() - o.radius*o.radius
+	discriminant := b*b - c
+	if c <= 0 || discriminant < 0 {
+		return 0, r3.Vector{}, false
+	}
+	t := -b - math.Sqrt(discriminant)
+	if t < 0 {
+		return 0, r3.Vector{}, false
+	}
+	return t, rayOrigin.Add(rayDir.Mul(t)).Sub(o.center).Normalize(), true
+}
+
+// boxHit intersects a unit ray with the box by the slab method, returning the distance and outward normal of the
+// face where it enters
+func (o obstacle) boxHit(rayOrigin, rayDir r3.Vector) (float64, r3.Vector, bool) {
+	origin := [3]float64{rayOrigin.X - o.center.X, rayOrigin.Y - o.center.Y, rayOrigin.Z - o.center.Z}
+	dir := [3]float64{rayDir.X, rayDir.Y, rayDir.Z}
+	half := [3]float64{o.half.X, o.half.Y, o.half.Z}
+	near, far := math.Inf(-1), math.Inf(1)
+	entry := -1
+	for axis := 0; axis < 3; axis++ {
+		if dir[axis] == 0 {
+			if math.Abs(origin[axis]) > half[axis] {
+				return 0, r3.Vector{}, false
+			}
+			continue
+		}
+		t1, t2 := (-half[axis]-origin[axis])/dir[axis], (half[axis]-origin[axis])/dir[axis]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > near {
+			near, entry = t1, axis
+		}
+		far = math.Min(far, t2)
+	}
+	if entry < 0 || near > far || near < 0 {
+		return 0, r3.Vector{}, false
+	}
+	normal := [3]float64{}
+	normal[entry] = -math.Copysign(1, dir[entry])
+	return near, r3.Vector{X: normal[0], Y: normal[1], Z: normal[2]}, true
+}
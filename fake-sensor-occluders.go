@@ -0,0 +1,111 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// Surfaces of a virtual monitor a ray can hit, reported in the hit details
+const (
+	surfaceGlass = "glass"
+	surfaceBezel = "bezel"
+	surfaceStand = "stand"
+)
+
+// Defaults of the bezel and stand geometry
+const (
+	defaultBezelDepthMM   = 3.0  // bezel front stands this far proud of the glass
+	defaultStandWidthMM   = 80.0 // stand neck width
+	defaultStandHeightMM  = 150.0
+	defaultStandSetbackMM = 40.0 // stand front sits this far behind the glass
+)
+
+// StandConfig is a monitor stand's neck, a rectangle below the screen set back from the glass
+type StandConfig struct {
+	WidthMM   float64 `json:"width_mm,omitempty"`
+	HeightMM  float64 `json:"height_mm,omitempty"`
+	SetbackMM float64 `json:"setback_mm,omitempty"`
+}
+
+// validateOccluders checks the bezel and stand of a monitor config
+// Both are only modeled on flat monitors.
+func (m MonitorConfig) validateOccluders(path string) error {
+	if m.BezelWidthMM < 0 {
+		return fmt.Errorf("'bezel_width_mm' must not be negative in %s", path)
+	}
+	if m.Stand != nil && (m.Stand.WidthMM < 0 || m.Stand.HeightMM < 0 || m.Stand.SetbackMM < 0) {
+		return fmt.Errorf("stand 'width_mm', 'height_mm' and 'setback_mm' must not be negative in %s", path)
+	}
+	if m.CurveRadius > 0 && (m.BezelWidthMM > 0 || m.Stand != nil) {
+		return fmt.Errorf("'bezel_width_mm' and 'stand' are only modeled on flat monitors in %s", path)
+	}
+	return nil
+}
+
+// applyOccluderDefaults fills in unset fields of the bezel and stand
+func applyOccluderDefaults(m *MonitorConfig) {
+	if m.BezelWidthMM > 0 && m.BezelDepthMM == 0 {
+		m.BezelDepthMM = defaultBezelDepthMM
+	}
+	if m.Stand == nil {
+		return
+	}
+	if m.Stand.WidthMM == 0 {
+		m.Stand.WidthMM = defaultStandWidthMM
+	}
+	if m.Stand.HeightMM == 0 {
+		m.Stand.HeightMM = defaultStandHeightMM
+	}
+	if m.Stand.SetbackMM == 0 {
+		m.Stand.SetbackMM = defaultStandSetbackMM
+	}
+}
+
+// planeHit intersects a ray with the plane parallel to the monitor, offset along its normal towards the viewer
+// Returns the distance along the ray and the hit's position from the monitor center along right and up.
+func (m virtualMonitor) planeHit(rayOrigin, rayDir r3.Vector, offset float64) (t, u, v float64, ok bool) {
+	point := m.center.Add(m.unitNormal.Mul(offset))
+	plane := calibrationhelpers.Plane{A: m.unitNormal.X, B: m.unitNormal.Y, C: m.unitNormal.Z, D: point.Dot(m.unitNormal)}
+	hit, t, ok := calibrationhelpers.RayPlaneIntersection(
+		calibrationhelpers.Point3D{X: rayOrigin.X, Y: rayOrigin.Y, Z: rayOrigin.Z},
+		calibrationhelpers.Point3D{X: rayDir.X, Y: rayDir.Y, Z: rayDir.Z},
+		plane,
+	)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	toIntersection := r3.Vector{X: hit.X, Y: hit.Y, Z: hit.Z}.Sub(m.center)
+	return t, toIntersection.Dot(m.right), toIntersection.Dot(m.up), true
+}
+
+// intersectOccluders finds the nearest hit on the bezel around the glass or the stand below it
+// The bezel is a flat frame bezelWidth wide around the glass, bezelDepth in front of it, so readings on it come
+// back a little short. The stand's front face hangs below the bezel, set back behind the glass, so readings
+// just past the bottom edge can land on it instead of missing.
+func (m virtualMonitor) intersectOccluders(rayOrigin, rayDir r3.Vector) (monitorHit, bool) {
+	var nearest monitorHit
+	found := false
+	halfWidth, halfHeight := m.width/2, m.height/2
+
+	if m.bezelWidth > 0 {
+		t, u, v, ok := m.planeHit(rayOrigin, rayDir, m.bezelDepth)
+		onFrame := math.Abs(u) <= halfWidth+m.bezelWidth && math.Abs(v) <= halfHeight+m.bezelWidth &&
+			(math.Abs(u) > halfWidth || math.Abs(v) > halfHeight)
+		if ok && onFrame {
+			nearest, found = monitorHit{t: t, u: u, v: v, normal: m.unitNormal, surface: surfaceBezel}, true
+		}
+	}
+
+	if m.stand != nil {
+		t, u, v, ok := m.planeHit(rayOrigin, rayDir, -m.stand.SetbackMM)
+		top := -halfHeight - m.bezelWidth
+		onStand := math.Abs(u) <= m.stand.WidthMM/2 && v < top && v >= top-m.stand.HeightMM
+		if ok && onStand && (!found || t < nearest.t) {
+			nearest, found = monitorHit{t: t, u: u, v: v, normal: m.unitNormal, surface: surfaceStand}, true
+		}
+	}
+	return nearest, found
+}
@@ -0,0 +1,96 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gantry"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// poseCacheEpsilon is how far the arm's end may move, in mm or radians, before a cached pose is looked up again
+const poseCacheEpsilon = 1e-6
+
+// poseCache remembers the sensor's last pose from the frame system, so readings polled at a high rate don't each
+// walk the frame system
+// A cached pose is reused until it is older than the TTL or the arm's end position or the gantry's position change
+// from when it was looked up. The end position rather than the joints is compared, since arms moved in Cartesian
+// space, like the simulated example's, may not report joints at all. Reading those back is much cheaper than a
+// frame system lookup, which fetches the inputs of every frame and composes their transforms. Other moving frames
+// between the sensor and the world aren't watched, so the TTL bounds how stale the pose can get when they move.
+type poseCache struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	pose   spatialmath.Pose // nil until the first lookup
+	end    spatialmath.Pose
+	gantry []float64
+	at     time.Time
+
+	hits, misses int
+}
+
+// newPoseCache returns a cache keeping poses for ttl, or nil for no cache when ttl is zero
+func newPoseCache(ttl time.Duration) *poseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &poseCache{ttl: ttl}
+}
+
+// get returns the cached pose if it is still valid, and otherwise looks it up and caches it
+// Without a cache every call looks the pose up.
+func (c *poseCache) get(ctx context.Context, a arm.Arm, g gantry.Gantry,
+	lookup func(context.Context) (spatialmath.Pose, error)) (spatialmath.Pose, error) {
+	if c == nil {
+		return lookup(ctx)
+	}
+	end, err := a.EndPosition(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get arm end position: %w", err)
+	}
+	positions, err := g.Position(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gantry position: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.pose != nil && time.Since(c.at) < c.ttl && spatialmath.PoseAlmostEqualEps(end, c.end, poseCacheEpsilon) &&
+		slices.Equal(positions, c.gantry) {
+		c.hits++
+		pose := c.pose
+		c.mu.Unlock()
+		return pose, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// The inputs are read before the lookup, so a move during it leaves a pose that the next call won't match
+	pose, err := lookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.pose, c.end, c.gantry, c.at = pose, end, positions, time.Now()
+	c.mu.Unlock()
+	return pose, nil
+}
+
+// stats reports the cache's TTL and how many lookups it has saved
+func (c *poseCache) stats() map[string]interface{} {
+	if c == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"enabled": true,
+		"ttl":     c.ttl.String(),
+		"hits":    c.hits,
+		"misses":  c.misses,
+	}
+}
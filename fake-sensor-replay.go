@@ -0,0 +1,141 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/golang/geo/r3"
+)
+
+// defaultReplayDegreeMM weighs sensor direction against position when matching a pose to the replayed log: at
+// typical scan ranges a degree of tilt moves the beam's spot on the glass by several mm
+const defaultReplayDegreeMM = 5.0
+
+// ReplayConfig replays the distances of a recorded scan log instead of simulating a monitor, to reproduce a
+// field calibration on the desk
+type ReplayConfig struct {
+	Path string `json:"path"` // scan log file, or the index of a chunked log
+
+	// MaxPoseErrorMM makes readings further than this from every logged pose misses, default 0 for no limit
+	MaxPoseErrorMM float64 `json:"max_pose_error_mm,omitempty"`
+	// DegreeMM is how many mm of position one degree of sensor direction is worth when matching poses, default 5
+	DegreeMM float64 `json:"degree_mm,omitempty"`
+}
+
+// Validate checks the replay configuration
+func (cfg *ReplayConfig) Validate(path string) error {
+	if cfg.Path == "" {
+		return fmt.Errorf("missing 'path' field in %s", path)
+	}
+	if cfg.MaxPoseErrorMM < 0 {
+		return fmt.Errorf("'max_pose_error_mm' must not be negative in %s", path)
+	}
+	if cfg.DegreeMM < 0 {
+		return fmt.Errorf("'degree_mm' must not be negative in %s", path)
+	}
+	return nil
+}
+
+// replayEntry is a logged sensor pose and what it read there
+type replayEntry struct {
+	position  r3.Vector
+	direction r3.Vector // unit beam direction
+	raw       []float64 // mm - NaN for a miss
+	point     r3.Vector // surface point, when the waypoint produced one
+	hasPoint  bool
+}
+
+// replayLog answers readings from the nearest logged pose
+// Repeated readings at one pose step through its raw readings in order, so a filter sees the recorded sequence.
+type replayLog struct {
+	path          string
+	entries       []replayEntry
+	maxPoseError  float64
+	degreeMM      float64
+	mu            sync.Mutex
+	nextRaw       []int // per entry, the raw reading to answer with next
+	lastRequested int   // entry matched by the previous reading, -1 before the first
+}
+
+// loadReplayLog reads the scan log named by the config
+// Entries without a sensor pose, or with nothing read at it, can't be matched and are dropped
+func loadReplayLog(cfg *ReplayConfig) (*replayLog, error) {
+	logged, err := calibrationhelpers.ReadScanLog(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	replay := &replayLog{path: cfg.Path, maxPoseError: cfg.MaxPoseErrorMM, degreeMM: cfg.DegreeMM, lastRequested: -1}
+	if replay.degreeMM == 0 {
+		replay.degreeMM = defaultReplayDegreeMM
+	}
+	for _, e := range logged {
+		if e.SensorPose == nil {
+			continue
+		}
+		pose, err := calibrationhelpers.PoseFromMap(e.SensorPose)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensor pose in scan log %s: %w", cfg.Path, err)
+		}
+		entry := replayEntry{
+			position:  pose.Point(),
+			direction: pose.Orientation().OrientationVectorRadians().Vector().Normalize(),
+			raw:       e.Raw,
+			point:     r3.Vector{X: e.Point.X, Y: e.Point.Y, Z: e.Point.Z},
+			hasPoint:  e.Status == types.WaypointOK,
+		}
+		if len(entry.raw) == 0 {
+			if math.IsNaN(e.Depth) || e.Depth == 0 {
+				continue
+			}
+			entry.raw = []float64{e.Depth}
+		}
+		replay.entries = append(replay.entries, entry)
+	}
+	if len(replay.entries) == 0 {
+		return nil, fmt.Errorf("scan log %s has no readings with a sensor pose to replay", cfg.Path)
+	}
+	replay.nextRaw = make([]int, len(replay.entries))
+	return replay, nil
+}
+
+// reading returns the next recorded reading of the logged pose nearest to the sensor
+func (r *replayLog) reading(position, direction r3.Vector) fakeReading {
+	direction = direction.Normalize()
+	nearest, nearestError := -1, math.Inf(1)
+	for i, e := range r.entries {
+		angle := math.Acos(math.Max(-1, math.Min(1, direction.Dot(e.direction)))) * 180 / math.Pi
+		poseError := position.Sub(e.position).Norm() + angle*r.degreeMM
+		if poseError < nearestError {
+			nearest, nearestError = i, poseError
+		}
+	}
+	reading := fakeReading{monitor: -1}
+	if r.maxPoseError > 0 && nearestError > r.maxPoseError {
+		return reading
+	}
+
+	r.mu.Lock()
+	entry := r.entries[nearest]
+	if nearest != r.lastRequested {
+		// Arriving at a pose replays its readings from the start, like the scan that recorded them
+		r.nextRaw[nearest] = 0
+		r.lastRequested = nearest
+	}
+	distance := entry.raw[r.nextRaw[nearest]%len(entry.raw)]
+	r.nextRaw[nearest]++
+	r.mu.Unlock()
+
+	if math.IsNaN(distance) || math.IsInf(distance, 0) {
+		return reading
+	}
+	reading.hit, reading.distanceMM = true, distance
+	if entry.hasPoint {
+		reading.point = entry.point
+	} else {
+		reading.point = position.Add(direction.Mul(distance))
+	}
+	return reading
+}
@@ -0,0 +1,103 @@
+package calibration
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/golang/geo/r3"
+)
+
+// Monitor definitions and beam patterns are immutable once built, so sensors with the same configuration
+// share them. This keeps load tests with hundreds of fake sensors from duplicating the same geometry.
+var (
+	sharedMonitors     = newSharedCache() // JSON of the monitor configs -> []virtualMonitor
+	sharedBeamPatterns = newSharedCache() // JSON of the beam config -> beamPattern
+)
+
+// sharedCache holds values built from a configuration for every sensor configured the same way
+// Sensors hold a reference to each entry they use, and an entry is dropped once the last one is released, so
+// reconfigured and closed sensors don't leave their geometry behind.
+type sharedCache struct {
+	mu      sync.Mutex
+	entries map[string]*sharedEntry
+}
+
+type sharedEntry struct {
+	value interface{}
+	refs  int
+}
+
+func newSharedCache() *sharedCache {
+	return &sharedCache{entries: map[string]*sharedEntry{}}
+}
+
+// acquire takes a reference to the value for key, building it if no sensor holds one
+// created reports whether this call built it.
+func (c *sharedCache) acquire(key string, build func() interface{}) (value interface{}, created bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &sharedEntry{value: build()}
+		c.entries[key] = entry
+	}
+	entry.refs++
+	return entry.value, !ok
+}
+
+// release drops a reference taken by acquire, "" for none
+func (c *sharedCache) release(key string) {
+	if key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if entry.refs--; entry.refs <= 0 {
+		delete(c.entries, key)
+	}
+}
+
+// directionPool recycles the per-reading buffers of beam ray directions
+var directionPool = sync.Pool{
+	New: func() interface{} {
+		return new([]r3.Vector)
+	},
+}
+
+// monitorsFor returns the virtual monitors for the configuration, shared with other sensors configured the same way
+// The returned slice must not be modified. key is the shared entry to release once the sensor is done with it, ""
+// for an unshared slice, and created reports whether this call built it.
+func monitorsFor(conf *SensorConfig) (monitors []virtualMonitor, key string, created bool) {
+	configs := conf.Monitors
+	if conf.Monitor != nil {
+		configs = append([]MonitorConfig{*conf.Monitor}, conf.Monitors...)
+	}
+	build := func() interface{} {
+		var monitors []virtualMonitor
+		for i := range configs {
+			monitors = append(monitors, newVirtualMonitor(&configs[i]))
+		}
+		return monitors
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return build().([]virtualMonitor), "", true
+	}
+	shared, created := sharedMonitors.acquire(string(data), build)
+	return shared.([]virtualMonitor), string(data), created
+}
+
+// beamPatternFor returns the beam pattern for the configuration, shared with other sensors configured the same way
+// key is the shared entry to release once the sensor is done with it, "" for an unshared pattern.
+func beamPatternFor(cfg *BeamConfig) (beam beamPattern, key string) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return newBeamPattern(cfg), ""
+	}
+	shared, _ := sharedBeamPatterns.acquire(string(data), func() interface{} { return newBeamPattern(cfg) })
+	return shared.(beamPattern), string(data)
+}
@@ -0,0 +1,45 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+)
+
+// referenceTemperatureC is the air temperature the simulated sensor converts echo times to distances at
+const referenceTemperatureC = 20.0
+
+// speedOfSound returns the speed of sound in dry air at the given temperature in m/s
+func speedOfSound(temperatureC float64) float64 {
+	return 331.3 * math.Sqrt(1+temperatureC/273.15)
+}
+
+// temperatureScale returns the factor the simulated sensor's distances are off by at the given air temperature
+// The sensor times the echo and converts it at the reference speed of sound, so in warmer air, where sound travels
+// faster, it reads short, by about 0.17% per degree.
+func temperatureScale(temperatureC float64) float64 {
+	return speedOfSound(referenceTemperatureC) / speedOfSound(temperatureC)
+}
+
+// validateTemperature checks that a temperature is above absolute zero
+func validateTemperature(temperatureC float64) error {
+	if math.IsNaN(temperatureC) || temperatureC <= -273.15 {
+		return fmt.Errorf("temperature must be above absolute zero, got %.2f °C", temperatureC)
+	}
+	return nil
+}
+
+// setTemperature handles a "set_temperature" command, changing the ambient temperature until the next
+// reconfigure that changes ambient_temperature_c
+func (s *calibrationFakeSensor) setTemperature(cmd map[string]interface{}) (map[string]interface{}, error) {
+	temperature, ok := cmd["temperature_c"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing 'temperature_c'")
+	}
+	if err := validateTemperature(temperature); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.temperature = temperature
+	s.mu.Unlock()
+	return map[string]interface{}{"temperature_c": temperature, "distance_scale": temperatureScale(temperature)}, nil
+}
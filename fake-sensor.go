@@ -1,23 +1,47 @@
 package calibration
 
 import (
+	calibrationhelpers "calibration/calibration-helpers"
 	"context"
 	"fmt"
 	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/geo/r3"
 	"go.viam.com/rdk/components/arm"
 	"go.viam.com/rdk/components/gantry"
 	"go.viam.com/rdk/components/sensor"
 	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/spatialmath"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	FakeSensor = resource.NewModel("jalen-monitor-cleaning", "calibration", "fake-sensor")
 )
 
+// defaultMaxRangeMM is the simulated ultrasonic sensor's max range unless max_range_mm is set
+const defaultMaxRangeMM = 4000.0
+
+// Miss behaviors, how a reading with no echo within the max range is reported
+// Real ultrasonic drivers disagree on this, so the fake sensor can mimic each of them
+const (
+	MissMaxRange = "max_range" // distance is the max range
+	MissZero     = "zero"      // distance is 0
+	MissNaN      = "nan"       // distance is NaN
+	MissError    = "error"     // Readings fails with errNoEcho
+)
+
+// errNoEcho is returned by readings that miss when miss_behavior is "error", which distance sources read as a miss
+var errNoEcho = calibrationhelpers.ErrNoEcho
+
 func init() {
 	resource.RegisterComponent(sensor.API, FakeSensor,
 		resource.Registration[sensor.Sensor, *SensorConfig]{
@@ -38,12 +62,66 @@ type MonitorConfig struct {
 	Up     *Vector3 `json:"up,omitempty"`     // direction vector - which way is "up"
 	Width  float64  `json:"width"`            // mm
 	Height float64  `json:"height"`           // mm
+
+	// CurveRadius bends the screen into a cylinder about its up axis, concave towards the viewer like an
+	// ultrawide curved display. Width is then the arc length across the glass. Zero for a flat monitor.
+	CurveRadius float64 `json:"curve_radius_mm,omitempty"`
+
+	// BezelWidthMM frames the glass with a bezel this wide, standing BezelDepthMM proud of it (default 3)
+	BezelWidthMM float64 `json:"bezel_width_mm,omitempty"`
+	BezelDepthMM float64 `json:"bezel_depth_mm,omitempty"`
+	// Stand hangs a stand's neck below the screen, which readings past the bottom edge can land on
+	Stand *StandConfig `json:"stand,omitempty"`
 }
 
 type SensorConfig struct {
-	Arm     string         `json:"arm"`
-	Gantry  string         `json:"gantry"`
-	Monitor *MonitorConfig `json:"monitor,omitempty"`
+	Arm      string          `json:"arm"`
+	Gantry   string          `json:"gantry"`
+	Monitor  *MonitorConfig  `json:"monitor,omitempty"`
+	Monitors []MonitorConfig `json:"monitors,omitempty"` // several screens, the nearest hit wins
+	// Obstacles are boxes and spheres in the world, such as cables and brackets, that echo before the screen
+	Obstacles []ObstacleConfig `json:"obstacles,omitempty"`
+	Noise     *NoiseConfig     `json:"noise,omitempty"`
+	Beam      *BeamConfig      `json:"beam,omitempty"`    // ultrasonic cone, a single ray if unset
+	Latency   *LatencyConfig   `json:"latency,omitempty"` // delay of every reading, none if unset
+
+	// HitDetails adds where the beam hit to every reading, for debugging edge detection
+	HitDetails bool `json:"hit_details,omitempty"`
+
+	MaxRangeMM   float64 `json:"max_range_mm,omitempty"`  // echoes beyond this are misses, default 4000
+	MissBehavior string  `json:"miss_behavior,omitempty"` // how misses are reported, default max_range
+
+	// ReadingUnits is "m" (default) to report "distance" in meters like the ultrasonic sensor, or "mm" to report
+	// "distance_mm" in millimeters, read with the service's "distance_mm" sensor_reading
+	ReadingUnits string `json:"reading_units,omitempty"`
+
+	// DropoutAngleDeg loses the echo of rays striking glass more obliquely than this, which reflect away from the
+	// sensor. Zero for none
+	DropoutAngleDeg float64 `json:"dropout_angle_deg,omitempty"`
+
+	// QuantizationMM rounds distances to multiples of this, like the ~3 mm steps of an HC-SR04. Zero for none
+	QuantizationMM float64 `json:"quantization_mm,omitempty"`
+
+	// AmbientTemperatureC scales distances by the speed of sound error of a sensor that assumes 20 °C air
+	AmbientTemperatureC *float64 `json:"ambient_temperature_c,omitempty"`
+
+	// WorldFrame is the root frame of the machine's frame system, readings trace rays in it. Default "world"
+	WorldFrame string `json:"world_frame,omitempty"`
+
+	// MountOffset is where the transducer sits in the sensor's frame, which readings trace rays from
+	MountOffset *MountOffsetConfig `json:"mount_offset,omitempty"`
+
+	// PoseCacheTTL (e.g. "100ms") reuses the sensor pose between readings while the arm and gantry stay still
+	PoseCacheTTL string `json:"pose_cache_ttl,omitempty"`
+
+	// GantryBacklashMM is the lost motion of the gantry's first axis when it reverses
+	GantryBacklashMM float64 `json:"gantry_backlash_mm,omitempty"`
+
+	// Drift moves the monitors over time, linearly or vibrating, to exercise drift checks. None if unset
+	Drift *DriftConfig `json:"drift,omitempty"`
+
+	// Replay answers readings from a recorded scan log instead of the virtual monitors
+	Replay *ReplayConfig `json:"replay,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -63,32 +141,310 @@ func (cfg *SensorConfig) Validate(path string) ([]string, []string, error) {
 	if cfg.Gantry == "" {
 		return nil, nil, fmt.Errorf("missing 'gantry' field in %s", path)
 	}
+	if cfg.Monitor != nil && len(cfg.Monitors) > 0 {
+		return nil, nil, fmt.Errorf("only one of 'monitor' and 'monitors' may be set in %s", path)
+	}
+	if cfg.GantryBacklashMM < 0 {
+		return nil, nil, fmt.Errorf("'gantry_backlash_mm' must not be negative in %s", path)
+	}
+	switch cfg.ReadingUnits {
+	case "", calibrationhelpers.UnitsMeters, calibrationhelpers.UnitsMillimeters:
+	default:
+		return nil, nil, fmt.Errorf("'reading_units' must be %s or %s in %s",
+			calibrationhelpers.UnitsMeters, calibrationhelpers.UnitsMillimeters, path)
+	}
+	if cfg.MountOffset != nil {
+		if err := cfg.MountOffset.Validate(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.PoseCacheTTL != "" {
+		if d, err := time.ParseDuration(cfg.PoseCacheTTL); err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("'pose_cache_ttl' must be a positive duration in %s", path)
+		}
+	}
+	for i := range cfg.Obstacles {
+		if err := cfg.Obstacles[i].Validate(fmt.Sprintf("%s.obstacles.%d", path, i)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Replay != nil {
+		if cfg.Monitor != nil || len(cfg.Monitors) > 0 || len(cfg.Obstacles) > 0 {
+			return nil, nil, fmt.Errorf("'replay' cannot be combined with 'monitor', 'monitors' or 'obstacles' in %s", path)
+		}
+		if err := cfg.Replay.Validate(path + ".replay"); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, m := range cfg.Monitors {
+		if m.Center == nil {
+			return nil, nil, fmt.Errorf("missing 'center' field in %s.monitors.%d", path, i)
+		}
+		if m.Width < 0 || m.Height < 0 {
+			return nil, nil, fmt.Errorf("'width' and 'height' must not be negative in %s.monitors.%d", path, i)
+		}
+		if err := m.validateCurve(fmt.Sprintf("%s.monitors.%d", path, i)); err != nil {
+			return nil, nil, err
+		}
+		if err := m.validateOccluders(fmt.Sprintf("%s.monitors.%d", path, i)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Monitor != nil {
+		if err := cfg.Monitor.validateCurve(path + ".monitor"); err != nil {
+			return nil, nil, err
+		}
+		if err := cfg.Monitor.validateOccluders(path + ".monitor"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Noise != nil {
+		if err := cfg.Noise.Validate(path + ".noise"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Beam != nil {
+		if err := cfg.Beam.Validate(path + ".beam"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Latency != nil {
+		if err := cfg.Latency.Validate(path + ".latency"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Drift != nil {
+		if err := cfg.Drift.Validate(path + ".drift"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.MaxRangeMM < 0 {
+		return nil, nil, fmt.Errorf("'max_range_mm' must not be negative in %s", path)
+	}
+	if cfg.DropoutAngleDeg < 0 || cfg.DropoutAngleDeg >= 90 {
+		return nil, nil, fmt.Errorf("'dropout_angle_deg' must be between 0 and 90 in %s", path)
+	}
+	if cfg.QuantizationMM < 0 {
+		return nil, nil, fmt.Errorf("'quantization_mm' must not be negative in %s", path)
+	}
+	if cfg.AmbientTemperatureC != nil {
+		if err := validateTemperature(*cfg.AmbientTemperatureC); err != nil {
+			return nil, nil, fmt.Errorf("invalid 'ambient_temperature_c' in %s: %w", path, err)
+		}
+	}
+	switch cfg.MissBehavior {
+	case "", MissMaxRange, MissZero, MissNaN, MissError:
+	default:
+		return nil, nil, fmt.Errorf("'miss_behavior' must be one of %s, %s, %s or %s in %s",
+			MissMaxRange, MissZero, MissNaN, MissError, path)
+	}
 
 	return []string{cfg.Arm, cfg.Gantry}, nil, nil
 }
 
+// validateCurve checks that a curved monitor bends less than a half circle
+func (m MonitorConfig) validateCurve(path string) error {
+	if m.CurveRadius < 0 {
+		return fmt.Errorf("'curve_radius_mm' must not be negative in %s", path)
+	}
+	if m.CurveRadius > 0 && m.Width >= math.Pi*m.CurveRadius {
+		return fmt.Errorf("'width' must be less than half the circumference of 'curve_radius_mm' in %s", path)
+	}
+	return nil
+}
+
+// missDistance is the distance in mm reported for a reading with no echo, unless misses are errors
+func (cfg *SensorConfig) missDistance() float64 {
+	switch cfg.MissBehavior {
+	case MissZero:
+		return 0
+	case MissNaN:
+		return math.NaN()
+	default:
+		return cfg.MaxRangeMM
+	}
+}
+
+// quantize rounds a distance in mm to the nearest multiple of step, the sensor's resolution; a zero step keeps it
+func quantize(mm, step float64) float64 {
+	if step <= 0 {
+		return mm
+	}
+	return math.Round(mm/step) * step
+}
+
 // calibrationFakeSensor simulates an ultrasonic sensor pointing at a virtual monitor
 type calibrationFakeSensor struct {
-	resource.AlwaysRebuild
-
 	name resource.Name
 
 	logger logging.Logger
-	cfg    *SensorConfig
 
 	cancelCtx  context.Context
 	cancelFunc func()
 
+	// Faults injected by "simulate_failure", kept across Reconfigure
+	faults *faultInjector
+
+	// mu guards everything below, which Reconfigure swaps in place
+	mu     sync.RWMutex
+	cfg    *SensorConfig
 	arm    arm.Arm
 	gantry gantry.Gantry
 	fs     framesystem.RobotFrameSystem
 
-	// Virtual monitor definition
-	monitorCenter   r3.Vector // Center point of monitor in world coordinates
-	monitorNormal   r3.Vector // Normal vector (direction monitor faces)
-	monitorWidth    float64   // Width in mm
-	monitorHeight   float64   // Height in mm
-	monitorUpVector r3.Vector // Which direction is "up" on the monitor
+	// Virtual monitors the simulated ray is tested against
+	monitors []virtualMonitor
+
+	// Obstacles tested against the ray with the monitors, nil for none
+	obstacles []obstacle
+
+	noise  noiseModel
+	jitter *poseJitter // nil without pose jitter
+
+	latency *readingLatency // nil without latency
+
+	// Ray directions sampled within the ultrasonic cone, relative to the sensor axis
+	beam beamPattern
+
+	// Entries of the shared monitor and beam caches the sensor holds, "" for none
+	monitorsKey, beamKey string
+
+	// Recorded readings answered instead of casting the beam, nil unless replaying a scan log
+	replay *replayLog
+
+	// Ambient air temperature in °C, from ambient_temperature_c or "set_temperature"
+	temperature float64
+
+	backlash *gantryBacklash // nil without gantry backlash
+
+	drift *monitorDrift // nil while the monitors hold still
+
+	poses *poseCache // nil without pose_cache_ttl
+
+	mount spatialmath.Pose // transducer pose in the sensor frame, nil without mount_offset
+
+	// readings coalesces concurrent Readings calls into a single frame lookup and ray cast, keyed by generation,
+	// which each measurement advances as it starts so later callers never share one taken before they called
+	readings   singleflight.Group
+	generation atomic.Uint64
+}
+
+// monitorHit is where a ray meets a virtual monitor
+type monitorHit struct {
+	t        float64   // mm along the ray
+	u, v     float64   // mm from the monitor center along right (the arc for a curved monitor) and up
+	normal   r3.Vector // unit surface normal at the hit
+	surface  string    // glass, bezel, stand or obstacle
+	obstacle string    // name of the obstacle hit, surface obstacle only
+}
+
+// fakeReading is one simulated measurement
+type fakeReading struct {
+	distanceMM float64 // noisy distance, set from miss_behavior on a miss
+	hit        bool
+	point      r3.Vector // true hit point in the world frame
+	monitor    int       // index of the monitor hit
+	u, v       float64   // mm - hit position on that monitor
+	surface    string    // part of that monitor hit: glass, bezel or stand, or obstacle
+	obstacle   string    // name of the obstacle hit instead of a monitor
+	incidence  float64   // degrees between the beam and the surface normal
+	specular   bool      // a ray reached the glass but its echo reflected away, set on a miss
+}
+
+// toMap returns the reading in the format of Readings in the given units, with the hit details if requested
+func (r fakeReading) toMap(units string, details bool) map[string]interface{} {
+	readings := map[string]interface{}{}
+	if units == calibrationhelpers.UnitsMillimeters {
+		readings["distance_mm"] = r.distanceMM
+	} else {
+		// Viam ultrasonic sensors return meters
+		readings["distance"] = float64(calibrationhelpers.Millimeters(r.distanceMM).Meters())
+	}
+	if !details {
+		return readings
+	}
+	readings["hit"] = r.hit
+	if r.specular {
+		readings["specular_dropout"] = true
+	}
+	if r.hit {
+		readings["hit_point"] = map[string]interface{}{"x": r.point.X, "y": r.point.Y, "z": r.point.Z}
+		if r.surface == surfaceObstacle {
+			readings["obstacle"] = r.obstacle
+		} else {
+			readings["monitor_index"] = r.monitor
+			readings["monitor_u"] = r.u
+			readings["monitor_v"] = r.v
+		}
+		readings["surface"] = r.surface
+		readings["incidence_angle_deg"] = r.incidence
+	}
+	return readings
+}
+
+// virtualMonitor is a rectangular patch of a plane in world coordinates
+// It is immutable once built, so sensors with the same configuration share it
+type virtualMonitor struct {
+	center   r3.Vector // Center point of monitor in world coordinates
+	normal   r3.Vector // Normal vector (direction monitor faces)
+	width    float64   // Width in mm, along the arc for a curved monitor
+	height   float64   // Height in mm
+	upVector r3.Vector // Which direction is "up" on the monitor
+	radius   float64   // Curve radius in mm, zero for a flat monitor
+
+	bezelWidth float64      // mm - frame around the glass, zero for none
+	bezelDepth float64      // mm - how far the bezel stands in front of the glass
+	stand      *StandConfig // stand below the screen, nil for none
+
+	// Orthonormal monitor axes, precomputed so every ray doesn't rebuild them
+	unitNormal r3.Vector
+	right      r3.Vector
+	up         r3.Vector
+}
+
+// applyMonitorDefaults fills in unset fields of a monitor config
+func applyMonitorDefaults(m *MonitorConfig) {
+	if m.Center == nil {
+		m.Center = &Vector3{X: 250, Y: -400, Z: 200}
+	}
+	if m.Normal == nil {
+		m.Normal = &Vector3{X: 0, Y: 1, Z: 0}
+	}
+	if m.Width == 0 {
+		m.Width = 500
+	}
+	if m.Height == 0 {
+		m.Height = 300
+	}
+	if m.Up == nil {
+		m.Up = &Vector3{X: 0, Y: 0, Z: 1}
+	}
+	applyOccluderDefaults(m)
+}
+
+func newVirtualMonitor(m *MonitorConfig) virtualMonitor {
+	monitor := virtualMonitor{
+		center:   r3.Vector{X: m.Center.X, Y: m.Center.Y, Z: m.Center.Z},
+		normal:   r3.Vector{X: m.Normal.X, Y: m.Normal.Y, Z: m.Normal.Z},
+		width:    m.Width,
+		height:   m.Height,
+		upVector: r3.Vector{X: m.Up.X, Y: m.Up.Y, Z: m.Up.Z},
+		radius:   m.CurveRadius,
+
+		bezelWidth: m.BezelWidthMM,
+		bezelDepth: m.BezelDepthMM,
+	}
+	if m.Stand != nil {
+		stand := *m.Stand
+		monitor.stand = &stand
+	}
+
+	// Right vector (perpendicular to normal and up vector)
+	monitor.right = monitor.upVector.Cross(monitor.normal).Normalize()
+	// Recalculate up vector to ensure orthogonality
+	monitor.up = monitor.normal.Cross(monitor.right).Normalize()
+	monitor.unitNormal = monitor.normal.Normalize()
+	return monitor
 }
 
 func newCalibrationFakeSensor(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (sensor.Sensor, error) {
@@ -102,63 +458,186 @@ func newCalibrationFakeSensor(ctx context.Context, deps resource.Dependencies, r
 }
 
 func NewFakeSensor(_ context.Context, deps resource.Dependencies, name resource.Name, conf *SensorConfig, logger logging.Logger) (sensor.Sensor, error) {
-	var err error
 	cancelCtx, cancelFunc := context.WithCancel(context.Background())
 
-	// Apply defaults for monitor configuration if not specified
-	if conf.Monitor == nil {
-		conf.Monitor = &MonitorConfig{}
+	s := &calibrationFakeSensor{
+		name:       name,
+		logger:     logger,
+		cancelCtx:  cancelCtx,
+		cancelFunc: cancelFunc,
+		faults:     newFaultInjector(),
 	}
-	if conf.Monitor.Center == nil {
-		conf.Monitor.Center = &Vector3{X: 250, Y: -400, Z: 200}
+
+	if err := s.applyConfig(deps, conf); err != nil {
+		cancelFunc()
+		return nil, err
 	}
-	if conf.Monitor.Normal == nil {
-		conf.Monitor.Normal = &Vector3{X: 0, Y: 1, Z: 0}
+	return s, nil
+}
+
+// Reconfigure swaps the arm/gantry dependencies and the simulation parameters without rebuilding the sensor
+func (s *calibrationFakeSensor) Reconfigure(_ context.Context, deps resource.Dependencies, rawConf resource.Config) error {
+	conf, err := resource.NativeConfig[*SensorConfig](rawConf)
+	if err != nil {
+		return err
 	}
-	if conf.Monitor.Width == 0 {
-		conf.Monitor.Width = 500
+	return s.applyConfig(deps, conf)
+}
+
+// applyConfig fills in defaults, resolves dependencies and installs the new configuration
+// Nothing changes if a dependency can't be resolved
+func (s *calibrationFakeSensor) applyConfig(deps resource.Dependencies, conf *SensorConfig) error {
+	// Apply defaults for monitor configuration if not specified
+	if conf.Monitor == nil && len(conf.Monitors) == 0 && conf.Replay == nil {
+		conf.Monitor = &MonitorConfig{}
 	}
-	if conf.Monitor.Height == 0 {
-		conf.Monitor.Height = 300
+	if conf.Monitor != nil {
+		applyMonitorDefaults(conf.Monitor)
 	}
-	if conf.Monitor.Up == nil {
-		conf.Monitor.Up = &Vector3{X: 0, Y: 0, Z: 1}
+	for i := range conf.Monitors {
+		applyMonitorDefaults(&conf.Monitors[i])
 	}
 
-	s := &calibrationFakeSensor{
-		name:       name,
-		logger:     logger,
-		cfg:        conf,
-		cancelCtx:  cancelCtx,
-		cancelFunc: cancelFunc,
+	// Apply defaults for noise configuration if not specified
+	if conf.Noise == nil {
+		conf.Noise = &NoiseConfig{}
+	}
+	if conf.Noise.Model == "" {
+		conf.Noise.Model = NoiseModelSine
+	}
 
-		// Monitor configuration from config
-		monitorCenter:   r3.Vector{X: conf.Monitor.Center.X, Y: conf.Monitor.Center.Y, Z: conf.Monitor.Center.Z},
-		monitorNormal:   r3.Vector{X: conf.Monitor.Normal.X, Y: conf.Monitor.Normal.Y, Z: conf.Monitor.Normal.Z},
-		monitorWidth:    conf.Monitor.Width,
-		monitorHeight:   conf.Monitor.Height,
-		monitorUpVector: r3.Vector{X: conf.Monitor.Up.X, Y: conf.Monitor.Up.Y, Z: conf.Monitor.Up.Z},
+	// Apply defaults for beam configuration if not specified
+	if conf.Beam == nil {
+		conf.Beam = &BeamConfig{}
+	}
+	if conf.Beam.AngleDeg > 0 && conf.Beam.Rays == 0 {
+		conf.Beam.Rays = defaultBeamRays
 	}
 
-	logger.Infof("Fake sensor monitor config: center=%+v, normal=%+v, up=%+v, w=%.1f, h=%.1f",
-		s.monitorCenter, s.monitorNormal, s.monitorUpVector, s.monitorWidth, s.monitorHeight)
+	if conf.MaxRangeMM == 0 {
+		conf.MaxRangeMM = defaultMaxRangeMM
+	}
+	if conf.WorldFrame == "" {
+		conf.WorldFrame = referenceframe.World
+	}
+	if conf.MissBehavior == "" {
+		conf.MissBehavior = MissMaxRange
+	}
 
-	s.arm, err = arm.FromProvider(deps, conf.Arm)
+	armComponent, err := arm.FromProvider(deps, conf.Arm)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	s.gantry, err = gantry.FromProvider(deps, conf.Gantry)
+	gantryComponent, err := gantry.FromProvider(deps, conf.Gantry)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	fs, err := framesystem.FromDependencies(deps)
+	if err != nil {
+		return err
 	}
 
-	s.fs, err = framesystem.FromDependencies(deps)
+	var ttl time.Duration
+	if conf.PoseCacheTTL != "" {
+		if ttl, err = time.ParseDuration(conf.PoseCacheTTL); err != nil {
+			return fmt.Errorf("invalid 'pose_cache_ttl': %w", err)
+		}
+	}
+	mount, err := conf.MountOffset.pose()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid 'mount_offset': %w", err)
+	}
+	var replay *replayLog
+	if conf.Replay != nil {
+		if replay, err = loadReplayLog(conf.Replay); err != nil {
+			return err
+		}
+		s.logger.Infof("Fake sensor replaying %d logged poses from %s", len(replay.entries), replay.path)
 	}
 
-	return s, nil
+	// Monitor configuration from config, shared with sensors configured the same way
+	// Taken after everything that can fail, since only installing the config releases the old references
+	monitors, monitorsKey, created := monitorsFor(conf)
+	for i, m := range monitors {
+		logf := s.logger.Debugf
+		if created {
+			logf = s.logger.Infof
+		}
+		logf("Fake sensor monitor %d config: center=%+v, normal=%+v, up=%+v, w=%.1f, h=%.1f, curve radius=%.1f",
+			i, m.center, m.normal, m.upVector, m.width, m.height, m.radius)
+	}
+	beam, beamKey := beamPatternFor(conf.Beam)
+	if len(beam) > 1 {
+		s.logger.Infof("Fake sensor beam: %.1f° cone sampled with %d rays", conf.Beam.AngleDeg, len(beam))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the running noise model when its config is unchanged so a seeded sequence isn't restarted
+	if s.cfg == nil || !reflect.DeepEqual(s.cfg.Noise, conf.Noise) {
+		var seed int64
+		s.noise, seed = newNoiseModel(conf.Noise)
+		s.jitter = newPoseJitter(conf.Noise, seed)
+		if conf.Noise.Model != NoiseModelSine {
+			s.logger.Infof("Fake sensor noise: model=%s, sigma=%.2f mm, bias=%.2f mm, seed=%d",
+				conf.Noise.Model, conf.Noise.sigma(), conf.Noise.BiasMM, seed)
+		}
+		if s.jitter != nil {
+			s.logger.Infof("Fake sensor pose jitter: %.3f mm, %.3f°, seed=%d",
+				conf.Noise.PoseJitterMM, conf.Noise.PoseJitterDeg, seed)
+		}
+	}
+
+	// Keep the running latency when its config is unchanged, like the noise
+	if s.cfg == nil || !reflect.DeepEqual(s.cfg.Latency, conf.Latency) {
+		var seed int64
+		s.latency, seed = newReadingLatency(conf.Latency)
+		if s.latency != nil {
+			s.logger.Infof("Fake sensor latency: %.1f ± %.1f ms, seed=%d", conf.Latency.MeanMS, conf.Latency.JitterMS, seed)
+		}
+	}
+
+	// Keep a temperature set at runtime unless the configured one changed
+	if s.cfg == nil || !reflect.DeepEqual(s.cfg.AmbientTemperatureC, conf.AmbientTemperatureC) {
+		s.temperature = referenceTemperatureC
+		if conf.AmbientTemperatureC != nil {
+			s.temperature = *conf.AmbientTemperatureC
+		}
+	}
+
+	// Keep the running drift, and how far it has moved the monitors, unless its config changed
+	if s.cfg == nil || !reflect.DeepEqual(s.cfg.Drift, conf.Drift) {
+		s.drift = newMonitorDrift(conf.Drift)
+		if s.drift != nil {
+			s.logger.Infof("Fake sensor monitor drift: %s, translation=%+v mm, rotation=%+v°", conf.Drift.Model,
+				s.drift.translation, s.drift.rotation)
+		}
+	}
+
+	// Keep the gantry's last direction unless the backlash changed
+	if s.cfg == nil || s.cfg.GantryBacklashMM != conf.GantryBacklashMM {
+		s.backlash = nil
+		if conf.GantryBacklashMM > 0 {
+			s.backlash = &gantryBacklash{mm: conf.GantryBacklashMM}
+			s.logger.Infof("Fake sensor gantry backlash: %.2f mm", conf.GantryBacklashMM)
+		}
+	}
+
+	s.cfg = conf
+	s.arm = armComponent
+	s.gantry = gantryComponent
+	s.fs = fs
+	sharedMonitors.release(s.monitorsKey)
+	sharedBeamPatterns.release(s.beamKey)
+	s.monitors, s.monitorsKey = monitors, monitorsKey
+	s.obstacles = newObstacles(conf.Obstacles)
+	s.beam, s.beamKey = beam, beamKey
+	s.replay = replay
+	s.mount = mount
+	// The dependencies may have changed, so start from an empty cache
+	s.poses = newPoseCache(ttl)
+	return nil
 }
 
 func (s *calibrationFakeSensor) Name() resource.Name {
@@ -166,18 +645,88 @@ func (s *calibrationFakeSensor) Name() resource.Name {
 }
 
 // Readings implements the sensor.Sensor interface
-// Returns a map with "distance" key containing the ultrasonic reading in meters
+// Returns a map with "distance" key containing the ultrasonic reading in meters, or "distance_mm" with
+// reading_units "mm", reporting misses as configured by miss_behavior
+// Concurrent callers (e.g. data capture and the calibration service) share one measurement, but only one that
+// starts after they call, so a caller whose move just finished never gets a reading from before it. The
+// measurement runs until the sensor is closed, while each caller stops waiting when its own ctx is done.
 func (s *calibrationFakeSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
-	// Get sensor pose in world coordinates using the frame system
-	sensorPoseInFrame, err := s.fs.GetPose(ctx, s.name.Name, "world", nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get sensor pose: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	pose := sensorPoseInFrame.Pose()
+	key := strconv.FormatUint(s.generation.Load(), 10)
+	measurement := s.readings.DoChan(key, func() (interface{}, error) {
+		s.generation.Add(1)
+		s.mu.RLock()
+		latency := s.latency
+		s.mu.RUnlock()
+		reading, err := s.faults.measure(s.cancelCtx, s.measure)
+		if err != nil {
+			return nil, err
+		}
+		// The echo is timed from where the reading was taken, then the result takes a while to come back
+		if err := latency.wait(s.cancelCtx); err != nil {
+			return nil, err
+		}
+		return reading, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-measurement:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		s.mu.RLock()
+		cfg := s.cfg
+		s.mu.RUnlock()
+		reading := res.Val.(fakeReading)
+		if !reading.hit {
+			if cfg.MissBehavior == MissError {
+				return nil, errNoEcho
+			}
+			reading.distanceMM = cfg.missDistance()
+		}
+		return reading.toMap(cfg.ReadingUnits, cfg.HitDetails), nil
+	}
+}
+
+// measure looks up the sensor pose and casts the beam's rays against the virtual monitors
+// Returns the simulated reading of the nearest echo
+func (s *calibrationFakeSensor) measure(ctx context.Context) (fakeReading, error) {
+	s.mu.RLock()
+	fs, noise, jitter, beam, maxRange, replay := s.fs, s.noise, s.jitter, s.beam, s.cfg.MaxRangeMM, s.replay
+	step, dropout := s.cfg.QuantizationMM, s.cfg.DropoutAngleDeg
+	world := s.cfg.WorldFrame
+	a, g, backlash, poses, mount := s.arm, s.gantry, s.backlash, s.poses, s.mount
+	scale := temperatureScale(s.temperature)
+	monitors := s.drift.apply(s.monitors, time.Now())
+	obstacles := s.obstacles
+	s.mu.RUnlock()
+
+	// Get sensor pose in world coordinates using the frame system, or the cache while nothing has moved
+	pose, err := poses.get(ctx, a, g, func(ctx context.Context) (spatialmath.Pose, error) {
+		sensorPoseInFrame, err := fs.GetPose(ctx, s.name.Name, world, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sensor pose: %w", err)
+		}
+		return sensorPoseInFrame.Pose(), nil
+	})
+	if err != nil {
+		return fakeReading{}, err
+	}
+	if mount != nil {
+		pose = spatialmath.Compose(pose, mount)
+	}
 	s.logger.Debugf("sensor pose in world frame: %+v", pose)
 
 	sensorPos := pose.Point()
+	slack, err := backlash.offset(ctx, g)
+	if err != nil {
+		return fakeReading{}, err
+	}
+	sensorPos.X += slack
 	orientation := pose.Orientation()
 	orientationVector := orientation.OrientationVectorRadians()
 	sensorDirWorld := r3.Vector{
@@ -185,94 +734,213 @@ func (s *calibrationFakeSensor) Readings(ctx context.Context, extra map[string]i
 		Y: orientationVector.OY,
 		Z: orientationVector.OZ,
 	}
-
-	// Calculate intersection with monitor plane (in mm)
-	distanceMM, hit := s.rayIntersectsMonitor(sensorPos, sensorDirWorld)
-
-	if hit {
-		// Add some realistic noise (±2mm)
-		noise := (math.Sin(float64(sensorPos.X+sensorPos.Z)) * 2.0)
-		distanceMM += noise
-
-		s.logger.Debugf("Fake sensor: HIT at distance %.2f mm (pos: %.1f,%.1f,%.1f)",
-			distanceMM, sensorPos.X, sensorPos.Y, sensorPos.Z)
+	if replay != nil {
+		return replay.reading(sensorPos, sensorDirWorld), nil
+	}
+	sensorPos, sensorDirWorld = jitter.perturb(sensorPos, sensorDirWorld)
+
+	// Calculate the nearest echo over every ray in the beam (in mm)
+	reading := fakeReading{monitor: -1}
+	var nearest monitorHit
+	var nearestDir r3.Vector
+	buf := directionPool.Get().(*[]r3.Vector)
+	*buf = beam.appendDirections((*buf)[:0], sensorDirWorld)
+	for _, dir := range *buf {
+		h, i, rayHit := rayIntersectsMonitor(monitors, sensorPos, dir)
+		if o, blocked := rayIntersectsObstacle(obstacles, sensorPos, dir); blocked && (!rayHit || o.t < h.t) {
+			h, i, rayHit = o, -1, true
+		}
+		// Glass is a mirror to ultrasound, so an oblique ray's echo never comes back
+		if rayHit && dropout > 0 && h.surface == surfaceGlass && incidenceDeg(dir, h.normal) > dropout {
+			reading.specular, rayHit = true, false
+		}
+		if rayHit && h.t < maxRange && (!reading.hit || h.t < nearest.t) {
+			nearest, nearestDir, reading.monitor, reading.hit = h, dir, i, true
+		}
+	}
+	directionPool.Put(buf)
+
+	if reading.hit {
+		nearestDir = nearestDir.Normalize()
+		reading.point = sensorPos.Add(nearestDir.Mul(nearest.t))
+		reading.u, reading.v, reading.surface, reading.obstacle = nearest.u, nearest.v, nearest.surface, nearest.obstacle
+		reading.incidence = incidenceDeg(nearestDir, nearest.normal)
+		reading.specular = false
+
+		// Convert the echo time at the wrong speed of sound, add realistic noise from the configured model, then
+		// round to the sensor's resolution
+		reading.distanceMM = quantize(nearest.t*scale+noise.sample(sensorPos), step)
+
+		if reading.surface == surfaceObstacle {
+			s.logger.Debugf("Fake sensor: HIT %s at distance %.2f mm (pos: %.1f,%.1f,%.1f)",
+				reading.obstacle, reading.distanceMM, sensorPos.X, sensorPos.Y, sensorPos.Z)
+		} else {
+			s.logger.Debugf("Fake sensor: HIT monitor %d at distance %.2f mm (pos: %.1f,%.1f,%.1f)",
+				reading.monitor, reading.distanceMM, sensorPos.X, sensorPos.Y, sensorPos.Z)
+		}
 	} else {
-		// No hit - return a large distance (out of range)
-		distanceMM = 4000.0 // Ultrasonic sensor max range in mm
-		s.logger.Debugf("Fake sensor: MISS, returning max distance (pos: %.1f,%.1f,%.1f)",
+		// No echo within range, Readings reports it as miss_behavior says
+		s.logger.Debugf("Fake sensor: MISS, specular dropout %t (pos: %.1f,%.1f,%.1f)", reading.specular,
 			sensorPos.X, sensorPos.Y, sensorPos.Z)
 	}
+	return reading, nil
+}
 
-	// Convert to meters for return value (Viam ultrasonic sensors return meters)
-	distanceMeters := distanceMM / 1000.0
+// incidenceDeg returns the angle in degrees between a ray and the surface normal it strikes
+func incidenceDeg(dir, normal r3.Vector) float64 {
+	return math.Acos(math.Min(1, math.Abs(dir.Normalize().Dot(normal)))) * 180 / math.Pi
+}
 
-	return map[string]interface{}{
-		"distance": distanceMeters,
-	}, nil
+// rayIntersectsMonitor checks if a ray from the sensor hits any of the virtual monitors
+// Returns (hit, index, true) for the nearest hit, (monitorHit{}, -1, false) if every monitor is missed
+func rayIntersectsMonitor(monitors []virtualMonitor, rayOrigin, rayDir r3.Vector) (monitorHit, int, bool) {
+	var nearest monitorHit
+	nearestIndex := -1
+	for i, m := range monitors {
+		h, hit := m.intersect(rayOrigin, rayDir)
+		if hit && (nearestIndex < 0 || h.t < nearest.t) {
+			nearest, nearestIndex = h, i
+		}
+	}
+	return nearest, nearestIndex, nearestIndex >= 0
 }
 
-// rayIntersectsMonitor checks if a ray from the sensor hits the virtual monitor
-// Returns (distance, true) if hit, (0, false) if miss
-func (s *calibrationFakeSensor) rayIntersectsMonitor(rayOrigin, rayDir r3.Vector) (float64, bool) {
+// intersect checks if a ray hits this monitor
+// Returns (hit, true) if hit, (monitorHit{}, false) if miss
+func (m virtualMonitor) intersect(rayOrigin, rayDir r3.Vector) (monitorHit, bool) {
 	// Normalize ray direction
 	rayDir = rayDir.Normalize()
-
-	// Check if ray is parallel to plane (dot product near zero)
-	denom := rayDir.Dot(s.monitorNormal)
-	if math.Abs(denom) < 0.001 {
-		return 0, false // Ray is parallel to plane
+	if m.radius > 0 {
+		return m.intersectCurved(rayOrigin, rayDir)
 	}
 
-	// Calculate intersection with infinite plane
-	// Plane equation: (P - monitorCenter) · monitorNormal = 0
-	// Ray equation: P = rayOrigin + t * rayDir
-	// Solving: t = (monitorCenter - rayOrigin) · monitorNormal / (rayDir · monitorNormal)
+	// Intersect the plane of the glass and check the hit is within the monitor bounds
+	t, u, v, ok := m.planeHit(rayOrigin, rayDir, 0)
+	onGlass := ok && math.Abs(u) <= m.width/2 && math.Abs(v) <= m.height/2
 
-	centerToOrigin := s.monitorCenter.Sub(rayOrigin)
-	t := centerToOrigin.Dot(s.monitorNormal) / denom
-
-	if t < 0 {
-		return 0, false // Intersection is behind the sensor
+	// The bezel or stand may be nearer, or catch a ray that misses the glass
+	if occluder, blocked := m.intersectOccluders(rayOrigin, rayDir); blocked && (!onGlass || occluder.t < t) {
+		return occluder, true
 	}
+	if !onGlass {
+		return monitorHit{}, false
+	}
+	return monitorHit{t: t, u: u, v: v, normal: m.unitNormal, surface: surfaceGlass}, true
+}
 
-	// Calculate intersection point
-	intersectionPoint := rayOrigin.Add(rayDir.Mul(t))
-
-	// Check if intersection point is within monitor bounds
-	// Create a 2D coordinate system on the monitor plane
-
-	// Right vector (perpendicular to normal and up vector)
-	rightVector := s.monitorUpVector.Cross(s.monitorNormal).Normalize()
-
-	// Recalculate up vector to ensure orthogonality
-	upVector := s.monitorNormal.Cross(rightVector).Normalize()
-
-	// Vector from monitor center to intersection point
-	toIntersection := intersectionPoint.Sub(s.monitorCenter)
-
-	// Project onto the monitor's 2D coordinate system
-	u := toIntersection.Dot(rightVector) // Horizontal distance from center
-	v := toIntersection.Dot(upVector)    // Vertical distance from center
-
-	// Check if within bounds
-	halfWidth := s.monitorWidth / 2
-	halfHeight := s.monitorHeight / 2
-
-	if math.Abs(u) <= halfWidth && math.Abs(v) <= halfHeight {
-		// Hit! Return distance
-		return t, true
+// intersectCurved finds the nearest hit on a curved monitor, a cylinder patch whose axis runs along up
+// through center + radius*normal, so the glass bows away from the viewer on both sides of center
+func (m virtualMonitor) intersectCurved(rayOrigin, rayDir r3.Vector) (monitorHit, bool) {
+	axis := m.center.Add(m.unitNormal.Mul(m.radius))
+	perpendicular := func(v r3.Vector) r3.Vector {
+		return v.Sub(m.up.Mul(v.Dot(m.up)))
 	}
 
-	// Intersection is outside monitor bounds
-	return 0, false
+	// Solve |o + t*d| = radius in the plane perpendicular to the axis
+	o := perpendicular(rayOrigin.Sub(axis))
+	d := perpendicular(rayDir)
+	a := d.Dot(d)
+	if a < 1e-12 {
+		return monitorHit{}, false // Ray runs along the axis
+	}
+	b := 2 * o.Dot(d)
+	c := o.Dot(o) - m.radius*m.radius
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return monitorHit{}, false
+	}
+	sqrtDisc := math.Sqrt(disc)
+	for _, t := range []float64{(-b - sqrtDisc) / (2 * a), (-b + sqrtDisc) / (2 * a)} {
+		if t < 0 {
+			continue
+		}
+		toPoint := rayOrigin.Add(rayDir.Mul(t)).Sub(axis)
+		radial := perpendicular(toPoint)
+		depth := -radial.Dot(m.unitNormal)
+		if depth <= 0 {
+			continue // Hit the half of the cylinder behind the viewer
+		}
+		u := m.radius * math.Atan2(radial.Dot(m.right), depth) // Arc length from center
+		v := toPoint.Dot(m.up)
+		if math.Abs(u) <= m.width/2 && math.Abs(v) <= m.height/2 {
+			// The glass faces the axis, so the normal points back along the radius
+			return monitorHit{t: t, u: u, v: v, normal: radial.Mul(-1 / radial.Norm()), surface: surfaceGlass}, true
+		}
+	}
+	return monitorHit{}, false
 }
 
+// DoCommand supports "get_ground_truth", which returns the simulated monitors for validating calibrations, and
+// "simulate_failure", which injects sensor faults for testing how the calibration service handles them
 func (s *calibrationFakeSensor) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
-	return nil, fmt.Errorf("not implemented")
+	command, _ := cmd["command"].(string)
+	switch command {
+	case "get_ground_truth":
+		return s.groundTruth()
+	case "simulate_failure":
+		return s.faults.set(cmd)
+	case "set_temperature":
+		return s.setTemperature(cmd)
+	case "set_drift":
+		return s.setDrift(cmd)
+	case "get_pose_cache_stats":
+		s.mu.RLock()
+		poses := s.poses
+		s.mu.RUnlock()
+		return poses.stats(), nil
+	default:
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// groundTruth reports the true pose and size of every virtual monitor in the world frame
+// With a drift the monitors are reported where it has moved them by now, and the drift itself under "drift".
+func (s *calibrationFakeSensor) groundTruth() (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.replay != nil {
+		return nil, fmt.Errorf("no ground truth while replaying scan log %s", s.replay.path)
+	}
+
+	now := time.Now()
+	monitors := make([]interface{}, 0, len(s.monitors))
+	for _, m := range s.drift.apply(s.monitors, now) {
+		monitor := map[string]interface{}{
+			"center":          vectorMap(m.center),
+			"normal":          vectorMap(m.normal),
+			"up":              vectorMap(m.upVector),
+			"width":           m.width,
+			"height":          m.height,
+			"curve_radius_mm": m.radius,
+			"bezel_width_mm":  m.bezelWidth,
+			"bezel_depth_mm":  m.bezelDepth,
+		}
+		if m.stand != nil {
+			monitor["stand"] = map[string]interface{}{
+				"width_mm":   m.stand.WidthMM,
+				"height_mm":  m.stand.HeightMM,
+				"setback_mm": m.stand.SetbackMM,
+			}
+		}
+		monitors = append(monitors, monitor)
+	}
+	truth := map[string]interface{}{
+		"frame":    s.cfg.WorldFrame,
+		"monitors": monitors,
+	}
+	if s.drift != nil {
+		truth["drift"] = s.drift.toMap(now)
+	}
+	return truth, nil
 }
 
 func (s *calibrationFakeSensor) Close(context.Context) error {
-	// Put close code here
 	s.cancelFunc()
+	// Let the shared geometry go once no other sensor uses it
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sharedMonitors.release(s.monitorsKey)
+	sharedBeamPatterns.release(s.beamKey)
+	s.monitorsKey, s.beamKey = "", ""
 	return nil
 }
@@ -0,0 +1,69 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+)
+
+// defaultFragmentThicknessMM matches the thin box of the visualization config
+const defaultFragmentThicknessMM = 1.0
+
+// exportFragment handles the "export_fragment" command, returning the last calibration as a Viam fragment
+// With "path" set the fragment is also written to that file, and with "align_to_gantry" the monitor X axis
+// follows the gantry instead of the measured bottom edge
+func (s *monitorCalibration) exportFragment(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+
+	config := calibrationhelpers.FragmentConfig{Thickness: defaultFragmentThicknessMM}
+	config.ComponentName, _ = cmd["name"].(string)
+	config.Parent, _ = cmd["parent"].(string)
+	if thickness, ok := cmd["thickness_mm"].(float64); ok {
+		config.Thickness = thickness
+	}
+	response := map[string]interface{}{}
+	if align, _ := cmd["align_to_gantry"].(bool); align {
+		config.AlignAxis = calibrationhelpers.GantryAxis
+		yaw, err := calibrationhelpers.AlignmentYaw(*s.lastResult, config.AlignAxis)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Infof("Aligning monitor frame with the gantry axis: %.3f° about the plane normal", yaw)
+		response["yaw_correction_deg"] = yaw
+	}
+	fragment, err := calibrationhelpers.GenerateFragment(*s.lastResult, config)
+	if err != nil {
+		return nil, err
+	}
+	response["fragment"] = fragment
+	response["session_id"] = s.lastResult.SessionID
+	if path, _ := cmd["path"].(string); path != "" {
+		if err := calibrationhelpers.WriteFragment(path, fragment); err != nil {
+			return nil, err
+		}
+		s.logger.Infof("✓ Exported monitor fragment to %s", path)
+		response["path"] = path
+	}
+	return response, nil
+}
+
+// generateVisualization handles the "generate_visualization" command, rebuilding the visualization config of the
+// last calibration with the "frame" to attach it to, the "thickness_mm" of its box and the component "name"
+// Unlike the config logged after calibrating, it is returned, so a UI can apply it with other parameters.
+func (s *monitorCalibration) generateVisualization(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	config := calibrationhelpers.FragmentConfig{Thickness: defaultFragmentThicknessMM}
+	config.ComponentName, _ = cmd["name"].(string)
+	config.Parent, _ = cmd["frame"].(string)
+	if thickness, ok := cmd["thickness_mm"].(float64); ok {
+		config.Thickness = thickness
+	}
+	visualization, err := calibrationhelpers.MonitorVisualization(*s.lastResult, config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"visualization": visualization}, nil
+}
@@ -0,0 +1,190 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// cylinderIterations bounds the Gauss-Newton refinement of the algebraic circle fit
+const cylinderIterations = 20
+
+// FitCylinder fits a cylinder with the given axis direction to the points
+// The cross-section circle is fit algebraically, then refined to minimize the radial distances
+func FitCylinder(points []Point3D, axis Point3D) (Cylinder, error) {
+	n := len(points)
+	if n < 4 {
+		return Cylinder{}, fmt.Errorf("need at least 4 points to fit a cylinder, got %d", n)
+	}
+	a := toVector(axis)
+	if a.Norm() < 1e-9 {
+		return Cylinder{}, fmt.Errorf("cylinder axis is zero")
+	}
+	a = a.Normalize()
+	e1 := a.Ortho()
+	e2 := a.Cross(e1)
+
+	// Project onto the cross-section, centered for numerical stability
+	var centroid r3.Vector
+	for _, p := range points {
+		centroid = centroid.Add(toVector(p))
+	}
+	centroid = centroid.Mul(1 / float64(n))
+	xs, ys := make([]float64, n), make([]float64, n)
+	for i, p := range points {
+		d := toVector(p).Sub(centroid)
+		xs[i], ys[i] = d.Dot(e1), d.Dot(e2)
+	}
+
+	cx, cy, radius, err := fitCircle(xs, ys)
+	if err != nil {
+		return Cylinder{}, err
+	}
+
+	center := centroid.Add(e1.Mul(cx)).Add(e2.Mul(cy))
+	var reference r3.Vector
+	for i := range xs {
+		reference = reference.Add(e1.Mul(xs[i] - cx)).Add(e2.Mul(ys[i] - cy))
+	}
+	if reference.Norm() < 1e-9 {
+		return Cylinder{}, fmt.Errorf("samples surround the axis, not a monitor patch")
+	}
+
+	cylinder := Cylinder{
+		Origin:    fromVector(center),
+		Axis:      fromVector(a),
+		Radius:    radius,
+		Reference: fromVector(reference.Normalize()),
+		ArcMin:    math.Inf(1),
+		ArcMax:    math.Inf(-1),
+		AxialMin:  math.Inf(1),
+		AxialMax:  math.Inf(-1),
+		Samples:   n,
+	}
+	rss := 0.0
+	for _, p := range points {
+		d := cylinder.Distance(p)
+		rss += d * d
+	}
+	cylinder.ResidualRMS = math.Sqrt(rss / float64(n))
+	cylinder.Extend(points...)
+	return cylinder, nil
+}
+
+// FitCylinderRANSAC fits a cylinder with the given axis direction to the largest set of points within threshold mm
+// of a candidate, so readings of the bezel or the wall past the edges don't bend it
+// Like PlaneFitter, candidates are fit through random samples, here of 4 points, and the one with the most inliers
+// is refit to them. Also returns the inliers.
+func FitCylinderRANSAC(logger Logger, points []Point3D, axis Point3D, threshold float64,
+	iterations int) (Cylinder, []Point3D, error) {
+	if len(points) < 4 {
+		return Cylinder{}, nil, fmt.Errorf("need at least 4 points to fit a cylinder, got %d", len(points))
+	}
+	if threshold <= 0 {
+		return Cylinder{}, nil, fmt.Errorf("RANSAC inlier threshold must be positive")
+	}
+	if iterations <= 0 {
+		return Cylinder{}, nil, fmt.Errorf("RANSAC iterations must be positive")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	var bestInliers []int
+	for i := 0; i < iterations; i++ {
+		candidate, err := FitCylinder(selectIndices(points, rng.Perm(len(points))[:4]), axis)
+		if err != nil {
+			continue // degenerate sample, try another
+		}
+		var inliers []int
+		for j, p := range points {
+			if math.Abs(candidate.Distance(p)) <= threshold {
+				inliers = append(inliers, j)
+			}
+		}
+		if len(inliers) > len(bestInliers) {
+			bestInliers = inliers
+			if len(bestInliers) == len(points) {
+				break // every point agrees, no better consensus is possible
+			}
+		}
+	}
+	if len(bestInliers) < 4 {
+		return Cylinder{}, nil, fmt.Errorf("RANSAC failed to find a cylinder with at least 4 inliers after %d iterations",
+			iterations)
+	}
+
+	logger.Infof("RANSAC cylinder fit: %d/%d inliers (threshold %.1f mm)", len(bestInliers), len(points), threshold)
+	inliers := selectIndices(points, bestInliers)
+	cylinder, err := FitCylinder(inliers, axis)
+	if err != nil {
+		return Cylinder{}, nil, err
+	}
+	return cylinder, inliers, nil
+}
+
+// fitCircle fits a circle to 2D points, returning its center and radius
+func fitCircle(xs, ys []float64) (float64, float64, float64, error) {
+	n := len(xs)
+
+	// Algebraic (Kåsa) fit: x² + y² + D*x + E*y + F = 0 is linear in D, E and F
+	design := mat.NewDense(n, 3, nil)
+	rhs := mat.NewVecDense(n, nil)
+	for i := range xs {
+		design.Set(i, 0, xs[i])
+		design.Set(i, 1, ys[i])
+		design.Set(i, 2, 1)
+		rhs.SetVec(i, -(xs[i]*xs[i] + ys[i]*ys[i]))
+	}
+	var params mat.VecDense
+	if err := params.SolveVec(design, rhs); err != nil {
+		return 0, 0, 0, fmt.Errorf("points do not span a curve: %w", err)
+	}
+	cx, cy := -params.AtVec(0)/2, -params.AtVec(1)/2
+	r2 := cx*cx + cy*cy - params.AtVec(2)
+	if r2 <= 0 || math.IsNaN(r2) {
+		return 0, 0, 0, fmt.Errorf("points do not lie on a circle")
+	}
+	radius := math.Sqrt(r2)
+
+	// Gauss-Newton on the geometric distances, which the algebraic fit biases for short arcs
+	jacobian := mat.NewDense(n, 3, nil)
+	residuals := mat.NewVecDense(n, nil)
+	for iter := 0; iter < cylinderIterations; iter++ {
+		for i := range xs {
+			dx, dy := xs[i]-cx, ys[i]-cy
+			dist := math.Hypot(dx, dy)
+			if dist < 1e-9 {
+				return 0, 0, 0, fmt.Errorf("a point lies on the cylinder axis")
+			}
+			jacobian.Set(i, 0, -dx/dist)
+			jacobian.Set(i, 1, -dy/dist)
+			jacobian.Set(i, 2, -1)
+			residuals.SetVec(i, -(dist - radius))
+		}
+		var step mat.VecDense
+		if err := step.SolveVec(jacobian, residuals); err != nil {
+			break // Keep the current estimate, the fit is as good as the geometry allows
+		}
+		cx += step.AtVec(0)
+		cy += step.AtVec(1)
+		radius += step.AtVec(2)
+		if math.Abs(step.AtVec(0))+math.Abs(step.AtVec(1))+math.Abs(step.AtVec(2)) < 1e-6 {
+			break
+		}
+	}
+	if radius <= 0 || math.IsNaN(radius) {
+		return 0, 0, 0, fmt.Errorf("circle fit did not converge")
+	}
+	return cx, cy, radius, nil
+}
+
+// toVector and fromVector convert between points and r3 vectors
+func toVector(p Point3D) r3.Vector {
+	return r3.Vector{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+func fromVector(v r3.Vector) Point3D {
+	return Point3D{X: v.X, Y: v.Y, Z: v.Z}
+}
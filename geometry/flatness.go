@@ -0,0 +1,135 @@
+package geometry
+
+import (
+	"calibration/types"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// FitDeviationSurface fits a polynomial deviation surface to the residuals of the points from the plane
+// Points further than maxResidual mm from the plane are treated as outliers and ignored (zero keeps all)
+// Terms the samples can't constrain (e.g. the uv cross term for a cross-shaped scan) are dropped by a rank-limited solve
+func FitDeviationSurface(points []Point3D, plane Plane, order int, maxResidual float64) (DeviationSurface, error) {
+	return fitDeviationSurface(points, plane, order, maxResidual, func(p Point3D) float64 {
+		return PointToPlaneDistance(p, plane)
+	})
+}
+
+// FitCylinderDeviationSurface is like FitDeviationSurface for a curved monitor, fitting the residuals of the points
+// from the cylinder rather than from its tangent plane, so the flatness measures the glass and not the curve
+// The surface is laid out on the tangent plane, with residuals along its normal.
+func FitCylinderDeviationSurface(points []Point3D, cylinder Cylinder, plane Plane, order int,
+	maxResidual float64) (DeviationSurface, error) {
+	// Distance grows away from the axis, so it runs against a tangent plane normal facing the axis
+	_, inward := cylinder.Apex()
+	sign := 1.0
+	if plane.A*inward.X+plane.B*inward.Y+plane.C*inward.Z > 0 {
+		sign = -1
+	}
+	return fitDeviationSurface(points, plane, order, maxResidual, func(p Point3D) float64 {
+		return sign * cylinder.Distance(p)
+	})
+}
+
+// fitDeviationSurface fits the deviation surface on the plane to the residuals the residual function gives
+func fitDeviationSurface(points []Point3D, plane Plane, order int, maxResidual float64,
+	residualOf func(Point3D) float64) (DeviationSurface, error) {
+	if order < 0 {
+		return DeviationSurface{}, fmt.Errorf("deviation order must not be negative")
+	}
+
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}
+	length := normal.Norm()
+	if length < 1e-9 {
+		return DeviationSurface{}, fmt.Errorf("plane normal is zero")
+	}
+	normal = normal.Mul(1 / length)
+
+	// Signed distances of each point from the plane
+	var samples []r3.Vector
+	var residuals []float64
+	for _, p := range points {
+		v := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}
+		residual := residualOf(p)
+		if maxResidual > 0 && math.Abs(residual) > maxResidual {
+			continue
+		}
+		samples = append(samples, v)
+		residuals = append(residuals, residual)
+	}
+
+	terms := types.DeviationTerms(order)
+	if len(samples) < len(terms) {
+		return DeviationSurface{}, fmt.Errorf("need at least %d points for an order %d deviation surface, got %d",
+			len(terms), order, len(samples))
+	}
+
+	// In-plane frame about the projected sample centroid
+	var centroid r3.Vector
+	for _, v := range samples {
+		centroid = centroid.Add(v)
+	}
+	centroid = centroid.Mul(1 / float64(len(samples)))
+	centroid = centroid.Sub(normal.Mul(centroid.Dot(normal) - plane.D/length))
+
+	uAxis := r3.Vector{X: 1}.Sub(normal.Mul(normal.X))
+	if uAxis.Norm() < 0.1 {
+		uAxis = normal.Ortho()
+	}
+	uAxis = uAxis.Normalize()
+	vAxis := normal.Cross(uAxis)
+
+	surface := DeviationSurface{
+		Order:   order,
+		Origin:  Point3D{X: centroid.X, Y: centroid.Y, Z: centroid.Z},
+		UAxis:   Point3D{X: uAxis.X, Y: uAxis.Y, Z: uAxis.Z},
+		VAxis:   Point3D{X: vAxis.X, Y: vAxis.Y, Z: vAxis.Z},
+		Normal:  Point3D{X: normal.X, Y: normal.Y, Z: normal.Z},
+		Samples: len(samples),
+	}
+
+	uv := make([][2]float64, len(samples))
+	for i, v := range samples {
+		d := v.Sub(centroid)
+		uv[i] = [2]float64{d.Dot(uAxis), d.Dot(vAxis)}
+		surface.Scale = math.Max(surface.Scale, math.Max(math.Abs(uv[i][0]), math.Abs(uv[i][1])))
+	}
+	if surface.Scale == 0 {
+		surface.Scale = 1
+	}
+
+	design := mat.NewDense(len(samples), len(terms), nil)
+	for i := range samples {
+		for j, term := range terms {
+			design.Set(i, j, math.Pow(uv[i][0]/surface.Scale, float64(term[0]))*math.Pow(uv[i][1]/surface.Scale, float64(term[1])))
+		}
+	}
+
+	var svd mat.SVD
+	if ok := svd.Factorize(design, mat.SVDThin); !ok {
+		return DeviationSurface{}, fmt.Errorf("SVD factorization failed")
+	}
+	rank := svd.Rank(1e-9)
+	var coefficients mat.Dense
+	svd.SolveTo(&coefficients, mat.NewDense(len(samples), 1, residuals), rank)
+	surface.Coefficients = mat.Col(nil, 0, &coefficients)
+
+	// Flatness statistics over the samples
+	minFit, maxFit := math.Inf(1), math.Inf(-1)
+	minRes, maxRes := math.Inf(1), math.Inf(-1)
+	sumSquared := 0.0
+	for i := range samples {
+		fit := surface.Deviation(Point3D{X: samples[i].X, Y: samples[i].Y, Z: samples[i].Z})
+		minFit, maxFit = math.Min(minFit, fit), math.Max(maxFit, fit)
+		minRes, maxRes = math.Min(minRes, residuals[i]), math.Max(maxRes, residuals[i])
+		sumSquared += (residuals[i] - fit) * (residuals[i] - fit)
+	}
+	surface.PeakToValley = maxFit - minFit
+	surface.ResidualPeakToValley = maxRes - minRes
+	surface.ResidualRMS = math.Sqrt(sumSquared / float64(len(samples)))
+
+	return surface, nil
+}
@@ -0,0 +1,121 @@
+// Package geometry holds the plane, fitting and flatness math behind monitor calibration
+// It depends only on gonum, golang/geo and the types it fits from calibration/types, so cloud-side analysis services
+// can reuse it without the rdk robot stack
+package geometry
+
+import (
+	"calibration/types"
+	"fmt"
+	"math"
+)
+
+// The plane, flatness and curve types are defined by the types package, which other modules consume with a
+// stable API, and re-exported here
+type (
+	// Point3D represents a 3D point in world space
+	Point3D = types.Point3D
+	// Plane represents a plane equation: Ax + By + Cz = D
+	Plane = types.Plane
+	// Covariance describes the parameter uncertainty of a least-squares plane fit
+	Covariance = types.Covariance
+	// DeviationSurface models how the glass departs from the fitted plane with a low-order polynomial
+	DeviationSurface = types.DeviationSurface
+	// Cylinder models a curved monitor as a patch of a circular cylinder
+	Cylinder = types.Cylinder
+)
+
+// PointDistanceFromPlane calculates the distance of a point from a plane
+func PointDistanceFromPlane(point Point3D, plane Plane) float64 {
+	// Distance = |Ax + By + Cz - D| / sqrt(A² + B² + C²)
+	numerator := math.Abs(plane.A*point.X + plane.B*point.Y + plane.C*point.Z - plane.D)
+	denominator := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+	return numerator / denominator
+}
+
+// PointToPlaneDistance returns the signed distance of a point from a plane, positive on the side the normal points to
+func PointToPlaneDistance(point Point3D, plane Plane) float64 {
+	length := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+	return (plane.A*point.X + plane.B*point.Y + plane.C*point.Z - plane.D) / length
+}
+
+// ProjectPointOntoPlane returns the point on a plane nearest to the given point
+func ProjectPointOntoPlane(point Point3D, plane Plane) Point3D {
+	normal := toVector(Point3D{X: plane.A, Y: plane.B, Z: plane.C}).Normalize()
+	return fromVector(toVector(point).Sub(normal.Mul(PointToPlaneDistance(point, plane))))
+}
+
+// RayPlaneIntersection intersects the ray from origin along direction with a plane
+// Returns the hit and the distance along the ray in units of direction's length. ok is false when the ray runs
+// parallel to the plane, within about 0.06°, or the plane is behind the origin.
+func RayPlaneIntersection(origin, direction Point3D, plane Plane) (hit Point3D, t float64, ok bool) {
+	normal := toVector(Point3D{X: plane.A, Y: plane.B, Z: plane.C})
+	dir := toVector(direction)
+	if normal.Norm() == 0 || dir.Norm() == 0 {
+		return Point3D{}, 0, false
+	}
+	normal = normal.Normalize()
+	denom := dir.Dot(normal)
+	if math.Abs(denom) < 0.001*dir.Norm() {
+		return Point3D{}, 0, false // Ray is parallel to plane
+	}
+	t = -PointToPlaneDistance(origin, plane) / denom
+	if t < 0 {
+		return Point3D{}, 0, false // Intersection is behind the origin
+	}
+	return fromVector(toVector(origin).Add(dir.Mul(t))), t, true
+}
+
+// PlaneFromThreePoints calculates a plane from 3 non-collinear points, with the normal towards +Y
+func PlaneFromThreePoints(p1, p2, p3 Point3D) (Plane, error) {
+	// Create two vectors in the plane
+	v1 := Point3D{X: p2.X - p1.X, Y: p2.Y - p1.Y, Z: p2.Z - p1.Z}
+	v2 := Point3D{X: p3.X - p1.X, Y: p3.Y - p1.Y, Z: p3.Z - p1.Z}
+
+	// Normal vector is the cross product
+	normal := Point3D{
+		X: v1.Y*v2.Z - v1.Z*v2.Y,
+		Y: v1.Z*v2.X - v1.X*v2.Z,
+		Z: v1.X*v2.Y - v1.Y*v2.X,
+	}
+
+	// Check for collinear points
+	length := math.Sqrt(normal.X*normal.X + normal.Y*normal.Y + normal.Z*normal.Z)
+	if length < 0.001 {
+		return Plane{}, fmt.Errorf("points are collinear, cannot define a plane")
+	}
+
+	// Ensure normal points away from monitor (positive Y direction preferred)
+	// This makes the orientation calculation consistent
+	if normal.Y < 0 {
+		normal.X = -normal.X
+		normal.Y = -normal.Y
+		normal.Z = -normal.Z
+	}
+
+	// Plane equation: A*x + B*y + C*z = D
+	plane := Plane{
+		A: normal.X,
+		B: normal.Y,
+		C: normal.Z,
+		D: normal.X*p1.X + normal.Y*p1.Y + normal.Z*p1.Z,
+	}
+
+	return plane, nil
+}
+
+// CalculatePlaneFrom3Points calculates a plane from 3 non-collinear points
+//
+// Deprecated: use PlaneFromThreePoints.
+func CalculatePlaneFrom3Points(p1, p2, p3 Point3D) (Plane, error) {
+	return PlaneFromThreePoints(p1, p2, p3)
+}
+
+// Logger is the logging the fitters need, satisfied by the rdk logger
+type Logger interface {
+	Infof(template string, args ...interface{})
+}
+
+// radToDeg converts radians to degrees
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
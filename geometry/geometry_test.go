@@ -0,0 +1,225 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+func pointsClose(a, b Point3D) bool {
+	return math.Abs(a.X-b.X) < tolerance && math.Abs(a.Y-b.Y) < tolerance && math.Abs(a.Z-b.Z) < tolerance
+}
+
+func TestRayPlaneIntersection(t *testing.T) {
+	// The monitor plane y = -400, its normal towards the sensor at the origin
+	monitor := Plane{B: 1, D: -400}
+	tests := []struct {
+		name      string
+		origin    Point3D
+		direction Point3D
+		plane     Plane
+		ok        bool
+		hit       Point3D
+		t         float64
+	}{
+		{
+			name:      "square on",
+			direction: Point3D{Y: -1},
+			plane:     monitor,
+			ok:        true,
+			hit:       Point3D{Y: -400},
+			t:         400,
+		},
+		{
+			name:      "distance in units of the direction's length",
+			direction: Point3D{Y: -2},
+			plane:     monitor,
+			ok:        true,
+			hit:       Point3D{Y: -400},
+			t:         200,
+		},
+		{
+			name:      "oblique",
+			origin:    Point3D{X: 10, Z: 5},
+			direction: Point3D{X: 1, Y: -1},
+			plane:     monitor,
+			ok:        true,
+			hit:       Point3D{X: 410, Y: -400, Z: 5},
+			t:         400,
+		},
+		{
+			name:      "unnormalized plane with the normal away from the origin",
+			direction: Point3D{Y: -1},
+			plane:     Plane{B: -3, D: 1200},
+			ok:        true,
+			hit:       Point3D{Y: -400},
+			t:         400,
+		},
+		{
+			name:      "parallel",
+			direction: Point3D{X: 1},
+			plane:     monitor,
+		},
+		{
+			name:      "within the parallel tolerance",
+			direction: Point3D{X: 1, Y: -0.0005},
+			plane:     monitor,
+		},
+		{
+			name:      "plane behind the origin",
+			direction: Point3D{Y: 1},
+			plane:     monitor,
+		},
+		{
+			name:      "zero direction",
+			direction: Point3D{},
+			plane:     monitor,
+		},
+		{
+			name:      "zero normal",
+			direction: Point3D{Y: -1},
+			plane:     Plane{D: -400},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hit, distance, ok := RayPlaneIntersection(tc.origin, tc.direction, tc.plane)
+			if ok != tc.ok {
+				t.Fatalf("ok = %v, want %v", ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			if !pointsClose(hit, tc.hit) {
+				t.Errorf("hit = %+v, want %+v", hit, tc.hit)
+			}
+			if math.Abs(distance-tc.t) > tolerance {
+				t.Errorf("t = %v, want %v", distance, tc.t)
+			}
+		})
+	}
+}
+
+func TestPointToPlaneDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		point    Point3D
+		plane    Plane
+		distance float64
+	}{
+		{"on the plane", Point3D{X: 7, Y: -400, Z: 3}, Plane{B: 1, D: -400}, 0},
+		{"on the normal's side", Point3D{Y: -390}, Plane{B: 1, D: -400}, 10},
+		{"behind the plane", Point3D{Y: -410}, Plane{B: 1, D: -400}, -10},
+		{"unnormalized plane", Point3D{Y: -390}, Plane{B: 4, D: -1600}, 10},
+		{"tilted plane", Point3D{X: 3, Z: 4}, Plane{A: 0.6, C: 0.8}, 5},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PointToPlaneDistance(tc.point, tc.plane); math.Abs(got-tc.distance) > tolerance {
+				t.Errorf("PointToPlaneDistance = %v, want %v", got, tc.distance)
+			}
+			if got := PointDistanceFromPlane(tc.point, tc.plane); math.Abs(got-math.Abs(tc.distance)) > tolerance {
+				t.Errorf("PointDistanceFromPlane = %v, want %v", got, math.Abs(tc.distance))
+			}
+		})
+	}
+}
+
+func TestProjectPointOntoPlane(t *testing.T) {
+	tests := []struct {
+		name      string
+		point     Point3D
+		plane     Plane
+		projected Point3D
+	}{
+		{"in front", Point3D{X: 7, Y: -390, Z: 3}, Plane{B: 1, D: -400}, Point3D{X: 7, Y: -400, Z: 3}},
+		{"behind", Point3D{X: 7, Y: -410, Z: 3}, Plane{B: 1, D: -400}, Point3D{X: 7, Y: -400, Z: 3}},
+		{"on the plane", Point3D{X: 7, Y: -400, Z: 3}, Plane{B: 1, D: -400}, Point3D{X: 7, Y: -400, Z: 3}},
+		{"unnormalized tilted plane", Point3D{X: 3, Z: 4}, Plane{A: 3, C: 4}, Point3D{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ProjectPointOntoPlane(tc.point, tc.plane)
+			if !pointsClose(got, tc.projected) {
+				t.Errorf("ProjectPointOntoPlane = %+v, want %+v", got, tc.projected)
+			}
+			if d := PointToPlaneDistance(got, tc.plane); math.Abs(d) > tolerance {
+				t.Errorf("projected point is %v from the plane", d)
+			}
+		})
+	}
+}
+
+func TestPlaneFromThreePoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		p1, p2, p3 Point3D
+		normal     Point3D // unit normal, towards +Y
+		offset     float64 // D of the plane with the unit normal
+	}{
+		{
+			name:   "monitor plane",
+			p1:     Point3D{X: 0, Y: -400, Z: 0},
+			p2:     Point3D{X: 500, Y: -400, Z: 0},
+			p3:     Point3D{X: 0, Y: -400, Z: 300},
+			normal: Point3D{Y: 1},
+			offset: -400,
+		},
+		{
+			name:   "the other winding still faces +Y",
+			p1:     Point3D{X: 0, Y: -400, Z: 0},
+			p2:     Point3D{X: 0, Y: -400, Z: 300},
+			p3:     Point3D{X: 500, Y: -400, Z: 0},
+			normal: Point3D{Y: 1},
+			offset: -400,
+		},
+		{
+			name:   "tilted",
+			p1:     Point3D{X: 0, Y: 0, Z: 0},
+			p2:     Point3D{X: 1, Y: 0, Z: 0},
+			p3:     Point3D{X: 0, Y: -1, Z: 1},
+			normal: Point3D{Y: math.Sqrt2 / 2, Z: math.Sqrt2 / 2},
+			offset: 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plane, err := PlaneFromThreePoints(tc.p1, tc.p2, tc.p3)
+			if err != nil {
+				t.Fatalf("PlaneFromThreePoints: %v", err)
+			}
+			length := math.Sqrt(plane.A*plane.A + plane.B*plane.B + plane.C*plane.C)
+			normal := Point3D{X: plane.A / length, Y: plane.B / length, Z: plane.C / length}
+			if !pointsClose(normal, tc.normal) {
+				t.Errorf("normal = %+v, want %+v", normal, tc.normal)
+			}
+			if offset := plane.D / length; math.Abs(offset-tc.offset) > tolerance {
+				t.Errorf("offset = %v, want %v", offset, tc.offset)
+			}
+			for _, p := range []Point3D{tc.p1, tc.p2, tc.p3} {
+				if d := PointToPlaneDistance(p, plane); math.Abs(d) > tolerance {
+					t.Errorf("%+v is %v from the plane", p, d)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaneFromThreePointsCollinear(t *testing.T) {
+	tests := []struct {
+		name       string
+		p1, p2, p3 Point3D
+	}{
+		{"on a line", Point3D{}, Point3D{X: 1, Y: 1, Z: 1}, Point3D{X: 2, Y: 2, Z: 2}},
+		{"repeated point", Point3D{X: 5}, Point3D{X: 5}, Point3D{Z: 3}},
+		{"all the same", Point3D{Y: -400}, Point3D{Y: -400}, Point3D{Y: -400}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := PlaneFromThreePoints(tc.p1, tc.p2, tc.p3); err == nil {
+				t.Error("PlaneFromThreePoints succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// PlaneFitter fits a plane to noisy surface samples using RANSAC
+// Readings near the monitor edges often land on the bezel or the wall behind it,
+// so candidate planes are scored by consensus rather than by total squared error
+type PlaneFitter struct {
+	InlierThreshold float64    // mm - max distance from a candidate plane to count as an inlier
+	Iterations      int        // number of random 3-point hypotheses to evaluate
+	Rand            *rand.Rand // random source for sampling, seeded deterministically if nil
+	Seed            *Plane     // plane from a previous calibration, tried before any random hypothesis
+}
+
+// seedInlierFraction is the share of points the seed plane must explain to skip the RANSAC search
+const seedInlierFraction = 0.8
+
+const (
+	// minIncidenceWeight keeps grazing readings in the fit, so the weights never leave too few points to span a plane
+	minIncidenceWeight = 0.05
+	// maxIncidenceIterations bounds the reweighting, which usually settles within a few rounds
+	maxIncidenceIterations = 10
+	// incidenceTolerance is the normal change, in degrees, below which reweighting has converged
+	incidenceTolerance = 1e-4
+)
+
+// Fit returns the plane with the largest inlier set, refined by a least-squares fit over those inliers
+func (f *PlaneFitter) Fit(logger Logger, points []Point3D) (Plane, error) {
+	plane, _, err := f.FitWithCovariance(logger, points)
+	return plane, err
+}
+
+// FitWithCovariance is like Fit but also returns the uncertainty of the least-squares refinement
+func (f *PlaneFitter) FitWithCovariance(logger Logger, points []Point3D) (Plane, Covariance, error) {
+	return f.FitWeighted(logger, points, nil)
+}
+
+// FitWeighted is like FitWithCovariance, but the least-squares refinement weighs each point by its weight
+// Consensus still counts every point the same, so weights only decide how much each inlier pulls on the plane.
+// Nil weights weigh all points equally.
+func (f *PlaneFitter) FitWeighted(logger Logger, points []Point3D, weights []float64) (Plane, Covariance, error) {
+	inliers, err := f.inliers(logger, points)
+	if err != nil {
+		return Plane{}, Covariance{}, err
+	}
+	var inlierWeights []float64
+	if weights != nil {
+		if len(weights) != len(points) {
+			return Plane{}, Covariance{}, fmt.Errorf("got %d weights for %d points", len(weights), len(points))
+		}
+		inlierWeights = selectIndices(weights, inliers)
+	}
+	return FitPlaneWeightedLeastSquares(selectIndices(points, inliers), inlierWeights)
+}
+
+// FitIncidenceWeighted fits a plane to points measured along beams, weighing each point by the cosine of its
+// beam's incidence angle on the plane
+// Ultrasonic readings get less accurate as the beam strikes the glass more obliquely, so steep readings pull less on
+// the plane. The incidence depends on the plane, so the weights are recomputed from each fit until the normal
+// settles. beams[i] is the direction the sensor looked in to measure points[i], any length; zero weighs it fully.
+// Also returns the number of reweighting rounds.
+func (f *PlaneFitter) FitIncidenceWeighted(logger Logger, points, beams []Point3D) (Plane, Covariance, int, error) {
+	if len(beams) != len(points) {
+		return Plane{}, Covariance{}, 0, fmt.Errorf("got %d beams for %d points", len(beams), len(points))
+	}
+	inliers, err := f.inliers(logger, points)
+	if err != nil {
+		return Plane{}, Covariance{}, 0, err
+	}
+	points, beams = selectIndices(points, inliers), selectIndices(beams, inliers)
+
+	plane, cov, err := FitPlaneWeightedLeastSquares(points, nil)
+	if err != nil {
+		return Plane{}, Covariance{}, 0, err
+	}
+	for round := 1; round <= maxIncidenceIterations; round++ {
+		next, nextCov, err := FitPlaneWeightedLeastSquares(points, IncidenceWeights(beams, plane))
+		if err != nil {
+			return Plane{}, Covariance{}, round, err
+		}
+		change := planeNormalAngle(plane, next)
+		plane, cov = next, nextCov
+		if change < incidenceTolerance {
+			logger.Infof("Incidence-weighted plane fit converged after %d rounds", round)
+			return plane, cov, round, nil
+		}
+	}
+	logger.Infof("Incidence-weighted plane fit still moving after %d rounds, using the last fit", maxIncidenceIterations)
+	return plane, cov, maxIncidenceIterations, nil
+}
+
+// IncidenceWeights returns the cosine of each beam's incidence angle on the plane, at least minIncidenceWeight
+// Zero beams, whose direction isn't known, weigh 1.
+func IncidenceWeights(beams []Point3D, plane Plane) []float64 {
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}.Normalize()
+	weights := make([]float64, len(beams))
+	for i, b := range beams {
+		beam := r3.Vector{X: b.X, Y: b.Y, Z: b.Z}
+		if beam.Norm() == 0 {
+			weights[i] = 1
+			continue
+		}
+		weights[i] = math.Max(minIncidenceWeight, math.Abs(beam.Normalize().Dot(normal)))
+	}
+	return weights
+}
+
+// planeNormalAngle returns the angle between two planes' normals in degrees, ignoring which way they point
+func planeNormalAngle(a, b Plane) float64 {
+	na := r3.Vector{X: a.A, Y: a.B, Z: a.C}.Normalize()
+	nb := r3.Vector{X: b.A, Y: b.B, Z: b.C}.Normalize()
+	return radToDeg(math.Acos(math.Min(1, math.Abs(na.Dot(nb)))))
+}
+
+// inliers returns the indices of the points in the largest consensus set
+func (f *PlaneFitter) inliers(logger Logger, points []Point3D) ([]int, error) {
+	if len(points) < 4 {
+		return nil, fmt.Errorf("need at least 4 points to fit a plane, got %d", len(points))
+	}
+	if f.InlierThreshold <= 0 {
+		return nil, fmt.Errorf("RANSAC inlier threshold must be positive")
+	}
+	if f.Iterations <= 0 {
+		return nil, fmt.Errorf("RANSAC iterations must be positive")
+	}
+
+	rng := f.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+
+	var bestInliers []int
+	iterations := f.Iterations
+	if f.Seed != nil {
+		bestInliers = planeInliers(points, *f.Seed, f.InlierThreshold)
+		if len(bestInliers) >= 4 && float64(len(bestInliers)) >= seedInlierFraction*float64(len(points)) {
+			logger.Infof("Seed plane explains %d/%d points, skipping RANSAC search", len(bestInliers), len(points))
+			iterations = 0
+		}
+	}
+	for i := 0; i < iterations; i++ {
+		// Sample 3 distinct points for a candidate plane
+		idx := rng.Perm(len(points))[:3]
+		candidate, err := PlaneFromThreePoints(points[idx[0]], points[idx[1]], points[idx[2]])
+		if err != nil {
+			continue // collinear sample, try another
+		}
+
+		inliers := planeInliers(points, candidate, f.InlierThreshold)
+		if len(inliers) > len(bestInliers) {
+			bestInliers = inliers
+			if len(bestInliers) == len(points) {
+				break // every point agrees, no better consensus is possible
+			}
+		}
+	}
+
+	if len(bestInliers) < 4 {
+		return nil, fmt.Errorf("RANSAC failed to find a plane with at least 4 inliers after %d iterations", iterations)
+	}
+
+	logger.Infof("RANSAC plane fit: %d/%d inliers (threshold %.1f mm)", len(bestInliers), len(points), f.InlierThreshold)
+	return bestInliers, nil
+}
+
+// FitPlaneLeastSquares fits a plane to the points and estimates the uncertainty of its parameters
+// The surface is modeled as offset = slopeU*u + slopeV*v + c in the plane's local frame, so the
+// covariance is sigma² (AᵀA)⁻¹ with sigma² estimated from the residuals
+func FitPlaneLeastSquares(points []Point3D) (Plane, Covariance, error) {
+	return FitPlaneWeightedLeastSquares(points, nil)
+}
+
+// FitPlaneWeightedLeastSquares is like FitPlaneLeastSquares with a relative weight per point, nil for equal weights
+// The weights are scaled to average 1, so the covariance is sigma² (AᵀWA)⁻¹ with sigma² from the weighted
+// residuals. ResidualRMS stays the plain RMS distance of the points from the plane.
+func FitPlaneWeightedLeastSquares(points []Point3D, weights []float64) (Plane, Covariance, error) {
+	n := len(points)
+	if n < 4 {
+		return Plane{}, Covariance{}, fmt.Errorf("need at least 4 points to estimate plane covariance, got %d", n)
+	}
+	weights, err := normalizeWeights(weights, n)
+	if err != nil {
+		return Plane{}, Covariance{}, err
+	}
+
+	plane, err := fitPlaneSVD(points, weights)
+	if err != nil {
+		return Plane{}, Covariance{}, err
+	}
+
+	// Build an orthonormal frame on the plane around the centroid
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}.Normalize()
+	uAxis := normal.Ortho()
+	vAxis := normal.Cross(uAxis)
+
+	var centroid r3.Vector
+	for i, p := range points {
+		centroid = centroid.Add(r3.Vector{X: p.X, Y: p.Y, Z: p.Z}.Mul(weights[i]))
+	}
+	centroid = centroid.Mul(1 / float64(n))
+
+	// Rows scaled by √w make the ordinary normal equations the weighted ones
+	design := mat.NewDense(n, 3, nil)
+	offsets := mat.NewVecDense(n, nil)
+	for i, p := range points {
+		d := r3.Vector{X: p.X, Y: p.Y, Z: p.Z}.Sub(centroid)
+		sw := math.Sqrt(weights[i])
+		design.Set(i, 0, sw*d.Dot(uAxis))
+		design.Set(i, 1, sw*d.Dot(vAxis))
+		design.Set(i, 2, sw)
+		offsets.SetVec(i, sw*d.Dot(normal))
+	}
+
+	var normalMatrix mat.Dense
+	normalMatrix.Mul(design.T(), design)
+	var inverse mat.Dense
+	if err := inverse.Inverse(&normalMatrix); err != nil {
+		return Plane{}, Covariance{}, fmt.Errorf("points do not span a plane, cannot estimate covariance: %w", err)
+	}
+
+	var aty mat.VecDense
+	aty.MulVec(design.T(), offsets)
+	var params mat.VecDense
+	params.MulVec(&inverse, &aty)
+
+	var predicted mat.VecDense
+	predicted.MulVec(design, &params)
+	rss, plainRSS := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		r := offsets.AtVec(i) - predicted.AtVec(i)
+		rss += r * r
+		plainRSS += r * r / weights[i]
+	}
+
+	dof := n - 3
+	sigma2 := rss / float64(dof)
+
+	cov := Covariance{
+		ResidualRMS:      math.Sqrt(plainRSS / float64(n)),
+		Samples:          n,
+		DegreesOfFreedom: dof,
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			cov.Matrix[i][j] = sigma2 * inverse.At(i, j)
+		}
+	}
+	// For small slopes the normal tilts by atan(slope) ≈ slope radians about each in-plane axis
+	cov.NormalStdDev = radToDeg(math.Sqrt(cov.Matrix[0][0] + cov.Matrix[1][1]))
+	cov.OffsetStdDev = math.Sqrt(cov.Matrix[2][2])
+
+	return plane, cov, nil
+}
+
+// planeInliers returns the indices of the points within threshold of the plane
+func planeInliers(points []Point3D, plane Plane, threshold float64) []int {
+	var inliers []int
+	for i, p := range points {
+		if PointDistanceFromPlane(p, plane) <= threshold {
+			inliers = append(inliers, i)
+		}
+	}
+	return inliers
+}
+
+// selectIndices returns the values at the indices, in order
+func selectIndices[T any](values []T, indices []int) []T {
+	selected := make([]T, len(indices))
+	for i, index := range indices {
+		selected[i] = values[index]
+	}
+	return selected
+}
+
+// normalizeWeights scales the weights to average 1, or returns n equal weights for nil
+func normalizeWeights(weights []float64, n int) ([]float64, error) {
+	normalized := make([]float64, n)
+	if weights == nil {
+		for i := range normalized {
+			normalized[i] = 1
+		}
+		return normalized, nil
+	}
+	if len(weights) != n {
+		return nil, fmt.Errorf("got %d weights for %d points", len(weights), n)
+	}
+	sum := 0.0
+	for _, w := range weights {
+		if w <= 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("point weights must be positive and finite, got %g", w)
+		}
+		sum += w
+	}
+	for i, w := range weights {
+		normalized[i] = w * float64(n) / sum
+	}
+	return normalized, nil
+}
+
+// fitPlaneSVD performs a total least-squares plane fit, weighing the points by weights (average 1)
+// The plane normal is the right singular vector with the smallest singular value of the centered data
+func fitPlaneSVD(points []Point3D, weights []float64) (Plane, error) {
+	if len(points) < 3 {
+		return Plane{}, fmt.Errorf("need at least 3 points to fit a plane")
+	}
+
+	n := len(points)
+
+	var centroid Point3D
+	for i, p := range points {
+		centroid.X += weights[i] * p.X
+		centroid.Y += weights[i] * p.Y
+		centroid.Z += weights[i] * p.Z
+	}
+	centroid.X /= float64(n)
+	centroid.Y /= float64(n)
+	centroid.Z /= float64(n)
+
+	data := mat.NewDense(n, 3, nil)
+	for i, p := range points {
+		sw := math.Sqrt(weights[i])
+		data.Set(i, 0, sw*(p.X-centroid.X))
+		data.Set(i, 1, sw*(p.Y-centroid.Y))
+		data.Set(i, 2, sw*(p.Z-centroid.Z))
+	}
+
+	var svd mat.SVD
+	if ok := svd.Factorize(data, mat.SVDThin); !ok {
+		return Plane{}, fmt.Errorf("SVD factorization failed")
+	}
+
+	var v mat.Dense
+	svd.VTo(&v)
+
+	normal := Point3D{X: v.At(0, 2), Y: v.At(1, 2), Z: v.At(2, 2)}
+	length := math.Sqrt(normal.X*normal.X + normal.Y*normal.Y + normal.Z*normal.Z)
+	if length < 0.001 {
+		return Plane{}, fmt.Errorf("points are degenerate, cannot define a plane")
+	}
+
+	// Match the normal convention of PlaneFromThreePoints (positive Y direction preferred)
+	if normal.Y < 0 {
+		normal.X = -normal.X
+		normal.Y = -normal.Y
+		normal.Z = -normal.Z
+	}
+
+	return Plane{
+		A: normal.X,
+		B: normal.Y,
+		C: normal.Z,
+		D: normal.X*centroid.X + normal.Y*centroid.Y + normal.Z*centroid.Z,
+	}, nil
+}
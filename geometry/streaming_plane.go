@@ -0,0 +1,179 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+)
+
+// StreamingPlaneFit fits a least-squares plane to points as they arrive, without storing them
+// It keeps the centroid and scatter matrix of the points, updated Welford-style so they stay accurate for points far
+// from the origin, so its memory is the same after ten points or ten million. The plane and covariance match
+// FitPlaneWeightedLeastSquares on the same points, but there is no outlier rejection: gate points before adding
+// them, e.g. by their distance from a previous fit. The zero value is ready to use.
+type StreamingPlaneFit struct {
+	weighted scatter // weighted sums the plane is fit from
+	plain    scatter // unweighted sums for the plain residual RMS
+}
+
+// scatter accumulates the weighted mean and scatter matrix of points about that mean
+type scatter struct {
+	n      int
+	weight float64       // sum of weights
+	mean   r3.Vector     // weighted mean
+	m      [3][3]float64 // weighted sum of outer products of deviations from the mean
+}
+
+// add updates the sums with a point of weight w, using West's weighted form of Welford's update
+func (s *scatter) add(p r3.Vector, w float64) {
+	s.n++
+	s.weight += w
+	delta := p.Sub(s.mean)
+	s.mean = s.mean.Add(delta.Mul(w / s.weight))
+	after := p.Sub(s.mean)
+	d, a := [3]float64{delta.X, delta.Y, delta.Z}, [3]float64{after.X, after.Y, after.Z}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			s.m[i][j] += w * d[i] * a[j]
+		}
+	}
+}
+
+// about returns the scatter matrix about another center
+func (s *scatter) about(center r3.Vector) [3][3]float64 {
+	offset := s.mean.Sub(center)
+	o := [3]float64{offset.X, offset.Y, offset.Z}
+	m := s.m
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] += s.weight * o[i] * o[j]
+		}
+	}
+	return m
+}
+
+// quadratic returns aᵀMb
+func quadratic(m [3][3]float64, a, b r3.Vector) float64 {
+	av, bv := [3]float64{a.X, a.Y, a.Z}, [3]float64{b.X, b.Y, b.Z}
+	sum := 0.0
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			sum += av[i] * m[i][j] * bv[j]
+		}
+	}
+	return sum
+}
+
+// Add adds a point with weight 1
+func (f *StreamingPlaneFit) Add(p Point3D) {
+	f.weighted.add(toVector(p), 1)
+	f.plain.add(toVector(p), 1)
+}
+
+// AddWeighted adds a point with a relative weight, like the weights of FitPlaneWeightedLeastSquares
+func (f *StreamingPlaneFit) AddWeighted(p Point3D, weight float64) error {
+	if weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return fmt.Errorf("point weights must be positive and finite, got %g", weight)
+	}
+	f.weighted.add(toVector(p), weight)
+	f.plain.add(toVector(p), 1)
+	return nil
+}
+
+// Samples returns the number of points added
+func (f *StreamingPlaneFit) Samples() int {
+	return f.weighted.n
+}
+
+// Centroid returns the weighted centroid of the points added
+func (f *StreamingPlaneFit) Centroid() Point3D {
+	return fromVector(f.weighted.mean)
+}
+
+// Plane returns the total least-squares plane through the points added so far
+// The normal is the scatter matrix's eigenvector with the smallest eigenvalue, with the positive Y direction
+// preferred like PlaneFromThreePoints.
+func (f *StreamingPlaneFit) Plane() (Plane, error) {
+	plane, _, err := f.solve()
+	return plane, err
+}
+
+// Fit returns the plane through the points added so far with the uncertainty of its parameters, as
+// FitPlaneWeightedLeastSquares would for the same points and weights
+func (f *StreamingPlaneFit) Fit() (Plane, Covariance, error) {
+	n := f.weighted.n
+	if n < 4 {
+		return Plane{}, Covariance{}, fmt.Errorf("need at least 4 points to estimate plane covariance, got %d", n)
+	}
+	plane, smallest, err := f.solve()
+	if err != nil {
+		return Plane{}, Covariance{}, err
+	}
+
+	// With the weights scaled to average 1 as the batch fit does, and the plane through the weighted centroid,
+	// the normal equations of offset = slopeU*u + slopeV*v + c split into the in-plane scatter and the weight sum,
+	// and the weighted residual sum of squares is the smallest eigenvalue
+	scale := float64(n) / f.weighted.weight
+	normal := r3.Vector{X: plane.A, Y: plane.B, Z: plane.C}
+	uAxis := normal.Ortho()
+	vAxis := normal.Cross(uAxis)
+	m := f.weighted.m
+	normalMatrix := mat.NewDense(3, 3, []float64{
+		scale * quadratic(m, uAxis, uAxis), scale * quadratic(m, uAxis, vAxis), 0,
+		scale * quadratic(m, vAxis, uAxis), scale * quadratic(m, vAxis, vAxis), 0,
+		0, 0, float64(n),
+	})
+	var inverse mat.Dense
+	if err := inverse.Inverse(normalMatrix); err != nil {
+		return Plane{}, Covariance{}, fmt.Errorf("points do not span a plane, cannot estimate covariance: %w", err)
+	}
+
+	dof := n - 3
+	sigma2 := math.Max(scale*smallest, 0) / float64(dof)
+	plainRSS := math.Max(quadratic(f.plain.about(f.weighted.mean), normal, normal), 0)
+	cov := Covariance{
+		ResidualRMS:      math.Sqrt(plainRSS / float64(n)),
+		Samples:          n,
+		DegreesOfFreedom: dof,
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			cov.Matrix[i][j] = sigma2 * inverse.At(i, j)
+		}
+	}
+	cov.NormalStdDev = radToDeg(math.Sqrt(cov.Matrix[0][0] + cov.Matrix[1][1]))
+	cov.OffsetStdDev = math.Sqrt(cov.Matrix[2][2])
+	return plane, cov, nil
+}
+
+// solve returns the plane through the weighted centroid and the scatter matrix's smallest eigenvalue
+func (f *StreamingPlaneFit) solve() (Plane, float64, error) {
+	if f.weighted.n < 3 {
+		return Plane{}, 0, fmt.Errorf("need at least 3 points to fit a plane")
+	}
+	m := f.weighted.m
+	sym := mat.NewSymDense(3, []float64{
+		m[0][0], m[0][1], m[0][2],
+		m[0][1], m[1][1], m[1][2],
+		m[0][2], m[1][2], m[2][2],
+	})
+	var eigen mat.EigenSym
+	if ok := eigen.Factorize(sym, true); !ok {
+		return Plane{}, 0, fmt.Errorf("eigendecomposition of the scatter matrix failed")
+	}
+	var vectors mat.Dense
+	eigen.VectorsTo(&vectors)
+
+	// Eigenvalues come in ascending order
+	normal := r3.Vector{X: vectors.At(0, 0), Y: vectors.At(1, 0), Z: vectors.At(2, 0)}
+	if normal.Norm() < 0.001 {
+		return Plane{}, 0, fmt.Errorf("points are degenerate, cannot define a plane")
+	}
+	normal = normal.Normalize()
+	if normal.Y < 0 {
+		normal = normal.Mul(-1)
+	}
+	return Plane{A: normal.X, B: normal.Y, C: normal.Z, D: normal.Dot(f.weighted.mean)}, eigen.Values(nil)[0], nil
+}
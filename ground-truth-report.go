@@ -0,0 +1,39 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"context"
+	"fmt"
+)
+
+// groundTruthReport handles the "ground_truth_report" command, scoring the last calibration against the fake sensor
+// The optional limits make the report fail, so CI can gate on "pass"
+func (s *monitorCalibration) groundTruthReport(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	var limits calibrationhelpers.GroundTruthLimits
+	limits.MaxTranslation, _ = cmd["max_translation_mm"].(float64)
+	limits.MaxNormalAngle, _ = cmd["max_normal_angle_deg"].(float64)
+	limits.MaxSizeError, _ = cmd["max_size_error_mm"].(float64)
+
+	truths, err := calibrationhelpers.FetchGroundTruth(ctx, s.sensor)
+	if err != nil {
+		return nil, err
+	}
+	if frame := truths[0].Frame; s.lastResult.Frame != "" && s.lastResult.Frame != frame {
+		return nil, fmt.Errorf("ground truth is in the %q frame, but the last calibration is in %q", frame, s.lastResult.Frame)
+	}
+	report, err := calibrationhelpers.CompareToGroundTruth(*s.lastResult, truths, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Ground truth: translation %.2f mm, normal %.3f°, roll %+.3f°, width %+.2f mm, height %+.2f mm (monitor %d)",
+		report.TranslationError, report.NormalAngleError, report.RollError, report.WidthError, report.HeightError,
+		report.MonitorIndex)
+	for _, failure := range report.Failures {
+		s.logger.Warnf("Ground truth check failed: %s", failure)
+	}
+	return report.ToMap()
+}
@@ -0,0 +1,80 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+)
+
+// Defaults of the exported residual heatmap
+const (
+	defaultHeatmapColumns    = 16
+	defaultHeatmapCellPixels = 32
+)
+
+// exportHeatmap handles the "export_heatmap" command, writing the residuals of the last scan over the last
+// calibrated monitor to a PNG or JSON file
+func (s *monitorCalibration) exportHeatmap(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil || len(s.lastScan) == 0 {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("export_heatmap requires a 'path'")
+	}
+	format, ok := cmd["format"].(string)
+	if !ok || format == "" {
+		var err error
+		format, err = calibrationhelpers.HeatmapFormatFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	columns := defaultHeatmapColumns
+	if n, ok := cmd["columns"].(float64); ok {
+		columns = int(n)
+	}
+	rows := 0
+	if n, ok := cmd["rows"].(float64); ok {
+		rows = int(n)
+	}
+	cellPixels := defaultHeatmapCellPixels
+	if n, ok := cmd["cell_pixels"].(float64); ok {
+		cellPixels = int(n)
+	}
+	scale, _ := cmd["scale_mm"].(float64)
+
+	heatmap, err := calibrationhelpers.BuildResidualHeatmap(*s.lastResult, s.lastScan, columns, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create heatmap file: %w", err)
+	}
+	if err := calibrationhelpers.WriteHeatmap(f, format, heatmap, cellPixels, scale); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close heatmap file: %w", err)
+	}
+
+	if heatmap.Worst != nil {
+		s.logger.Infof("✓ Exported %dx%d residual heatmap to %s, worst cell row %d col %d at %+.2f mm",
+			heatmap.Columns, heatmap.Rows, path, heatmap.Worst.Row, heatmap.Worst.Col, heatmap.Worst.Mean)
+	} else {
+		s.logger.Infof("✓ Exported %dx%d residual heatmap to %s", heatmap.Columns, heatmap.Rows, path)
+	}
+	response, err := heatmap.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["path"] = path
+	response["format"] = format
+	response["frame"] = s.calibrationConfig.Hardware.ReferenceFrame()
+	response["session_id"] = s.lastResult.SessionID
+	return response, nil
+}
@@ -0,0 +1,58 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+)
+
+// exportMCAP handles the "export_mcap" command, writing the most recent calibration session to an MCAP file at
+// "path" for Foxglove
+// The session's result is included when the run succeeded. With "scan_log" set, the scan log at that path is
+// written instead, without a result.
+func (s *monitorCalibration) exportMCAP(cmd map[string]interface{}) (map[string]interface{}, error) {
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("export_mcap requires a 'path'")
+	}
+	session := calibrationhelpers.MCAPSession{Frame: s.calibrationConfig.Hardware.ReferenceFrame()}
+	sessionID := ""
+	if scanLog, _ := cmd["scan_log"].(string); scanLog != "" {
+		entries, err := calibrationhelpers.ReadScanLog(scanLog)
+		if err != nil {
+			return nil, err
+		}
+		session.Entries = entries
+	} else {
+		if len(s.lastWaypoints) == 0 {
+			return nil, fmt.Errorf("no calibration session available, run calibrate first or pass a 'scan_log'")
+		}
+		session.Entries, sessionID = s.lastWaypoints, s.lastWaypointsSession
+		if s.lastResult != nil && s.lastResult.SessionID == sessionID {
+			session.Result = s.lastResult
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCAP file: %w", err)
+	}
+	export, err := calibrationhelpers.WriteSessionMCAP(f, session)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close MCAP file: %w", err)
+	}
+
+	s.logger.Infof("✓ Exported %d scan waypoints as %d MCAP messages to %s", export.Waypoints, export.Messages, path)
+	response, err := export.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	response["path"] = path
+	response["session_id"] = sessionID
+	response["result"] = session.Result != nil
+	return response, nil
+}
@@ -0,0 +1,75 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+)
+
+// Defaults of the exported monitor mesh
+const (
+	defaultMeshThicknessMM = 10.0 // depth of the panel behind the glass
+	defaultMeshSegments    = 32   // strips a curved monitor's arc is split into
+)
+
+// exportMesh handles the "export_mesh" command, writing the last calibrated monitor to an OBJ or STL file
+func (s *monitorCalibration) exportMesh(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("export_mesh requires a 'path'")
+	}
+	format, ok := cmd["format"].(string)
+	if !ok || format == "" {
+		var err error
+		format, err = calibrationhelpers.MeshFormatFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	convention, _ := cmd["convention"].(string)
+	if err := calibrationhelpers.ValidateConvention(convention); err != nil {
+		return nil, err
+	}
+	if convention == "" {
+		convention = calibrationhelpers.ConventionViam
+	}
+	thickness := defaultMeshThicknessMM
+	if t, ok := cmd["thickness_mm"].(float64); ok {
+		thickness = t
+	}
+	segments := defaultMeshSegments
+	if n, ok := cmd["segments"].(float64); ok {
+		segments = int(n)
+	}
+
+	mesh, err := calibrationhelpers.MonitorMesh(*s.lastResult, thickness, segments)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mesh file: %w", err)
+	}
+	if err := calibrationhelpers.ExportMesh(f, format, mesh, s.name.Name, convention); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close mesh file: %w", err)
+	}
+
+	s.logger.Infof("✓ Exported monitor mesh with %d triangles to %s", len(mesh.Triangles), path)
+	return map[string]interface{}{
+		"path":       path,
+		"format":     format,
+		"vertices":   len(mesh.Vertices),
+		"triangles":  len(mesh.Triangles),
+		"frame":      s.calibrationConfig.Hardware.ReferenceFrame(),
+		"convention": convention,
+		"session_id": s.lastResult.SessionID,
+	}, nil
+}
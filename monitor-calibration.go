@@ -2,17 +2,25 @@ package calibration
 
 import (
 	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/scanpath"
+	"calibration/types"
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/camera"
 	"go.viam.com/rdk/components/gantry"
 	"go.viam.com/rdk/components/generic"
 	"go.viam.com/rdk/components/sensor"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/services/motion"
+	"go.viam.com/rdk/services/vision"
 )
 
 var (
@@ -28,9 +36,131 @@ func init() {
 }
 
 type Config struct {
-	Arm        string `json:"arm"`
-	Gantry     string `json:"gantry"`
-	Sensor     string `json:"sensor"`
+	Arm    string `json:"arm"`
+	Gantry string `json:"gantry"`
+	Sensor string `json:"sensor"`
+	// SensorReading is the shape of the sensor's readings: "ultrasonic" (default, "distance" in meters as the Viam
+	// ultrasonic sensor reports it), "distance" (any numeric "distance" in meters) or "distance_mm"
+	SensorReading string `json:"sensor_reading,omitempty"`
+	// Fusion reads further sensors mounted beside Sensor at every pose and averages their distances
+	Fusion *FusionConfig `json:"fusion,omitempty"`
+
+	// WorldFrame is the root frame of the machine's frame system, which results are expressed in. Default "world"
+	WorldFrame string `json:"world_frame,omitempty"`
+	// Topology selects which side of the rig moves: "moving_sensor" (default) or "moving_monitor"
+	Topology string `json:"topology,omitempty"`
+	// MonitorFrame is the frame the monitor is mounted to, required for the moving monitor topology
+	MonitorFrame string `json:"monitor_frame,omitempty"`
+	// SensorMove splits "move_sensor" moves between the gantry and arm: "gantry_first" (default), "arm_first", or
+	// "optimize_reach"
+	SensorMove string `json:"sensor_move,omitempty"`
+	// ArmReachMM is the farthest the end effector gets from the arm base, checked before scanning. Estimated from
+	// the arm's kinematics when unset
+	ArmReachMM float64 `json:"arm_reach_mm,omitempty"`
+	// MountOffset is where the transducer sits in the sensor's frame, when that frame is the arm's tool frame
+	MountOffset *MountOffsetConfig `json:"mount_offset,omitempty"`
+	// GantryBacklashMM is the lost motion of each gantry axis when it reverses, corrected for in sensor poses.
+	// "estimate_backlash" measures it for the first axis
+	GantryBacklashMM []float64 `json:"gantry_backlash_mm,omitempty"`
+
+	// Homing homes the gantry, and optionally checks a reference target, at the start of every run
+	Homing *HomingConfig `json:"homing,omitempty"`
+
+	// MonitorHint plans the gantry window and Z scan around a rough monitor position at the start of every run
+	MonitorHint *MonitorHintConfig `json:"monitor_hint,omitempty"`
+
+	// ScanMode selects how surface points are collected: "linear" (default), "angular", "grid", or "adaptive"
+	ScanMode string `json:"scan_mode,omitempty"`
+	// ScanPattern orders the grid scan waypoints: "raster", "serpentine" (default), or "spiral"
+	ScanPattern string `json:"scan_pattern,omitempty"`
+	// XSpacingMM and ZSpacingMM set the max grid sample spacing along the gantry and arm height
+	XSpacingMM float64 `json:"x_spacing_mm,omitempty"`
+	ZSpacingMM float64 `json:"z_spacing_mm,omitempty"`
+	// CoarseFactor and RefineResidualMM tune the adaptive scan's first pass spacing and refinement threshold
+	CoarseFactor     int     `json:"coarse_factor,omitempty"`
+	RefineResidualMM float64 `json:"refine_residual_mm,omitempty"`
+	// DeviationOrder is the polynomial order of the flatness deviation surface (default 2)
+	DeviationOrder *int `json:"deviation_order,omitempty"`
+	// CornerSweeps enables corner detection with this many sweeps per direction (at least 2)
+	CornerSweeps int `json:"corner_sweeps,omitempty"`
+	// EdgePrecisionMM is how closely edge searches bisect the monitor edge (default 0.5)
+	EdgePrecisionMM float64 `json:"edge_precision_mm,omitempty"`
+	// CurvedMonitor fits a cylinder instead of relying on a plane, for curved ultrawide displays
+	CurvedMonitor bool `json:"curved_monitor,omitempty"`
+	// IncidenceWeighting weighs scan points by how squarely the beam struck the glass (default false)
+	IncidenceWeighting *bool `json:"incidence_weighting,omitempty"`
+	// SettleTimeout and ReadTimeout (e.g. "5s") skip scan waypoints whose move or reading takes longer
+	SettleTimeout string `json:"settle_timeout,omitempty"`
+	ReadTimeout   string `json:"read_timeout,omitempty"`
+	// MaxRangeMM skips scan readings at or beyond this distance as misses (default 4000)
+	MaxRangeMM float64 `json:"max_range_mm,omitempty"`
+	// ScanWorkers filter captured readings while the hardware moves on (default 2), with up to ScanQueueDepth
+	// captures waiting before the scan pauses (default 4). The moves and sensor reads themselves stay sequential.
+	ScanWorkers    int `json:"scan_workers,omitempty"`
+	ScanQueueDepth int `json:"scan_queue_depth,omitempty"`
+	// MaxScanPoints bounds the points each scan pass keeps for the final fit, thinning them evenly once reached
+	// (default 0, keep all)
+	MaxScanPoints int `json:"max_scan_points,omitempty"`
+	// MoveSpeed is the gantry speed in mm/sec (default 50). DwellTimeMS holds the sensor still at each scan waypoint
+	// before reading, and SamplesPerPoint sets the readings taken at each pose, averaged without a filter (default
+	// the filter's samples, or 1 without a filter)
+	MoveSpeed       float64 `json:"move_speed,omitempty"`
+	DwellTimeMS     float64 `json:"dwell_time_ms,omitempty"`
+	SamplesPerPoint int     `json:"samples_per_point,omitempty"`
+	// Filter smooths repeated readings at each pose before plane fitting and edge detection
+	Filter *FilterConfig `json:"filter,omitempty"`
+	// ReadRetry retries failed sensor reads before a scan waypoint is skipped with "read_error"
+	ReadRetry *ReadRetryConfig `json:"read_retry,omitempty"`
+	// WristJoint is the index of the joint swept in angular scan mode
+	WristJoint *int `json:"wrist_joint,omitempty"`
+	// WristSweepDeg is the half-angle of each wrist sweep in angular scan mode
+	WristSweepDeg float64 `json:"wrist_sweep_deg,omitempty"`
+	// OrientationSweep refines the fitted plane normal with wrist sweeps at a few gantry positions
+	OrientationSweep *OrientationSweepConfig `json:"orientation_sweep,omitempty"`
+
+	// Cleaning tunes the generated cleaning paths and pad wear compensation
+	Cleaning *CleaningConfig `json:"cleaning,omitempty"`
+
+	// Targets lists the monitors this service calibrates, for rigs where one gantry reaches several screens
+	Targets []TargetConfig `json:"targets,omitempty"`
+
+	// Alerts rejects calibrations whose quality metrics fall outside these limits
+	Alerts *AlertsConfig `json:"alerts,omitempty"`
+
+	// DriftCheck periodically probes the calibrated monitor and alerts when it has moved
+	DriftCheck *DriftCheckConfig `json:"drift_check,omitempty"`
+
+	// ScanLog records every scan waypoint with its sensor pose and raw and filtered readings to a CSV or JSONL file
+	ScanLog *ScanLogConfig `json:"scan_log,omitempty"`
+
+	// ScanTraceSize is how many of the latest scan waypoints "get_scan_trace" returns, default 500
+	ScanTraceSize int `json:"scan_trace_size,omitempty"`
+
+	// ReportDir is where an HTML report of each successful calibration is written, for operators to review
+	ReportDir string `json:"report_dir,omitempty"`
+
+	// DataCapture buffers every scan waypoint, tagged with its session, for the data manager to capture through
+	// the "capture_scan_samples" command
+	DataCapture *DataCaptureConfig `json:"data_capture,omitempty"`
+
+	// SoftLimits enables the "jog" command, which keeps the tool clear of the calibrated monitor during manual moves
+	SoftLimits *SoftLimitsConfig `json:"soft_limits,omitempty"`
+
+	// Vision enables the "vision_calibrate" command, a coarse calibration from AprilTags on the monitor corners
+	Vision *VisionConfig `json:"vision,omitempty"`
+
+	// Motion plans linear and grid scan moves with the motion service instead of moving the arm and gantry directly
+	Motion *MotionConfig `json:"motion,omitempty"`
+
+	// ChainMaxAge enables chained calibration: a saved result younger than this (e.g. "24h") seeds the next run
+	ChainMaxAge string `json:"chain_max_age,omitempty"`
+
+	// ResultsPath is where the last calibration is saved, defaults to the module data directory
+	ResultsPath string `json:"results_path,omitempty"`
+	// ResultStore selects the persistence backend, defaults to a local file at ResultsPath
+	ResultStore *ResultStoreConfig `json:"result_store,omitempty"`
+	// AuditLogPath is where the audit log of calibration actions is appended, defaults to the module data directory
+	AuditLogPath string `json:"audit_log_path,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -53,7 +183,207 @@ func (cfg *Config) Validate(path string) ([]string, []string, error) {
 	if cfg.Sensor == "" {
 		return nil, nil, fmt.Errorf("missing 'sensor' field in %s", path)
 	}
-	return []string{cfg.Arm, cfg.Gantry, cfg.Sensor}, nil, nil
+	switch cfg.Topology {
+	case "", calibrationhelpers.TopologyMovingSensor:
+	case calibrationhelpers.TopologyMovingMonitor:
+		if cfg.MonitorFrame == "" {
+			return nil, nil, fmt.Errorf("missing 'monitor_frame' field in %s, required for topology %q", path, cfg.Topology)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown 'topology' %q in %s", cfg.Topology, path)
+	}
+	switch cfg.SensorReading {
+	case "", calibrationhelpers.DistanceUltrasonic, calibrationhelpers.DistanceMeters, calibrationhelpers.DistanceMillimeters:
+	default:
+		return nil, nil, fmt.Errorf("unknown 'sensor_reading' %q in %s", cfg.SensorReading, path)
+	}
+	switch cfg.SensorMove {
+	case "", calibrationhelpers.SplitGantryFirst, calibrationhelpers.SplitArmFirst, calibrationhelpers.SplitOptimizeReach:
+	default:
+		return nil, nil, fmt.Errorf("unknown 'sensor_move' %q in %s", cfg.SensorMove, path)
+	}
+	switch cfg.ScanMode {
+	case "", calibrationhelpers.ScanModeLinear, calibrationhelpers.ScanModeAngular, calibrationhelpers.ScanModeGrid,
+		calibrationhelpers.ScanModeAdaptive:
+	default:
+		return nil, nil, fmt.Errorf("unknown 'scan_mode' %q in %s", cfg.ScanMode, path)
+	}
+	switch scanpath.Pattern(cfg.ScanPattern) {
+	case "", scanpath.Raster, scanpath.Serpentine, scanpath.Spiral:
+	default:
+		return nil, nil, fmt.Errorf("unknown 'scan_pattern' %q in %s", cfg.ScanPattern, path)
+	}
+	if cfg.DeviationOrder != nil && (*cfg.DeviationOrder < 0 || *cfg.DeviationOrder > 4) {
+		return nil, nil, fmt.Errorf("'deviation_order' must be between 0 and 4 in %s", path)
+	}
+	if cfg.CornerSweeps < 0 || cfg.CornerSweeps == 1 {
+		return nil, nil, fmt.Errorf("'corner_sweeps' must be 0 or at least 2 in %s", path)
+	}
+	if cfg.EdgePrecisionMM < 0 {
+		return nil, nil, fmt.Errorf("'edge_precision_mm' must not be negative in %s", path)
+	}
+	if cfg.XSpacingMM < 0 || cfg.ZSpacingMM < 0 {
+		return nil, nil, fmt.Errorf("'x_spacing_mm' and 'z_spacing_mm' must not be negative in %s", path)
+	}
+	if cfg.CoarseFactor < 0 || cfg.CoarseFactor == 1 {
+		return nil, nil, fmt.Errorf("'coarse_factor' must be at least 2 in %s", path)
+	}
+	if cfg.RefineResidualMM < 0 {
+		return nil, nil, fmt.Errorf("'refine_residual_mm' must not be negative in %s", path)
+	}
+	for name, value := range map[string]string{"settle_timeout": cfg.SettleTimeout, "read_timeout": cfg.ReadTimeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("'%s' must be a positive duration in %s", name, path)
+		}
+	}
+	if cfg.MaxRangeMM < 0 {
+		return nil, nil, fmt.Errorf("'max_range_mm' must not be negative in %s", path)
+	}
+	if cfg.MoveSpeed < 0 {
+		return nil, nil, fmt.Errorf("'move_speed' must not be negative in %s", path)
+	}
+	if cfg.DwellTimeMS < 0 {
+		return nil, nil, fmt.Errorf("'dwell_time_ms' must not be negative in %s", path)
+	}
+	if cfg.SamplesPerPoint < 0 {
+		return nil, nil, fmt.Errorf("'samples_per_point' must not be negative in %s", path)
+	}
+	if cfg.SamplesPerPoint > 0 && cfg.Filter != nil && cfg.Filter.Samples > 0 {
+		return nil, nil, fmt.Errorf("set 'samples_per_point' or the filter's 'samples', not both in %s", path)
+	}
+	if cfg.ArmReachMM < 0 {
+		return nil, nil, fmt.Errorf("'arm_reach_mm' must not be negative in %s", path)
+	}
+	if cfg.MountOffset != nil {
+		if err := cfg.MountOffset.Validate(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, backlash := range cfg.GantryBacklashMM {
+		if backlash < 0 {
+			return nil, nil, fmt.Errorf("'gantry_backlash_mm' must not be negative in %s", path)
+		}
+	}
+	if cfg.ScanWorkers < 0 || cfg.ScanQueueDepth < 0 {
+		return nil, nil, fmt.Errorf("'scan_workers' and 'scan_queue_depth' must not be negative in %s", path)
+	}
+	if cfg.MaxScanPoints < 0 {
+		return nil, nil, fmt.Errorf("'max_scan_points' must not be negative in %s", path)
+	}
+	if cfg.WristSweepDeg < 0 || cfg.WristSweepDeg >= 90 {
+		return nil, nil, fmt.Errorf("'wrist_sweep_deg' must be between 0 and 90 in %s", path)
+	}
+	if cfg.OrientationSweep != nil {
+		if err := cfg.OrientationSweep.Validate(path + ".orientation_sweep"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.ChainMaxAge != "" {
+		maxAge, err := time.ParseDuration(cfg.ChainMaxAge)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid 'chain_max_age' in %s: %w", path, err)
+		}
+		if maxAge <= 0 {
+			return nil, nil, fmt.Errorf("'chain_max_age' must be positive in %s", path)
+		}
+	}
+	deps := []string{cfg.Arm, cfg.Gantry, cfg.Sensor}
+	if cfg.Cleaning != nil {
+		if err := cfg.Cleaning.Validate(path + ".cleaning"); err != nil {
+			return nil, nil, err
+		}
+		if cfg.Cleaning.PadThicknessSensor != "" {
+			deps = append(deps, cfg.Cleaning.PadThicknessSensor)
+		}
+	}
+	if cfg.Fusion != nil {
+		if err := cfg.Fusion.Validate(path + ".fusion"); err != nil {
+			return nil, nil, err
+		}
+		deps = append(deps, cfg.Fusion.Sensors...)
+	}
+	if cfg.Filter != nil {
+		if err := cfg.Filter.Validate(path + ".filter"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.ReadRetry != nil {
+		if err := cfg.ReadRetry.Validate(path + ".read_retry"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Alerts != nil {
+		if err := cfg.Alerts.Validate(path + ".alerts"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.DriftCheck != nil {
+		if err := cfg.DriftCheck.Validate(path + ".drift_check"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.ScanLog != nil {
+		if err := cfg.ScanLog.Validate(path + ".scan_log"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.DataCapture != nil {
+		if err := cfg.DataCapture.Validate(path + ".data_capture"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.ScanTraceSize < 0 {
+		return nil, nil, fmt.Errorf("'scan_trace_size' must not be negative in %s", path)
+	}
+	if cfg.Homing != nil {
+		if err := cfg.Homing.Validate(path + ".homing"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.MonitorHint != nil {
+		if err := cfg.MonitorHint.Validate(path + ".monitor_hint"); err != nil {
+			return nil, nil, err
+		}
+		if cfg.Topology == calibrationhelpers.TopologyMovingMonitor {
+			return nil, nil, fmt.Errorf("'monitor_hint' needs the %q topology in %s", calibrationhelpers.TopologyMovingSensor, path)
+		}
+	}
+	if cfg.SoftLimits != nil {
+		if err := cfg.SoftLimits.Validate(path + ".soft_limits"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Vision != nil {
+		if err := cfg.Vision.Validate(path + ".vision"); err != nil {
+			return nil, nil, err
+		}
+		deps = append(deps, cfg.Vision.Camera, cfg.Vision.TagDetector)
+	}
+	if cfg.Motion != nil {
+		if err := cfg.Motion.Validate(path + ".motion"); err != nil {
+			return nil, nil, err
+		}
+		deps = append(deps, motion.Named(cfg.Motion.Service).String())
+	}
+	if cfg.ResultStore != nil {
+		if err := cfg.ResultStore.Validate(path + ".result_store"); err != nil {
+			return nil, nil, err
+		}
+	}
+	names := map[string]bool{}
+	for i := range cfg.Targets {
+		if err := cfg.Targets[i].Validate(fmt.Sprintf("%s.targets.%d", path, i)); err != nil {
+			return nil, nil, err
+		}
+		if names[cfg.Targets[i].Name] {
+			return nil, nil, fmt.Errorf("duplicate target name %q in %s", cfg.Targets[i].Name, path)
+		}
+		names[cfg.Targets[i].Name] = true
+	}
+	return deps, nil, nil
 }
 
 // monitorCalibration simulates an ultrasonic sensor pointing at a virtual monitor
@@ -68,14 +398,38 @@ type monitorCalibration struct {
 	cancelCtx  context.Context
 	cancelFunc func()
 
-	arm    arm.Arm
-	gantry gantry.Gantry
-	sensor sensor.Sensor
+	arm               arm.Arm
+	gantry            gantry.Gantry
+	sensor            sensor.Sensor
 	calibrationConfig calibrationhelpers.CalibrationConfig
 
 	fs framesystem.RobotFrameSystem
 
-	doCommandLock sync.Mutex
+	lastResult      *types.CalibrationResult        // most recent successful calibration
+	lastScan        []calibrationhelpers.CloudPoint // points from the most recent scan, kept even if calibration failed later
+	lastScanSession string                          // calibration session of lastScan
+	// lastWaypoints are the scan waypoints of the most recent run, successful or not, for export_mcap
+	lastWaypoints        []calibrationhelpers.ScanLogEntry
+	lastWaypointsSession string
+	store                calibrationhelpers.ResultStore // where the last result is persisted, nil to disable
+	chainMaxAge          time.Duration                  // max age of a result used to seed the next calibration, zero to disable
+	padThickness         float64                        // mm - current cleaning pad thickness
+	padSensor            sensor.Sensor                  // optional source of pad thickness readings
+	camera               camera.Camera                  // optional camera for vision calibration
+	tagDetector          vision.Service                 // optional AprilTag detector for vision calibration
+	motion               motion.Service                 // optional planner for collision-aware scan moves
+	progress             *progressTracker               // state of the running calibration, for status polling
+	jobs                 *recalibrationJobs             // one-tap recalibrations started by "recalibrate_now"
+	metrics              *calibrationMetrics            // calibration health over the service's lifetime
+
+	captureBuffer *calibrationhelpers.ScanSampleBuffer // scan samples awaiting the data manager, nil to disable
+	scanTrace     *calibrationhelpers.ScanTrace        // latest scan waypoints, for drawing the trajectory live
+
+	audit          *calibrationhelpers.AuditLog // record of calibration actions, nil to disable
+	actionSessions []string                     // sessions started by the audited action in progress
+
+	doCommandLock           sync.Mutex
+	activeBackgroundWorkers sync.WaitGroup
 }
 
 func newMonitorCalibration(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (resource.Resource, error) {
@@ -99,17 +453,30 @@ func NewMonitorCalibration(ctx context.Context, deps resource.Dependencies, name
 		cfg:        conf,
 		cancelCtx:  cancelCtx,
 		cancelFunc: cancelFunc,
+		progress:   newProgressTracker(),
+		jobs:       newRecalibrationJobs(),
+		metrics:    newCalibrationMetrics(),
+	}
+	if conf.DataCapture != nil {
+		s.captureBuffer = conf.DataCapture.buffer()
 	}
+	traceSize := conf.ScanTraceSize
+	if traceSize == 0 {
+		traceSize = defaultScanTraceSize
+	}
+	s.scanTrace = calibrationhelpers.NewScanTrace(traceSize)
 
-	s.arm, err = arm.FromProvider(deps, conf.Arm)
+	a, err := arm.FromProvider(deps, conf.Arm)
 	if err != nil {
 		return nil, err
 	}
+	s.arm = &meteredArm{Arm: a, metrics: s.metrics}
 
-	s.gantry, err = gantry.FromProvider(deps, conf.Gantry)
+	g, err := gantry.FromProvider(deps, conf.Gantry)
 	if err != nil {
 		return nil, err
 	}
+	s.gantry = &meteredGantry{Gantry: g, metrics: s.metrics}
 
 	s.sensor, err = sensor.FromProvider(deps, conf.Sensor)
 	if err != nil {
@@ -121,22 +488,172 @@ func NewMonitorCalibration(ctx context.Context, deps resource.Dependencies, name
 		return nil, err
 	}
 
-	s.calibrationConfig = calibrationhelpers.CalibrationConfig{
-		Hardware: calibrationhelpers.HardwareConfig{
-			GripperWidth: 106.4, // mm - default gripper width
-			WorldFrame:   "world",
-		},
-		Scanning: calibrationhelpers.ScanningConfig{
-			ZStepSize:   10.0, // mm
-			ZNumSteps:   10,
-			XNumSteps:   10,
-			GantrySpeed: 50.0, // mm/sec
-		},
-		Detection: calibrationhelpers.DetectionConfig{
-			PlaneThreshold: 20.0, // mm
-			EdgeStepSize:   10.0, // mm
-		},
-		ArmPositions: calibrationhelpers.DefaultArmPositions,
+	s.calibrationConfig = calibrationhelpers.NewDefaultConfig()
+	s.calibrationConfig.Robot.ArmName = conf.Arm
+	s.calibrationConfig.Robot.GantryName = conf.Gantry
+	s.calibrationConfig.Robot.SensorName = conf.Sensor
+	if conf.WorldFrame != "" {
+		s.calibrationConfig.Hardware.WorldFrame = conf.WorldFrame
+	}
+	if conf.Topology != "" {
+		s.calibrationConfig.Hardware.Topology = conf.Topology
+	}
+	s.calibrationConfig.Hardware.MonitorFrame = conf.MonitorFrame
+	s.calibrationConfig.Hardware.DistanceReading = conf.SensorReading
+	if conf.SensorMove != "" {
+		s.calibrationConfig.Hardware.SensorMove = conf.SensorMove
+	}
+	s.calibrationConfig.Hardware.ArmReach = conf.ArmReachMM
+	if s.calibrationConfig.Hardware.MountOffset, err = conf.MountOffset.pose(); err != nil {
+		return nil, fmt.Errorf("invalid 'mount_offset': %w", err)
+	}
+	if conf.Homing != nil {
+		s.calibrationConfig.Homing = conf.Homing.homing()
+	}
+	if conf.MonitorHint != nil {
+		s.calibrationConfig.Hint = conf.MonitorHint.hint()
+	}
+	if len(conf.GantryBacklashMM) > 0 {
+		s.calibrationConfig.Backlash = calibrationhelpers.NewGantryBacklash(conf.GantryBacklashMM)
+		s.gantry = s.calibrationConfig.Backlash.Wrap(s.gantry)
+	}
+	if conf.ScanMode != "" {
+		s.calibrationConfig.Scanning.Mode = conf.ScanMode
+	}
+	if conf.ScanPattern != "" {
+		s.calibrationConfig.Scanning.Pattern = conf.ScanPattern
+	}
+	s.calibrationConfig.Scanning.XSpacing = conf.XSpacingMM
+	s.calibrationConfig.Scanning.ZSpacing = conf.ZSpacingMM
+	if conf.CoarseFactor > 0 {
+		s.calibrationConfig.Scanning.CoarseFactor = conf.CoarseFactor
+	}
+	if conf.RefineResidualMM > 0 {
+		s.calibrationConfig.Scanning.RefineResidual = conf.RefineResidualMM
+	}
+	if conf.DeviationOrder != nil {
+		s.calibrationConfig.Detection.DeviationOrder = *conf.DeviationOrder
+	}
+	s.calibrationConfig.Detection.CornerSweeps = conf.CornerSweeps
+	if conf.EdgePrecisionMM > 0 {
+		s.calibrationConfig.Detection.EdgePrecision = conf.EdgePrecisionMM
+	}
+	s.calibrationConfig.Detection.Curved = conf.CurvedMonitor
+	if conf.IncidenceWeighting != nil {
+		s.calibrationConfig.Detection.IncidenceWeighting = *conf.IncidenceWeighting
+	}
+	if conf.SettleTimeout != "" {
+		if s.calibrationConfig.Scanning.SettleTimeout, err = time.ParseDuration(conf.SettleTimeout); err != nil {
+			return nil, err
+		}
+	}
+	if conf.ReadTimeout != "" {
+		if s.calibrationConfig.Scanning.ReadTimeout, err = time.ParseDuration(conf.ReadTimeout); err != nil {
+			return nil, err
+		}
+	}
+	if conf.MaxRangeMM > 0 {
+		s.calibrationConfig.Scanning.MaxRange = conf.MaxRangeMM
+	}
+	if conf.ScanWorkers > 0 {
+		s.calibrationConfig.Scanning.Workers = conf.ScanWorkers
+	}
+	if conf.ScanQueueDepth > 0 {
+		s.calibrationConfig.Scanning.QueueDepth = conf.ScanQueueDepth
+	}
+	s.calibrationConfig.Scanning.MaxPoints = conf.MaxScanPoints
+	if conf.MoveSpeed > 0 {
+		s.calibrationConfig.Scanning.GantrySpeed = conf.MoveSpeed
+	}
+	s.calibrationConfig.Scanning.Dwell = time.Duration(conf.DwellTimeMS * float64(time.Millisecond))
+	s.calibrationConfig.Scanning.SamplesPerPoint = conf.SamplesPerPoint
+	if conf.Filter != nil {
+		conf.Filter.apply(&s.calibrationConfig.Filter)
+	}
+	if conf.ReadRetry != nil {
+		conf.ReadRetry.apply(&s.calibrationConfig.Retry)
+	}
+	if conf.WristJoint != nil {
+		s.calibrationConfig.Scanning.WristJoint = *conf.WristJoint
+	}
+	if conf.WristSweepDeg != 0 {
+		s.calibrationConfig.Scanning.WristSweepAngle = conf.WristSweepDeg
+	}
+	if conf.OrientationSweep != nil {
+		s.calibrationConfig.OrientationSweep = conf.OrientationSweep.sweep(s.calibrationConfig.Scanning.WristJoint)
+	}
+	if conf.Cleaning != nil {
+		conf.Cleaning.apply(&s.calibrationConfig.Cleaning)
+		if conf.Cleaning.PadThicknessSensor != "" {
+			s.padSensor, err = sensor.FromProvider(deps, conf.Cleaning.PadThicknessSensor)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if conf.Alerts != nil {
+		conf.Alerts.apply(&s.calibrationConfig.Alerts)
+	}
+	if conf.Vision != nil {
+		conf.Vision.apply(&s.calibrationConfig.Vision)
+		s.camera, err = camera.FromProvider(deps, conf.Vision.Camera)
+		if err != nil {
+			return nil, err
+		}
+		s.tagDetector, err = vision.FromProvider(deps, conf.Vision.TagDetector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.Motion != nil {
+		s.motion, err = motion.FromProvider(deps, conf.Motion.Service)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.padThickness = s.calibrationConfig.Cleaning.PadThickness
+	if conf.ChainMaxAge != "" {
+		s.chainMaxAge, err = time.ParseDuration(conf.ChainMaxAge)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.calibrationConfig.Validate(); err != nil {
+		return nil, err
+	}
+	for i := range conf.Targets {
+		if _, err := conf.Targets[i].apply(s.calibrationConfig); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Fusion != nil {
+		if s.sensor, err = conf.Fusion.fusedSensor(deps, s.fs, s.sensor, s.calibrationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	s.store, err = newResultStore(ctx, conf, name.Name)
+	if err != nil {
+		return nil, err
+	}
+	s.loadLastResult(ctx)
+	s.logSavedSession()
+	s.audit = newAuditLog(conf, name.Name)
+
+	for i := range conf.Targets {
+		if conf.Targets[i].Interval != "" {
+			s.activeBackgroundWorkers.Add(1)
+			go s.runSchedule()
+			break
+		}
+	}
+	if conf.DriftCheck != nil {
+		interval, err := time.ParseDuration(conf.DriftCheck.Interval)
+		if err != nil {
+			return nil, err
+		}
+		s.activeBackgroundWorkers.Add(1)
+		go s.runDriftCheck(interval)
 	}
 
 	return s, nil
@@ -146,154 +663,632 @@ func (s *monitorCalibration) Name() resource.Name {
 	return s.name
 }
 
+// DoCommand dispatches on the "command" key; with no command it runs a calibration
 func (s *monitorCalibration) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	command, _ := cmd["command"].(string)
+
+	// Progress queries, captures, scan changes and recalibration taps answer immediately, even while a calibration holds the lock
+	switch command {
+	case "status":
+		return s.progress.status(), nil
+	case "get_events":
+		return s.progress.getEvents(cmd), nil
+	case "get_metrics":
+		return s.metrics.getMetrics(), nil
+	case "capture_scan_samples":
+		return s.captureScanSamples(cmd)
+	case "get_scan_trace":
+		return s.getScanTrace(cmd)
+	case "update_scan":
+		return s.progress.updateScan(cmd)
+	case "recalibrate_now":
+		return s.recalibrateNow(), nil
+	case "get_job":
+		return s.jobs.get(cmd)
+	case "get_audit_log":
+		return s.getAuditLog(cmd)
+	case "pause":
+		return s.progress.pause()
+	case "abort":
+		return s.abort(ctx)
+	case "resume":
+		// A paused run resumes immediately, otherwise resuming an interrupted session calibrates below
+		if response, running, err := s.progress.resumeRunning(); running {
+			return response, err
+		}
+	}
+
 	s.doCommandLock.Lock()
 	defer s.doCommandLock.Unlock()
 
-	s.logger.Info("=== STARTING CALIBRATION ===")
+	if auditedCommands[command] {
+		return s.audited(command, calibrationhelpers.AuditTriggerCommand, cmd, func() (map[string]interface{}, error) {
+			return s.runCommand(ctx, command, cmd)
+		})
+	}
+	return s.runCommand(ctx, command, cmd)
+}
 
-	// STEP 1: Center the X axis (gantry position)
-	s.logger.Info("Step 1: Centering X axis (gantry)...")
-	centerPosition, err := calibrationhelpers.CenterGantry(ctx, s.gantry, s.calibrationConfig.Scanning)
+// runCommand runs the commands that wait for doCommandLock, which the caller must hold
+func (s *monitorCalibration) runCommand(ctx context.Context, command string, cmd map[string]interface{}) (map[string]interface{}, error) {
+	switch command {
+	case "", "calibrate":
+		return s.calibrateCommand(ctx, cmd)
+	case "calibrate_all":
+		return s.calibrateAll(ctx)
+	case "stitch_monitors":
+		return s.stitchMonitors(ctx, cmd)
+	case "set_pad_thickness":
+		return s.setPadThickness(cmd)
+	case "get_cleaning_path":
+		return s.getCleaningPath(ctx, cmd)
+	case "export_point_cloud":
+		return s.exportPointCloud(cmd)
+	case "export_fragment":
+		return s.exportFragment(cmd)
+	case "generate_visualization":
+		return s.generateVisualization(cmd)
+	case "export_mesh":
+		return s.exportMesh(cmd)
+	case "export_heatmap":
+		return s.exportHeatmap(cmd)
+	case "export_report":
+		return s.exportReport(cmd)
+	case "export_mcap":
+		return s.exportMCAP(cmd)
+	case "get_last_calibration":
+		return s.getLastCalibration()
+	case "get_summary":
+		return s.getSummary()
+	case "vision_calibrate":
+		return s.visionCalibrate(ctx, cmd)
+	case "import_calibration":
+		return s.importCalibration(ctx, cmd)
+	case "ground_truth_report":
+		return s.groundTruthReport(ctx, cmd)
+	case "compare_calibration":
+		return s.compareCalibration(cmd)
+	case "check_drift":
+		return s.checkDriftCommand(ctx)
+	case "jog":
+		return s.jog(ctx, cmd)
+	case "move_sensor":
+		return s.moveSensor(ctx, cmd)
+	case "probe_point":
+		return s.probePoint(ctx, cmd)
+	case "check_workspace":
+		return s.checkWorkspaceCommand(ctx)
+	case "self_test":
+		return s.selfTest(ctx)
+	case "estimate_backlash":
+		return s.estimateBacklash(ctx)
+	case "resume":
+		return s.resumeSession(ctx)
+	default:
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// calibrateCommand handles the "calibrate" command, with a configured target's overrides if "target" is given
+// The caller must hold doCommandLock
+func (s *monitorCalibration) calibrateCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	config := s.calibrationConfig
+	targetName, hasTarget := cmd["target"].(string)
+	if hasTarget {
+		target, err := s.findTarget(targetName)
+		if err != nil {
+			return nil, err
+		}
+		if config, err = target.apply(config); err != nil {
+			return nil, err
+		}
+	} else {
+		// The last result belongs to whichever target ran last, so only untargeted runs chain from it
+		config = s.chainFromLastResult(cmd, config)
+		config = s.baselineFromLastResult(cmd, config)
+	}
+	config = s.acceptLowSpan(cmd, config)
+	if dryRun, _ := cmd["dry_run"].(bool); dryRun {
+		return s.dryRun(ctx, targetName, config)
+	}
+	result, err := s.runCalibration(ctx, targetName, config)
 	if err != nil {
 		return nil, err
 	}
-	s.logger.Infof("Moving gantry to center position: %f mm", centerPosition)
-	s.logger.Info("✓ Gantry centered")
+	// Generate visualization and print results
+	return s.calibrationReport(result, config)
+}
 
-	// STEP 2: Scan Z axis to collect points that should form a straight line on the monitor plane
-	s.logger.Info("Step 2: Scanning Z axis to detect straight line...")
-	zScanPoints, err := calibrationhelpers.PerformZScan(ctx, s.logger, s.fs, s.sensor, s.arm, s.calibrationConfig)
+// calibrate runs the full calibration routine with the given configuration
+func (s *monitorCalibration) calibrate(ctx context.Context, config calibrationhelpers.CalibrationConfig) (types.CalibrationResult, error) {
+	logger := s.runLogger(config)
+	logger.Info("=== STARTING CALIBRATION ===")
+
+	// Home the gantry first, so the scan is planned and measured from a fresh zero
+	if err := s.homeGantry(ctx, config); err != nil {
+		return types.CalibrationResult{}, err
+	}
+
+	// Plan the scan region around the monitor hint, from the sensor's pose at home
+	config, hintPlan, err := s.applyMonitorHint(ctx, config, true)
 	if err != nil {
-		return nil, err
+		return types.CalibrationResult{}, err
+	}
+	if hintPlan != nil {
+		logger.Infof("✓ Planned scan from monitor hint: gantry %.1f to %.1f mm, Z scan from %+.1f mm in %.1f mm steps, standoff %.1f mm, range %.1f mm",
+			hintPlan.GantryMin, hintPlan.GantryMax, hintPlan.ZMin, hintPlan.ZStepSize, hintPlan.Standoff, hintPlan.MaxRange)
 	}
-	s.logger.Infof("✓ Collected %d points along Z axis", len(zScanPoints))
 
-	// Fit a line to the Z scan points
-	_, zPoint2, err := calibrationhelpers.FitLineToPoints(s.logger, zScanPoints)
+	// Fail before the scan moves anything if the scan would leave the gantry travel or the arm's reach
+	if err := s.checkWorkspace(ctx, config); err != nil {
+		return types.CalibrationResult{}, err
+	}
+
+	// STEP 1: Center the X axis (gantry position)
+	logger.Info("Step 1: Centering X axis (gantry)...")
+	centerPosition, err := calibrationhelpers.CenterGantry(ctx, s.gantry, config.Scanning)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fit line to Z scan: %w", err)
+		return types.CalibrationResult{}, err
 	}
-	s.logger.Info("✓ Fitted line to Z scan points")
+	logger.Infof("Moving gantry to center position: %f mm", centerPosition)
+	logger.Info("✓ Gantry centered")
 
-	// STEP 3: Scan X axis (move gantry) to collect points that form another straight line
-	s.logger.Info("Step 3: Scanning X axis (gantry) to detect straight line...")
-	xScanPoints, err := calibrationhelpers.PerformXScan(ctx, s.logger, s.fs, s.sensor, s.arm, s.gantry, s.calibrationConfig)
+	// Record why waypoints didn't produce points and which way the sensor looked for each point, alongside any
+	// other progress reporting
+	var diagnostics types.ScanDiagnostics
+	var budgetInputs calibrationhelpers.ErrorBudgetCollector
+	beams := map[pointKey]types.Point3D{}
+	report := config.Progress
+	config.Progress = func(progress calibrationhelpers.ScanProgress) {
+		diagnostics.Record(progress.Diagnostic())
+		budgetInputs.Record(progress)
+		if progress.Status == types.WaypointOK && progress.Reading.SensorPose != nil {
+			sensor := progress.Reading.SensorPose.Point()
+			beams[keyOf(progress.Point)] = types.Point3D{X: progress.Point.X - sensor.X, Y: progress.Point.Y - sensor.Y, Z: progress.Point.Z - sensor.Z}
+		}
+		for _, p := range progress.Dropped {
+			delete(beams, keyOf(p))
+		}
+		if report != nil {
+			report(progress)
+		}
+	}
+
+	// STEPS 2-3: Collect surface points using the configured scan mode
+	if err := s.enterPhase(ctx, config, phaseScanning); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	s.progress.setScanTotal(s.expectedScanPoints(ctx, config))
+	var scan scanData
+	switch config.Scanning.Mode {
+	case calibrationhelpers.ScanModeAngular:
+		scan, err = s.angularScan(ctx, config)
+	case calibrationhelpers.ScanModeGrid, calibrationhelpers.ScanModeAdaptive:
+		scan, err = s.gridScan(ctx, config)
+	default:
+		scan, err = s.linearScan(ctx, config)
+	}
 	if err != nil {
-		return nil, err
+		return types.CalibrationResult{}, err
+	}
+	xPoint1, xPoint2, zPoint2 := scan.xPoint1, scan.xPoint2, scan.zPoint
+	if len(diagnostics.Failures) > 0 {
+		logger.Warnf("Scan coverage %.0f%%: %d of %d waypoints skipped %v",
+			100*diagnostics.Coverage, len(diagnostics.Failures), diagnostics.Waypoints, diagnostics.Counts)
 	}
-	s.logger.Infof("✓ Collected %d points along X axis", len(xScanPoints))
 
-	// Fit a line to the X scan points
-	xPoint1, xPoint2, err := calibrationhelpers.FitLineToPoints(s.logger, xScanPoints)
+	// STEP 4: Fit a plane to all scan points, rejecting outliers near the monitor edges
+	if err := s.enterPhase(ctx, config, phasePlaneFit); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	logger.Info("Step 4: Fitting plane to scan points (RANSAC)...")
+	fitter := calibrationhelpers.NewPlaneFitter(config.Detection)
+	if config.Seed != nil {
+		fitter.Seed = &config.Seed.Plane
+	}
+	var plane types.Plane
+	var planeCov types.Covariance
+	if config.Detection.IncidenceWeighting {
+		// Steep readings are less accurate, so weigh each point by how squarely its beam struck the fitted plane
+		scanBeams := make([]types.Point3D, len(scan.points))
+		for i, p := range scan.points {
+			scanBeams[i] = beams[keyOf(p)]
+		}
+		plane, planeCov, _, err = fitter.FitIncidenceWeighted(logger, scan.points, scanBeams)
+	} else {
+		plane, planeCov, err = fitter.FitWithCovariance(logger, scan.points)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fit line to X scan: %w", err)
+		return types.CalibrationResult{}, calibrationhelpers.FitError("plane", len(scan.points), err)
+	}
+	logger.Infof("✓ Plane equation: %f*x + %f*y + %f*z = %f", plane.A, plane.B, plane.C, plane.D)
+	logger.Infof("  Plane uncertainty: normal ±%.3f°, offset ±%.2f mm, residual RMS %.2f mm (%d samples)",
+		planeCov.NormalStdDev, planeCov.OffsetStdDev, planeCov.ResidualRMS, planeCov.Samples)
+	var budget *types.ErrorBudget
+	if found, err := budgetInputs.Budget(plane, planeCov, config.ReadingsPerPose()); err != nil {
+		logger.Warnf("Could not compute error budget: %v", err)
+	} else {
+		for _, source := range found.Sources {
+			logger.Infof("  Error budget %s: %.2f mm per point (%.0f%%), normal ±%.3f°, offset ±%.2f mm",
+				source.Name, source.Sigma, 100*source.Share, source.NormalStdDev, source.OffsetStdDev)
+		}
+		logger.Infof("  %s", found.Advice)
+		budget = &found
 	}
-	s.logger.Info("✓ Fitted line to X scan points")
+	if plane, err = s.refineOrientation(ctx, config, plane, scan.points); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	s.lastScan = calibrationhelpers.CloudPointsFromScan(scan.points, plane, config.Detection.RansacThreshold)
+	s.lastScanSession = config.SessionID
+
+	// A curved monitor bends away from any plane, so fit a cylinder about the vertical axis to the points on the
+	// glass and orient the monitor frame by the plane touching the middle of it
+	var deviation types.DeviationSurface
+	if config.Detection.Curved {
+		cylinder, inliers, err := calibrationhelpers.FitCylinderRANSAC(logger, scan.points, types.Point3D{Z: 1},
+			config.Detection.RansacThreshold, config.Detection.RansacIterations)
+		if err != nil {
+			return types.CalibrationResult{}, calibrationhelpers.FitError("curved monitor", len(scan.points), err)
+		}
+		plane = calibrationhelpers.TangentPlane(cylinder)
+		config.Curve = &cylinder
+		logger.Infof("✓ Curved monitor: radius %.1f mm, residual RMS %.2f mm (%d samples)",
+			cylinder.Radius, cylinder.ResidualRMS, cylinder.Samples)
 
-	// STEP 4: Use those 3 points to construct a plane
-	s.logger.Info("Step 4: Constructing plane from 3 points...")
-	plane, err := calibrationhelpers.CalculatePlaneFrom3Points(zPoint2, xPoint1, xPoint2)
+		// Measure flatness against the cylinder, which the curve itself doesn't depart from
+		deviation, err = calibrationhelpers.FitCylinderDeviationSurface(inliers, cylinder, plane,
+			config.Detection.DeviationOrder, config.Detection.PlaneThreshold)
+	} else {
+		// Measure flatness from the residual field of the points that landed on the monitor
+		deviation, err = calibrationhelpers.FitDeviationSurface(scan.points, plane, config.Detection.DeviationOrder,
+			config.Detection.PlaneThreshold)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate plane: %w", err)
+		logger.Warnf("Could not fit flatness deviation surface: %v", err)
+		s.progress.event("warn", "could not fit flatness deviation surface: "+err.Error())
+	} else {
+		logger.Infof("✓ Flatness: peak-to-valley %.2f mm (raw residuals %.2f mm, order %d fit RMS %.2f mm)",
+			deviation.PeakToValley, deviation.ResidualPeakToValley, deviation.Order, deviation.ResidualRMS)
 	}
-	s.logger.Infof("✓ Plane equation: %f*x + %f*y + %f*z = %f", plane.A, plane.B, plane.C, plane.D)
 
 	// STEP 5: Find Z limits (top and bottom edges)
-	s.logger.Info("Step 5: Finding Z limits (top and bottom edges)...")
+	if err := s.enterPhase(ctx, config, phaseVerticalEdges); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	logger.Info("Step 5: Finding Z limits (top and bottom edges)...")
 
 	// Center gantry again for edge detection
-	_, err = calibrationhelpers.CenterGantry(ctx, s.gantry, s.calibrationConfig.Scanning)
+	_, err = calibrationhelpers.CenterGantry(ctx, s.gantry, config.Scanning)
 	if err != nil {
-		return nil, err
+		return types.CalibrationResult{}, err
 	}
 
 	// Reset arm position for bottom edge search
-	err = s.arm.MoveToJointPositions(ctx, s.calibrationConfig.ArmPositions.BottomScan, nil)
+	err = s.arm.MoveToJointPositions(ctx, config.ArmPositions.BottomScan, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset arm: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to reset arm: %w", err)
 	}
 
-	s.logger.Info("Searching for bottom edge...")
-	bottomResult, err := calibrationhelpers.FindVerticalEdge(ctx, s.logger, s.fs, s.sensor, s.arm, plane, -1, s.calibrationConfig)
+	logger.Info("Searching for bottom edge...")
+	bottomResult, err := calibrationhelpers.FindVerticalEdge(ctx, logger, s.fs, s.sensor, s.arm, plane, -1, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find bottom edge: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to find bottom edge: %w", err)
 	}
 
 	// Reset arm and find top edge
-	err = s.arm.MoveToJointPositions(ctx, s.calibrationConfig.ArmPositions.TopScan, nil)
+	err = s.arm.MoveToJointPositions(ctx, config.ArmPositions.TopScan, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset arm: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to reset arm: %w", err)
 	}
 
-	s.logger.Info("Searching for top edge...")
-	topResult, err := calibrationhelpers.FindVerticalEdge(ctx, s.logger, s.fs, s.sensor, s.arm, plane, 1, s.calibrationConfig)
+	logger.Info("Searching for top edge...")
+	topResult, err := calibrationhelpers.FindVerticalEdge(ctx, logger, s.fs, s.sensor, s.arm, plane, 1, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find top edge: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to find top edge: %w", err)
 	}
 
-	s.logger.Infof("✓ Z limits found: bottom=%f, top=%f, height=%f",
+	logger.Infof("✓ Z limits found: bottom=%f, top=%f, height=%f",
 		bottomResult.SurfacePoint.Z, topResult.SurfacePoint.Z, topResult.SurfacePoint.Z-bottomResult.SurfacePoint.Z)
 
 	// STEP 6: Find X limits (left and right edges)
-	s.logger.Info("Step 6: Finding X limits (left and right edges)...")
+	if err := s.enterPhase(ctx, config, phaseHorizontalEdges); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	logger.Info("Step 6: Finding X limits (left and right edges)...")
 
 	// Reset arm to middle position
-	err = s.arm.MoveToJointPositions(ctx, s.calibrationConfig.ArmPositions.Home, nil)
+	err = s.arm.MoveToJointPositions(ctx, config.ArmPositions.Home, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset arm: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to reset arm: %w", err)
 	}
 
 	// Get gantry lengths for edge detection
 	gantryLengths, err := s.gantry.Lengths(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gantry lengths: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to get gantry lengths: %w", err)
 	}
 
-	s.logger.Info("Searching for left edge...")
-	leftResult, err := calibrationhelpers.FindHorizontalEdge(ctx, s.logger, s.fs, s.sensor, s.gantry, plane, gantryLengths, 1, s.calibrationConfig)
+	logger.Info("Searching for left edge...")
+	leftResult, err := calibrationhelpers.FindHorizontalEdge(ctx, logger, s.fs, s.sensor, s.gantry, plane, gantryLengths, 1, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find left edge: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to find left edge: %w", err)
 	}
 
-	s.logger.Info("Searching for right edge...")
-	rightResult, err := calibrationhelpers.FindHorizontalEdge(ctx, s.logger, s.fs, s.sensor, s.gantry, plane, gantryLengths, -1, s.calibrationConfig)
+	logger.Info("Searching for right edge...")
+	rightResult, err := calibrationhelpers.FindHorizontalEdge(ctx, logger, s.fs, s.sensor, s.gantry, plane, gantryLengths, -1, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find right edge: %w", err)
+		return types.CalibrationResult{}, fmt.Errorf("failed to find right edge: %w", err)
 	}
 
-	s.logger.Infof("✓ X limits found: left=%.1f, right=%.1f, width=%.1f",
+	logger.Infof("✓ X limits found: left=%.1f, right=%.1f, width=%.1f",
 		leftResult.SurfacePoint.X, rightResult.SurfacePoint.X, leftResult.SurfacePoint.X-rightResult.SurfacePoint.X)
 
 	// STEP 7: Inferred rectangle bounds
-	s.logger.Info("Step 7: Inferred rectangle bounds:")
-	s.logger.Infof("  Bottom-left: (surface X=%.1f, Z=%.1f)", leftResult.SurfacePoint.X, bottomResult.SurfacePoint.Z)
-	s.logger.Infof("  Bottom-right: (surface X=%.1f, Z=%.1f)", rightResult.SurfacePoint.X, bottomResult.SurfacePoint.Z)
-	s.logger.Infof("  Top-left: (surface X=%.1f, Z=%.1f)", leftResult.SurfacePoint.X, topResult.SurfacePoint.Z)
-	s.logger.Infof("  Top-right: (surface X=%.1f, Z=%.1f)", rightResult.SurfacePoint.X, topResult.SurfacePoint.Z)
-	s.logger.Infof("  Dimensions: width=%.1f mm, height=%.1f mm",
+	logger.Info("Step 7: Inferred rectangle bounds:")
+	logger.Infof("  Bottom-left: (surface X=%.1f, Z=%.1f)", leftResult.SurfacePoint.X, bottomResult.SurfacePoint.Z)
+	logger.Infof("  Bottom-right: (surface X=%.1f, Z=%.1f)", rightResult.SurfacePoint.X, bottomResult.SurfacePoint.Z)
+	logger.Infof("  Top-left: (surface X=%.1f, Z=%.1f)", leftResult.SurfacePoint.X, topResult.SurfacePoint.Z)
+	logger.Infof("  Top-right: (surface X=%.1f, Z=%.1f)", rightResult.SurfacePoint.X, topResult.SurfacePoint.Z)
+	logger.Infof("  Dimensions: width=%.1f mm, height=%.1f mm",
 		leftResult.SurfacePoint.X-rightResult.SurfacePoint.X, topResult.SurfacePoint.Z-bottomResult.SurfacePoint.Z)
 
+	// Optionally locate the corners, which also captures in-plane rotation
+	var corners *types.MonitorCorners
+	if config.Detection.CornerSweeps > 0 {
+		if err := s.enterPhase(ctx, config, phaseCorners); err != nil {
+			return types.CalibrationResult{}, err
+		}
+		logger.Info("Detecting monitor corners...")
+		found, err := calibrationhelpers.FindMonitorCorners(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, plane, config)
+		if err != nil {
+			return types.CalibrationResult{}, fmt.Errorf("failed to find monitor corners: %w", err)
+		}
+		logger.Infof("  Top-left: (%.1f, %.1f, %.1f), top-right: (%.1f, %.1f, %.1f)",
+			found.TopLeft.X, found.TopLeft.Y, found.TopLeft.Z, found.TopRight.X, found.TopRight.Y, found.TopRight.Z)
+		logger.Infof("  Bottom-left: (%.1f, %.1f, %.1f), bottom-right: (%.1f, %.1f, %.1f)",
+			found.BottomLeft.X, found.BottomLeft.Y, found.BottomLeft.Z, found.BottomRight.X, found.BottomRight.Y, found.BottomRight.Z)
+		corners = &found
+	}
+
+	if config.Curve != nil {
+		config.Curve.Extend(bottomResult.SurfacePoint, topResult.SurfacePoint, leftResult.SurfacePoint, rightResult.SurfacePoint)
+		logger.Infof("  Curved width along the glass: %.1f mm", config.Curve.ArcMax-config.Curve.ArcMin)
+	}
+
 	// Create calibration result
-	result := calibrationhelpers.CalibrationResult{
-		Plane:   plane,
-		BottomZ: bottomResult.SurfacePoint.Z,
-		TopZ:    topResult.SurfacePoint.Z,
-		LeftX:   leftResult.SurfacePoint.X,
-		RightX:  rightResult.SurfacePoint.X,
-		XPoint1: xPoint1,
-		XPoint2: xPoint2,
-		ZPoint1: zPoint2,
+	result := types.CalibrationResult{
+		Plane:            plane,
+		PlaneUncertainty: planeCov,
+		Deviation:        deviation,
+		BottomZ:          bottomResult.SurfacePoint.Z,
+		TopZ:             topResult.SurfacePoint.Z,
+		LeftX:            leftResult.SurfacePoint.X,
+		RightX:           rightResult.SurfacePoint.X,
+		XPoint1:          xPoint1,
+		XPoint2:          xPoint2,
+		ZPoint1:          zPoint2,
+		Corners:          corners,
+		Cylinder:         config.Curve,
+		Timestamp:        time.Now().UTC(),
+		Frame:            config.Hardware.ReferenceFrame(),
+		SessionID:        config.SessionID,
+		Chained:          config.Seed != nil,
+		Scan:             &diagnostics,
+		ErrorBudget:      budget,
 	}
 
-	// Generate visualization and print results
-	vizConfig := calibrationhelpers.GenerateVisualizationConfig(s.logger, result, s.calibrationConfig.Hardware.WorldFrame)
+	// Apply site-specific corrections or vetoes registered by embedders
+	if err := calibrationhelpers.RunPostProcessors(&result); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	if err := s.correctReferencePoints(&result, "calibration"); err != nil {
+		return types.CalibrationResult{}, err
+	}
+
+	// Without corner detection, store the corners of the rectangle the edges describe, so the frame, reports and
+	// chaining all work from corners whether or not the monitor is rolled
+	if result.Corners == nil {
+		derived, err := calibrationhelpers.ResultCorners(result)
+		if err != nil {
+			return types.CalibrationResult{}, fmt.Errorf("failed to derive monitor corners: %w", err)
+		}
+		result.Corners = &derived
+	}
+	diagnostics.MeasureSpan(scan.points, result)
+	logger.Infof("  Scan span: %.0f%% of the width, %.0f%% of the height",
+		100*diagnostics.WidthSpan, 100*diagnostics.HeightSpan)
+
+	// Reject results outside the alert limits, keeping the previous result
+	if err := s.enterPhase(ctx, config, phaseValidation); err != nil {
+		return types.CalibrationResult{}, err
+	}
+	if err := s.validateResult(result, config); err != nil {
+		return types.CalibrationResult{}, err
+	}
+
+	s.lastResult = &result
+	s.saveLastResult(ctx)
+	return result, nil
+
+}
+
+// calibrationReport returns the visualization config for a new result, with the scan diagnostics and error budget
+func (s *monitorCalibration) calibrationReport(result types.CalibrationResult, config calibrationhelpers.CalibrationConfig) (map[string]interface{}, error) {
+	logger := s.runLogger(config)
+	report := calibrationhelpers.GenerateVisualizationConfig(logger, result, config.Hardware.ReferenceFrame())
+	report["session_id"] = result.SessionID
+	if result.Scan != nil {
+		diagnostics, err := result.Scan.ToMap()
+		if err != nil {
+			return nil, err
+		}
+		report["scan_diagnostics"] = diagnostics
+	}
+	if result.ErrorBudget != nil {
+		budget, err := result.ErrorBudget.ToMap()
+		if err != nil {
+			return nil, err
+		}
+		report["error_budget"] = budget
+	}
+	return report, nil
+}
+
+// scanData holds the surface points from the scanning phase and the reference points used for orientation
+type scanData struct {
+	points  []types.Point3D
+	xPoint1 types.Point3D // start of the horizontal reference line
+	xPoint2 types.Point3D // end of the horizontal reference line
+	zPoint  types.Point3D // point above the horizontal line
+}
+
+// pointKey is a scan point rounded to the micrometre, so a point that picked up rounding error on its way through
+// the scan still finds the beam it was read with
+type pointKey [3]int64
+
+func keyOf(p types.Point3D) pointKey {
+	return pointKey{int64(math.Round(p.X * 1000)), int64(math.Round(p.Y * 1000)), int64(math.Round(p.Z * 1000))}
+}
+
+// linearScan scans the Z axis with the arm and the X axis with the gantry
+func (s *monitorCalibration) linearScan(ctx context.Context, config calibrationhelpers.CalibrationConfig) (scanData, error) {
+	logger := s.runLogger(config)
+	// STEP 2: Scan Z axis to collect points that should form a straight line on the monitor plane
+	logger.Info("Step 2: Scanning Z axis to detect straight line...")
+	zScanPoints, err := calibrationhelpers.PerformZScan(ctx, logger, s.fs, s.sensor, s.arm, config)
+	if err != nil {
+		return scanData{}, err
+	}
+	logger.Infof("✓ Collected %d points along Z axis", len(zScanPoints))
+
+	// Fit a line to the Z scan points
+	_, zPoint2, err := calibrationhelpers.FitLineToPoints(logger, zScanPoints)
+	if err != nil {
+		return scanData{}, fmt.Errorf("failed to fit line to Z scan: %w", err)
+	}
+	logger.Info("✓ Fitted line to Z scan points")
+
+	// STEP 3: Scan X axis (move gantry) to collect points that form another straight line
+	logger.Info("Step 3: Scanning X axis (gantry) to detect straight line...")
+	xScanPoints, err := calibrationhelpers.PerformXScan(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, config)
+	if err != nil {
+		return scanData{}, err
+	}
+	logger.Infof("✓ Collected %d points along X axis", len(xScanPoints))
+
+	// Fit a line to the X scan points
+	xPoint1, xPoint2, err := calibrationhelpers.FitLineToPoints(logger, xScanPoints)
+	if err != nil {
+		return scanData{}, fmt.Errorf("failed to fit line to X scan: %w", err)
+	}
+	logger.Info("✓ Fitted line to X scan points")
+
+	return scanData{
+		points:  append(append([]types.Point3D{}, zScanPoints...), xScanPoints...),
+		xPoint1: xPoint1,
+		xPoint2: xPoint2,
+		zPoint:  zPoint2,
+	}, nil
+}
+
+// angularScan sweeps the wrist at several heights, using the lowest fan as the horizontal reference line
+func (s *monitorCalibration) angularScan(ctx context.Context, config calibrationhelpers.CalibrationConfig) (scanData, error) {
+	logger := s.runLogger(config)
+	logger.Info("Steps 2-3: Sweeping wrist to cast ray fans across the monitor...")
+	fans, err := calibrationhelpers.PerformAngularScan(ctx, logger, s.fs, s.sensor, s.arm, config)
+	if err != nil {
+		return scanData{}, err
+	}
+
+	var data scanData
+	for _, fan := range fans {
+		data.points = append(data.points, fan...)
+	}
+	logger.Infof("✓ Collected %d points from %d ray fans", len(data.points), len(fans))
+
+	data.xPoint1, data.xPoint2, err = calibrationhelpers.FitLineToPoints(logger, fans[0])
+	if err != nil {
+		return scanData{}, fmt.Errorf("failed to fit line to lowest ray fan: %w", err)
+	}
+
+	top1, top2, err := calibrationhelpers.FitLineToPoints(logger, fans[len(fans)-1])
+	if err != nil {
+		return scanData{}, fmt.Errorf("failed to fit line to highest ray fan: %w", err)
+	}
+	data.zPoint = types.Point3D{
+		X: (top1.X + top2.X) / 2,
+		Y: (top1.Y + top2.Y) / 2,
+		Z: (top1.Z + top2.Z) / 2,
+	}
+	logger.Info("✓ Fitted reference lines to ray fans")
+
+	return data, nil
+}
+
+// gridScan covers the scan region with the configured pattern, using the bottom row as the horizontal reference line
+// Adaptive scans sample part of the same grid, so they share the reference line extraction
+func (s *monitorCalibration) gridScan(ctx context.Context, config calibrationhelpers.CalibrationConfig) (scanData, error) {
+	logger := s.runLogger(config)
+	var waypoints []scanpath.Waypoint
+	var points []types.Point3D
+	var err error
+	if config.Scanning.Mode == calibrationhelpers.ScanModeAdaptive {
+		logger.Infof("Steps 2-3: Scanning adaptive %s grid...", config.Scanning.Pattern)
+		waypoints, points, err = calibrationhelpers.PerformAdaptiveScan(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, config)
+	} else {
+		logger.Infof("Steps 2-3: Scanning %s grid...", config.Scanning.Pattern)
+		waypoints, points, err = calibrationhelpers.PerformGridScan(ctx, logger, s.fs, s.sensor, s.arm, s.gantry, config)
+	}
+	if err != nil {
+		return scanData{}, err
+	}
+	logger.Infof("✓ Collected %d grid points", len(points))
+
+	// Group points by row, ordered by column, since patterns like spiral visit rows out of order
+	topRow := 0
+	for _, wp := range waypoints {
+		topRow = max(topRow, wp.Row)
+	}
+	bottom := map[int]types.Point3D{}
+	var top []types.Point3D
+	for i, wp := range waypoints {
+		if wp.Row == 0 {
+			bottom[wp.Col] = points[i]
+		}
+		if wp.Row == topRow {
+			top = append(top, points[i])
+		}
+	}
+	// Skipped waypoints leave gaps, so order the bottom row by column rather than indexing by it
+	cols := make([]int, 0, len(bottom))
+	for col := range bottom {
+		cols = append(cols, col)
+	}
+	sort.Ints(cols)
+	bottomRow := make([]types.Point3D, 0, len(bottom))
+	for _, col := range cols {
+		bottomRow = append(bottomRow, bottom[col])
+	}
+
+	data := scanData{points: points}
+	data.xPoint1, data.xPoint2, err = calibrationhelpers.FitLineToPoints(logger, bottomRow)
+	if err != nil {
+		return scanData{}, fmt.Errorf("failed to fit line to bottom grid row: %w", err)
+	}
+
+	for _, p := range top {
+		data.zPoint.X += p.X / float64(len(top))
+		data.zPoint.Y += p.Y / float64(len(top))
+		data.zPoint.Z += p.Z / float64(len(top))
+	}
+	logger.Info("✓ Fitted reference line to bottom grid row")
 
-	return vizConfig, nil
+	return data, nil
 }
 
 func (s *monitorCalibration) Close(context.Context) error {
 	// Put close code here
 	s.cancelFunc()
+	s.activeBackgroundWorkers.Wait()
 	return nil
 }
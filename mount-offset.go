@@ -0,0 +1,45 @@
+package calibration
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// MountOffsetConfig is where the ultrasonic transducer sits in the sensor's frame, for sensors whose frame is the
+// arm's tool frame rather than the transducer itself
+// It is written like the translation and orientation of a Viam frame config. The transducer beams along the
+// offset's orientation vector, so an offset without an orientation leaves the beam along the sensor frame's.
+type MountOffsetConfig struct {
+	Translation *Vector3                       `json:"translation,omitempty"` // mm
+	Orientation *spatialmath.OrientationConfig `json:"orientation,omitempty"`
+}
+
+// Validate checks that the orientation parses
+func (cfg *MountOffsetConfig) Validate(path string) error {
+	if _, err := cfg.pose(); err != nil {
+		return fmt.Errorf("invalid 'mount_offset' in %s: %w", path, err)
+	}
+	return nil
+}
+
+// pose returns the transducer's pose in the sensor frame, nil without an offset
+func (cfg *MountOffsetConfig) pose() (spatialmath.Pose, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var translation r3.Vector
+	if cfg.Translation != nil {
+		translation = r3.Vector{X: cfg.Translation.X, Y: cfg.Translation.Y, Z: cfg.Translation.Z}
+	}
+	orientation := spatialmath.NewZeroOrientation()
+	if cfg.Orientation != nil {
+		o, err := cfg.Orientation.ParseConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse orientation: %w", err)
+		}
+		orientation = o
+	}
+	return spatialmath.NewPose(translation, orientation), nil
+}
@@ -0,0 +1,64 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"fmt"
+	"os"
+)
+
+// exportPointCloud handles the "export_point_cloud" command, writing the last scan to a PLY or PCD file
+func (s *monitorCalibration) exportPointCloud(cmd map[string]interface{}) (map[string]interface{}, error) {
+	if len(s.lastScan) == 0 {
+		return nil, fmt.Errorf("no scan data available, run calibrate first")
+	}
+
+	path, ok := cmd["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("export_point_cloud requires a 'path'")
+	}
+	format, ok := cmd["format"].(string)
+	if !ok || format == "" {
+		var err error
+		format, err = calibrationhelpers.PointCloudFormatFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	convention, _ := cmd["convention"].(string)
+	if err := calibrationhelpers.ValidateConvention(convention); err != nil {
+		return nil, err
+	}
+	if convention == "" {
+		convention = calibrationhelpers.ConventionViam
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create point cloud file: %w", err)
+	}
+	if err := calibrationhelpers.ExportPointCloud(f, format, s.lastScan, convention); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close point cloud file: %w", err)
+	}
+
+	valid := 0
+	for _, p := range s.lastScan {
+		if p.Valid {
+			valid++
+		}
+	}
+	s.logger.Infof("✓ Exported %d scan points (%d valid) to %s", len(s.lastScan), valid, path)
+	return map[string]interface{}{
+		"path":       path,
+		"format":     format,
+		"points":     len(s.lastScan),
+		"valid":      valid,
+		"frame":      s.calibrationConfig.Hardware.ReferenceFrame(),
+		"convention": convention,
+		"session_id": s.lastScanSession,
+	}, nil
+}
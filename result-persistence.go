@@ -0,0 +1,230 @@
+package calibration
+
+import (
+	calibrationhelpers "calibration/calibration-helpers"
+	"calibration/types"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Result store backends
+const (
+	// ResultStoreFile keeps the last result in results_path
+	ResultStoreFile = "file"
+	// ResultStoreDataSync also drops every result into a directory uploaded by the Viam data manager
+	ResultStoreDataSync = "data_sync"
+	// ResultStoreS3 keeps the last result in an S3-compatible bucket
+	ResultStoreS3 = "s3"
+)
+
+// ResultStoreConfig selects where calibration results are persisted
+type ResultStoreConfig struct {
+	Type string `json:"type"`
+
+	// data_sync: a directory listed in the data manager's additional sync paths
+	SyncDir string `json:"sync_dir,omitempty"`
+
+	// s3: bucket and object key, plus endpoint for S3-compatible services such as MinIO
+	Bucket          string `json:"bucket,omitempty"`
+	Key             string `json:"key,omitempty"`
+	Region          string `json:"region,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`     // defaults to the AWS credential chain
+	SecretAccessKey string `json:"secret_access_key,omitempty"` // defaults to the AWS credential chain
+}
+
+// Validate checks the result store configuration
+func (cfg *ResultStoreConfig) Validate(path string) error {
+	switch cfg.Type {
+	case ResultStoreFile:
+	case ResultStoreDataSync:
+		if cfg.SyncDir == "" {
+			return fmt.Errorf("missing 'sync_dir' field in %s", path)
+		}
+	case ResultStoreS3:
+		if cfg.Bucket == "" {
+			return fmt.Errorf("missing 'bucket' field in %s", path)
+		}
+		if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+			return fmt.Errorf("'access_key_id' and 'secret_access_key' must be set together in %s", path)
+		}
+	default:
+		return fmt.Errorf("unknown result store type %q in %s, expected %q, %q or %q",
+			cfg.Type, path, ResultStoreFile, ResultStoreDataSync, ResultStoreS3)
+	}
+	return nil
+}
+
+// defaultResultsPath places the saved result in the module data directory Viam provides, if any
+func defaultResultsPath(resourceName string) string {
+	dataDir := os.Getenv("VIAM_MODULE_DATA")
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, resourceName+"-calibration.json")
+}
+
+// defaultResultKey names the bucket object after the machine part so a fleet can share one bucket
+func defaultResultKey(resourceName string) string {
+	key := resourceName + "-calibration.json"
+	if partID := os.Getenv("VIAM_MACHINE_PART_ID"); partID != "" {
+		return path.Join(partID, key)
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return path.Join(hostname, key)
+	}
+	return key
+}
+
+// newResultStore builds the configured result store, nil when persistence is disabled
+func newResultStore(ctx context.Context, conf *Config, resourceName string) (calibrationhelpers.ResultStore, error) {
+	localPath := conf.ResultsPath
+	if localPath == "" {
+		localPath = defaultResultsPath(resourceName)
+	}
+
+	storeConf := conf.ResultStore
+	if storeConf == nil {
+		storeConf = &ResultStoreConfig{Type: ResultStoreFile}
+	}
+
+	switch storeConf.Type {
+	case ResultStoreDataSync:
+		if localPath == "" {
+			return nil, fmt.Errorf("'results_path' is required for the %q result store outside a Viam module", ResultStoreDataSync)
+		}
+		return &calibrationhelpers.DataSyncStore{
+			Local:   &calibrationhelpers.FileStore{Path: localPath},
+			SyncDir: storeConf.SyncDir,
+			Name:    resourceName,
+		}, nil
+	case ResultStoreS3:
+		var opts []func(*awsconfig.LoadOptions) error
+		region := storeConf.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		opts = append(opts, awsconfig.WithRegion(region))
+		if storeConf.AccessKeyID != "" {
+			opts = append(opts, awsconfig.WithCredentialsProvider(
+				credentials.NewStaticCredentialsProvider(storeConf.AccessKeyID, storeConf.SecretAccessKey, "")))
+		}
+		awsConf, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load S3 configuration: %w", err)
+		}
+		client := s3.NewFromConfig(awsConf, func(o *s3.Options) {
+			if storeConf.Endpoint != "" {
+				o.BaseEndpoint = aws.String(storeConf.Endpoint)
+				o.UsePathStyle = true
+			}
+		})
+		key := storeConf.Key
+		if key == "" {
+			key = defaultResultKey(resourceName)
+		}
+		return &calibrationhelpers.S3Store{Client: client, Bucket: storeConf.Bucket, Key: key}, nil
+	default:
+		if localPath == "" {
+			return nil, nil
+		}
+		return &calibrationhelpers.FileStore{Path: localPath}, nil
+	}
+}
+
+// loadLastResult restores the most recent calibration saved before a restart
+func (s *monitorCalibration) loadLastResult(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	result, err := s.store.Load(ctx)
+	if err != nil {
+		if errors.Is(err, calibrationhelpers.ErrNoSavedResult) {
+			s.logger.Infof("No saved calibration in %s", s.store)
+		} else {
+			s.logger.Warnf("Ignoring saved calibration: %v", err)
+		}
+		return
+	}
+	if err := s.correctReferencePoints(&result, "saved calibration"); err != nil {
+		s.logger.Warnf("Ignoring saved calibration: %v", err)
+		return
+	}
+	s.lastResult = &result
+	s.logger.Infof("✓ Loaded calibration from %s (calibrated %s)", s.store, result.Timestamp.Format(time.RFC3339))
+}
+
+// referencePointCorrections explains each correction CorrectReferencePoints may apply
+var referencePointCorrections = map[string]string{
+	calibrationhelpers.CorrectionSwappedX:  "XPoint1 and XPoint2 ran towards the right edge, swapped them",
+	calibrationhelpers.CorrectionMirroredZ: "ZPoint1 was below the X points, mirrored it above them",
+}
+
+// correctReferencePoints puts a result's orientation reference points in order before it is adopted
+// Hand-edited or externally produced points in the wrong order would otherwise silently mirror or upend the monitor
+// frame, so every correction is logged and added to the event log.
+func (s *monitorCalibration) correctReferencePoints(result *types.CalibrationResult, source string) error {
+	corrections, err := calibrationhelpers.CorrectReferencePoints(result)
+	if err != nil {
+		return fmt.Errorf("%s has unusable orientation reference points: %w", source, err)
+	}
+	for _, correction := range corrections {
+		s.logger.Warnf("Corrected the %s: %s", source, referencePointCorrections[correction])
+		s.progress.event("warn", fmt.Sprintf("%s corrected: %s", source, referencePointCorrections[correction]))
+	}
+	return nil
+}
+
+// saveLastResult persists the most recent calibration so it survives restarts
+func (s *monitorCalibration) saveLastResult(ctx context.Context) {
+	if s.store == nil || s.lastResult == nil {
+		return
+	}
+	if err := s.store.Save(ctx, *s.lastResult); err != nil {
+		s.logger.Warnf("Failed to persist calibration: %v", err)
+		return
+	}
+	s.logger.Infof("✓ Calibration saved to %s", s.store)
+}
+
+// getLastCalibration handles the "get_last_calibration" command
+func (s *monitorCalibration) getLastCalibration() (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	result, err := calibrationhelpers.ResultToMap(*s.lastResult)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"schema_version": calibrationhelpers.ResultSchemaVersion,
+		"result":         result,
+		"visualization":  calibrationhelpers.GenerateVisualizationConfig(s.logger, *s.lastResult, s.lastResult.Frame),
+	}, nil
+}
+
+// getSummary handles the "get_summary" command, returning the last calibration as plain text and Markdown
+func (s *monitorCalibration) getSummary() (map[string]interface{}, error) {
+	if s.lastResult == nil {
+		return nil, fmt.Errorf("no calibration available, run calibrate first")
+	}
+	summary, err := calibrationhelpers.Summarize(*s.lastResult)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"grade":    summary.Grade,
+		"text":     summary.Text(),
+		"markdown": summary.Markdown(),
+	}, nil
+}
@@ -0,0 +1,196 @@
+// Package scanpath generates scan waypoint sequences over a rectangular region in front of the monitor
+// Waypoints are expressed in actuator space: X is gantry travel and Z is the arm height offset from its home pose
+package scanpath
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// Pattern selects the order waypoints are visited in
+type Pattern string
+
+const (
+	// Raster visits rows bottom to top, each row in the same +X direction
+	Raster Pattern = "raster"
+	// Serpentine (boustrophedon) visits rows bottom to top, alternating direction to avoid long return moves
+	Serpentine Pattern = "serpentine"
+	// Spiral starts at the center of the region and winds outward, sampling the middle of the screen first
+	Spiral Pattern = "spiral"
+)
+
+// Region is the bounding box to scan, in mm
+// A zero-width dimension produces a single column or row
+type Region struct {
+	XMin, XMax float64 // gantry travel
+	ZMin, ZMax float64 // arm height offset from the home pose
+}
+
+// Waypoint is a single sample location
+type Waypoint struct {
+	X, Z float64 // mm
+	Row  int     // grid row index, 0 at ZMin
+	Col  int     // grid column index, 0 at XMin
+	ID   string  // stable ID of the sample location, see Lattice.ID
+}
+
+// Generate returns the waypoints covering the region at the given spacing, ordered by pattern
+// Spacing is a maximum: samples are spread evenly so both ends of each axis are always included
+func Generate(region Region, pattern Pattern, xSpacing, zSpacing float64) ([]Waypoint, error) {
+	lattice, err := NewLattice(region, xSpacing, zSpacing)
+	if err != nil {
+		return nil, err
+	}
+	return lattice.Generate(Every(len(lattice.Zs), 1), Every(len(lattice.Xs), 1), pattern)
+}
+
+// cellOrder returns every (row, col) cell of a rows×cols grid in the order the pattern visits them
+func cellOrder(rows, cols int, pattern Pattern) ([][2]int, error) {
+	cells := make([][2]int, 0, rows*cols)
+	switch pattern {
+	case Raster, "":
+		for row := 0; row < rows; row++ {
+			for col := 0; col < cols; col++ {
+				cells = append(cells, [2]int{row, col})
+			}
+		}
+	case Serpentine:
+		for row := 0; row < rows; row++ {
+			for i := 0; i < cols; i++ {
+				col := i
+				if row%2 == 1 {
+					col = cols - 1 - i
+				}
+				cells = append(cells, [2]int{row, col})
+			}
+		}
+	case Spiral:
+		cells = spiralOrder(rows, cols)
+	default:
+		return nil, fmt.Errorf("unknown scan pattern %q", pattern)
+	}
+	return cells, nil
+}
+
+// axisSamples spreads samples evenly from min to max, no further apart than spacing
+func axisSamples(min, max, spacing float64) ([]float64, error) {
+	span := max - min
+	if span == 0 {
+		return []float64{min}, nil
+	}
+	if spacing <= 0 {
+		return nil, fmt.Errorf("spacing must be positive")
+	}
+
+	count := int(math.Ceil(span/spacing-1e-9)) + 1
+	step := span / float64(count-1)
+	samples := make([]float64, count)
+	for i := range samples {
+		samples[i] = min + float64(i)*step
+	}
+	return samples, nil
+}
+
+// spiralOrder returns every (row, col) cell of a rows×cols grid in a square spiral from the center outward
+func spiralOrder(rows, cols int) [][2]int {
+	total := rows * cols
+	cells := make([][2]int, 0, total)
+	row, col := (rows-1)/2, (cols-1)/2
+
+	// Directions: +col, +row, -col, -row with leg lengths 1, 1, 2, 2, 3, 3, ...
+	directions := [4][2]int{{0, 1}, {1, 0}, {0, -1}, {-1, 0}}
+	legLength := 1
+	for dir := 0; len(cells) < total; dir++ {
+		for step := 0; step < legLength && len(cells) < total; step++ {
+			if row >= 0 && row < rows && col >= 0 && col < cols {
+				cells = append(cells, [2]int{row, col})
+			}
+			row += directions[dir%4][0]
+			col += directions[dir%4][1]
+		}
+		if dir%2 == 1 {
+			legLength++
+		}
+	}
+	return cells
+}
+
+// Lattice is the full-density grid of sample locations over a region
+// Adaptive scans sample a subset of it, so every waypoint keeps the Row and Col of the full grid
+type Lattice struct {
+	Xs []float64 // gantry positions of the columns
+	Zs []float64 // arm height offsets of the rows
+
+	hash string
+}
+
+// NewLattice spreads the grid over the region at the given spacing, like Generate
+func NewLattice(region Region, xSpacing, zSpacing float64) (Lattice, error) {
+	if region.XMax < region.XMin || region.ZMax < region.ZMin {
+		return Lattice{}, fmt.Errorf("invalid scan region %+v", region)
+	}
+	xs, err := axisSamples(region.XMin, region.XMax, xSpacing)
+	if err != nil {
+		return Lattice{}, fmt.Errorf("x axis: %w", err)
+	}
+	zs, err := axisSamples(region.ZMin, region.ZMax, zSpacing)
+	if err != nil {
+		return Lattice{}, fmt.Errorf("z axis: %w", err)
+	}
+	lattice := Lattice{Xs: xs, Zs: zs}
+	lattice.hash = lattice.Hash()
+	return lattice, nil
+}
+
+// Hash identifies the grid by its sample positions, rounded to 0.01 mm
+// Any plan over the same grid has the same hash, whatever its pattern, so a restart with the same scan
+// parameters visits waypoints with the same IDs
+func (l Lattice) Hash() string {
+	h := sha256.New()
+	for _, axis := range [][]float64{l.Xs, l.Zs} {
+		_ = binary.Write(h, binary.LittleEndian, int64(len(axis)))
+		for _, v := range axis {
+			_ = binary.Write(h, binary.LittleEndian, int64(math.Round(v*100)))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+// ID returns the stable ID of a grid cell: the plan hash, row and column, e.g. "3f2a9c01-r2c5"
+func (l Lattice) ID(row, col int) string {
+	hash := l.hash
+	if hash == "" {
+		hash = l.Hash()
+	}
+	return fmt.Sprintf("%s-r%dc%d", hash, row, col)
+}
+
+// At returns the waypoint at a grid cell
+func (l Lattice) At(row, col int) Waypoint {
+	return Waypoint{X: l.Xs[col], Z: l.Zs[row], Row: row, Col: col, ID: l.ID(row, col)}
+}
+
+// Generate returns the waypoints at the given row and column indices, ordered by pattern as if they formed a grid
+func (l Lattice) Generate(rows, cols []int, pattern Pattern) ([]Waypoint, error) {
+	cells, err := cellOrder(len(rows), len(cols), pattern)
+	if err != nil {
+		return nil, err
+	}
+	waypoints := make([]Waypoint, len(cells))
+	for i, cell := range cells {
+		waypoints[i] = l.At(rows[cell[0]], cols[cell[1]])
+	}
+	return waypoints, nil
+}
+
+// Every returns the indices 0, step, 2*step, ... up to n-1, always including n-1
+func Every(n, step int) []int {
+	indices := make([]int, 0, n/step+2)
+	for i := 0; i < n-1; i += step {
+		indices = append(indices, i)
+	}
+	return append(indices, n-1)
+}
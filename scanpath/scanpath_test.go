@@ -0,0 +1,214 @@
+package scanpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func cellsOf(waypoints []Waypoint) [][2]int {
+	cells := make([][2]int, len(waypoints))
+	for i, wp := range waypoints {
+		cells[i] = [2]int{wp.Row, wp.Col}
+	}
+	return cells
+}
+
+func TestGenerateOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  Region
+		pattern Pattern
+		cells   [][2]int // (row, col) in visit order
+	}{
+		{
+			name:    "raster",
+			region:  Region{XMax: 100, ZMax: 50},
+			pattern: Raster,
+			cells:   [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}},
+		},
+		{
+			name:    "empty pattern is raster",
+			region:  Region{XMax: 100, ZMax: 50},
+			pattern: "",
+			cells:   [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}},
+		},
+		{
+			name:    "serpentine reverses every other row",
+			region:  Region{XMax: 100, ZMax: 100},
+			pattern: Serpentine,
+			cells:   [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 2}, {1, 1}, {1, 0}, {2, 0}, {2, 1}, {2, 2}},
+		},
+		{
+			name:    "spiral winds out from the center",
+			region:  Region{XMax: 100, ZMax: 100},
+			pattern: Spiral,
+			cells:   [][2]int{{1, 1}, {1, 2}, {2, 2}, {2, 1}, {2, 0}, {1, 0}, {0, 0}, {0, 1}, {0, 2}},
+		},
+		{
+			name:    "spiral skips cells outside a non-square grid",
+			region:  Region{XMax: 100, ZMax: 50},
+			pattern: Spiral,
+			cells:   [][2]int{{0, 1}, {0, 2}, {1, 2}, {1, 1}, {1, 0}, {0, 0}},
+		},
+		{
+			name:    "single sample",
+			region:  Region{XMin: 20, XMax: 20, ZMin: 5, ZMax: 5},
+			pattern: Spiral,
+			cells:   [][2]int{{0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waypoints, err := Generate(tt.region, tt.pattern, 50, 50)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if got := cellsOf(waypoints); !reflect.DeepEqual(got, tt.cells) {
+				t.Errorf("order = %v, want %v", got, tt.cells)
+			}
+			lattice, err := NewLattice(tt.region, 50, 50)
+			if err != nil {
+				t.Fatalf("NewLattice: %v", err)
+			}
+			for _, wp := range waypoints {
+				if wp.X != lattice.Xs[wp.Col] || wp.Z != lattice.Zs[wp.Row] {
+					t.Errorf("waypoint %+v isn't at its lattice position", wp)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   Region
+		pattern  Pattern
+		xSpacing float64
+	}{
+		{name: "unknown pattern", region: Region{XMax: 100, ZMax: 100}, pattern: "zigzag", xSpacing: 50},
+		{name: "inverted region", region: Region{XMin: 100, ZMax: 100}, pattern: Raster, xSpacing: 50},
+		{name: "zero spacing", region: Region{XMax: 100, ZMax: 100}, pattern: Raster, xSpacing: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(tt.region, tt.pattern, tt.xSpacing, 50); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestAxisSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max float64
+		spacing  float64
+		samples  []float64
+	}{
+		{name: "exact spacing", max: 100, spacing: 50, samples: []float64{0, 50, 100}},
+		{name: "spacing is a maximum", max: 100, spacing: 30, samples: []float64{0, 25, 50, 75, 100}},
+		{name: "spacing wider than the span", min: 10, max: 40, spacing: 100, samples: []float64{10, 40}},
+		{name: "zero span", min: 7, max: 7, spacing: 0, samples: []float64{7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := axisSamples(tt.min, tt.max, tt.spacing)
+			if err != nil {
+				t.Fatalf("axisSamples: %v", err)
+			}
+			if !reflect.DeepEqual(samples, tt.samples) {
+				t.Errorf("samples = %v, want %v", samples, tt.samples)
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		n, step int
+		indices []int
+	}{
+		{n: 5, step: 1, indices: []int{0, 1, 2, 3, 4}},
+		{n: 5, step: 2, indices: []int{0, 2, 4}},
+		{n: 6, step: 2, indices: []int{0, 2, 4, 5}},
+		{n: 4, step: 10, indices: []int{0, 3}},
+		{n: 1, step: 3, indices: []int{0}},
+	}
+	for _, tt := range tests {
+		if got := Every(tt.n, tt.step); !reflect.DeepEqual(got, tt.indices) {
+			t.Errorf("Every(%d, %d) = %v, want %v", tt.n, tt.step, got, tt.indices)
+		}
+	}
+}
+
+func TestLatticeIDs(t *testing.T) {
+	region := Region{XMax: 100, ZMax: 100}
+	base, err := NewLattice(region, 50, 50)
+	if err != nil {
+		t.Fatalf("NewLattice: %v", err)
+	}
+	tests := []struct {
+		name     string
+		region   Region
+		xSpacing float64
+		zSpacing float64
+		same     bool // whether the IDs match the base grid's
+	}{
+		{name: "same grid", region: region, xSpacing: 50, zSpacing: 50, same: true},
+		{name: "spacing change that gives the same samples", region: region, xSpacing: 60, zSpacing: 99, same: true},
+		{name: "sub-rounding shift", region: Region{XMin: 0.001, XMax: 100.001, ZMax: 100}, xSpacing: 50, zSpacing: 50, same: true},
+		{name: "denser grid", region: region, xSpacing: 25, zSpacing: 50, same: false},
+		{name: "shifted region", region: Region{XMin: 10, XMax: 110, ZMax: 100}, xSpacing: 50, zSpacing: 50, same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lattice, err := NewLattice(tt.region, tt.xSpacing, tt.zSpacing)
+			if err != nil {
+				t.Fatalf("NewLattice: %v", err)
+			}
+			if same := lattice.ID(1, 2) == base.ID(1, 2); same != tt.same {
+				t.Errorf("ID %s vs base %s: same = %v, want %v", lattice.ID(1, 2), base.ID(1, 2), same, tt.same)
+			}
+		})
+	}
+}
+
+func TestLatticeIDsIgnorePatternAndSubset(t *testing.T) {
+	lattice, err := NewLattice(Region{XMax: 200, ZMax: 100}, 50, 50)
+	if err != nil {
+		t.Fatalf("NewLattice: %v", err)
+	}
+	ids := map[[2]int]string{}
+	full, err := lattice.Generate(Every(len(lattice.Zs), 1), Every(len(lattice.Xs), 1), Raster)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	for _, wp := range full {
+		ids[[2]int{wp.Row, wp.Col}] = wp.ID
+	}
+	if len(ids) != len(full) {
+		t.Fatalf("%d distinct cells for %d waypoints", len(ids), len(full))
+	}
+
+	// Other patterns and the coarse subsets of an adaptive scan keep the full grid's IDs
+	for _, pattern := range []Pattern{Serpentine, Spiral} {
+		for _, step := range []int{1, 2, 3} {
+			waypoints, err := lattice.Generate(Every(len(lattice.Zs), step), Every(len(lattice.Xs), step), pattern)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			for _, wp := range waypoints {
+				if want := ids[[2]int{wp.Row, wp.Col}]; wp.ID != want {
+					t.Errorf("%s every %d: cell (%d, %d) has ID %s, want %s", pattern, step, wp.Row, wp.Col, wp.ID, want)
+				}
+			}
+		}
+	}
+
+	// A lattice built without NewLattice works out the same hash
+	bare := Lattice{Xs: lattice.Xs, Zs: lattice.Zs}
+	if bare.ID(2, 4) != lattice.ID(2, 4) {
+		t.Errorf("bare lattice ID %s, want %s", bare.ID(2, 4), lattice.ID(2, 4))
+	}
+}
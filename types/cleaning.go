@@ -0,0 +1,19 @@
+package types
+
+// Surface models used when generating cleaning paths
+const (
+	// SurfaceFlat cleans the fitted plane
+	SurfaceFlat = "flat"
+	// SurfaceCorrected follows the fitted deviation surface so the pad stays in contact with bowed glass
+	SurfaceCorrected = "corrected"
+)
+
+// CleaningConfig contains parameters for generating cleaning paths over the calibrated monitor
+type CleaningConfig struct {
+	StrokeSpacing   float64 // mm - distance between horizontal cleaning strokes
+	EdgeMargin      float64 // mm - inset from the monitor edges
+	PadThickness    float64 // mm - thickness of a new cleaning pad
+	PadCompression  float64 // mm - how far to compress the pad against the glass for the target contact pressure
+	MinPadThickness float64 // mm - pads thinner than this are worn out
+	Surface         string  // SurfaceFlat or SurfaceCorrected
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// Cylinder models a curved monitor as a patch of a circular cylinder
+// Ultrawide curved displays bend about their vertical axis, so a plane fit leaves tens of mm of residual at the sides
+type Cylinder struct {
+	Origin Point3D `json:"origin"` // point on the axis, level with the middle of the samples
+	Axis   Point3D `json:"axis"`   // unit axis direction
+	Radius float64 `json:"radius"` // mm
+
+	// Reference is the unit direction from the axis towards the middle of the samples, where arc length is zero
+	Reference Point3D `json:"reference"`
+
+	// Extent of the samples on the surface: arc length around the axis from Reference (positive
+	// counterclockwise looking down the axis) and height along the axis from Origin, all in mm
+	ArcMin   float64 `json:"arc_min"`
+	ArcMax   float64 `json:"arc_max"`
+	AxialMin float64 `json:"axial_min"`
+	AxialMax float64 `json:"axial_max"`
+
+	ResidualRMS float64 `json:"residual_rms"` // mm - RMS radial distance of the samples from the surface
+	Samples     int     `json:"samples"`
+}
+
+// Distance returns the signed radial distance of a point from the surface, positive away from the axis
+func (c Cylinder) Distance(p Point3D) float64 {
+	return c.radial(p).Norm() - c.Radius
+}
+
+// Locate returns the arc length and axial height of a point on the surface, see Cylinder.ArcMin
+func (c Cylinder) Locate(p Point3D) (arc, axial float64) {
+	axis := toVector(c.Axis)
+	reference := toVector(c.Reference)
+	radial := c.radial(p)
+	arc = c.Radius * math.Atan2(radial.Dot(axis.Cross(reference)), radial.Dot(reference))
+	axial = toVector(p).Sub(toVector(c.Origin)).Dot(axis)
+	return arc, axial
+}
+
+// Extend grows the extent to cover the points, e.g. the edges found after the scan
+func (c *Cylinder) Extend(points ...Point3D) {
+	for _, p := range points {
+		arc, axial := c.Locate(p)
+		c.ArcMin, c.ArcMax = math.Min(c.ArcMin, arc), math.Max(c.ArcMax, arc)
+		c.AxialMin, c.AxialMax = math.Min(c.AxialMin, axial), math.Max(c.AxialMax, axial)
+	}
+}
+
+// Apex returns the point on the surface in the middle of the extent, and the unit surface normal there
+// pointing towards the axis, i.e. towards a viewer of a concave screen
+func (c Cylinder) Apex() (Point3D, Point3D) {
+	axis := toVector(c.Axis)
+	reference := toVector(c.Reference)
+	angle := (c.ArcMin + c.ArcMax) / 2 / c.Radius
+	radial := reference.Mul(math.Cos(angle)).Add(axis.Cross(reference).Mul(math.Sin(angle)))
+	point := toVector(c.Origin).Add(axis.Mul((c.AxialMin + c.AxialMax) / 2)).Add(radial.Mul(c.Radius))
+	return fromVector(point), fromVector(radial.Mul(-1))
+}
+
+// radial returns the component of the point's offset from the axis perpendicular to it
+func (c Cylinder) radial(p Point3D) r3.Vector {
+	axis := toVector(c.Axis)
+	d := toVector(p).Sub(toVector(c.Origin))
+	return d.Sub(axis.Mul(d.Dot(axis)))
+}
+
+// toVector and fromVector convert between points and r3 vectors
+func toVector(p Point3D) r3.Vector {
+	return r3.Vector{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+func fromVector(v r3.Vector) Point3D {
+	return Point3D{X: v.X, Y: v.Y, Z: v.Z}
+}
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Waypoint outcomes reported in WaypointDiagnostic.Status
+const (
+	// WaypointOK means the waypoint produced a surface point
+	WaypointOK = "ok"
+	// WaypointIKFailure means the arm could not reach the waypoint pose
+	WaypointIKFailure = "ik_failure"
+	// WaypointSettleTimeout means the arm or gantry did not finish moving within the settle timeout
+	WaypointSettleTimeout = "settle_timeout"
+	// WaypointReadTimeout means the sensor did not answer within the read timeout
+	WaypointReadTimeout = "read_timeout"
+	// WaypointReadError means the sensor reading failed on every attempt
+	WaypointReadError = "read_error"
+	// WaypointOutOfRange means the sensor reported its max range, i.e. the ray missed the monitor
+	WaypointOutOfRange = "out_of_range"
+)
+
+// WaypointDiagnostic records a waypoint that did not produce a surface point
+type WaypointDiagnostic struct {
+	Scan      string  `json:"scan"`
+	Index     int     `json:"index"`
+	ID        string  `json:"id,omitempty"`
+	GantryMM  float64 `json:"gantry_mm"`
+	ZOffsetMM float64 `json:"z_offset_mm"`
+	Status    string  `json:"status"`
+	Detail    string  `json:"detail,omitempty"`
+	Retries   int     `json:"retries,omitempty"` // failed sensor reads retried at this waypoint
+}
+
+// ScanDiagnostics aggregates waypoint outcomes over a calibration run, to explain low coverage
+type ScanDiagnostics struct {
+	Waypoints int                  `json:"waypoints"` // waypoints visited
+	Counts    map[string]int       `json:"counts"`    // waypoints per status
+	Coverage  float64              `json:"coverage"`  // share of waypoints that produced a surface point
+	Failures  []WaypointDiagnostic `json:"failures,omitempty"`
+	Retries   int                  `json:"retries,omitempty"` // failed sensor reads retried over all waypoints
+
+	// Share of the detected width and height the surface points span, set by MeasureSpan
+	WidthSpan  float64 `json:"width_span,omitempty"`
+	HeightSpan float64 `json:"height_span,omitempty"`
+}
+
+// WaypointOutcome reports the outcome of one scan waypoint, such as a WaypointDiagnostic or the
+// calibrationhelpers.ScanProgress of a scan callback
+type WaypointOutcome interface {
+	Diagnostic() WaypointDiagnostic
+}
+
+// Diagnostic returns the diagnostic itself, so it can be passed to ScanDiagnostics.Record
+func (w WaypointDiagnostic) Diagnostic() WaypointDiagnostic {
+	return w
+}
+
+// Record adds the outcome of one waypoint, an empty status counting as WaypointOK
+// Passing a calibrationhelpers.ScanProgress, as before the types package existed, still works but is deprecated: pass
+// its Diagnostic() instead.
+func (d *ScanDiagnostics) Record(outcome WaypointOutcome) {
+	waypoint := outcome.Diagnostic()
+	if d.Counts == nil {
+		d.Counts = map[string]int{}
+	}
+	if waypoint.Status == "" {
+		waypoint.Status = WaypointOK
+	}
+	d.Waypoints++
+	d.Retries += waypoint.Retries
+	d.Counts[waypoint.Status]++
+	d.Coverage = float64(d.Counts[WaypointOK]) / float64(d.Waypoints)
+	if waypoint.Status != WaypointOK {
+		d.Failures = append(d.Failures, waypoint)
+	}
+}
+
+// MeasureSpan sets how much of the monitor extent found by the edge searches the scan points span
+// A single scan line fits a plane confidently but wrongly, so this is checked by the scan span alert. The points are
+// compared along the edges of the corners, so a monitor rolled in its plane is measured along its own width and
+// height. Results without corners fall back to the edges measured along world X and Z.
+func (d *ScanDiagnostics) MeasureSpan(points []Point3D, result CalibrationResult) {
+	if len(points) == 0 {
+		return
+	}
+	if c := result.Corners; c != nil {
+		if width, ok := spanAlong(points, c.TopRight, c.TopLeft); ok {
+			d.WidthSpan = width
+		}
+		if height, ok := spanAlong(points, c.BottomLeft, c.TopLeft); ok {
+			d.HeightSpan = height
+		}
+		return
+	}
+	minX, maxX, minZ, maxZ := points[0].X, points[0].X, points[0].Z, points[0].Z
+	for _, p := range points[1:] {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minZ, maxZ = math.Min(minZ, p.Z), math.Max(maxZ, p.Z)
+	}
+	// The edges are measured along world X and Z, so the points are compared along the same axes
+	if width := math.Abs(result.LeftX - result.RightX); width > 0 {
+		d.WidthSpan = math.Min(1, (maxX-minX)/width)
+	}
+	if height := math.Abs(result.TopZ - result.BottomZ); height > 0 {
+		d.HeightSpan = math.Min(1, (maxZ-minZ)/height)
+	}
+}
+
+// spanAlong returns the share of the distance from start to end that the points span in that direction, at most 1
+// Reports false when start and end coincide.
+func spanAlong(points []Point3D, start, end Point3D) (float64, bool) {
+	dx, dy, dz := end.X-start.X, end.Y-start.Y, end.Z-start.Z
+	length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if length == 0 {
+		return 0, false
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		t := (p.X*dx + p.Y*dy + p.Z*dz) / length
+		lo, hi = math.Min(lo, t), math.Max(hi, t)
+	}
+	return math.Min(1, (hi-lo)/length), true
+}
+
+// ToMap converts the diagnostics to a map of JSON values, suitable for returning from DoCommand
+func (d ScanDiagnostics) ToMap() (map[string]interface{}, error) {
+	m, err := jsonToMap(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scan diagnostics: %w", err)
+	}
+	return m, nil
+}
+
+// jsonToMap round-trips v through JSON into a generic map
+func jsonToMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
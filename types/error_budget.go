@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+)
+
+// Error budget sources
+const (
+	ErrorSourceSensor      = "sensor_noise"
+	ErrorSourcePositioning = "positioning"
+	ErrorSourceFit         = "fit_residual"
+)
+
+// ErrorSource is one contribution to the uncertainty of the fitted plane
+type ErrorSource struct {
+	Name         string  `json:"name"`
+	Sigma        float64 `json:"sigma_mm"`       // mm - 1-sigma error this source adds to each scan point along the normal
+	Share        float64 `json:"share"`          // share of the residual variance, 0 to 1
+	NormalStdDev float64 `json:"normal_std_dev"` // degrees - this source's part of the normal uncertainty
+	OffsetStdDev float64 `json:"offset_std_dev"` // mm - this source's part of the offset uncertainty
+	Measured     bool    `json:"measured"`       // false when the scan collected no data for this source
+}
+
+// ErrorBudget attributes the uncertainty of the fitted plane to its sources
+// The residual variance of the fit is split into sensor noise, measured from the spread of repeated readings,
+// positioning error, measured from where the arm and gantry reported they ended up, and whatever is left, which
+// is surface shape the plane can't follow plus unmodeled errors. Since the plane covariance scales with the
+// residual variance, each source's part of it scales with the square root of its share.
+type ErrorBudget struct {
+	Sources      []ErrorSource `json:"sources"`
+	ResidualRMS  float64       `json:"residual_rms"`   // mm
+	NormalStdDev float64       `json:"normal_std_dev"` // degrees
+	OffsetStdDev float64       `json:"offset_std_dev"` // mm
+	Dominant     string        `json:"dominant"`
+	Advice       string        `json:"advice"`
+}
+
+// ToMap converts the budget to a map of JSON values, suitable for returning from DoCommand
+func (b ErrorBudget) ToMap() (map[string]interface{}, error) {
+	m, err := jsonToMap(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode error budget: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// DeviationSurface models how the glass departs from the fitted plane with a low-order polynomial
+// The deviation along the plane normal is w(u, v) = Σ c·(u/s)^i·(v/s)^j for i+j <= Order, where (u, v)
+// are in-plane coordinates in mm about Origin and s is Scale. Bowed panels and glass overlays show up
+// as curvature terms that a flat plane can't represent.
+type DeviationSurface struct {
+	Order        int       `json:"order"`
+	Coefficients []float64 `json:"coefficients"` // mm, in DeviationTerms order
+	Origin       Point3D   `json:"origin"`       // plane point the (u, v) coordinates are measured from
+	UAxis        Point3D   `json:"u_axis"`       // unit in-plane axis, the projection of world X where possible
+	VAxis        Point3D   `json:"v_axis"`       // unit in-plane axis, normal × U
+	Normal       Point3D   `json:"normal"`       // unit plane normal, positive deviations are along it
+	Scale        float64   `json:"scale"`        // mm - normalizes (u, v) for numerical conditioning
+
+	PeakToValley         float64 `json:"peak_to_valley"`          // mm - range of the fitted surface over the samples
+	ResidualPeakToValley float64 `json:"residual_peak_to_valley"` // mm - range of the raw residuals from the plane
+	ResidualRMS          float64 `json:"residual_rms"`            // mm - RMS of the samples about the fitted surface
+	Samples              int     `json:"samples"`
+}
+
+// Deviation returns the modeled offset of the glass from the plane, along the normal, at the projection of p
+func (s DeviationSurface) Deviation(p Point3D) float64 {
+	if len(s.Coefficients) == 0 {
+		return 0
+	}
+	d := r3.Vector{X: p.X - s.Origin.X, Y: p.Y - s.Origin.Y, Z: p.Z - s.Origin.Z}
+	u := d.Dot(r3.Vector{X: s.UAxis.X, Y: s.UAxis.Y, Z: s.UAxis.Z})
+	v := d.Dot(r3.Vector{X: s.VAxis.X, Y: s.VAxis.Y, Z: s.VAxis.Z})
+	return s.evaluateLocal(u, v)
+}
+
+// SurfaceNormal returns the unit normal of the deviation-corrected glass at the projection of p
+// With no fitted coefficients this is the plane normal
+func (s DeviationSurface) SurfaceNormal(p Point3D) Point3D {
+	normal := r3.Vector{X: s.Normal.X, Y: s.Normal.Y, Z: s.Normal.Z}
+	if len(s.Coefficients) == 0 {
+		return s.Normal
+	}
+	uAxis := r3.Vector{X: s.UAxis.X, Y: s.UAxis.Y, Z: s.UAxis.Z}
+	vAxis := r3.Vector{X: s.VAxis.X, Y: s.VAxis.Y, Z: s.VAxis.Z}
+	d := r3.Vector{X: p.X - s.Origin.X, Y: p.Y - s.Origin.Y, Z: p.Z - s.Origin.Z}
+	dwdu, dwdv := s.gradientLocal(d.Dot(uAxis), d.Dot(vAxis))
+
+	// The surface r(u, v) = u·U + v·V + w·N has normal ∂r/∂u × ∂r/∂v ∝ N - w_u·U - w_v·V
+	n := normal.Sub(uAxis.Mul(dwdu)).Sub(vAxis.Mul(dwdv)).Normalize()
+	return Point3D{X: n.X, Y: n.Y, Z: n.Z}
+}
+
+// gradientLocal returns the partial derivatives of the deviation with respect to u and v (mm per mm)
+func (s DeviationSurface) gradientLocal(u, v float64) (float64, float64) {
+	dwdu, dwdv := 0.0, 0.0
+	for i, term := range DeviationTerms(s.Order) {
+		pu, pv := float64(term[0]), float64(term[1])
+		if term[0] > 0 {
+			dwdu += s.Coefficients[i] * pu / s.Scale * math.Pow(u/s.Scale, pu-1) * math.Pow(v/s.Scale, pv)
+		}
+		if term[1] > 0 {
+			dwdv += s.Coefficients[i] * pv / s.Scale * math.Pow(u/s.Scale, pu) * math.Pow(v/s.Scale, pv-1)
+		}
+	}
+	return dwdu, dwdv
+}
+
+func (s DeviationSurface) evaluateLocal(u, v float64) float64 {
+	w := 0.0
+	for i, term := range DeviationTerms(s.Order) {
+		w += s.Coefficients[i] * math.Pow(u/s.Scale, float64(term[0])) * math.Pow(v/s.Scale, float64(term[1]))
+	}
+	return w
+}
+
+// DeviationTerms lists the (u power, v power) pairs with total degree <= order, by increasing degree, in the order
+// of DeviationSurface.Coefficients
+func DeviationTerms(order int) [][2]int {
+	var terms [][2]int
+	for degree := 0; degree <= order; degree++ {
+		for i := degree; i >= 0; i-- {
+			terms = append(terms, [2]int{i, degree - i})
+		}
+	}
+	return terms
+}
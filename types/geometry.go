@@ -0,0 +1,28 @@
+package types
+
+// Point3D represents a 3D point in world space
+type Point3D struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Plane represents a plane equation: Ax + By + Cz = D
+type Plane struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+	C float64 `json:"c"`
+	D float64 `json:"d"`
+}
+
+// Covariance describes the parameter uncertainty of a least-squares plane fit
+// Parameters are expressed in the plane's local frame at the sample centroid:
+// the two slopes of the surface along in-plane axes U and V, and the offset along the normal
+type Covariance struct {
+	Matrix           [3][3]float64 `json:"matrix"`             // covariance of (slopeU, slopeV, offset mm)
+	ResidualRMS      float64       `json:"residual_rms"`       // mm - RMS distance of the samples from the plane
+	NormalStdDev     float64       `json:"normal_std_dev"`     // degrees - 1-sigma uncertainty of the normal direction
+	OffsetStdDev     float64       `json:"offset_std_dev"`     // mm - 1-sigma uncertainty of the plane position along its normal
+	Samples          int           `json:"samples"`            // number of points used in the fit
+	DegreesOfFreedom int           `json:"degrees_of_freedom"` // samples minus fitted parameters
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"time"
+)
+
+// CalibrationResult holds the final calibration data
+type CalibrationResult struct {
+	Plane         Plane   `json:"plane"`
+	BottomZ       float64 `json:"bottom_z"`
+	TopZ          float64 `json:"top_z"`
+	LeftX         float64 `json:"left_x"`
+	RightX        float64 `json:"right_x"`
+	MonitorWidth  float64 `json:"monitor_width"`
+	MonitorHeight float64 `json:"monitor_height"`
+
+	// Uncertainty of the fitted plane, used to judge whether enough samples were collected
+	PlaneUncertainty Covariance `json:"plane_uncertainty"`
+
+	// Flatness: how the glass deviates from the fitted plane
+	Deviation DeviationSurface `json:"deviation"`
+
+	// 3 Points for orientation calculation
+	XPoint1 Point3D `json:"x_point_1"`
+	XPoint2 Point3D `json:"x_point_2"`
+	ZPoint1 Point3D `json:"z_point_1"`
+
+	// Corners of a possibly rotated monitor: detected with corner detection, otherwise those of the rectangle the
+	// edge searches found. Nil in results from modules that only stored corners after corner detection.
+	Corners *MonitorCorners `json:"corners,omitempty"`
+
+	// Cylinder describes a curved monitor, nil for flat ones. Plane is then tangent to the middle of the glass
+	// and the left and right edges bound the chord, while the cylinder's arc extent is the width along the glass.
+	Cylinder *Cylinder `json:"cylinder,omitempty"`
+
+	// When the calibration finished and which frame its coordinates are expressed in
+	Timestamp time.Time `json:"timestamp"`
+	Frame     string    `json:"frame"`
+
+	// SessionID is the UUID of the calibration run that produced the result, empty for results from before
+	// version 1.1.0
+	SessionID string `json:"session_id,omitempty"`
+
+	// Chained is set when a previous result seeded the scan and edge searches
+	Chained bool `json:"chained,omitempty"`
+
+	// Coarse is set for vision calibrations from corner tags that no ultrasonic scan has refined yet
+	Coarse bool `json:"coarse,omitempty"`
+
+	// Scan records how many scan waypoints produced points and why the others didn't
+	Scan *ScanDiagnostics `json:"scan_diagnostics,omitempty"`
+
+	// ErrorBudget attributes the plane uncertainty to sensor noise, positioning and fit residuals
+	ErrorBudget *ErrorBudget `json:"error_budget,omitempty"`
+}
+
+// MonitorCorners are the four monitor corners on the fitted plane
+// Left is towards +X, matching LeftX > RightX in CalibrationResult
+type MonitorCorners struct {
+	TopLeft     Point3D `json:"top_left"`
+	TopRight    Point3D `json:"top_right"`
+	BottomLeft  Point3D `json:"bottom_left"`
+	BottomRight Point3D `json:"bottom_right"`
+	Rotation    float64 `json:"rotation"`    // degrees - in-plane rotation of the top edge from the plane's horizontal
+	EdgePoints  int     `json:"edge_points"` // hit/miss transitions used to fit the edges
+}
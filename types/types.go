@@ -0,0 +1,11 @@
+// Package types holds the calibration data types other modules consume, such as the calibration result, with a
+// stable API
+//
+// The package follows semantic versioning through Version: within a major version, types and fields are only
+// added, never renamed, removed or retyped, so code built against an older minor version keeps compiling. It
+// defines the plane, flatness and curve types the geometry package fits, and like geometry it depends only on the
+// standard library, gonum and golang/geo, never on the rdk.
+package types
+
+// Version is the semantic version of this package's API
+const Version = "1.2.0"